@@ -1,9 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/ovh/cds/cli"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
 )
 
 var (
@@ -16,6 +23,11 @@ var (
 		[]*cobra.Command{
 			cli.NewListCommand(workflowListCmd, workflowListRun, nil),
 			cli.NewGetCommand(workflowShowCmd, workflowShowRun, nil),
+			cli.NewCommand(workflowStopCmd, workflowStopRun, nil),
+			cli.NewCommand(workflowLogsCmd, workflowLogsRun, nil),
+			cli.NewCommand(workflowExportCmd, workflowExportRun, nil),
+			cli.NewCommand(workflowImportCmd, workflowImportRun, nil),
+			workflowArtifact,
 		})
 )
 
@@ -51,3 +63,155 @@ func workflowShowRun(v cli.Values) (interface{}, error) {
 	}
 	return *w, nil
 }
+
+var workflowStopCmd = cli.Command{
+	Name:  "stop",
+	Short: "Stop a CDS workflow run or a single node run",
+	Long:  "Stop all the running pipelines of a workflow run. If node-run-id is set, only this node run is stopped.",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "name"},
+		{Name: "run-number"},
+	},
+	OptionnalArgs: []cli.Arg{
+		{Name: "node-run-id"},
+	},
+}
+
+func workflowStopRun(v cli.Values) error {
+	number, err := strconv.ParseInt(v["run-number"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("run-number parameter have to be an integer")
+	}
+
+	if v["node-run-id"] == "" {
+		run, err := client.WorkflowStop(v["project-key"], v["name"], number)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Workflow %s #%d has been stopped\n", v["name"], run.Number)
+		return nil
+	}
+
+	nodeRunID, err := strconv.ParseInt(v["node-run-id"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("node-run-id parameter have to be an integer")
+	}
+	nodeRun, err := client.WorkflowNodeStop(v["project-key"], v["name"], number, nodeRunID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Workflow node run %d of %s #%d.%d has been stopped\n", nodeRun.ID, v["name"], nodeRun.Number, nodeRun.SubNumber)
+	return nil
+}
+
+var workflowLogsCmd = cli.Command{
+	Name:  "logs",
+	Short: "Show the logs of a job of a CDS workflow run",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "name"},
+		{Name: "run-number"},
+		{Name: "node-run-id"},
+		{Name: "run-job-id"},
+	},
+	Flags: []cli.Flag{
+		{
+			Name:  "follow",
+			Usage: "Follow the logs until the end of the job",
+			Kind:  reflect.Bool,
+		},
+	},
+}
+
+func workflowLogsRun(v cli.Values) error {
+	ids := map[string]int64{}
+	for _, k := range []string{"run-number", "node-run-id", "run-job-id"} {
+		id, err := strconv.ParseInt(v[k], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s parameter have to be an integer", k)
+		}
+		ids[k] = id
+	}
+
+	logs := make(chan sdk.Log)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.WorkflowNodeRunJobLogs(v["project-key"], v["name"], ids["run-number"], ids["node-run-id"], ids["run-job-id"], v.GetBool("follow"), logs)
+	}()
+
+	currentStep := int64(-1)
+	for l := range logs {
+		if l.StepOrder != currentStep {
+			currentStep = l.StepOrder
+			fmt.Printf("--- Step %d ---\n", currentStep)
+		}
+		fmt.Print(l.Val)
+	}
+	return <-errs
+}
+
+var workflowExportCmd = cli.Command{
+	Name:  "export",
+	Short: "Export a CDS workflow",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "name"},
+	},
+	Flags: []cli.Flag{
+		{
+			Name:    "format",
+			Default: "yaml",
+			Usage:   "Specify export format (json, yaml or hcl)",
+			Kind:    reflect.String,
+		},
+	},
+}
+
+func workflowExportRun(v cli.Values) error {
+	btes, err := client.WorkflowExport(v["project-key"], v["name"], v.GetString("format"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(btes))
+	return nil
+}
+
+var workflowImportCmd = cli.Command{
+	Name:  "import",
+	Short: "Import a CDS workflow",
+	Long:  "Import a CDS workflow from a yaml, json or hcl file. The pipelines, applications and environments are resolved by their names in the project.",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "filename"},
+	},
+	Flags: []cli.Flag{
+		{
+			Name:  "force",
+			Usage: "Override the workflow if it already exists",
+			Kind:  reflect.Bool,
+		},
+	},
+}
+
+func workflowImportRun(v cli.Values) error {
+	filename := v["filename"]
+	format := "yaml"
+	if strings.HasSuffix(filename, ".json") {
+		format = "json"
+	} else if strings.HasSuffix(filename, ".hcl") {
+		format = "hcl"
+	}
+
+	btes, _, err := exportentities.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	w, err := client.WorkflowImport(v["project-key"], btes, format, v.GetBool("force"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Workflow %s has been imported\n", w.Name)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ovh/cds/cli"
+)
+
+var (
+	workflowArtifactCmd = cli.Command{
+		Name:  "artifact",
+		Short: "Manage CDS workflow run artifacts",
+	}
+
+	workflowArtifact = cli.NewCommand(workflowArtifactCmd, nil,
+		[]*cobra.Command{
+			cli.NewListCommand(workflowArtifactListCmd, workflowArtifactListRun, nil),
+			cli.NewCommand(workflowArtifactDownloadCmd, workflowArtifactDownloadRun, nil),
+		})
+)
+
+var workflowArtifactListCmd = cli.Command{
+	Name:  "list",
+	Short: "List the artifacts of a CDS workflow run",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "name"},
+		{Name: "run-number"},
+	},
+}
+
+func workflowArtifactListRun(v cli.Values) (cli.ListResult, error) {
+	number, err := strconv.ParseInt(v["run-number"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("run-number parameter have to be an integer")
+	}
+	arts, err := client.WorkflowRunArtifacts(v["project-key"], v["name"], number)
+	if err != nil {
+		return nil, err
+	}
+	return cli.AsListResult(arts), nil
+}
+
+var workflowArtifactDownloadCmd = cli.Command{
+	Name:  "download",
+	Short: "Download the artifacts of a CDS workflow run",
+	Long:  "Download the artifacts of a CDS workflow run. If the objectstore supports it, the artifacts are downloaded directly from it.",
+	Args: []cli.Arg{
+		{Name: "project-key"},
+		{Name: "name"},
+		{Name: "run-number"},
+	},
+	OptionnalArgs: []cli.Arg{
+		{Name: "artifact-name"},
+	},
+	Flags: []cli.Flag{
+		{
+			Name:    "dir",
+			Default: ".",
+			Usage:   "Directory where the artifacts are downloaded",
+			Kind:    reflect.String,
+		},
+	},
+}
+
+func workflowArtifactDownloadRun(v cli.Values) error {
+	number, err := strconv.ParseInt(v["run-number"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("run-number parameter have to be an integer")
+	}
+	arts, err := client.WorkflowRunArtifacts(v["project-key"], v["name"], number)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for _, a := range arts {
+		if v["artifact-name"] != "" && a.Name != v["artifact-name"] {
+			continue
+		}
+		found = true
+
+		f, err := os.OpenFile(filepath.Join(v.GetString("dir"), a.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(a.Perm))
+		if err != nil {
+			return err
+		}
+		if err := client.WorkflowNodeRunArtifactDownload(v["project-key"], v["name"], a.ID, f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Artifact %s has been downloaded\n", a.Name)
+	}
+
+	if !found {
+		return fmt.Errorf("No artifact found")
+	}
+	return nil
+}
@@ -182,6 +182,47 @@ Tag the current branch and push it.`
 		return err
 	}
 
+	// ----------------------------------- Cache Save -----------------------
+	cacheSave := sdk.NewAction(sdk.CacheSave)
+	cacheSave.Type = sdk.BuiltinAction
+	cacheSave.Description = `CDS Builtin Action.
+Save directories in a cache of the project, to restore them in the next builds with CacheRestore.`
+
+	cacheSave.Parameter(sdk.Parameter{
+		Name: "key",
+		Description: `Key of the cache, ie. go-{{.cds.application}}-{{hashFiles "go.sum"}}.
+hashFiles returns the hash of the files matching the given patterns.`,
+		Type: sdk.StringParameter,
+	})
+	cacheSave.Parameter(sdk.Parameter{
+		Name:        "path",
+		Description: "Paths to save, relative to the workspace, one per line. Glob patterns are allowed.",
+		Type:        sdk.TextParameter,
+	})
+	if err := checkBuiltinAction(db, cacheSave); err != nil {
+		return err
+	}
+
+	// ----------------------------------- Cache Restore -----------------------
+	cacheRestore := sdk.NewAction(sdk.CacheRestore)
+	cacheRestore.Type = sdk.BuiltinAction
+	cacheRestore.Description = `CDS Builtin Action.
+Restore in the workspace directories saved with CacheSave.`
+
+	cacheRestore.Parameter(sdk.Parameter{
+		Name:        "key",
+		Description: `Key of the cache, ie. go-{{.cds.application}}-{{hashFiles "go.sum"}}.`,
+		Type:        sdk.StringParameter,
+	})
+	cacheRestore.Parameter(sdk.Parameter{
+		Name:        "restoreKeys",
+		Description: "If there is no cache for the key, the latest cache with a key starting with one of these prefixes is restored, one per line. ie. go-{{.cds.application}}-",
+		Type:        sdk.TextParameter,
+	})
+	if err := checkBuiltinAction(db, cacheRestore); err != nil {
+		return err
+	}
+
 	return nil
 }
 
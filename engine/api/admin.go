@@ -7,6 +7,9 @@ import (
 
 	"github.com/ovh/cds/engine/api/businesscontext"
 	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/secret"
+	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
 )
 
@@ -33,3 +36,19 @@ func deleteAdminMaintenanceHandler(w http.ResponseWriter, r *http.Request, db *g
 	cache.Delete("maintenance")
 	return nil
 }
+
+func postAdminSecretRotationHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	rotation, err := secret.StartRotation(database.GetDBMap, 100)
+	if err != nil {
+		return sdk.WrapError(err, "postAdminSecretRotationHandler> Unable to start rotation")
+	}
+	return WriteJSON(w, r, rotation, http.StatusAccepted)
+}
+
+func getAdminSecretRotationHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	rotation, ok := secret.LoadRotation()
+	if !ok {
+		return sdk.ErrNotFound
+	}
+	return WriteJSON(w, r, rotation, http.StatusOK)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ovh/cds/engine/api/artifact"
 	"github.com/ovh/cds/engine/api/businesscontext"
 	"github.com/ovh/cds/engine/api/environment"
+	"github.com/ovh/cds/engine/api/objectstore"
 	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/sdk"
@@ -266,6 +267,14 @@ func downloadArtifactDirectHandler(w http.ResponseWriter, r *http.Request, db *g
 	return nil
 }
 
+func getArtifactsStoreHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	store := sdk.ArtifactsStore{
+		TemporaryURLSupported: objectstore.TemporaryURLSupported(),
+		TemporaryURLMaxSize:   objectstore.TemporaryURLMaxSize(),
+	}
+	return WriteJSON(w, r, store, http.StatusOK)
+}
+
 func generateHash() (string, error) {
 	size := 128
 	bs := make([]byte, size)
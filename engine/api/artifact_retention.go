@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/purge"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+)
+
+// getArtifactRetentionsHandler returns the retention rules of a project and of its workflows
+func getArtifactRetentionsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	key := mux.Vars(r)["permProjectKey"]
+
+	proj, errP := project.Load(db, key, c.User)
+	if errP != nil {
+		return sdk.WrapError(errP, "getArtifactRetentionsHandler> Cannot load project %s", key)
+	}
+
+	rules, err := purge.LoadArtifactRetentions(db, proj.ID)
+	if err != nil {
+		return sdk.WrapError(err, "getArtifactRetentionsHandler> Cannot load rules of project %s", key)
+	}
+	return WriteJSON(w, r, rules, http.StatusOK)
+}
+
+// putArtifactRetentionHandler sets the retention rule of a project, or of a workflow
+func putArtifactRetentionHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	proj, errP := project.Load(db, key, c.User)
+	if errP != nil {
+		return sdk.WrapError(errP, "putArtifactRetentionHandler> Cannot load project %s", key)
+	}
+
+	var rule sdk.ArtifactRetention
+	if err := UnmarshalBody(r, &rule); err != nil {
+		return sdk.WrapError(err, "putArtifactRetentionHandler> Cannot read body")
+	}
+	if err := checkArtifactRetention(&rule); err != nil {
+		return sdk.WrapError(err, "putArtifactRetentionHandler> Invalid rule")
+	}
+
+	rule.ProjectID = proj.ID
+	rule.WorkflowID = 0
+	rule.WorkflowName = ""
+	if name, ok := vars["permWorkflowName"]; ok {
+		wf, errW := workflow.Load(db, key, name, c.User)
+		if errW != nil {
+			return sdk.WrapError(errW, "putArtifactRetentionHandler> Cannot load workflow %s", name)
+		}
+		rule.WorkflowID = wf.ID
+		rule.WorkflowName = wf.Name
+	}
+
+	if err := purge.UpsertArtifactRetention(db, &rule); err != nil {
+		return sdk.WrapError(err, "putArtifactRetentionHandler> Cannot save rule")
+	}
+	return WriteJSON(w, r, rule, http.StatusOK)
+}
+
+// deleteArtifactRetentionHandler removes the retention rule of a project, or of a workflow
+func deleteArtifactRetentionHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+
+	proj, errP := project.Load(db, key, c.User)
+	if errP != nil {
+		return sdk.WrapError(errP, "deleteArtifactRetentionHandler> Cannot load project %s", key)
+	}
+
+	var workflowID int64
+	if name, ok := vars["permWorkflowName"]; ok {
+		wf, errW := workflow.Load(db, key, name, c.User)
+		if errW != nil {
+			return sdk.WrapError(errW, "deleteArtifactRetentionHandler> Cannot load workflow %s", name)
+		}
+		workflowID = wf.ID
+	}
+
+	if err := purge.DeleteArtifactRetention(db, proj.ID, workflowID); err != nil {
+		return sdk.WrapError(err, "deleteArtifactRetentionHandler> Cannot delete rule")
+	}
+	return nil
+}
+
+// getArtifactPurgeReportHandler returns the artifacts of a project which would be purged by its retention rules
+func getArtifactPurgeReportHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	key := mux.Vars(r)["permProjectKey"]
+
+	proj, errP := project.Load(db, key, c.User)
+	if errP != nil {
+		return sdk.WrapError(errP, "getArtifactPurgeReportHandler> Cannot load project %s", key)
+	}
+
+	report, err := purge.ArtifactsRun(db, proj.ID, true)
+	if err != nil {
+		return sdk.WrapError(err, "getArtifactPurgeReportHandler> Cannot compute report of project %s", key)
+	}
+	return WriteJSON(w, r, report, http.StatusOK)
+}
+
+func checkArtifactRetention(rule *sdk.ArtifactRetention) error {
+	if rule.KeepLastRuns < 0 || rule.KeepDays < 0 {
+		return sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("keep_last_runs and keep_days must be positive"))
+	}
+	for _, t := range rule.KeepTags {
+		if t == "" || t[0] == '=' {
+			return sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("invalid tag %q", t))
+		}
+	}
+	return nil
+}
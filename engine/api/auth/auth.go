@@ -20,7 +20,7 @@ import (
 	"github.com/ovh/cds/sdk/log"
 )
 
-//Driver is an interface to all auth method (local, ldap and beyond...)
+//Driver is an interface to all auth method (local, ldap, oidc and beyond...)
 type Driver interface {
 	Open(options interface{}, store sessionstore.Store) error
 	Store() sessionstore.Store
@@ -41,6 +41,8 @@ func GetDriver(c context.Context, mode string, options interface{}, storeOptions
 	switch mode {
 	case "ldap":
 		d = &LDAPClient{}
+	case "oidc":
+		d = &OIDCClient{}
 	default:
 		d = &LocalClient{}
 	}
@@ -0,0 +1,537 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/sessionstore"
+	"github.com/ovh/cds/engine/api/user"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+// oidcClockSkew is the tolerance applied on ID token time claims
+const oidcClockSkew = time.Minute
+
+// oidcStateCookie binds the state of a login to the browser which has started it
+const oidcStateCookie = "cds_oidc_state"
+
+//OIDCConfig handles all config to use an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+}
+
+//OIDCClient is an auth driver which authenticates users against an OpenID Connect provider.
+//Sessions and local users are handled as with the local driver.
+type OIDCClient struct {
+	LocalClient
+	conf       OIDCConfig
+	provider   oidcProvider
+	httpClient *http.Client
+
+	mutex sync.RWMutex
+	keys  map[string]*rsa.PublicKey
+}
+
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+//OIDCClaims are the claims of a verified ID token
+type OIDCClaims map[string]interface{}
+
+//String returns the string value of a claim
+func (c OIDCClaims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+//Strings returns the value of a claim as a list of strings, a single string claim returns a list of one element
+func (c OIDCClaims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func (c OIDCClaims) time(name string) (time.Time, bool) {
+	f, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+//Open discovers the provider configuration
+func (c *OIDCClient) Open(options interface{}, store sessionstore.Store) error {
+	conf, ok := options.(OIDCConfig)
+	if !ok {
+		return fmt.Errorf("invalid OIDC configuration")
+	}
+	if conf.Issuer == "" || conf.ClientID == "" || conf.RedirectURL == "" {
+		return fmt.Errorf("OIDC issuer, client id and redirect url are mandatory")
+	}
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+	if conf.UsernameClaim == "" {
+		conf.UsernameClaim = "preferred_username"
+	}
+	c.conf = conf
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if err := c.LocalClient.Open(options, store); err != nil {
+		return err
+	}
+	return c.discover()
+}
+
+func (c *OIDCClient) discover() error {
+	u := strings.TrimSuffix(c.conf.Issuer, "/") + "/.well-known/openid-configuration"
+	log.Info("Auth> Loading OIDC provider configuration from %s", u)
+	if err := c.getJSON(u, &c.provider); err != nil {
+		return sdk.WrapError(err, "OIDCClient.discover> Unable to load provider configuration")
+	}
+	if strings.TrimSuffix(c.provider.Issuer, "/") != strings.TrimSuffix(c.conf.Issuer, "/") {
+		return fmt.Errorf("OIDCClient.discover> issuer mismatch: %s != %s", c.provider.Issuer, c.conf.Issuer)
+	}
+	if c.provider.AuthorizationEndpoint == "" || c.provider.TokenEndpoint == "" || c.provider.JWKSURI == "" {
+		return fmt.Errorf("OIDCClient.discover> incomplete provider configuration")
+	}
+	return c.loadKeys()
+}
+
+func (c *OIDCClient) getJSON(u string, i interface{}) error {
+	resp, err := c.httpClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(i)
+}
+
+func (c *OIDCClient) loadKeys() error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := c.getJSON(c.provider.JWKSURI, &set); err != nil {
+		return sdk.WrapError(err, "OIDCClient.loadKeys> Unable to load JWKS")
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errn := base64.RawURLEncoding.DecodeString(k.N)
+		e, erre := base64.RawURLEncoding.DecodeString(k.E)
+		if errn != nil || erre != nil {
+			log.Warning("OIDCClient.loadKeys> Invalid key %s", k.Kid)
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.mutex.Lock()
+	c.keys = keys
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *OIDCClient) key(kid string) *rsa.PublicKey {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if k, ok := c.keys[kid]; ok {
+		return k
+	}
+	// Without kid in the token header, accept the only key of the set
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k
+		}
+	}
+	return nil
+}
+
+//NewOIDCVerifier returns a random PKCE code verifier
+func NewOIDCVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func oidcChallenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+//AuthCodeURL returns the provider url to redirect the user to
+func (c *OIDCClient) AuthCodeURL(state, nonce, verifier string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", c.conf.ClientID)
+	v.Set("redirect_uri", c.conf.RedirectURL)
+	v.Set("scope", strings.Join(c.conf.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", oidcChallenge(verifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(c.provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.provider.AuthorizationEndpoint + sep + v.Encode()
+}
+
+//SetStateCookie sets a short-lived cookie with the hash of the state on the browser which starts the login
+func (c *OIDCClient) SetStateCookie(w http.ResponseWriter, state string, ttl time.Duration) {
+	http.SetCookie(w, c.stateCookie(oidcStateHash(state), int(ttl.Seconds())))
+}
+
+//CheckStateCookie returns true if the callback comes from the browser which has started the login with this state,
+//so that a callback url sent to another user is refused. The cookie is cleared.
+func (c *OIDCClient) CheckStateCookie(w http.ResponseWriter, r *http.Request, state string) bool {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return false
+	}
+	http.SetCookie(w, c.stateCookie("", -1))
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(oidcStateHash(state))) == 1
+}
+
+func (c *OIDCClient) stateCookie(value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+	}
+	if u, err := url.Parse(c.conf.RedirectURL); err == nil {
+		cookie.Secure = u.Scheme == "https"
+		if u.Path != "" {
+			cookie.Path = u.Path
+		}
+	}
+	return cookie
+}
+
+func oidcStateHash(state string) string {
+	h := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+//Exchange trades the authorization code for an ID token
+func (c *OIDCClient) Exchange(code, verifier string) (string, error) {
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", c.conf.RedirectURL)
+	v.Set("client_id", c.conf.ClientID)
+	v.Set("code_verifier", verifier)
+
+	req, err := http.NewRequest(http.MethodPost, c.provider.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.conf.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.conf.ClientID), url.QueryEscape(c.conf.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", sdk.WrapError(err, "OIDCClient.Exchange> Unable to call token endpoint")
+	}
+	defer resp.Body.Close()
+
+	var tok oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", sdk.WrapError(err, "OIDCClient.Exchange> Unable to read token response (HTTP %d)", resp.StatusCode)
+	}
+	if tok.Error != "" {
+		return "", fmt.Errorf("OIDCClient.Exchange> %s: %s", tok.Error, tok.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OIDCClient.Exchange> token endpoint returned HTTP %d", resp.StatusCode)
+	}
+	if tok.IDToken == "" {
+		return "", fmt.Errorf("OIDCClient.Exchange> no id_token in token response")
+	}
+	return tok.IDToken, nil
+}
+
+//VerifyIDToken checks the signature of the ID token against the provider keys and validates its claims
+func (c *OIDCClient) VerifyIDToken(raw, nonce string) (OIDCClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid id token header: %v", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported id token algorithm %s", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token signature: %v", err)
+	}
+
+	k := c.key(header.Kid)
+	if k == nil {
+		// The provider may have rotated its keys
+		if err := c.loadKeys(); err != nil {
+			return nil, err
+		}
+		if k = c.key(header.Kid); k == nil {
+			return nil, fmt.Errorf("unknown id token key %s", header.Kid)
+		}
+	}
+
+	h := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid id token signature")
+	}
+
+	claims := OIDCClaims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid id token claims: %v", err)
+	}
+
+	if claims.String("iss") != c.provider.Issuer {
+		return nil, fmt.Errorf("invalid id token issuer %s", claims.String("iss"))
+	}
+	var audOK bool
+	for _, aud := range claims.Strings("aud") {
+		audOK = audOK || aud == c.conf.ClientID
+	}
+	if !audOK {
+		return nil, fmt.Errorf("id token not issued for %s", c.conf.ClientID)
+	}
+	now := time.Now()
+	exp, ok := claims.time("exp")
+	if !ok || now.After(exp.Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("id token expired")
+	}
+	if iat, ok := claims.time("iat"); ok && iat.After(now.Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("id token issued in the future")
+	}
+	if claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("invalid id token nonce")
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, i interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, i)
+}
+
+//Username returns the CDS username from the ID token claims
+func (c *OIDCClient) Username(claims OIDCClaims) string {
+	username := claims.String(c.conf.UsernameClaim)
+	if username == "" {
+		username = strings.Split(claims.String("email"), "@")[0]
+	}
+	return username
+}
+
+//Groups returns the group names from the configured groups claim
+func (c *OIDCClient) Groups(claims OIDCClaims) []string {
+	if c.conf.GroupsClaim == "" {
+		return nil
+	}
+	return claims.Strings(c.conf.GroupsClaim)
+}
+
+//AuthentifyCode exchanges the authorization code, verifies the ID token, then creates or updates the matching user
+func (c *OIDCClient) AuthentifyCode(db gorp.SqlExecutor, code, verifier, nonce string) (*sdk.User, error) {
+	raw, err := c.Exchange(code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := c.VerifyIDToken(raw, nonce)
+	if err != nil {
+		return nil, sdk.WrapError(sdk.ErrUnauthorized, "OIDCClient.AuthentifyCode> %s", err)
+	}
+
+	u, err := c.insertOrUpdateUser(db, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.conf.GroupsClaim != "" {
+		if err := c.syncGroups(db, u, c.Groups(claims)); err != nil {
+			return nil, sdk.WrapError(err, "OIDCClient.AuthentifyCode> Unable to sync groups of %s", u.Username)
+		}
+	}
+	return u, nil
+}
+
+func (c *OIDCClient) insertOrUpdateUser(db gorp.SqlExecutor, claims OIDCClaims) (*sdk.User, error) {
+	username := c.Username(claims)
+	if username == "" {
+		return nil, sdk.WrapError(sdk.ErrInvalidUser, "OIDCClient.insertOrUpdateUser> No username in claim %s", c.conf.UsernameClaim)
+	}
+
+	u, err := user.LoadUserAndAuth(db, username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, sdk.WrapError(err, "OIDCClient.insertOrUpdateUser> Unable to load user %s", username)
+	}
+	//Never take over a user coming from another origin
+	if u != nil && u.Origin != "oidc" {
+		return nil, sdk.WrapError(sdk.ErrInvalidUser, "OIDCClient.insertOrUpdateUser> User %s already exists with origin %s", username, u.Origin)
+	}
+
+	newUser := u == nil
+	if newUser {
+		u = &sdk.User{
+			Username: username,
+			Origin:   "oidc",
+		}
+	}
+	if fullname := claims.String("name"); fullname != "" {
+		u.Fullname = fullname
+	} else if u.Fullname == "" {
+		u.Fullname = username
+	}
+	if email := claims.String("email"); email != "" {
+		u.Email = email
+	}
+
+	if newUser {
+		a := &sdk.Auth{EmailVerified: true}
+		if err := user.InsertUser(db, u, a); err != nil {
+			return nil, sdk.WrapError(err, "OIDCClient.insertOrUpdateUser> Unable to insert user %s", username)
+		}
+		u.Auth = *a
+		log.Info("OIDCClient.insertOrUpdateUser> User %s created", username)
+		return u, nil
+	}
+
+	if err := user.UpdateUser(db, *u); err != nil {
+		return nil, sdk.WrapError(err, "OIDCClient.insertOrUpdateUser> Unable to update user %s", username)
+	}
+	return u, nil
+}
+
+//syncGroups makes the user member of the existing CDS groups listed in the groups claim, and removes it from the others.
+//The default group and the shared infrastructure group are left untouched.
+func (c *OIDCClient) syncGroups(db gorp.SqlExecutor, u *sdk.User, names []string) error {
+	current, err := group.LoadGroupByUser(db, u.ID)
+	if err != nil {
+		return err
+	}
+
+	isMember := map[string]bool{}
+	for _, g := range current {
+		isMember[g.Name] = true
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+		if isMember[name] {
+			continue
+		}
+		g, err := group.LoadGroup(db, name)
+		if err == sdk.ErrGroupNotFound {
+			log.Debug("OIDCClient.syncGroups> Group %s does not exist", name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := group.InsertUserInGroup(db, g.ID, u.ID, false); err != nil {
+			return err
+		}
+		log.Info("OIDCClient.syncGroups> User %s added in group %s", u.Username, name)
+	}
+
+	for _, g := range current {
+		if wanted[g.Name] || g.Name == group.SharedInfraGroupName || group.IsDefaultGroupID(g.ID) {
+			continue
+		}
+		if err := group.DeleteUserFromGroup(db, g.ID, u.ID); err != nil {
+			if err == sdk.ErrNotEnoughAdmin {
+				log.Warning("OIDCClient.syncGroups> User %s is the last admin of group %s", u.Username, g.Name)
+				continue
+			}
+			return err
+		}
+		log.Info("OIDCClient.syncGroups> User %s removed from group %s", u.Username, g.Name)
+	}
+	return nil
+}
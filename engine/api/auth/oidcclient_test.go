@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/sessionstore"
+)
+
+// fakeIssuer is a local stand-in for an OpenID Connect provider
+type fakeIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key, kid: "key-1"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": f.kid,
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "cds" || secret != "s3cr3t" || r.FormValue("code") != "the-code" || oidcChallenge(r.FormValue("code_verifier")) != f.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     f.sign(t, f.kid, f.claims),
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeIssuer) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	h := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (f *fakeIssuer) validClaims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                f.URL,
+		"aud":                "cds",
+		"sub":                "1234",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              nonce,
+		"preferred_username": "john.doe",
+		"name":               "John Doe",
+		"email":              "john.doe@example.com",
+		"groups":             []string{"devs", "ops"},
+	}
+}
+
+func newTestOIDCClient(t *testing.T, f *fakeIssuer) *OIDCClient {
+	store, err := sessionstore.Get(context.Background(), "local", "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &OIDCClient{}
+	err = c.Open(OIDCConfig{
+		Issuer:       f.URL,
+		ClientID:     "cds",
+		ClientSecret: "s3cr3t",
+		RedirectURL:  "http://cds.local/login/oidc/callback",
+		GroupsClaim:  "groups",
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestOIDCClientAuthCodeFlow(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	c := newTestOIDCClient(t, f)
+
+	verifier, err := NewOIDCVerifier()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(c.AuthCodeURL("the-state", "the-nonce", verifier))
+	assert.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "cds", q.Get("client_id"))
+	assert.Equal(t, "the-state", q.Get("state"))
+	assert.Equal(t, "the-nonce", q.Get("nonce"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, "openid profile email", q.Get("scope"))
+
+	f.challenge = q.Get("code_challenge")
+	f.claims = f.validClaims("the-nonce")
+
+	// Wrong verifier
+	_, err = c.Exchange("the-code", "wrong")
+	assert.Error(t, err)
+
+	raw, err := c.Exchange("the-code", verifier)
+	assert.NoError(t, err)
+
+	claims, err := c.VerifyIDToken(raw, "the-nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, "john.doe", c.Username(claims))
+	assert.Equal(t, []string{"devs", "ops"}, c.Groups(claims))
+
+	_, err = c.VerifyIDToken(raw, "another-nonce")
+	assert.Error(t, err)
+}
+
+func TestOIDCClientVerifyIDToken(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	c := newTestOIDCClient(t, f)
+
+	valid := f.validClaims("n")
+	_, err := c.VerifyIDToken(f.sign(t, f.kid, valid), "n")
+	assert.NoError(t, err)
+
+	tests := map[string]func(map[string]interface{}){
+		"expired":       func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiration": func(c map[string]interface{}) { delete(c, "exp") },
+		"wrong issuer":  func(c map[string]interface{}) { c["iss"] = "http://evil.local" },
+		"wrong audience": func(c map[string]interface{}) {
+			c["aud"] = []string{"another-client"}
+		},
+		"future": func(c map[string]interface{}) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+	}
+	for name, alter := range tests {
+		claims := f.validClaims("n")
+		alter(claims)
+		_, err := c.VerifyIDToken(f.sign(t, f.kid, claims), "n")
+		assert.Error(t, err, name)
+	}
+
+	// Audience as a list
+	claims := f.validClaims("n")
+	claims["aud"] = []string{"another-client", "cds"}
+	_, err = c.VerifyIDToken(f.sign(t, f.kid, claims), "n")
+	assert.NoError(t, err)
+
+	// Unknown key
+	_, err = c.VerifyIDToken(f.sign(t, "key-2", valid), "n")
+	assert.Error(t, err)
+
+	// Tampered payload
+	token := f.sign(t, f.kid, valid)
+	valid["preferred_username"] = "admin"
+	forged, _ := json.Marshal(valid)
+	parts := strings.Split(token, ".")
+	_, err = c.VerifyIDToken(parts[0]+"."+base64.RawURLEncoding.EncodeToString(forged)+"."+parts[2], "n")
+	assert.Error(t, err)
+
+	// Keys rotation on the provider side
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	f.key = newKey
+	f.kid = "key-2"
+	_, err = c.VerifyIDToken(f.sign(t, "key-2", f.validClaims("n")), "n")
+	assert.NoError(t, err)
+}
+
+func TestOIDCClientStateCookie(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	c := newTestOIDCClient(t, f)
+
+	rec := httptest.NewRecorder()
+	c.SetStateCookie(rec, "the-state", 10*time.Minute)
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, "/login/oidc/callback", cookies[0].Path)
+	assert.NotContains(t, cookies[0].Value, "the-state")
+
+	callback := func(cookie *http.Cookie) *http.Request {
+		r := httptest.NewRequest("GET", "http://cds.local/login/oidc/callback?code=the-code&state=the-state", nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		return r
+	}
+
+	// the callback url is opened by the browser which has started the login
+	rec = httptest.NewRecorder()
+	assert.True(t, c.CheckStateCookie(rec, callback(cookies[0]), "the-state"))
+	cleared := rec.Result().Cookies()
+	if assert.Len(t, cleared, 1) {
+		assert.True(t, cleared[0].MaxAge < 0)
+	}
+
+	// the callback url is sent to another user
+	assert.False(t, c.CheckStateCookie(httptest.NewRecorder(), callback(nil), "the-state"))
+	assert.False(t, c.CheckStateCookie(httptest.NewRecorder(), callback(cookies[0]), "another-state"))
+}
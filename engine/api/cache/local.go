@@ -211,7 +211,7 @@ func (s *LocalStore) Publish(channel string, value interface{}) {
 		return
 	}
 	s.Mutex.Lock()
-	l.PushBack(b)
+	l.PushFront(b)
 	s.Mutex.Unlock()
 }
 
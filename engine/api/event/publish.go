@@ -2,6 +2,7 @@ package event
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/fatih/structs"
@@ -74,11 +75,11 @@ func PublishPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild, previous *
 	}
 
 	e := sdk.EventPipelineBuild{
-		Version:     pb.Version,
-		BuildNumber: pb.BuildNumber,
-		Status:      pb.Status,
-		Start:       pb.Start.Unix(),
-		Done:        pb.Done.Unix(),
+		Version:               pb.Version,
+		BuildNumber:           pb.BuildNumber,
+		Status:                pb.Status,
+		Start:                 pb.Start.Unix(),
+		Done:                  pb.Done.Unix(),
 		RepositoryManagerName: rmn,
 		RepositoryFullname:    rfn,
 		PipelineName:          pb.Pipeline.Name,
@@ -92,3 +93,129 @@ func PublishPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild, previous *
 
 	Publish(e)
 }
+
+// PublishWorkflowNodeRunApproval sends an event about a workflow node run waiting for approval
+func PublishWorkflowNodeRunApproval(wr *sdk.WorkflowRun, nr *sdk.WorkflowNodeRun, a *sdk.WorkflowNodeRunApproval) {
+	e := sdk.EventWorkflowNodeRunApproval{
+		ProjectKey:   wr.Workflow.ProjectKey,
+		WorkflowName: wr.Workflow.Name,
+		Number:       nr.Number,
+		SubNumber:    nr.SubNumber,
+		NodeRunID:    nr.ID,
+		Status:       sdk.StatusFromString(nr.Status),
+		NbApprovals:  nr.NbApprovals(),
+	}
+	if node := wr.Workflow.GetNode(nr.WorkflowNodeID); node != nil {
+		e.PipelineName = node.Pipeline.Name
+		if node.Context != nil {
+			if node.Context.Application != nil {
+				e.ApplicationName = node.Context.Application.Name
+			}
+			if node.Context.Environment != nil {
+				e.EnvironmentName = node.Context.Environment.Name
+			}
+			if node.Context.Approval != nil {
+				e.NbApprovers = node.Context.Approval.NbApprovers
+				e.Groups = node.Context.Approval.Groups
+			}
+		}
+	}
+	if a != nil {
+		e.Username = a.Username
+		e.Approved = a.Approved
+		e.Comment = a.Comment
+	}
+
+	Publish(e)
+}
+
+// PublishWorkflowNodeRun sends an event about the status of a workflow node run
+func PublishWorkflowNodeRun(wr *sdk.WorkflowRun, nr *sdk.WorkflowNodeRun) {
+	Publish(workflowNodeRunEvent(wr, nr))
+}
+
+// PublishWorkflowRun sends an event about a workflow run which is over, with the last run of each node
+func PublishWorkflowRun(wr *sdk.WorkflowRun) {
+	e := sdk.EventWorkflowRun{
+		ProjectKey:   wr.Workflow.ProjectKey,
+		WorkflowName: wr.Workflow.Name,
+		Number:       wr.Number,
+		Status:       sdk.StatusSuccess,
+	}
+
+	for _, nodeRuns := range wr.WorkflowNodeRuns {
+		if len(nodeRuns) == 0 {
+			continue
+		}
+		last := &nodeRuns[0]
+		for i := range nodeRuns {
+			if nodeRuns[i].SubNumber > last.SubNumber {
+				last = &nodeRuns[i]
+			}
+		}
+		ne := workflowNodeRunEvent(wr, last)
+		switch ne.Status {
+		case sdk.StatusFail:
+			e.Status = sdk.StatusFail
+		case sdk.StatusStopped:
+			if e.Status != sdk.StatusFail {
+				e.Status = sdk.StatusStopped
+			}
+		}
+		if last.WorkflowNodeID == wr.Workflow.RootID {
+			e.BranchName = ne.BranchName
+			e.Hash = ne.Hash
+			e.PullRequestID = ne.PullRequestID
+			e.RepositoryManagerName = ne.RepositoryManagerName
+			e.RepositoryFullname = ne.RepositoryFullname
+		}
+		e.Nodes = append(e.Nodes, ne)
+	}
+
+	Publish(e)
+}
+
+func workflowNodeRunEvent(wr *sdk.WorkflowRun, nr *sdk.WorkflowNodeRun) sdk.EventWorkflowNodeRun {
+	e := sdk.EventWorkflowNodeRun{
+		ProjectKey:   wr.Workflow.ProjectKey,
+		WorkflowName: wr.Workflow.Name,
+		Number:       nr.Number,
+		SubNumber:    nr.SubNumber,
+		NodeRunID:    nr.ID,
+		Status:       sdk.StatusFromString(nr.Status),
+		Start:        nr.Start.Unix(),
+		Done:         nr.Done.Unix(),
+	}
+	if node := wr.Workflow.GetNode(nr.WorkflowNodeID); node != nil {
+		e.NodeName = node.Name
+		e.PipelineName = node.Pipeline.Name
+		if node.Context != nil {
+			if node.Context.Application != nil {
+				e.ApplicationName = node.Context.Application.Name
+				if node.Context.Application.RepositoriesManager != nil {
+					e.RepositoryManagerName = node.Context.Application.RepositoriesManager.Name
+					e.RepositoryFullname = node.Context.Application.RepositoryFullname
+				}
+			}
+			if node.Context.Environment != nil {
+				e.EnvironmentName = node.Context.Environment.Name
+			}
+		}
+	}
+	if p := sdk.ParameterFind(nr.BuildParameters, "git.branch"); p != nil {
+		e.BranchName = p.Value
+	}
+	if p := sdk.ParameterFind(nr.BuildParameters, "git.hash"); p != nil {
+		e.Hash = p.Value
+	}
+	if p := sdk.ParameterFind(nr.BuildParameters, "cds.pr.id"); p != nil {
+		e.PullRequestID, _ = strconv.ParseInt(p.Value, 10, 64)
+	}
+	if nr.Tests != nil {
+		e.TestsTotal = nr.Tests.Total
+		e.TestsOK = nr.Tests.TotalOK
+		e.TestsKO = nr.Tests.TotalKO
+		e.TestsSkipped = nr.Tests.TotalSkipped
+	}
+	return e
+}
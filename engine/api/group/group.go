@@ -26,6 +26,10 @@ func DeleteGroupAndDependencies(db gorp.SqlExecutor, group *sdk.Group) error {
 		return sdk.WrapError(err, "deleteGroupAndDependencies: Cannot delete group pipeline %s: %s", group.Name)
 	}
 
+	if err := deleteGroupWorkflowByGroup(db, group); err != nil {
+		return sdk.WrapError(err, "deleteGroupAndDependencies: Cannot delete group workflow %s", group.Name)
+	}
+
 	if err := deleteGroupApplicationByGroup(db, group); err != nil {
 		return sdk.WrapError(err, "deleteGroupAndDependencies: Cannot delete group application %s: %s", group.Name)
 	}
@@ -297,6 +301,11 @@ func CheckUserInDefaultGroup(db gorp.SqlExecutor, userID int64) error {
 	return nil
 }
 
+// IsDefaultGroupID returns true if groupID is the default group in which every user is granted
+func IsDefaultGroupID(groupID int64) bool {
+	return defaultGroupID != 0 && defaultGroupID == groupID
+}
+
 // DeleteGroupUserByGroup Delete all user from a group
 func DeleteGroupUserByGroup(db gorp.SqlExecutor, group *sdk.Group) error {
 	query := `DELETE FROM group_user WHERE group_id=$1`
@@ -0,0 +1,76 @@
+package group
+
+import (
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadAllWorkflowGroupByRole load all group for the given workflow and role
+func LoadAllWorkflowGroupByRole(db gorp.SqlExecutor, workflowID int64, role int) ([]sdk.GroupPermission, error) {
+	groupsPermission := []sdk.GroupPermission{}
+	query := `
+		SELECT workflow_group.group_id, workflow_group.role
+		FROM workflow_group
+		WHERE workflow_group.workflow_id = $1 AND role = $2;
+	`
+	rows, err := db.Query(query, workflowID, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var gPermission sdk.GroupPermission
+		if err := rows.Scan(&gPermission.Group.ID, &gPermission.Permission); err != nil {
+			return nil, err
+		}
+		groupsPermission = append(groupsPermission, gPermission)
+	}
+	return groupsPermission, nil
+}
+
+// CheckGroupInWorkflow  Check if the group is already attached to the workflow
+func CheckGroupInWorkflow(db gorp.SqlExecutor, workflowID, groupID int64) (bool, error) {
+	query := `SELECT COUNT(group_id) FROM workflow_group WHERE workflow_id = $1 AND group_id = $2`
+
+	var nb int64
+	if err := db.QueryRow(query, workflowID, groupID).Scan(&nb); err != nil {
+		return false, err
+	}
+	return (nb != 0), nil
+}
+
+// InsertGroupInWorkflow add permissions on Workflow to Group
+func InsertGroupInWorkflow(db gorp.SqlExecutor, workflowID, groupID int64, role int) error {
+	query := `INSERT INTO workflow_group (workflow_id, group_id, role) VALUES($1,$2,$3)`
+	_, err := db.Exec(query, workflowID, groupID, role)
+	return err
+}
+
+// InsertProjectGroupsInWorkflow gives to the workflow the same groups as its project
+func InsertProjectGroupsInWorkflow(db gorp.SqlExecutor, workflowID, projectID int64) error {
+	query := `INSERT INTO workflow_group (workflow_id, group_id, role)
+		SELECT $1, project_group.group_id, project_group.role FROM project_group WHERE project_group.project_id = $2`
+	_, err := db.Exec(query, workflowID, projectID)
+	return err
+}
+
+// UpdateGroupRoleInWorkflow update permission on workflow
+func UpdateGroupRoleInWorkflow(db gorp.SqlExecutor, workflowID, groupID int64, role int) error {
+	query := `UPDATE workflow_group SET role = $1 WHERE workflow_id = $2 AND group_id = $3`
+	_, err := db.Exec(query, role, workflowID, groupID)
+	return err
+}
+
+// DeleteGroupFromWorkflow removes access to workflow to group members
+func DeleteGroupFromWorkflow(db gorp.SqlExecutor, workflowID, groupID int64) error {
+	query := `DELETE FROM workflow_group WHERE workflow_id = $1 AND group_id = $2`
+	_, err := db.Exec(query, workflowID, groupID)
+	return err
+}
+
+func deleteGroupWorkflowByGroup(db gorp.SqlExecutor, group *sdk.Group) error {
+	query := `DELETE FROM workflow_group WHERE group_id=$1`
+	_, err := db.Exec(query, group.ID)
+	return err
+}
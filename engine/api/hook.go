@@ -17,6 +17,8 @@ import (
 	"github.com/ovh/cds/engine/api/hook"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitea"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitlab"
 	"github.com/ovh/cds/engine/api/workflow"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
@@ -47,6 +49,24 @@ func receiveHook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *busi
 		UID:        r.FormValue("uid"),
 	}
 
+	// Gitlab, Gitea and Gogs do not interpolate the hook link, details are in the payload
+	pushHooks := []struct {
+		header, event string
+		parse         func([]byte) (pushHook, error)
+	}{
+		{repogitlab.HookEventHeader, repogitlab.PushHookEvent, func(data []byte) (pushHook, error) { return repogitlab.ParsePushHook(data) }},
+		{repogitea.HookEventHeader, repogitea.PushHookEvent, func(data []byte) (pushHook, error) { return repogitea.ParsePushHook(data) }},
+	}
+	for _, p := range pushHooks {
+		ok, errP := readPushHook(r, data, &rh, p.header, p.event, p.parse)
+		if errP != nil {
+			return errP
+		}
+		if !ok {
+			return nil
+		}
+	}
+
 	if db == nil {
 		hook.Recovery(rh, fmt.Errorf("database not available"))
 		return err
@@ -62,6 +82,36 @@ func receiveHook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *busi
 	return nil
 }
 
+//pushHook is the payload of a push webhook, for the repositories managers which do not interpolate the hook link
+type pushHook interface {
+	Branch() string
+	Hash() string
+	Author() string
+	ChangeType() string
+}
+
+//readPushHook fills the received hook from the payload if the request has the event header.
+//It returns false if the hook must be ignored because the event is not a push.
+func readPushHook(r *http.Request, data []byte, rh *hook.ReceivedHook, header, event string, parse func([]byte) (pushHook, error)) (bool, error) {
+	e := r.Header.Get(header)
+	if e == "" {
+		return true, nil
+	}
+	if e != event {
+		log.Debug("receiveHook> ignoring %s %s", header, e)
+		return false, nil
+	}
+	h, err := parse(data)
+	if err != nil {
+		return false, sdk.NewError(sdk.ErrWrongRequest, err)
+	}
+	rh.Branch = h.Branch()
+	rh.Hash = h.Hash()
+	rh.Author = h.Author()
+	rh.Message = h.ChangeType()
+	return true, nil
+}
+
 func addHook(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	projectKey := vars["key"]
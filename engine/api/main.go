@@ -28,6 +28,7 @@ import (
 	"github.com/ovh/cds/engine/api/objectstore"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/poller"
+	"github.com/ovh/cds/engine/api/purge"
 	"github.com/ovh/cds/engine/api/queue"
 	"github.com/ovh/cds/engine/api/repositoriesmanager"
 	"github.com/ovh/cds/engine/api/scheduler"
@@ -37,6 +38,7 @@ import (
 	"github.com/ovh/cds/engine/api/user"
 	"github.com/ovh/cds/engine/api/worker"
 	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/engine/api/workflowhook"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
 )
@@ -91,6 +93,9 @@ var mainCmd = &cobra.Command{
 
 		//Initialize secret driver
 		secret.Init(viper.GetString(viperServerSecretKey))
+		if err := secret.InitKeyring(viper.GetStringSlice(viperServerSecretKeyring)); err != nil {
+			log.Fatalf("Cannot initialize secrets keyring: %s", err)
+		}
 
 		//Initialize mail package
 		mail.Init(viper.GetString(viperSMTPUser),
@@ -108,6 +113,8 @@ var mainCmd = &cobra.Command{
 			objectstoreKind = objectstore.Openstack
 		case "filesystem", "local":
 			objectstoreKind = objectstore.Filesystem
+		case "s3":
+			objectstoreKind = objectstore.S3
 		default:
 			log.Fatalf("Unsupported objecstore mode : %s", viper.GetString(viperArtifactMode))
 		}
@@ -126,6 +133,16 @@ var mainCmd = &cobra.Command{
 				Filesystem: objectstore.ConfigOptionsFilesystem{
 					Basedir: viper.GetString(viperArtifactLocalBasedir),
 				},
+				S3: objectstore.ConfigOptionsS3{
+					Endpoint:        viper.GetString(viperArtifactS3Endpoint),
+					Region:          viper.GetString(viperArtifactS3Region),
+					Bucket:          viper.GetString(viperArtifactS3Bucket),
+					Prefix:          viper.GetString(viperArtifactS3Prefix),
+					AccessKeyID:     viper.GetString(viperArtifactS3AccessKeyID),
+					SecretAccessKey: viper.GetString(viperArtifactS3SecretAccessKey),
+					PathStyle:       viper.GetBool(viperArtifactS3PathStyle),
+					PartSize:        viper.GetInt64(viperArtifactS3PartSize),
+				},
 			},
 		}
 
@@ -162,6 +179,7 @@ var mainCmd = &cobra.Command{
 
 		cache.Initialize(viper.GetString(viperCacheMode), viper.GetString(viperCacheRedisHost), viper.GetString(viperCacheRedisPassword), viper.GetInt(viperCacheTTL))
 		InitLastUpdateBroker(ctx, database.GetDBMap)
+		InitWorkflowLogsBroker(ctx)
 
 		router = &Router{
 			mux: mux.NewRouter(),
@@ -178,10 +196,15 @@ var mainCmd = &cobra.Command{
 			APIBaseURL:             viper.GetString(viperURLAPI),
 			DisableGithubSetStatus: viper.GetBool(viperVCSRepoGithubStatusDisabled),
 			DisableGithubStatusURL: viper.GetBool(viperVCSRepoGithubStatusURLDisabled),
+			DisableGitlabSetStatus: viper.GetBool(viperVCSRepoGitlabStatusDisabled),
+			DisableGiteaSetStatus:  viper.GetBool(viperVCSRepoGiteaStatusDisabled),
 			DisableStashSetStatus:  viper.GetBool(viperVCSRepoBitbucketStatusDisabled),
 			GithubSecret:           viper.GetString(viperVCSRepoGithubSecret),
+			GitlabSecret:           viper.GetString(viperVCSRepoGitlabSecret),
 			StashPrivateKey:        viper.GetString(viperVCSRepoBitbucketPrivateKey),
 			StashConsumerKey:       viper.GetString(viperVCSRepoBitbucketConsumerKey),
+			GitPrivateKey:          viper.GetString(viperVCSRepoGitPrivateKey),
+			GitMirrorsDirectory:    viper.GetString(viperVCSRepoGitMirrorsDirectory),
 		}
 		if err := repositoriesmanager.Initialize(rmInitOpts); err != nil {
 			log.Warning("Error initializing repositories manager connections: %s", err)
@@ -189,6 +212,7 @@ var mainCmd = &cobra.Command{
 
 		//Initiliaze hook package
 		hook.Init(viper.GetString(viperURLAPI))
+		workflowhook.Init(viper.GetString(viperURLAPI))
 
 		//Intialize notification package
 		notification.Init(viper.GetString(viperURLAPI), baseURL)
@@ -196,8 +220,8 @@ var mainCmd = &cobra.Command{
 		// Initialize the auth driver
 		var authMode string
 		var authOptions interface{}
-		switch viper.GetBool(viperAuthLDAPEnable) {
-		case true:
+		switch {
+		case viper.GetBool(viperAuthLDAPEnable):
 			authMode = "ldap"
 			authOptions = auth.LDAPConfig{
 				Host:         viper.GetString(viperAuthLDAPHost),
@@ -207,6 +231,17 @@ var mainCmd = &cobra.Command{
 				SSL:          viper.GetBool(viperAuthLDAPSSL),
 				UserFullname: viper.GetString(viperAuthLDAPFullname),
 			}
+		case viper.GetBool(viperAuthOIDCEnable):
+			authMode = "oidc"
+			authOptions = auth.OIDCConfig{
+				Issuer:        viper.GetString(viperAuthOIDCIssuer),
+				ClientID:      viper.GetString(viperAuthOIDCClientID),
+				ClientSecret:  viper.GetString(viperAuthOIDCClientSecret),
+				RedirectURL:   viper.GetString(viperAuthOIDCRedirectURL),
+				Scopes:        viper.GetStringSlice(viperAuthOIDCScopes),
+				UsernameClaim: viper.GetString(viperAuthOIDCUsernameClaim),
+				GroupsClaim:   viper.GetString(viperAuthOIDCGroupsClaim),
+			}
 		default:
 			authMode = "local"
 		}
@@ -254,6 +289,12 @@ var mainCmd = &cobra.Command{
 		go hookRecoverer(ctx, database.GetDBMap)
 
 		go user.PersistentSessionTokenCleaner(ctx, database.GetDBMap)
+		go purge.Artifacts(ctx, database.GetDBMap)
+		go purge.Runs(ctx, database.GetDBMap, purge.RunsOptions{
+			KeepLastRuns: viper.GetInt(viperWorkflowsPurgeKeepRuns),
+			KeepDays:     viper.GetInt(viperWorkflowsPurgeKeepDays),
+			BatchSize:    viper.GetInt(viperWorkflowsPurgeBatchSize),
+		})
 
 		if !viper.GetBool(viperVCSPollingDisabled) {
 			go poller.Initialize(ctx, 10, database.GetDBMap)
@@ -267,6 +308,14 @@ var mainCmd = &cobra.Command{
 			log.Warning("⚠ Cron Scheduler is disabled")
 		}
 
+		if !viper.GetBool(viperWorkflowsHooksDisabled) {
+			if err := workflowhook.Initialize(ctx, 10, database.GetDBMap); err != nil {
+				log.Warning("⚠ Error while initializing workflow hooks routine: %s", err)
+			}
+		} else {
+			log.Warning("⚠ Workflow hooks are disabled")
+		}
+
 		s := &http.Server{
 			Addr:           ":" + viper.GetString(viperServerHTTPPort),
 			Handler:        router.mux,
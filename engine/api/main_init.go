@@ -25,6 +25,7 @@ const (
 	viperServerSessionTTL               = "server.http.sessionTTL"
 	viperServerGRPCPort                 = "server.grpc.port"
 	viperServerSecretKey                = "server.secrets.key"
+	viperServerSecretKeyring            = "server.secrets.keyring"
 	viperLogLevel                       = "log.level"
 	viperDBUser                         = "db.user"
 	viperDBPassword                     = "db.password"
@@ -48,6 +49,14 @@ const (
 	viperAuthLDAPBase                   = "auth.ldap.base"
 	viperAuthLDAPDN                     = "auth.ldap.dn"
 	viperAuthLDAPFullname               = "auth.ldap.fullname"
+	viperAuthOIDCEnable                 = "auth.oidc.enable"
+	viperAuthOIDCIssuer                 = "auth.oidc.issuer"
+	viperAuthOIDCClientID               = "auth.oidc.clientid"
+	viperAuthOIDCClientSecret           = "auth.oidc.clientsecret"
+	viperAuthOIDCRedirectURL            = "auth.oidc.redirecturl"
+	viperAuthOIDCScopes                 = "auth.oidc.scopes"
+	viperAuthOIDCUsernameClaim          = "auth.oidc.usernameclaim"
+	viperAuthOIDCGroupsClaim            = "auth.oidc.groupsclaim"
 	viperAuthDefaultGroup               = "auth.defaultgroup"
 	viperAuthSharedInfraToken           = "auth.sharedinfra.token"
 	viperSMTPDisable                    = "smtp.disable"
@@ -65,6 +74,14 @@ const (
 	viperArtifactOSTenant               = "artifact.openstack.tenant"
 	viperArtifactOSRegion               = "artifact.openstack.region"
 	viperArtifactOSContainerPrefix      = "artifact.openstack.containerprefix"
+	viperArtifactS3Endpoint             = "artifact.s3.endpoint"
+	viperArtifactS3Region               = "artifact.s3.region"
+	viperArtifactS3Bucket               = "artifact.s3.bucket"
+	viperArtifactS3Prefix               = "artifact.s3.prefix"
+	viperArtifactS3AccessKeyID          = "artifact.s3.accesskeyid"
+	viperArtifactS3SecretAccessKey      = "artifact.s3.secretaccesskey"
+	viperArtifactS3PathStyle            = "artifact.s3.pathstyle"
+	viperArtifactS3PartSize             = "artifact.s3.partsize"
 	viperEventsKafkaEnabled             = "events.kafka.enabled"
 	viperEventsKafkaBroker              = "events.kafka.broker"
 	viperEventsKafkaTopic               = "events.kafka.topic"
@@ -72,9 +89,19 @@ const (
 	viperEventsKafkaPassword            = "events.kafka.password"
 	viperSchedulersDisabled             = "schedulers.disabled"
 	viperVCSPollingDisabled             = "vcs.polling.disabled"
+	viperWorkflowsHooksDisabled         = "workflows.hooks.disabled"
+	viperWorkflowsPurgeKeepRuns         = "workflows.purge.keepruns"
+	viperWorkflowsPurgeKeepDays         = "workflows.purge.keepdays"
+	viperWorkflowsPurgeBatchSize        = "workflows.purge.batchsize"
+	viperWorkflowsCacheQuota            = "workflows.cache.quota"
 	viperVCSRepoGithubStatusDisabled    = "vcs.repositories.github.statuses_disabled"
 	viperVCSRepoGithubStatusURLDisabled = "vcs.repositories.github.statuses_url_disabled"
 	viperVCSRepoGithubSecret            = "vcs.repositories.github.clientsecret"
+	viperVCSRepoGitlabStatusDisabled    = "vcs.repositories.gitlab.statuses_disabled"
+	viperVCSRepoGitlabSecret            = "vcs.repositories.gitlab.clientsecret"
+	viperVCSRepoGiteaStatusDisabled     = "vcs.repositories.gitea.statuses_disabled"
+	viperVCSRepoGitPrivateKey           = "vcs.repositories.git.privatekey"
+	viperVCSRepoGitMirrorsDirectory     = "vcs.repositories.git.mirrorsdirectory"
 	viperVCSRepoBitbucketStatusDisabled = "vcs.repositories.bitbucket.statuses_disabled"
 	viperVCSRepoBitbucketConsumerKey    = "vcs.repositories.bitbucket.consumerkey"
 	viperVCSRepoBitbucketPrivateKey     = "vcs.repositories.bitbucket.privatekey"
@@ -212,6 +239,7 @@ const tmpl = `###################################
 # CDS_SERVER_HTTP_SESSIONTTL
 # CDS_SERVER_GRPC_PORT
 # CDS_SERVER_SECRETS_KEY
+# CDS_SERVER_SECRETS_KEYRING
 # CDS_LOG_LEVEL
 # CDS_DB_USER
 # CDS_DB_PASSWORD
@@ -235,6 +263,14 @@ const tmpl = `###################################
 # CDS_AUTH_LDAP_BASE
 # CDS_AUTH_LDAP_DN
 # CDS_AUTH_LDAP_FULLNAME
+# CDS_AUTH_OIDC_ENABLE
+# CDS_AUTH_OIDC_ISSUER
+# CDS_AUTH_OIDC_CLIENTID
+# CDS_AUTH_OIDC_CLIENTSECRET
+# CDS_AUTH_OIDC_REDIRECTURL
+# CDS_AUTH_OIDC_SCOPES
+# CDS_AUTH_OIDC_USERNAMECLAIM
+# CDS_AUTH_OIDC_GROUPSCLAIM
 # CDS_AUTH_DEFAULTGROUP
 # CDS_AUTH_SHAREDINFRA_TOKEN
 # CDS_SMTP_DISABLE
@@ -252,6 +288,14 @@ const tmpl = `###################################
 # CDS_ARTIFACT_OPENSTACK_TENANT
 # CDS_ARTIFACT_OPENSTACK_REGION
 # CDS_ARTIFACT_OPENSTACK_CONTAINERPREFIX
+# CDS_ARTIFACT_S3_ENDPOINT
+# CDS_ARTIFACT_S3_REGION
+# CDS_ARTIFACT_S3_BUCKET
+# CDS_ARTIFACT_S3_PREFIX
+# CDS_ARTIFACT_S3_ACCESSKEYID
+# CDS_ARTIFACT_S3_SECRETACCESSKEY
+# CDS_ARTIFACT_S3_PATHSTYLE
+# CDS_ARTIFACT_S3_PARTSIZE
 # CDS_EVENTS_KAFKA_ENABLED
 # CDS_EVENTS_KAFKA_BROKER
 # CDS_EVENTS_KAFKA_TOPIC
@@ -259,9 +303,18 @@ const tmpl = `###################################
 # CDS_EVENTS_KAFKA_PASSWORD
 # CDS_SCHEDULERS_DISABLED
 # CDS_VCS_POLLING_DISABLED
+# CDS_WORKFLOWS_HOOKS_DISABLED
+# CDS_WORKFLOWS_PURGE_KEEPRUNS
+# CDS_WORKFLOWS_PURGE_KEEPDAYS
+# CDS_WORKFLOWS_PURGE_BATCHSIZE
 # CDS_VCS_REPOSITORIES_GITHUB_STATUSES_DISABLED
 # CDS_VCS_REPOSITORIES_GITHUB_STATUSES_URL_DISABLED
 # CDS_VCS_REPOSITORIES_GITHUB_CLIENTSECRET
+# CDS_VCS_REPOSITORIES_GITLAB_STATUSES_DISABLED
+# CDS_VCS_REPOSITORIES_GITLAB_CLIENTSECRET
+# CDS_VCS_REPOSITORIES_GITEA_STATUSES_DISABLED
+# CDS_VCS_REPOSITORIES_GIT_PRIVATEKEY
+# CDS_VCS_REPOSITORIES_GIT_MIRRORSDIRECTORY
 # CDS_VCS_REPOSITORIES_BITBUCKET_STATUSES_DISABLED
 # CDS_VCS_REPOSITORIES_BITBUCKET_CONSUMERKEY
 # CDS_VCS_REPOSITORIES_BITBUCKET_PRIVATEKEY
@@ -303,6 +356,10 @@ keys = "/app/keys"
 		# AES Cypher key for database encryption. 32 char.
 		# This is mandatory
     key = "{{.ServerSecretsKey}}"
+		# Versioned AES Cypher keys, as "<version>:<key>". The key with the greatest version encrypts the secrets,
+		# the secrets encrypted with any key of the keyring or with the key above can be decrypted.
+		# After adding a key, the secrets are encrypted again with POST /admin/secrets/rotation
+		# keyring = ["1:{{.ServerSecretsKey}}"]
 
 
 ################################
@@ -360,6 +417,23 @@ defaultgroup = ""
 	# Define CDS user fullname from LDAP attribute
 	fullname = "{{.GivenName}} {{.SN}}"
 
+	# OpenID Connect authentication, with authorization code flow and PKCE.
+	# Users are created at their first login, local users are still able to login.
+	[auth.oidc]
+	enable = false
+	# Issuer URL, the provider configuration is loaded from <issuer>/.well-known/openid-configuration
+	issuer = ""
+	clientid = ""
+	clientsecret = ""
+	# URL the provider redirects to with the authorization code, i.e. <api-url>/login/oidc/callback
+	redirecturl = ""
+	scopes = ["openid", "profile", "email"]
+	# ID token claim used as CDS username
+	usernameclaim = "preferred_username"
+	# ID token claim listing the groups of the user. Users are added to the existing CDS groups
+	# listed in this claim and removed from the others. Leave empty to disable groups mapping.
+	groupsclaim = ""
+
 #####################
 # CDS SMTP Settings #
 #####################
@@ -375,9 +449,10 @@ from = "no-reply@cds.org"
 ##########################
 # CDS Artifacts Settings #
 ##########################
-# Either filesystem local storage or Openstack Swift Storage are supported
+# Either filesystem local storage, Openstack Swift Storage or S3 compatible storage are supported
 [artifact]
 # mode = "swift#
+# mode = "s3"
 mode = "local"
 
     [artifact.local]
@@ -391,6 +466,16 @@ mode = "local"
     region = "<OS_REGION_NAME>"
     containerprefix = "" # Use if your want to prefix containers
 
+    [artifact.s3]
+    endpoint = "" # Leave empty to use AWS, or set the url of your MinIO / Ceph RGW (example: http://localhost:9000)
+    region = "us-east-1"
+    bucket = "cds-artifacts"
+    prefix = "" # Use if your want to prefix objects keys
+    accesskeyid = "<S3_ACCESS_KEY_ID>"
+    secretaccesskey = "<S3_SECRET_ACCESS_KEY>"
+    pathstyle = false # Set to true for most of the self-hosted storages
+    partsize = 16777216 # Size in bytes of the parts of multipart uploads, at least 5MB
+
 #######################
 # CDS Events Settings #
 #######################
@@ -409,6 +494,26 @@ mode = "local"
 [schedulers]
 disabled = false #This is mainly for dev purpose, you should not have to change it
 
+###############################
+# CDS Workflow Hooks Settings #
+###############################
+[workflows]
+    [workflows.hooks]
+    disabled = false #This is mainly for dev purpose, you should not have to change it
+
+    # Purge of the old workflow runs, with their jobs, logs and artifacts.
+    # The latest run of each branch and the released runs are always kept.
+    # The purge is disabled if keepruns and keepdays are 0
+    [workflows.purge]
+    keepruns = 0 # Number of runs kept for each workflow
+    keepdays = 0 # Runs younger than keepdays days are kept
+    batchsize = 100 # Number of runs deleted in a transaction
+
+    # Caches saved by the CacheSave action. When the caches of a project exceed the quota,
+    # the least recently used ones are deleted. The quota is disabled if it is 0
+    [workflows.cache]
+    quota = 2048 # Size in MB of the caches of each project
+
 ####################
 # CDS VCS Settings #
 ####################
@@ -423,6 +528,17 @@ disabled = false #This is mainly for dev purpose, you should not have to change
     statuses_url_disabled = false # Set to true if you don't want CDS to push CDS URL in statuses on Github API
     clientsecret = ""
 
+    [vcs.repositories.gitlab]
+    statuses_disabled = false # Set to true if you don't want CDS to push statuses on Gitlab API
+    clientsecret = ""
+
+    [vcs.repositories.gitea]
+    statuses_disabled = false # Set to true if you don't want CDS to push statuses on Gitea API
+
+    [vcs.repositories.git]
+    privatekey = "" # SSH private key used to reach the git servers
+    mirrorsdirectory = "" # Directory of the local mirrors of the repositories, default is in the temp directory
+
     [vcs.repositories.bitbucket]
     statuses_disabled = false
     privatekey = ""
@@ -9,6 +9,8 @@ import (
 
 func (router *Router) init() {
 	router.Handle("/login", POST(LoginUser, Auth(false)))
+	router.Handle("/login/oidc", GET(oidcLoginHandler, Auth(false)))
+	router.Handle("/login/oidc/callback", GET(oidcCallbackHandler, Auth(false)))
 
 	// Action
 	router.Handle("/action", GET(getActionsHandler))
@@ -21,6 +23,7 @@ func (router *Router) init() {
 	// Admin
 	router.Handle("/admin/warning", DELETE(adminTruncateWarningsHandler, NeedAdmin(true)))
 	router.Handle("/admin/maintenance", POST(postAdminMaintenanceHandler, NeedAdmin(true)), GET(getAdminMaintenanceHandler, NeedAdmin(true)), DELETE(deleteAdminMaintenanceHandler, NeedAdmin(true)))
+	router.Handle("/admin/secrets/rotation", POST(postAdminSecretRotationHandler, NeedAdmin(true)), GET(getAdminSecretRotationHandler, NeedAdmin(true)))
 
 	// Action plugin
 	router.Handle("/plugin", POST(addPluginHandler, NeedAdmin(true)), PUT(updatePluginHandler, NeedAdmin(true)))
@@ -48,6 +51,7 @@ func (router *Router) init() {
 
 	// Hooks
 	router.Handle("/hook", POST(receiveHook, Auth(false) /* Public handler called by third parties */))
+	router.Handle("/hook/workflow/{uuid}", POST(postWorkflowWebHookHandler, Auth(false) /* Public handler called by third parties */))
 
 	// Overall health
 	router.Handle("/mon/status", GET(statusHandler, Auth(false)))
@@ -119,6 +123,8 @@ func (router *Router) init() {
 
 	router.Handle("/project/{permProjectKey}/pipeline", GET(getPipelinesHandler), POST(addPipeline))
 	router.Handle("/project/{permProjectKey}/import/pipeline", POST(importPipelineHandler))
+	router.Handle("/project/{permProjectKey}/artifacts/retention", GET(getArtifactRetentionsHandler), PUT(putArtifactRetentionHandler), DELETE(deleteArtifactRetentionHandler))
+	router.Handle("/project/{permProjectKey}/artifacts/retention/report", GET(getArtifactPurgeReportHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/application", GET(getApplicationUsingPipelineHandler))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/group", POST(addGroupInPipelineHandler), PUT(updateGroupsOnPipelineHandler, DEPRECATED))
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/group/{group}", PUT(updateGroupRoleOnPipelineHandler), DELETE(deleteGroupFromPipelineHandler))
@@ -133,20 +139,31 @@ func (router *Router) init() {
 
 	// Workflows
 	router.Handle("/project/{permProjectKey}/workflows", POST(postWorkflowHandler), GET(getWorkflowsHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}", GET(getWorkflowHandler), PUT(putWorkflowHandler), DELETE(deleteWorkflowHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}", GET(getWorkflowHandler), PUT(putWorkflowHandler), DELETE(deleteWorkflowHandler))
+	router.Handle("/project/{permProjectKey}/import/workflows", POST(postWorkflowImportHandler))
+	router.Handle("/project/{permProjectKey}/export/workflows/{permWorkflowName}", GET(getWorkflowExportHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/groups", POST(postWorkflowGroupHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/groups/{groupName}", PUT(putWorkflowGroupHandler), DELETE(deleteWorkflowGroupHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/artifacts/retention", PUT(putArtifactRetentionHandler), DELETE(deleteArtifactRetentionHandler))
 	// Workflows run
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs", GET(getWorkflowRunsHandler), POST(postWorkflowRunHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/latest", GET(getLatestWorkflowRunHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/tags", GET(getWorkflowRunTagsHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}", GET(getWorkflowRunHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}/artifacts", GET(getWorkflowRunArtifactsHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}/nodes/{id}", GET(getWorkflowNodeRunHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}/nodes/{id}/job/{runJobId}/step/{stepOrder}", GET(getWorkflowNodeRunJobStepHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}/nodes/{id}/artifacts", GET(getWorkflowNodeRunArtifactsHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/artifact/{artifactId}", GET(getDownloadArtifactHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/node/{nodeID}/triggers/condition", GET(getWorkflowTriggerConditionHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/join/{joinID}/triggers/condition", GET(getWorkflowTriggerJoinConditionHandler))
-	router.Handle("/project/{permProjectKey}/workflows/{workflowName}/runs/{number}/nodes/{id}/release", POST(releaseApplicationWorkflowHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs", GET(getWorkflowRunsHandler), POSTEXECUTE(postWorkflowRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/latest", GET(getLatestWorkflowRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/tags", GET(getWorkflowRunTagsHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}", GET(getWorkflowRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/stop", POSTEXECUTE(stopWorkflowRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/artifacts", GET(getWorkflowRunArtifactsHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}", GET(getWorkflowNodeRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/stop", POSTEXECUTE(stopWorkflowNodeRunHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/restart", POSTEXECUTE(postWorkflowNodeRunRestartHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/approval", POSTEXECUTE(postWorkflowNodeRunApprovalHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/job/{runJobId}/step/{stepOrder}", GET(getWorkflowNodeRunJobStepHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/job/{runJobId}/logs", GET(getWorkflowNodeRunJobLogsHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/artifacts", GET(getWorkflowNodeRunArtifactsHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/artifact/{artifactId}", GET(getDownloadArtifactHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/artifact/{artifactId}/url", GET(getDownloadArtifactWithTempURLHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/node/{nodeID}/triggers/condition", GET(getWorkflowTriggerConditionHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/join/{joinID}/triggers/condition", GET(getWorkflowTriggerJoinConditionHandler))
+	router.Handle("/project/{permProjectKey}/workflows/{permWorkflowName}/runs/{number}/nodes/{id}/release", POST(releaseApplicationWorkflowHandler))
 
 	// DEPRECATED
 	router.Handle("/project/{key}/pipeline/{permPipelineKey}/action/{jobID}", PUT(updatePipelineActionHandler, DEPRECATED), DELETE(deleteJobHandler))
@@ -182,6 +199,7 @@ func (router *Router) init() {
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/{buildNumber}/artifact", GET(listArtifactsBuildHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/{buildNumber}/artifact/{tag}", POSTEXECUTE(uploadArtifactHandler))
 	router.Handle("/project/{key}/application/{permApplicationName}/pipeline/{permPipelineKey}/artifact/download/{id}", GET(downloadArtifactHandler))
+	router.Handle("/artifact/store", GET(getArtifactsStoreHandler))
 	router.Handle("/artifact/{hash}", GET(downloadArtifactDirectHandler, Auth(false)))
 
 	// Hooks
@@ -210,12 +228,20 @@ func (router *Router) init() {
 	router.Handle("/queue/workflows/{id}/book", POST(postBookWorkflowJobHandler, NeedHatchery()))
 	router.Handle("/queue/workflows/{id}/infos", GET(getWorkflowJobHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{id}/spawn/infos", POST(postSpawnInfosWorkflowJobHandler, NeedHatchery()))
+	router.Handle("/queue/workflows/{id}/priority", PUT(putWorkflowJobPriorityHandler, NeedAdmin(true)))
 	router.Handle("/queue/workflows/{permID}/result", POSTEXECUTE(postWorkflowJobResultHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{permID}/log", POSTEXECUTE(postWorkflowJobLogsHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{permID}/test", POSTEXECUTE(postWorkflowJobTestsResultsHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{permID}/variable", POSTEXECUTE(postWorkflowJobVariableHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{permID}/step", POSTEXECUTE(postWorkflowJobStepStatusHandler, NeedWorker()))
 	router.Handle("/queue/workflows/{permID}/artifact/{tag}", POSTEXECUTE(postWorkflowJobArtifactHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/artifact/{tag}/url", POSTEXECUTE(postWorkflowJobArtifactWithTempURLHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/artifact/{tag}/url/callback", POSTEXECUTE(postWorkflowJobArtifactWithTempURLCallbackHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/cache/{key}", POSTEXECUTE(postWorkflowJobCacheHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/cache/{key}/restore", POSTEXECUTE(postWorkflowJobCacheRestoreHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/workercache/{key}", POSTEXECUTE(postWorkerCacheHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/workercache/{key}/restore", POSTEXECUTE(postWorkerCacheRestoreHandler, NeedWorker()))
+	router.Handle("/queue/workflows/{permID}/workercache/{key}/download", POSTEXECUTE(postWorkerCacheDownloadHandler, NeedWorker()))
 
 	router.Handle("/variable/type", GET(getVariableTypeHandler))
 	router.Handle("/parameter/type", GET(getParameterTypeHandler))
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/ovh/cds/sdk"
 )
 
 var storage Driver
 
+//TemporaryURLTTL is the validity of the temporary urls issued by the drivers
+const TemporaryURLTTL = 15 * time.Minute
+
 //Status is for status handler
 func Status() string {
 	if storage == nil {
@@ -43,6 +47,36 @@ func DeleteArtifact(o Object) error {
 	return fmt.Errorf("store not initialized")
 }
 
+//TemporaryURLSupported returns true if the default objectstore driver is able to issue temporary urls
+func TemporaryURLSupported() bool {
+	_, ok := storage.(DriverWithTemporaryURL)
+	return ok
+}
+
+//TemporaryURLMaxSize returns the maximum size of an artifact uploaded with a temporary url, 0 if there is no limit
+func TemporaryURLMaxSize() int64 {
+	if d, ok := storage.(DriverWithTemporaryURL); ok {
+		return d.TemporaryURLMaxSize()
+	}
+	return 0
+}
+
+//StoreArtifactURL returns a temporary url to upload an artifact directly on the default objectstore driver, and the path of the object
+func StoreArtifactURL(o Object) (string, string, error) {
+	if d, ok := storage.(DriverWithTemporaryURL); ok {
+		return d.StoreURL(o, TemporaryURLTTL)
+	}
+	return "", "", sdk.ErrNotImplemented
+}
+
+//FetchArtifactURL returns a temporary url to download an artifact directly from the default objectstore driver
+func FetchArtifactURL(o Object) (string, error) {
+	if d, ok := storage.(DriverWithTemporaryURL); ok {
+		return d.FetchURL(o, TemporaryURLTTL)
+	}
+	return "", sdk.ErrNotImplemented
+}
+
 //StorePlugin call Store on the common driver
 func StorePlugin(art sdk.ActionPlugin, data io.ReadCloser) (string, error) {
 	if storage != nil {
@@ -94,6 +128,7 @@ func DeleteTemplateExtension(tmpl sdk.TemplateExtension) error {
 // Driver allows artifact to be stored and retrieve the same way to any backend
 // - Openstack / Swift
 // - Filesystem
+// - S3 compatible storages
 type Driver interface {
 	Status() string
 	Store(o Object, data io.ReadCloser) (string, error)
@@ -101,6 +136,17 @@ type Driver interface {
 	Delete(o Object) error
 }
 
+// DriverWithTemporaryURL is implemented by the drivers able to issue short-lived signed urls,
+// so that the objects are uploaded and downloaded without going through the API
+// - S3 compatible storages
+// The bigger objects are uploaded through the API.
+type DriverWithTemporaryURL interface {
+	Driver
+	StoreURL(o Object, ttl time.Duration) (string, string, error)
+	TemporaryURLMaxSize() int64
+	FetchURL(o Object, ttl time.Duration) (string, error)
+}
+
 // Initialize setup wanted ObjectStore driver
 func Initialize(c context.Context, cfg Config) error {
 	var err error
@@ -119,6 +165,7 @@ const (
 	Openstack Kind = iota
 	Filesystem
 	Swift
+	S3
 )
 
 //TODO Use github.com/graymeta/stow
@@ -133,6 +180,7 @@ type Config struct {
 type ConfigOptions struct {
 	Openstack  ConfigOptionsOpenstack
 	Filesystem ConfigOptionsFilesystem
+	S3         ConfigOptionsS3
 }
 
 // ConfigOptionsOpenstack is used by ConfigOptions
@@ -150,6 +198,18 @@ type ConfigOptionsFilesystem struct {
 	Basedir string
 }
 
+// ConfigOptionsS3 is used by ConfigOptions
+type ConfigOptionsS3 struct {
+	Region          string
+	Bucket          string
+	Prefix          string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool
+	PartSize        int64
+}
+
 // New initialise a new ArtifactStorage
 func New(c context.Context, cfg Config) (Driver, error) {
 	switch cfg.Kind {
@@ -162,6 +222,15 @@ func New(c context.Context, cfg Config) (Driver, error) {
 			cfg.Options.Openstack.ContainerPrefix)
 	case Filesystem:
 		return NewFilesystemStore(cfg.Options.Filesystem.Basedir)
+	case S3:
+		return NewS3Store(cfg.Options.S3.Region,
+			cfg.Options.S3.Bucket,
+			cfg.Options.S3.Prefix,
+			cfg.Options.S3.Endpoint,
+			cfg.Options.S3.AccessKeyID,
+			cfg.Options.S3.SecretAccessKey,
+			cfg.Options.S3.PathStyle,
+			cfg.Options.S3.PartSize)
 	default:
 		return nil, fmt.Errorf("Invalid flag --artifact-mode")
 	}
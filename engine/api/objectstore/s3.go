@@ -0,0 +1,454 @@
+package objectstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ovh/cds/sdk/log"
+)
+
+const (
+	s3DefaultRegion   = "us-east-1"
+	s3MinPartSize     = 5 * 1024 * 1024
+	s3DefaultPartSize = 16 * 1024 * 1024
+	s3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MaxURLTTL       = 7 * 24 * time.Hour
+	s3MaxPutSize      = 5 * 1024 * 1024 * 1024
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// S3Store implements ObjectStore interface with any S3 compatible storage (AWS, MinIO, Ceph RGW...)
+type S3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	prefix          string
+	accessKeyID     string
+	secretAccessKey string
+	pathStyle       bool
+	partSize        int64
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Store creates a new ObjectStore with S3 driver.
+// If endpoint is empty, the AWS endpoint of the region is used. Path-style addressing (endpoint/bucket/key)
+// is needed by most of the self-hosted implementations, virtual-hosted addressing (bucket.endpoint/key) is used otherwise.
+func NewS3Store(region, bucket, prefix, endpoint, accessKeyID, secretAccessKey string, pathStyle bool, partSize int64) (*S3Store, error) {
+	log.Info("Objectstore> Initialize S3 driver on endpoint: %s, region: %s, bucket: %s, prefix: %s, path-style: %t", endpoint, region, bucket, prefix, pathStyle)
+	if bucket == "" {
+		return nil, fmt.Errorf("artifact storage is s3, but artifact.s3.bucket is not provided")
+	}
+
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("artifact storage is s3, but artifact.s3.accesskeyid or artifact.s3.secretaccesskey is not provided")
+	}
+
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("artifact storage is s3, but endpoint %s is invalid", endpoint)
+	}
+
+	if partSize == 0 {
+		partSize = s3DefaultPartSize
+	}
+	if partSize < s3MinPartSize {
+		return nil, fmt.Errorf("artifact storage is s3, but part size must be at least %d bytes", s3MinPartSize)
+	}
+
+	return &S3Store{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		prefix:          prefix,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		pathStyle:       pathStyle,
+		partSize:        partSize,
+		client:          &http.Client{Timeout: 5 * time.Minute},
+		now:             time.Now,
+	}, nil
+}
+
+//Status return S3 storage status
+func (s *S3Store) Status() string {
+	resp, err := s.do("HEAD", "", nil, nil, nil)
+	if err != nil {
+		return "S3 KO (" + err.Error() + ")"
+	}
+	resp.Body.Close()
+	return "S3 OK"
+}
+
+// Store stores an object in the bucket. Small objects are sent with a single request,
+// bigger ones are sent with a multipart upload
+func (s *S3Store) Store(o Object, data io.ReadCloser) (string, error) {
+	defer data.Close()
+	key := s.key(o)
+
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(data, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		resp, errP := s.do("PUT", key, nil, nil, buf[:n])
+		if errP != nil {
+			return "", errP
+		}
+		resp.Body.Close()
+		return s.bucket + "/" + key, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.multipartUpload(key, buf, data); err != nil {
+		return "", err
+	}
+	return s.bucket + "/" + key, nil
+}
+
+// Fetch gets an object from the bucket
+func (s *S3Store) Fetch(o Object) (io.ReadCloser, error) {
+	resp, err := s.do("GET", s.key(o), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object from the bucket
+func (s *S3Store) Delete(o Object) error {
+	resp, err := s.do("DELETE", s.key(o), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// StoreURL returns a presigned url to upload an object with a single PUT request, and the path of the object.
+// S3 refuses a single PUT of more than 5GB, see TemporaryURLMaxSize.
+func (s *S3Store) StoreURL(o Object, ttl time.Duration) (string, string, error) {
+	key := s.key(o)
+	u, err := s.presign("PUT", key, ttl)
+	if err != nil {
+		return "", "", err
+	}
+	return u, s.bucket + "/" + key, nil
+}
+
+// TemporaryURLMaxSize returns the maximum size of an object uploaded with a single PUT request
+func (s *S3Store) TemporaryURLMaxSize() int64 {
+	return s3MaxPutSize
+}
+
+// FetchURL returns a presigned url to download an object
+func (s *S3Store) FetchURL(o Object, ttl time.Duration) (string, error) {
+	return s.presign("GET", s.key(o), ttl)
+}
+
+func (s *S3Store) key(o Object) string {
+	return s.prefix + strings.TrimPrefix(path.Join(o.GetPath(), o.GetName()), "/")
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// multipartUpload sends first, then the rest of data by parts of partSize bytes.
+// The upload is aborted on error so that the parts are not kept in the bucket
+func (s *S3Store) multipartUpload(key string, first []byte, data io.Reader) error {
+	resp, err := s.do("POST", key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	var initiate s3InitiateMultipartUploadResult
+	errD := xml.NewDecoder(resp.Body).Decode(&initiate)
+	resp.Body.Close()
+	if errD != nil || initiate.UploadID == "" {
+		return fmt.Errorf("unable to initiate multipart upload of %s: %v", key, errD)
+	}
+	uploadID := initiate.UploadID
+
+	complete := s3CompleteMultipartUpload{}
+	errU := func() error {
+		part := first
+		for number := 1; ; number++ {
+			resp, err := s.do("PUT", key, url.Values{
+				"partNumber": {strconv.Itoa(number)},
+				"uploadId":   {uploadID},
+			}, nil, part)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			complete.Parts = append(complete.Parts, s3CompletedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+			n, err := io.ReadFull(data, first)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return err
+			}
+			part = first[:n]
+		}
+	}()
+
+	if errU == nil {
+		body, errM := xml.Marshal(complete)
+		if errM != nil {
+			return errM
+		}
+		resp, errU = s.do("POST", key, url.Values{"uploadId": {uploadID}}, nil, body)
+		if errU == nil {
+			//Complete can return an error in the body of a 200 response
+			errU = s3CheckBody(resp)
+		}
+	}
+
+	if errU != nil {
+		if resp, err := s.do("DELETE", key, url.Values{"uploadId": {uploadID}}, nil, nil); err != nil {
+			log.Warning("S3Store.multipartUpload> Unable to abort upload %s of %s: %s", uploadID, key, err)
+		} else {
+			resp.Body.Close()
+		}
+		return errU
+	}
+	return nil
+}
+
+func s3CheckBody(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var e s3Error
+	if xml.Unmarshal(body, &e) == nil {
+		return fmt.Errorf("s3 error %s: %s", e.Code, e.Message)
+	}
+	return nil
+}
+
+// do sends a signed request to the bucket. The response is returned only if its status is 2xx
+func (s *S3Store) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e s3Error
+		b, _ := ioutil.ReadAll(resp.Body)
+		if xml.Unmarshal(b, &e) == nil && e.Code != "" {
+			return nil, fmt.Errorf("s3 error %s on %s %s: %s (%d)", e.Code, method, key, e.Message, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("s3 error on %s %s: %s", method, key, resp.Status)
+	}
+	return resp, nil
+}
+
+// url computes the url of a key in the bucket with path-style or virtual-hosted addressing
+func (s *S3Store) url(key string, query url.Values) *url.URL {
+	u := &url.URL{
+		Scheme: s.endpoint.Scheme,
+		Host:   s.endpoint.Host,
+	}
+	p := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.pathStyle {
+		p += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	p += "/" + key
+	u.Path = p
+	u.RawPath = s3Escape(p, false)
+	u.RawQuery = s3CanonicalQuery(query)
+	return u
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	t := s.now().UTC()
+	payloadHash := s3EmptyPayload
+	if len(body) > 0 {
+		h := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(h[:])
+	}
+	req.Header.Set("X-Amz-Date", t.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := s3CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope, signature := s.signature(t, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKeyID, scope, signedHeaders, signature))
+}
+
+// presign returns an url authenticated with query parameters, valid for ttl
+func (s *S3Store) presign(method, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > s3MaxURLTTL {
+		return "", fmt.Errorf("invalid temporary url validity %s", ttl)
+	}
+	t := s.now().UTC()
+	u := s.url(key, nil)
+	scope := strings.Join([]string{t.Format(s3DateFormat), s.region, "s3", "aws4_request"}, "/")
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.accessKeyID + "/" + scope},
+		"X-Amz-Date":          {t.Format(s3TimeFormat)},
+		"X-Amz-Expires":       {strconv.FormatInt(int64(ttl/time.Second), 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = s3CanonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+
+	_, signature := s.signature(t, canonicalRequest)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// signature returns the credential scope and the signature of a canonical request
+func (s *S3Store) signature(t time.Time, canonicalRequest string) (string, string) {
+	scope := strings.Join([]string{t.Format(s3DateFormat), s.region, "s3", "aws4_request"}, "/")
+	h := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format(s3TimeFormat),
+		scope,
+		hex.EncodeToString(h[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + s.secretAccessKey)
+	for _, k := range []string{t.Format(s3DateFormat), s.region, "s3", "aws4_request"} {
+		key = s3HMAC(key, k)
+	}
+	return scope, hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalHeaders returns the signed headers list and the canonical headers: host,
+// the content and range headers and all the x-amz-* headers
+func s3CanonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") || k == "content-type" || k == "content-md5" || k == "range" {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var canonical bytes.Buffer
+	for _, k := range keys {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	return strings.Join(keys, ";"), canonical.String()
+}
+
+// s3CanonicalQuery encodes the query sorted by key, as expected by the signature
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := []string{}
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes all the characters but the unreserved ones
+func s3Escape(s string, escapeSlash bool) string {
+	var buf bytes.Buffer
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
@@ -30,6 +30,7 @@ func initPermissionFunc() map[string]PermCheckFunc {
 		"permActionName":      checkActionPermissions,
 		"permEnvironmentName": checkEnvironmentPermissions,
 		"permModelID":         checkWorkerModelPermissions,
+		"permWorkflowName":    checkWorkflowPermissions,
 	}
 }
 
@@ -97,11 +98,16 @@ func checkPermission(routeVar map[string]string, c *businesscontext.Ctx, permiss
 	return permissionOk
 }
 
-func checkProjectPermissions(projectKey string, c *businesscontext.Ctx, permission int, routeVar map[string]string) bool {
+func checkProjectPermissions(projectKey string, c *businesscontext.Ctx, permissionValue int, routeVar map[string]string) bool {
+	// On workflow routes, the expected permission is checked on the workflow itself
+	if _, ok := routeVar["permWorkflowName"]; ok {
+		permissionValue = permission.PermissionRead
+	}
+
 	if c.User.Groups != nil {
 		for _, g := range c.User.Groups {
 			for _, p := range g.ProjectGroups {
-				if projectKey == p.Project.Key && p.Permission >= permission {
+				if projectKey == p.Project.Key && p.Permission >= permissionValue {
 					return true
 				}
 			}
@@ -111,6 +117,23 @@ func checkProjectPermissions(projectKey string, c *businesscontext.Ctx, permissi
 	return false
 }
 
+func checkWorkflowPermissions(workflowName string, c *businesscontext.Ctx, permissionValue int, routeVar map[string]string) bool {
+	// Check if param key exist
+	if projectKey, ok := routeVar["permProjectKey"]; ok {
+		for _, g := range c.User.Groups {
+			for _, w := range g.WorkflowGroups {
+				if workflowName == w.Workflow.Name && w.Permission >= permissionValue && projectKey == w.Workflow.ProjectKey {
+					return true
+				}
+			}
+		}
+		log.Warning("Access denied. user %s on workflow %s", c.User.Username, workflowName)
+	} else {
+		log.Warning("Wrong route configuration. need permProjectKey parameter")
+	}
+	return false
+}
+
 func checkPipelinePermissions(pipelineName string, c *businesscontext.Ctx, permission int, routeVar map[string]string) bool {
 	// Check if param key exist
 	if projectKey, ok := routeVar["key"]; ok {
@@ -15,6 +15,9 @@ const (
 	PermissionRead = 4
 	// PermissionReadExecute  read & execute permission on the resource
 	PermissionReadExecute = 5
+	// PermissionReadExecuteApprove read/execute permission on a workflow, plus the approval of protected manual runs.
+	// Write permission on a workflow implies the approval.
+	PermissionReadExecuteApprove = 6
 	// PermissionReadWriteExecute read/execute/write permission on the resource
 	PermissionReadWriteExecute = 7
 )
@@ -88,6 +91,36 @@ func EnvironmentPermission(envID int64, user *sdk.User) int {
 	return max
 }
 
+// WorkflowPermission  Get the permission for the given workflow
+func WorkflowPermission(workflowID int64, user *sdk.User) int {
+	if user.Admin {
+		return PermissionReadWriteExecute
+	}
+	max := 0
+	for _, g := range user.Groups {
+		for _, wg := range g.WorkflowGroups {
+			if wg.Workflow.ID == workflowID && wg.Permission > max {
+				max = wg.Permission
+			}
+		}
+	}
+	return max
+}
+
+// AccessToWorkflow check if we can access the given workflow with the given access
+func AccessToWorkflow(workflowID int64, user *sdk.User, access int) bool {
+	if user.Admin {
+		return true
+	}
+
+	for _, g := range user.Groups {
+		if g.ID == SharedInfraGroupID {
+			return true
+		}
+	}
+	return WorkflowPermission(workflowID, user) >= access
+}
+
 // AccessToApplication check if we can modify the given application
 func AccessToApplication(applicationID int64, user *sdk.User, access int) bool {
 	if user.Admin {
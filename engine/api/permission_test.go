@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/sdk"
 )
 
@@ -117,3 +119,114 @@ func Test_checkWorkerModelPermissionsByUser(t *testing.T) {
 		}
 	}
 }
+
+func Test_checkWorkflowPermissions(t *testing.T) {
+	u := &sdk.User{
+		Username: "foo",
+		Groups: []sdk.Group{
+			{
+				ID: 1,
+				ProjectGroups: []sdk.ProjectGroup{
+					{Project: sdk.Project{Key: "KEY"}, Permission: permission.PermissionRead},
+				},
+				WorkflowGroups: []sdk.WorkflowGroup{
+					{Workflow: sdk.Workflow{ID: 1, Name: "build", ProjectKey: "KEY"}, Permission: permission.PermissionReadWriteExecute},
+					{Workflow: sdk.Workflow{ID: 2, Name: "deploy", ProjectKey: "KEY"}, Permission: permission.PermissionReadExecute},
+				},
+			},
+		},
+	}
+	c := &businesscontext.Ctx{User: u}
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		perm     int
+		expected bool
+	}{
+		{"write on build", map[string]string{"permProjectKey": "KEY", "permWorkflowName": "build"}, permission.PermissionReadWriteExecute, true},
+		{"execute on deploy", map[string]string{"permProjectKey": "KEY", "permWorkflowName": "deploy"}, permission.PermissionReadExecute, true},
+		{"write on deploy", map[string]string{"permProjectKey": "KEY", "permWorkflowName": "deploy"}, permission.PermissionReadWriteExecute, false},
+		{"read on unknown workflow", map[string]string{"permProjectKey": "KEY", "permWorkflowName": "other"}, permission.PermissionRead, false},
+		{"read on another project", map[string]string{"permProjectKey": "OTHER", "permWorkflowName": "build"}, permission.PermissionRead, false},
+		{"write on project", map[string]string{"permProjectKey": "KEY"}, permission.PermissionReadWriteExecute, false},
+	}
+	for _, tt := range tests {
+		if got := checkPermission(tt.vars, c, tt.perm); got != tt.expected {
+			t.Errorf("%q. checkPermission() = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func Test_checkManualRunPermission(t *testing.T) {
+	wf := &sdk.Workflow{ID: 1}
+	protected := &sdk.WorkflowNode{Name: "prod", Context: &sdk.WorkflowNodeContext{ProtectedManualRun: true}}
+	unprotected := &sdk.WorkflowNode{Name: "dev", Context: &sdk.WorkflowNodeContext{}}
+
+	userWithPerm := func(p int) *sdk.User {
+		return &sdk.User{
+			Username: "foo",
+			Groups: []sdk.Group{
+				{
+					ID: 1,
+					WorkflowGroups: []sdk.WorkflowGroup{
+						{Workflow: sdk.Workflow{ID: 1}, Permission: p},
+					},
+				},
+			},
+		}
+	}
+
+	if err := checkManualRunPermission(wf, unprotected, userWithPerm(permission.PermissionReadExecute)); err != nil {
+		t.Errorf("unprotected node should be run with execute permission: %v", err)
+	}
+	if err := checkManualRunPermission(wf, protected, userWithPerm(permission.PermissionReadExecute)); err == nil {
+		t.Errorf("protected node should not be run with execute permission")
+	}
+	if err := checkManualRunPermission(wf, protected, userWithPerm(permission.PermissionReadExecuteApprove)); err != nil {
+		t.Errorf("protected node should be run with approve permission: %v", err)
+	}
+	if err := checkManualRunPermission(wf, protected, userWithPerm(permission.PermissionReadWriteExecute)); err != nil {
+		t.Errorf("protected node should be run with write permission: %v", err)
+	}
+	if err := checkManualRunPermission(wf, nil, userWithPerm(permission.PermissionReadWriteExecute)); err == nil {
+		t.Errorf("unknown node should not be run")
+	}
+}
+
+func Test_checkApprovalPermission(t *testing.T) {
+	byPermission := &sdk.WorkflowNode{Name: "prod", Context: &sdk.WorkflowNodeContext{Approval: &sdk.WorkflowNodeApproval{NbApprovers: 1}}}
+	byGroups := &sdk.WorkflowNode{Name: "prod", Context: &sdk.WorkflowNodeContext{Approval: &sdk.WorkflowNodeApproval{NbApprovers: 1, Groups: []string{"ops"}}}}
+	noApproval := &sdk.WorkflowNode{Name: "dev", Context: &sdk.WorkflowNodeContext{}}
+
+	userWithPerm := func(groupName string, p int) *sdk.User {
+		return &sdk.User{
+			Username: "foo",
+			Groups: []sdk.Group{
+				{
+					ID:   1,
+					Name: groupName,
+					WorkflowGroups: []sdk.WorkflowGroup{
+						{Workflow: sdk.Workflow{ID: 1}, Permission: p},
+					},
+				},
+			},
+		}
+	}
+
+	if err := checkApprovalPermission(1, byPermission, userWithPerm("devs", permission.PermissionReadExecute)); err == nil {
+		t.Errorf("node should not be approved with execute permission")
+	}
+	if err := checkApprovalPermission(1, byPermission, userWithPerm("devs", permission.PermissionReadExecuteApprove)); err != nil {
+		t.Errorf("node should be approved with approve permission: %v", err)
+	}
+	if err := checkApprovalPermission(1, byGroups, userWithPerm("devs", permission.PermissionReadWriteExecute)); err == nil {
+		t.Errorf("node should not be approved by a user outside of the approval groups")
+	}
+	if err := checkApprovalPermission(1, byGroups, userWithPerm("ops", permission.PermissionReadExecute)); err != nil {
+		t.Errorf("node should be approved by a member of the approval groups: %v", err)
+	}
+	if err := checkApprovalPermission(1, noApproval, userWithPerm("ops", permission.PermissionReadWriteExecute)); err == nil {
+		t.Errorf("node without approval should not be approved")
+	}
+}
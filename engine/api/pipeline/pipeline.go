@@ -387,7 +387,7 @@ func attachPipelineWarnings(pb *sdk.PipelineBuild) {
 					job := &stage.Jobs[iB]
 					for iSt := range build.Job.StepStatus {
 						step := &build.Job.StepStatus[iSt]
-						if build.Job.Action.Actions[iSt].Enabled && build.Job.Action.Actions[iSt].Optional && step.Status == sdk.StatusFail.String() {
+						if build.Job.Action.Actions[iSt].Enabled && build.Job.Action.Actions[iSt].Optional && (step.Status == sdk.StatusFail.String() || step.Status == sdk.StatusTimeout.String()) {
 							w := sdk.PipelineBuildWarning{Type: sdk.OptionalStepFailed, Action: build.Job.Action.Actions[iSt]}
 							pb.Warnings = append(pb.Warnings, w)
 							stage.Warnings = append(stage.Warnings, w)
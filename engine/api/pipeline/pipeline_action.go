@@ -8,6 +8,7 @@ import (
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/database/gorpmapping"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
 )
@@ -102,9 +103,22 @@ func InsertJob(db gorp.SqlExecutor, job *sdk.Job, stageID int64, pip *sdk.Pipeli
 	}
 	job.PipelineStageID = stage.ID
 
+	cache, err := gorpmapping.JSONToNullString(job.Cache)
+	if err != nil {
+		return err
+	}
+	matrix, err := gorpmapping.JSONToNullString(job.Matrix)
+	if err != nil {
+		return err
+	}
+	needs, err := gorpmapping.JSONToNullString(job.Needs)
+	if err != nil {
+		return err
+	}
+
 	// Create pipeline action
-	query := `INSERT INTO pipeline_action (pipeline_stage_id, action_id, enabled) VALUES ($1, $2, $3) RETURNING id`
-	if err := db.QueryRow(query, job.PipelineStageID, job.Action.ID, job.Enabled).Scan(&job.PipelineActionID); err != nil {
+	query := `INSERT INTO pipeline_action (pipeline_stage_id, action_id, enabled, cache, timeout, matrix, needs) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	if err := db.QueryRow(query, job.PipelineStageID, job.Action.ID, job.Enabled, cache, job.Timeout, matrix, needs).Scan(&job.PipelineActionID); err != nil {
 		return err
 	}
 	return nil
@@ -121,8 +135,21 @@ func UpdateJob(db gorp.SqlExecutor, job *sdk.Job, userID int64) error {
 		return sdk.ErrForbidden
 	}
 
-	query := `UPDATE pipeline_action set action_id=$1, pipeline_stage_id=$2, enabled=$4  WHERE id=$3`
-	_, err = db.Exec(query, job.Action.ID, job.PipelineStageID, job.PipelineActionID, job.Enabled)
+	cache, err := gorpmapping.JSONToNullString(job.Cache)
+	if err != nil {
+		return err
+	}
+	matrix, err := gorpmapping.JSONToNullString(job.Matrix)
+	if err != nil {
+		return err
+	}
+	needs, err := gorpmapping.JSONToNullString(job.Needs)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE pipeline_action set action_id=$1, pipeline_stage_id=$2, enabled=$4, cache=$5, timeout=$6, matrix=$7, needs=$8  WHERE id=$3`
+	_, err = db.Exec(query, job.Action.ID, job.PipelineStageID, job.PipelineActionID, job.Enabled, cache, job.Timeout, matrix, needs)
 	if err != nil {
 		return err
 	}
@@ -137,9 +164,22 @@ func DeleteJob(db gorp.SqlExecutor, job sdk.Job, userID int64) error {
 
 // UpdatePipelineAction Update an action in a pipeline
 func UpdatePipelineAction(db gorp.SqlExecutor, job sdk.Job) error {
-	query := `UPDATE pipeline_action set action_id=$1, pipeline_stage_id=$2, enabled=$4  WHERE id=$3`
+	cache, err := gorpmapping.JSONToNullString(job.Cache)
+	if err != nil {
+		return err
+	}
+	matrix, err := gorpmapping.JSONToNullString(job.Matrix)
+	if err != nil {
+		return err
+	}
+	needs, err := gorpmapping.JSONToNullString(job.Needs)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE pipeline_action set action_id=$1, pipeline_stage_id=$2, enabled=$4, cache=$5, timeout=$6, matrix=$7, needs=$8  WHERE id=$3`
 
-	_, err := db.Exec(query, job.Action.ID, job.PipelineStageID, job.PipelineActionID, job.Enabled)
+	_, err = db.Exec(query, job.Action.ID, job.PipelineStageID, job.PipelineActionID, job.Enabled, cache, job.Timeout, matrix, needs)
 	if err != nil {
 		return err
 	}
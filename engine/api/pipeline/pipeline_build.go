@@ -1376,7 +1376,7 @@ func RestartPipelineBuild(db gorp.SqlExecutor, pb *sdk.PipelineBuild) error {
 			stage.Status = sdk.StatusWaiting
 			// Delete logs
 			for _, pbJob := range stage.PipelineBuildJobs {
-				if pbJob.Status == sdk.StatusFail.String() {
+				if pbJob.Status == sdk.StatusFail.String() || pbJob.Status == sdk.StatusTimeout.String() {
 					if err := DeleteBuildLogs(db, pbJob.ID); err != nil {
 						return err
 					}
@@ -12,6 +12,7 @@ import (
 	"github.com/lib/pq"
 
 	"github.com/ovh/cds/engine/api/action"
+	"github.com/ovh/cds/engine/api/database/gorpmapping"
 	"github.com/ovh/cds/engine/api/trigger"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
@@ -155,7 +156,7 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 	SELECT  pipeline_stage_R.id as stage_id, pipeline_stage_R.pipeline_id, pipeline_stage_R.name, pipeline_stage_R.last_modified,
 			pipeline_stage_R.build_order, pipeline_stage_R.enabled, pipeline_stage_R.parameter,
 			pipeline_stage_R.expected_value, pipeline_action_R.id as pipeline_action_id, pipeline_action_R.action_id, pipeline_action_R.action_last_modified,
-			pipeline_action_R.action_args, pipeline_action_R.action_enabled
+			pipeline_action_R.action_args, pipeline_action_R.action_enabled, pipeline_action_R.cache, pipeline_action_R.timeout, pipeline_action_R.matrix, pipeline_action_R.needs
 	FROM (
 		SELECT  pipeline_stage.id, pipeline_stage.pipeline_id,
 				pipeline_stage.name, pipeline_stage.last_modified ,pipeline_stage.build_order,
@@ -168,7 +169,7 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 	LEFT OUTER JOIN (
 		SELECT  pipeline_action.id, action.id as action_id, action.name as action_name, action.last_modified as action_last_modified,
 				pipeline_action.args as action_args, pipeline_action.enabled as action_enabled,
-				pipeline_action.pipeline_stage_id
+				pipeline_action.pipeline_stage_id, pipeline_action.cache, pipeline_action.timeout, pipeline_action.matrix, pipeline_action.needs
 		FROM action
 		JOIN pipeline_action ON pipeline_action.action_id = action.id
 	) as pipeline_action_R ON pipeline_action_R.pipeline_stage_id = pipeline_stage_R.id
@@ -189,9 +190,9 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 	for rows.Next() {
 		var stageID, pipelineID int64
 		var stageBuildOrder int
-		var pipelineActionID, actionID sql.NullInt64
+		var pipelineActionID, actionID, actionTimeout sql.NullInt64
 		var stageName string
-		var stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs sql.NullString
+		var stagePrerequisiteParameter, stagePrerequisiteExpectedValue, actionArgs, actionCache, actionMatrix, actionNeeds sql.NullString
 		var stageEnabled, actionEnabled sql.NullBool
 		var stageLastModified, actionLastModified pq.NullTime
 
@@ -199,7 +200,7 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 			&stageID, &pipelineID, &stageName, &stageLastModified,
 			&stageBuildOrder, &stageEnabled, &stagePrerequisiteParameter,
 			&stagePrerequisiteExpectedValue, &pipelineActionID, &actionID, &actionLastModified,
-			&actionArgs, &actionEnabled)
+			&actionArgs, &actionEnabled, &actionCache, &actionTimeout, &actionMatrix, &actionNeeds)
 		if err != nil {
 			return err
 		}
@@ -247,10 +248,20 @@ func LoadPipelineStage(db gorp.SqlExecutor, p *sdk.Pipeline, args ...FuncArg) er
 					PipelineActionID: pipelineActionID.Int64,
 					LastModified:     actionLastModified.Time.Unix(),
 					Enabled:          actionEnabled.Bool,
+					Timeout:          actionTimeout.Int64,
 					Action: sdk.Action{
 						ID: actionID.Int64,
 					},
 				}
+				if err := gorpmapping.JSONNullString(actionCache, &j.Cache); err != nil {
+					return err
+				}
+				if err := gorpmapping.JSONNullString(actionMatrix, &j.Matrix); err != nil {
+					return err
+				}
+				if err := gorpmapping.JSONNullString(actionNeeds, &j.Needs); err != nil {
+					return err
+				}
 				mapAllActions[pipelineActionID.Int64] = j
 				mapActionsStages[stageID] = append(mapActionsStages[stageID], *j)
 
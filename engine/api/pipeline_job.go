@@ -31,6 +31,14 @@ func addJobToStageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap
 	if err := UnmarshalBody(r, &job); err != nil {
 		return err
 	}
+	if job.Matrix != nil {
+		if _, err := job.Matrix.Combinations(); err != nil {
+			return sdk.WrapError(err, "addJobToStageHandler> Invalid matrix")
+		}
+	}
+	if err := job.CheckCache(); err != nil {
+		return sdk.WrapError(err, "addJobToStageHandler> Invalid cache")
+	}
 
 	pip, errl := pipeline.LoadPipeline(db, projectKey, pipelineName, false)
 	if errl != nil {
@@ -46,6 +54,9 @@ func addJobToStageHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap
 	for _, s := range pip.Stages {
 		if s.ID == stageID {
 			found = true
+			if err := sdk.CheckJobsNeeds(append(s.Jobs, job)); err != nil {
+				return sdk.WrapError(err, "addJobToStageHandler> Invalid needs")
+			}
 			break
 		}
 	}
@@ -120,6 +131,14 @@ func updateJobHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c
 	if err := UnmarshalBody(r, &job); err != nil {
 		return err
 	}
+	if job.Matrix != nil {
+		if _, err := job.Matrix.Combinations(); err != nil {
+			return sdk.WrapError(err, "updateJobHandler> Invalid matrix")
+		}
+	}
+	if err := job.CheckCache(); err != nil {
+		return sdk.WrapError(err, "updateJobHandler> Invalid cache")
+	}
 
 	if jobID != job.PipelineActionID {
 		return sdk.WrapError(sdk.ErrInvalidID, "updateJobHandler>Pipeline action does not match")
@@ -138,10 +157,17 @@ func updateJobHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c
 	found := false
 	for _, s := range pipelineData.Stages {
 		if s.ID == stageID {
-			for _, j := range s.Jobs {
+			jobs := make([]sdk.Job, len(s.Jobs))
+			for i, j := range s.Jobs {
+				jobs[i] = j
 				if j.PipelineActionID == jobID {
 					found = true
-					break
+					jobs[i] = job
+				}
+			}
+			if found {
+				if err := sdk.CheckJobsNeeds(jobs); err != nil {
+					return sdk.WrapError(err, "updateJobHandler> Invalid needs")
 				}
 			}
 		}
@@ -215,10 +241,15 @@ func deleteJobHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c
 	var jobToDelete sdk.Job
 stageLoop:
 	for _, s := range pipelineData.Stages {
-		for _, j := range s.Jobs {
+		for i, j := range s.Jobs {
 			if j.PipelineActionID == jobID {
 				jobToDelete = j
 				found = true
+				// the other jobs of the stage must not need the deleted job
+				jobs := append(append([]sdk.Job{}, s.Jobs[:i]...), s.Jobs[i+1:]...)
+				if err := sdk.CheckJobsNeeds(jobs); err != nil {
+					return sdk.WrapError(err, "deleteJobHandler> Job %s is needed", j.Action.Name)
+				}
 				break stageLoop
 			}
 		}
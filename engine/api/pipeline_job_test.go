@@ -227,11 +227,31 @@ func TestDeleteJobHandler(t *testing.T) {
 	uri := router.getRoute("DELETE", deleteJobHandler, vars)
 	test.NotEmpty(t, uri)
 
+	//6. A job needed by another job of the stage can't be deleted
+	needing := &sdk.Job{
+		Enabled:         true,
+		PipelineStageID: stage.ID,
+		Needs:           []string{"myJob"},
+		Action: sdk.Action{
+			Enabled: true,
+			Name:    "myNeedingJob",
+		},
+	}
+	test.NoError(t, pipeline.InsertJob(db, needing, stage.ID, pip))
+
 	req, _ := http.NewRequest("DELETE", uri, nil)
 	assets.AuthentifyRequest(t, req, u, pass)
+	w := httptest.NewRecorder()
+	router.mux.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	test.NoError(t, pipeline.DeleteJob(db, *needing, u.ID))
+
+	req, _ = http.NewRequest("DELETE", uri, nil)
+	assets.AuthentifyRequest(t, req, u, pass)
 
 	//7. Do the request
-	w := httptest.NewRecorder()
+	w = httptest.NewRecorder()
 	router.mux.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
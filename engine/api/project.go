@@ -60,6 +60,11 @@ func updateProjectHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap
 	}
 	// Update in DB is made given the primary key
 	proj.ID = p.ID
+	// The concurrency cap and the priority of a project are quotas, only administrators can change them
+	if !c.User.Admin {
+		proj.MaxConcurrentJobs = p.MaxConcurrentJobs
+		proj.MaxWorkflowPriority = p.MaxWorkflowPriority
+	}
 	if errUp := project.Update(db, proj, c.User); errUp != nil {
 		return sdk.WrapError(errUp, "updateProject> Cannot update project %s", key)
 	}
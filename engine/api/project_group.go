@@ -13,6 +13,7 @@ import (
 	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/pipeline"
 	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/workflow"
 	"github.com/ovh/cds/sdk"
 )
 
@@ -281,6 +282,28 @@ func addGroupInProject(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c
 		}
 	}
 
+	// apply on workflow
+	workflows, errlw := workflow.LoadAll(tx, p.Key)
+	if errlw != nil {
+		return sdk.WrapError(errlw, "AddGroupInProject: Cannot load workflows for project %s", p.Name)
+	}
+
+	for _, wf := range workflows {
+		if permission.AccessToWorkflow(wf.ID, c.User, permission.PermissionReadWriteExecute) {
+			inWf, err := group.CheckGroupInWorkflow(tx, wf.ID, g.ID)
+			if err != nil {
+				return sdk.WrapError(err, "AddGroupInProject: Cannot check if group %s is already in the workflow %s", g.Name, wf.Name)
+			}
+			if inWf {
+				if err := group.UpdateGroupRoleInWorkflow(tx, wf.ID, g.ID, groupProject.Permission); err != nil {
+					return sdk.WrapError(err, "AddGroupInProject: Cannot update group %s on workflow %s", g.Name, wf.Name)
+				}
+			} else if err := group.InsertGroupInWorkflow(tx, wf.ID, g.ID, groupProject.Permission); err != nil {
+				return sdk.WrapError(err, "AddGroupInProject: Cannot insert group %s on workflow %s", g.Name, wf.Name)
+			}
+		}
+	}
+
 	if err := project.UpdateLastModified(tx, c.User, p); err != nil {
 		return sdk.WrapError(err, "AddGroupInProject: Cannot update last modified date")
 	}
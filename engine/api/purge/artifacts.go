@@ -0,0 +1,280 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/artifact"
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//candidate is a workflow run or a pipeline build holding artifacts
+type candidate struct {
+	id      int64
+	date    time.Time
+	tags    []sdk.WorkflowRunTag
+	running bool
+}
+
+//Artifacts is the goroutine purging the artifacts according to the retention rules
+func Artifacts(c context.Context, DBFunc func() *gorp.DbMap) {
+	tick := time.NewTicker(1 * time.Hour).C
+	for {
+		select {
+		case <-c.Done():
+			if c.Err() != nil {
+				log.Error("Exiting purge.Artifacts: %v", c.Err())
+				return
+			}
+		case <-tick:
+			db := DBFunc()
+			rules, err := LoadAllArtifactRetentions(db)
+			if err != nil {
+				log.Warning("purge.Artifacts> Error : %s", err)
+				continue
+			}
+			projects := map[int64]bool{}
+			for _, r := range rules {
+				if projects[r.ProjectID] {
+					continue
+				}
+				projects[r.ProjectID] = true
+				report, err := ArtifactsRun(db, r.ProjectID, false)
+				if err != nil {
+					log.Warning("purge.Artifacts> Error on project %d : %s", r.ProjectID, err)
+					continue
+				}
+				if n := len(report.WorkflowArtifacts) + len(report.Artifacts); n > 0 {
+					log.Info("purge.Artifacts> %d artifacts (%d bytes) purged on project %d", n, report.Size, r.ProjectID)
+				}
+			}
+		}
+	}
+}
+
+//ArtifactsRun purges the artifacts of a project according to its retention rules. With dryRun, it only returns
+//the artifacts to be purged.
+func ArtifactsRun(db gorp.SqlExecutor, projectID int64, dryRun bool) (*sdk.ArtifactPurgeReport, error) {
+	report := &sdk.ArtifactPurgeReport{
+		DryRun:            dryRun,
+		WorkflowArtifacts: []sdk.WorkflowNodeRunArtifact{},
+		Artifacts:         []sdk.Artifact{},
+	}
+
+	rules, err := LoadArtifactRetentions(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+	var projectRule *sdk.ArtifactRetention
+	workflowRules := map[int64]*sdk.ArtifactRetention{}
+	for i := range rules {
+		if rules[i].WorkflowID == 0 {
+			projectRule = &rules[i]
+		} else {
+			workflowRules[rules[i].WorkflowID] = &rules[i]
+		}
+	}
+
+	var workflowIDs []int64
+	if _, err := db.Select(&workflowIDs, "SELECT id FROM workflow WHERE project_id = $1", projectID); err != nil {
+		return nil, sdk.WrapError(err, "ArtifactsRun> Unable to load workflows of project %d", projectID)
+	}
+
+	now := time.Now()
+	for _, id := range workflowIDs {
+		rule, ok := workflowRules[id]
+		if !ok {
+			rule = projectRule
+		}
+		if rule == nil || !rule.IsActive() {
+			continue
+		}
+		if err := purgeWorkflowArtifacts(db, id, rule, now, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if projectRule != nil && projectRule.IsActive() {
+		if err := purgePipelineArtifacts(db, projectID, projectRule, now, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+//toPurge returns the ids of the candidates, sorted from the most recent, which are not kept by the rule
+func toPurge(r *sdk.ArtifactRetention, candidates []candidate, now time.Time) []int64 {
+	ids := []int64{}
+	for i, c := range candidates {
+		if i < r.KeepLastRuns {
+			continue
+		}
+		if r.KeepDays > 0 && c.date.After(now.AddDate(0, 0, -r.KeepDays)) {
+			continue
+		}
+		if r.KeepTagged(c.tags) {
+			continue
+		}
+		ids = append(ids, c.id)
+	}
+	return ids
+}
+
+func purgeWorkflowArtifacts(db gorp.SqlExecutor, workflowID int64, r *sdk.ArtifactRetention, now time.Time, report *sdk.ArtifactPurgeReport) error {
+	var runs []struct {
+		ID    int64     `db:"id"`
+		Start time.Time `db:"start"`
+	}
+	if _, err := db.Select(&runs, "SELECT id, start FROM workflow_run WHERE workflow_id = $1 ORDER BY num DESC", workflowID); err != nil {
+		return sdk.WrapError(err, "purgeWorkflowArtifacts> Unable to load runs of workflow %d", workflowID)
+	}
+	if len(runs) <= r.KeepLastRuns {
+		return nil
+	}
+
+	var tags []sdk.WorkflowRunTag
+	query := `SELECT workflow_run_tag.workflow_run_id, workflow_run_tag.tag, workflow_run_tag.value
+		FROM workflow_run_tag
+		JOIN workflow_run ON workflow_run.id = workflow_run_tag.workflow_run_id
+		WHERE workflow_run.workflow_id = $1`
+	if _, err := db.Select(&tags, query, workflowID); err != nil {
+		return sdk.WrapError(err, "purgeWorkflowArtifacts> Unable to load tags of workflow %d", workflowID)
+	}
+	runTags := map[int64][]sdk.WorkflowRunTag{}
+	for _, t := range tags {
+		runTags[t.WorkflowRunID] = append(runTags[t.WorkflowRunID], t)
+	}
+
+	candidates := make([]candidate, len(runs))
+	for i, run := range runs {
+		candidates[i] = candidate{id: run.ID, date: run.Start, tags: runTags[run.ID]}
+	}
+	ids := toPurge(r, candidates, now)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var arts []sdk.WorkflowNodeRunArtifact
+	query = `SELECT workflow_run_id, workflow_node_run_id, id, name, tag, download_hash, size, perm, md5sum, object_path, created
+		FROM workflow_node_run_artifacts
+		WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[])
+		ORDER BY id`
+	if _, err := db.Select(&arts, query, joinIDs(ids)); err != nil {
+		return sdk.WrapError(err, "purgeWorkflowArtifacts> Unable to load artifacts of workflow %d", workflowID)
+	}
+
+	for i := range arts {
+		a := &arts[i]
+		if !report.DryRun {
+			if err := deleteObject(a); err != nil {
+				return sdk.WrapError(err, "purgeWorkflowArtifacts> Cannot delete artifact %d in store", a.ID)
+			}
+			if _, err := db.Exec("DELETE FROM workflow_node_run_artifacts WHERE id = $1", a.ID); err != nil {
+				return sdk.WrapError(err, "purgeWorkflowArtifacts> Cannot delete artifact %d in DB", a.ID)
+			}
+		}
+		report.WorkflowArtifacts = append(report.WorkflowArtifacts, *a)
+		report.Size += a.Size
+	}
+	return nil
+}
+
+func purgePipelineArtifacts(db gorp.SqlExecutor, projectID int64, r *sdk.ArtifactRetention, now time.Time, report *sdk.ArtifactPurgeReport) error {
+	query := `SELECT artifact.id, artifact.name, artifact.tag, artifact.build_number, COALESCE(artifact.size, 0), COALESCE(artifact.created, 'epoch'),
+		artifact.pipeline_id, artifact.application_id, artifact.environment_id,
+		pipeline.name, project.projectkey, application.name, environment.name
+		FROM artifact
+		JOIN pipeline ON pipeline.id = artifact.pipeline_id
+		JOIN project ON project.id = pipeline.project_id
+		JOIN application ON application.id = artifact.application_id
+		JOIN environment ON environment.id = artifact.environment_id
+		WHERE pipeline.project_id = $1
+		ORDER BY artifact.pipeline_id, artifact.application_id, artifact.environment_id, artifact.build_number DESC`
+	rows, err := db.Query(query, projectID)
+	if err != nil {
+		return sdk.WrapError(err, "purgePipelineArtifacts> Unable to load artifacts of project %d", projectID)
+	}
+
+	//The artifacts are grouped by build, and the builds by pipeline, application and environment
+	type build struct {
+		candidate
+		arts []sdk.Artifact
+	}
+	groups := map[string][]*build{}
+	keys := []string{}
+	for rows.Next() {
+		var a sdk.Artifact
+		var created time.Time
+		var pipID, appID, envID int64
+		if err := rows.Scan(&a.ID, &a.Name, &a.Tag, &a.BuildNumber, &a.Size, &created, &pipID, &appID, &envID,
+			&a.Pipeline, &a.Project, &a.Application, &a.Environment); err != nil {
+			rows.Close()
+			return sdk.WrapError(err, "purgePipelineArtifacts> Unable to scan artifact")
+		}
+		k := fmt.Sprintf("%d-%d-%d", pipID, appID, envID)
+		builds, ok := groups[k]
+		if !ok {
+			keys = append(keys, k)
+		}
+		if len(builds) == 0 || builds[len(builds)-1].id != int64(a.BuildNumber) {
+			builds = append(builds, &build{candidate: candidate{id: int64(a.BuildNumber)}})
+		}
+		b := builds[len(builds)-1]
+		if created.After(b.date) {
+			b.date = created
+		}
+		b.tags = append(b.tags, sdk.WorkflowRunTag{Tag: a.Tag})
+		b.arts = append(b.arts, a)
+		groups[k] = builds
+	}
+	rows.Close()
+
+	for _, k := range keys {
+		builds := groups[k]
+		candidates := make([]candidate, len(builds))
+		for i, b := range builds {
+			candidates[i] = b.candidate
+		}
+		purged := map[int64]bool{}
+		for _, id := range toPurge(r, candidates, now) {
+			purged[id] = true
+		}
+		for _, b := range builds {
+			if !purged[b.id] {
+				continue
+			}
+			for _, a := range b.arts {
+				if !report.DryRun {
+					if err := artifact.DeleteArtifact(db, a.ID); err != nil {
+						return sdk.WrapError(err, "purgePipelineArtifacts> Cannot delete artifact %d", a.ID)
+					}
+				}
+				report.Artifacts = append(report.Artifacts, a)
+				report.Size += a.Size
+			}
+		}
+	}
+	return nil
+}
+
+//deleteObject deletes an artifact from the objectstore, an artifact already deleted is not an error
+func deleteObject(o objectstore.Object) error {
+	if err := objectstore.DeleteArtifact(o); err != nil && !strings.Contains(err.Error(), "404") {
+		return err
+	}
+	return nil
+}
+
+func joinIDs(ids []int64) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = fmt.Sprintf("%d", id)
+	}
+	return strings.Join(s, ",")
+}
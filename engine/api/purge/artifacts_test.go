@@ -0,0 +1,71 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/test/assets"
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_toPurge(t *testing.T) {
+	now := time.Now()
+	candidates := []candidate{
+		{id: 5, date: now.Add(-1 * time.Hour)},
+		{id: 4, date: now.AddDate(0, 0, -2)},
+		{id: 3, date: now.AddDate(0, 0, -5), tags: []sdk.WorkflowRunTag{{Tag: "release", Value: "v1.0"}}},
+		{id: 2, date: now.AddDate(0, 0, -10), tags: []sdk.WorkflowRunTag{{Tag: "git.branch", Value: "master"}}},
+		{id: 1, date: now.AddDate(0, 0, -20)},
+	}
+
+	tests := []struct {
+		name string
+		rule sdk.ArtifactRetention
+		want []int64
+	}{
+		{"keep last runs", sdk.ArtifactRetention{KeepLastRuns: 2}, []int64{3, 2, 1}},
+		{"keep days", sdk.ArtifactRetention{KeepDays: 7}, []int64{2, 1}},
+		{"keep last runs and days", sdk.ArtifactRetention{KeepLastRuns: 4, KeepDays: 1}, []int64{1}},
+		{"keep tag", sdk.ArtifactRetention{KeepLastRuns: 1, KeepTags: []string{"release"}}, []int64{4, 2, 1}},
+		{"keep tag value", sdk.ArtifactRetention{KeepLastRuns: 1, KeepTags: []string{"git.branch=master", "release=v2.0"}}, []int64{4, 3, 1}},
+		{"keep everything", sdk.ArtifactRetention{KeepLastRuns: 10}, []int64{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, toPurge(&tt.rule, candidates, now), tt.name)
+	}
+}
+
+func TestUpsertArtifactRetention(t *testing.T) {
+	db := test.SetupPG(t)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, nil)
+
+	r := sdk.ArtifactRetention{ProjectID: proj.ID, KeepLastRuns: 5}
+	test.NoError(t, UpsertArtifactRetention(db, &r))
+	assert.NotZero(t, r.ID)
+
+	r.KeepDays = 30
+	r.KeepTags = []string{"release"}
+	test.NoError(t, UpsertArtifactRetention(db, &r))
+
+	// the rule of the project is updated, not inserted again
+	other := sdk.ArtifactRetention{ProjectID: proj.ID, KeepLastRuns: 5, KeepDays: 30, KeepTags: []string{"release"}}
+	test.NoError(t, UpsertArtifactRetention(db, &other))
+	assert.Equal(t, r.ID, other.ID)
+
+	rules, err := LoadArtifactRetentions(db, proj.ID)
+	test.NoError(t, err)
+	if !assert.Len(t, rules, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, r, rules[0])
+
+	_, err = LoadArtifactRetention(db, proj.ID, 1)
+	assert.Equal(t, sdk.ErrNotFound, err)
+
+	test.NoError(t, DeleteArtifactRetention(db, proj.ID, 0))
+	assert.Equal(t, sdk.ErrNotFound, DeleteArtifactRetention(db, proj.ID, 0))
+}
@@ -0,0 +1,105 @@
+package purge
+
+import (
+	"database/sql"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database/gorpmapping"
+	"github.com/ovh/cds/sdk"
+)
+
+const artifactRetentionQuery = `SELECT artifact_retention.id, artifact_retention.project_id, artifact_retention.workflow_id, workflow.name,
+	artifact_retention.keep_last_runs, artifact_retention.keep_days, artifact_retention.keep_tags
+	FROM artifact_retention
+	LEFT OUTER JOIN workflow ON workflow.id = artifact_retention.workflow_id`
+
+//LoadArtifactRetentions loads the retention rules of a project and of its workflows
+func LoadArtifactRetentions(db gorp.SqlExecutor, projectID int64) ([]sdk.ArtifactRetention, error) {
+	rules, err := loadArtifactRetentions(db, artifactRetentionQuery+` WHERE artifact_retention.project_id = $1 ORDER BY workflow.name NULLS FIRST`, projectID)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadArtifactRetentions> Unable to load rules of project %d", projectID)
+	}
+	return rules, nil
+}
+
+//LoadAllArtifactRetentions loads all the retention rules
+func LoadAllArtifactRetentions(db gorp.SqlExecutor) ([]sdk.ArtifactRetention, error) {
+	rules, err := loadArtifactRetentions(db, artifactRetentionQuery+` ORDER BY artifact_retention.project_id`)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadAllArtifactRetentions> Unable to load rules")
+	}
+	return rules, nil
+}
+
+//LoadArtifactRetention loads the retention rule of a project, or of a workflow if workflowID is not 0
+func LoadArtifactRetention(db gorp.SqlExecutor, projectID, workflowID int64) (*sdk.ArtifactRetention, error) {
+	rules, err := loadArtifactRetentions(db, artifactRetentionQuery+` WHERE artifact_retention.project_id = $1 AND artifact_retention.workflow_id IS NOT DISTINCT FROM $2`, projectID, nullID(workflowID))
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadArtifactRetention> Unable to load rule of project %d workflow %d", projectID, workflowID)
+	}
+	if len(rules) == 0 {
+		return nil, sdk.ErrNotFound
+	}
+	return &rules[0], nil
+}
+
+//UpsertArtifactRetention inserts or updates the retention rule of a project or of a workflow
+func UpsertArtifactRetention(db gorp.SqlExecutor, r *sdk.ArtifactRetention) error {
+	tags, err := gorpmapping.JSONToNullString(r.KeepTags)
+	if err != nil {
+		return sdk.WrapError(err, "UpsertArtifactRetention> Unable to marshal tags")
+	}
+
+	query := `INSERT INTO artifact_retention (project_id, workflow_id, keep_last_runs, keep_days, keep_tags)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id, COALESCE(workflow_id, 0))
+		DO UPDATE SET keep_last_runs = EXCLUDED.keep_last_runs, keep_days = EXCLUDED.keep_days, keep_tags = EXCLUDED.keep_tags
+		RETURNING id`
+	if err := db.QueryRow(query, r.ProjectID, nullID(r.WorkflowID), r.KeepLastRuns, r.KeepDays, tags).Scan(&r.ID); err != nil {
+		return sdk.WrapError(err, "UpsertArtifactRetention> Unable to upsert rule")
+	}
+	return nil
+}
+
+//DeleteArtifactRetention deletes the retention rule of a project, or of a workflow if workflowID is not 0
+func DeleteArtifactRetention(db gorp.SqlExecutor, projectID, workflowID int64) error {
+	query := `DELETE FROM artifact_retention WHERE project_id = $1 AND workflow_id IS NOT DISTINCT FROM $2`
+	res, err := db.Exec(query, projectID, nullID(workflowID))
+	if err != nil {
+		return sdk.WrapError(err, "DeleteArtifactRetention> Unable to delete rule")
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sdk.ErrNotFound
+	}
+	return nil
+}
+
+func loadArtifactRetentions(db gorp.SqlExecutor, query string, args ...interface{}) ([]sdk.ArtifactRetention, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []sdk.ArtifactRetention{}
+	for rows.Next() {
+		var r sdk.ArtifactRetention
+		var workflowID sql.NullInt64
+		var workflowName, tags sql.NullString
+		if err := rows.Scan(&r.ID, &r.ProjectID, &workflowID, &workflowName, &r.KeepLastRuns, &r.KeepDays, &tags); err != nil {
+			return nil, err
+		}
+		r.WorkflowID = workflowID.Int64
+		r.WorkflowName = workflowName.String
+		if err := gorpmapping.JSONNullString(tags, &r.KeepTags); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
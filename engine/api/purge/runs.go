@@ -0,0 +1,202 @@
+package purge
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//RunsOptions defines the workflow runs kept by the purge. The latest run of each branch, the released runs and the
+//runs which are not over are always kept.
+type RunsOptions struct {
+	KeepLastRuns int
+	KeepDays     int
+	BatchSize    int
+}
+
+//Runs is the goroutine purging the old workflow runs, with their jobs, logs and artifacts
+func Runs(c context.Context, DBFunc func() *gorp.DbMap, opts RunsOptions) {
+	if opts.KeepLastRuns <= 0 && opts.KeepDays <= 0 {
+		log.Warning("purge.Runs> Purge of the workflow runs is disabled, no retention is configured")
+		return
+	}
+	tick := time.NewTicker(30 * time.Minute).C
+	for {
+		select {
+		case <-c.Done():
+			if c.Err() != nil {
+				log.Error("Exiting purge.Runs: %v", c.Err())
+				return
+			}
+		case <-tick:
+			n, err := RunsRun(DBFunc(), opts)
+			if err != nil {
+				log.Warning("purge.Runs> Error : %s", err)
+			}
+			if n > 0 {
+				log.Info("purge.Runs> %d workflow runs purged", n)
+			}
+		}
+	}
+}
+
+//RunsRun purges the workflow runs of all the workflows, and returns the number of runs purged
+func RunsRun(db *gorp.DbMap, opts RunsOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	var workflowIDs []int64
+	if _, err := db.Select(&workflowIDs, "SELECT id FROM workflow ORDER BY id"); err != nil {
+		return 0, sdk.WrapError(err, "RunsRun> Unable to load workflows")
+	}
+
+	var purged int
+	now := time.Now()
+	for _, id := range workflowIDs {
+		candidates, err := loadRunCandidates(db, id)
+		if err != nil {
+			return purged, err
+		}
+		ids := runsToPurge(opts, candidates, now)
+		for len(ids) > 0 {
+			batch := ids
+			if len(batch) > opts.BatchSize {
+				batch = ids[:opts.BatchSize]
+			}
+			ids = ids[len(batch):]
+			if err := deleteRuns(db, batch); err != nil {
+				return purged, sdk.WrapError(err, "RunsRun> Unable to purge runs of workflow %d", id)
+			}
+			purged += len(batch)
+		}
+	}
+	return purged, nil
+}
+
+//runsToPurge returns the ids of the runs, sorted from the most recent, to be purged
+func runsToPurge(opts RunsOptions, candidates []candidate, now time.Time) []int64 {
+	rule := &sdk.ArtifactRetention{
+		KeepLastRuns: opts.KeepLastRuns,
+		KeepDays:     opts.KeepDays,
+		KeepTags:     []string{sdk.WorkflowRunTagRelease},
+	}
+	if !rule.IsActive() {
+		return []int64{}
+	}
+
+	kept := map[int64]bool{}
+	branches := map[string]bool{}
+	for _, c := range candidates {
+		if c.running {
+			kept[c.id] = true
+		}
+		for _, t := range c.tags {
+			if t.Tag != "git.branch" {
+				continue
+			}
+			for _, b := range strings.Split(t.Value, ",") {
+				if !branches[b] {
+					branches[b] = true
+					kept[c.id] = true
+				}
+			}
+		}
+	}
+
+	ids := []int64{}
+	for _, id := range toPurge(rule, candidates, now) {
+		if !kept[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func loadRunCandidates(db gorp.SqlExecutor, workflowID int64) ([]candidate, error) {
+	var runs []struct {
+		ID      int64     `db:"id"`
+		Start   time.Time `db:"start"`
+		Running bool      `db:"running"`
+	}
+	query := `SELECT workflow_run.id, workflow_run.start,
+		EXISTS (SELECT 1 FROM workflow_node_run WHERE workflow_node_run.workflow_run_id = workflow_run.id AND workflow_node_run.status = ANY(string_to_array($2, ','))) AS running
+		FROM workflow_run
+		WHERE workflow_run.workflow_id = $1
+		ORDER BY workflow_run.num DESC`
+	running := make([]string, len(sdk.StatusRunning))
+	for i, s := range sdk.StatusRunning {
+		running[i] = s.String()
+	}
+	status := strings.Join(running, ",")
+	if _, err := db.Select(&runs, query, workflowID, status); err != nil {
+		return nil, sdk.WrapError(err, "loadRunCandidates> Unable to load runs of workflow %d", workflowID)
+	}
+
+	var tags []sdk.WorkflowRunTag
+	query = `SELECT workflow_run_tag.workflow_run_id, workflow_run_tag.tag, workflow_run_tag.value
+		FROM workflow_run_tag
+		JOIN workflow_run ON workflow_run.id = workflow_run_tag.workflow_run_id
+		WHERE workflow_run.workflow_id = $1`
+	if _, err := db.Select(&tags, query, workflowID); err != nil {
+		return nil, sdk.WrapError(err, "loadRunCandidates> Unable to load tags of workflow %d", workflowID)
+	}
+	runTags := map[int64][]sdk.WorkflowRunTag{}
+	for _, t := range tags {
+		runTags[t.WorkflowRunID] = append(runTags[t.WorkflowRunID], t)
+	}
+
+	candidates := make([]candidate, len(runs))
+	for i, r := range runs {
+		candidates[i] = candidate{id: r.ID, date: r.Start, tags: runTags[r.ID], running: r.Running}
+	}
+	return candidates, nil
+}
+
+//deleteRuns deletes a batch of workflow runs in a transaction, with their artifacts, jobs, logs and tags
+func deleteRuns(db *gorp.DbMap, ids []int64) error {
+	tx, errB := db.Begin()
+	if errB != nil {
+		return sdk.WrapError(errB, "deleteRuns> Unable to start a transaction")
+	}
+	defer tx.Rollback()
+
+	sIDs := joinIDs(ids)
+	var arts []sdk.WorkflowNodeRunArtifact
+	query := `SELECT workflow_run_id, workflow_node_run_id, id, name, tag, download_hash, size, perm, md5sum, object_path, created
+		FROM workflow_node_run_artifacts
+		WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[])`
+	if _, err := tx.Select(&arts, query, sIDs); err != nil {
+		return sdk.WrapError(err, "deleteRuns> Unable to load artifacts")
+	}
+	queries := []string{
+		`DELETE FROM workflow_node_run_job_logs WHERE workflow_node_run_id IN (SELECT id FROM workflow_node_run WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[]))`,
+		`DELETE FROM workflow_node_run_job WHERE workflow_node_run_id IN (SELECT id FROM workflow_node_run WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[]))`,
+		`DELETE FROM workflow_node_run_artifacts WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[])`,
+		`DELETE FROM workflow_node_run WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[])`,
+		`DELETE FROM workflow_run_tag WHERE workflow_run_id = ANY(string_to_array($1, ',')::bigint[])`,
+		`DELETE FROM workflow_run WHERE id = ANY(string_to_array($1, ',')::bigint[])`,
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q, sIDs); err != nil {
+			return sdk.WrapError(err, "deleteRuns> Unable to delete runs")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "deleteRuns> Unable to commit")
+	}
+
+	//The objects are deleted once nothing refers to them anymore, an object left in the store is only lost space
+	for i := range arts {
+		if err := deleteObject(&arts[i]); err != nil {
+			log.Warning("deleteRuns> Cannot delete artifact %d in store: %s", arts[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_runsToPurge(t *testing.T) {
+	now := time.Now()
+	branch := func(b string) []sdk.WorkflowRunTag {
+		return []sdk.WorkflowRunTag{{Tag: "git.branch", Value: b}}
+	}
+	candidates := []candidate{
+		{id: 8, date: now, tags: branch("master"), running: true},
+		{id: 7, date: now.AddDate(0, 0, -1), tags: branch("master")},
+		{id: 6, date: now.AddDate(0, 0, -2), tags: branch("feat")},
+		{id: 5, date: now.AddDate(0, 0, -3), tags: branch("master")},
+		{id: 4, date: now.AddDate(0, 0, -4), tags: append(branch("master"), sdk.WorkflowRunTag{Tag: sdk.WorkflowRunTagRelease, Value: "v1.0"})},
+		{id: 3, date: now.AddDate(0, 0, -5), tags: branch("feat,fix")},
+		{id: 2, date: now.AddDate(0, 0, -6), tags: branch("fix")},
+		{id: 1, date: now.AddDate(0, 0, -7)},
+	}
+
+	tests := []struct {
+		name string
+		opts RunsOptions
+		want []int64
+	}{
+		{"no retention", RunsOptions{}, []int64{}},
+		{"keep last runs", RunsOptions{KeepLastRuns: 1}, []int64{7, 5, 2, 1}},
+		{"keep days", RunsOptions{KeepDays: 4}, []int64{2, 1}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, runsToPurge(tt.opts, candidates, now), tt.name)
+	}
+}
@@ -258,7 +258,7 @@ func syncPipelineBuildJob(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
 				if finalStatus == sdk.StatusBuilding || finalStatus == sdk.StatusDisabled {
 					finalStatus = sdk.StatusSkipped
 				}
-			case sdk.StatusFail.String():
+			case sdk.StatusFail.String(), sdk.StatusTimeout.String():
 				finalStatus = sdk.StatusFail
 				break finalStageLoop
 			case sdk.StatusSuccess.String():
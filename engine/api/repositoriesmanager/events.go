@@ -1,8 +1,10 @@
 package repositoriesmanager
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/go-gorp/gorp"
 	"github.com/mitchellh/mapstructure"
@@ -51,25 +53,37 @@ func retryEvent(e *sdk.Event, err error) {
 func processEvent(db gorp.SqlExecutor, event sdk.Event) error {
 	log.Debug("repositoriesmanager>processEvent> receive: type:%s all: %+v", event.EventType, event)
 
-	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
+	var projectKey, repositoryManagerName string
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}):
+		var eventpb sdk.EventPipelineBuild
+		if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
+			log.Error("Error during consumption: %s", err)
+			return err
+		}
+		projectKey, repositoryManagerName = eventpb.ProjectKey, eventpb.RepositoryManagerName
+	case fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}):
+		var eventnr sdk.EventWorkflowNodeRun
+		if err := mapstructure.Decode(event.Payload, &eventnr); err != nil {
+			log.Error("Error during consumption: %s", err)
+			return err
+		}
+		projectKey, repositoryManagerName = eventnr.ProjectKey, eventnr.RepositoryManagerName
+	case fmt.Sprintf("%T", sdk.EventWorkflowRun{}):
+		return processWorkflowRunEvent(db, event)
+	default:
 		return nil
 	}
 
-	var eventpb sdk.EventPipelineBuild
-	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
-		log.Error("Error during consumption: %s", err)
-		return err
-	}
-
-	if eventpb.RepositoryManagerName == "" {
+	if repositoryManagerName == "" {
 		return nil
 	}
 
 	log.Debug("repositoriesmanager>processEvent> event:%+v", event)
 
-	c, erra := AuthorizedClient(db, eventpb.ProjectKey, eventpb.RepositoryManagerName)
+	c, erra := AuthorizedClient(db, projectKey, repositoryManagerName)
 	if erra != nil {
-		return fmt.Errorf("repositoriesmanager>processEvent> AuthorizedClient (%s, %s) > err:%s", eventpb.ProjectKey, eventpb.RepositoryManagerName, erra)
+		return fmt.Errorf("repositoriesmanager>processEvent> AuthorizedClient (%s, %s) > err:%s", projectKey, repositoryManagerName, erra)
 	}
 
 	if err := c.SetStatus(event); err != nil {
@@ -79,3 +93,49 @@ func processEvent(db gorp.SqlExecutor, event sdk.Event) error {
 
 	return nil
 }
+
+//processWorkflowRunEvent comments the pull request built by a workflow run
+func processWorkflowRunEvent(db gorp.SqlExecutor, event sdk.Event) error {
+	var eventwr sdk.EventWorkflowRun
+	if err := mapstructure.Decode(event.Payload, &eventwr); err != nil {
+		log.Error("Error during consumption: %s", err)
+		return err
+	}
+
+	if eventwr.RepositoryManagerName == "" || eventwr.PullRequestID == 0 {
+		return nil
+	}
+
+	c, erra := AuthorizedClient(db, eventwr.ProjectKey, eventwr.RepositoryManagerName)
+	if erra != nil {
+		return fmt.Errorf("repositoriesmanager>processWorkflowRunEvent> AuthorizedClient (%s, %s) > err:%s", eventwr.ProjectKey, eventwr.RepositoryManagerName, erra)
+	}
+
+	if err := c.PullRequestComment(eventwr.RepositoryFullname, eventwr.PullRequestID, pullRequestComment(options.UIBaseURL, eventwr)); err != nil {
+		return fmt.Errorf("repositoriesmanager>processWorkflowRunEvent> PullRequestComment > err:%s", err)
+	}
+
+	return nil
+}
+
+//pullRequestComment returns the summary of a workflow run in markdown
+func pullRequestComment(uiURL string, e sdk.EventWorkflowRun) string {
+	runURL := fmt.Sprintf("%s/project/%s/workflow/%s/run/%d", uiURL, e.ProjectKey, e.WorkflowName, e.Number)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "**CDS** workflow [%s #%d](%s) on commit %s: **%s**\n\n", e.WorkflowName, e.Number, runURL, e.Hash, e.Status)
+	buf.WriteString("| Pipeline | Status | Tests |\n")
+	buf.WriteString("| --- | --- | --- |\n")
+
+	nodes := make([]sdk.EventWorkflowNodeRun, len(e.Nodes))
+	copy(nodes, e.Nodes)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeName < nodes[j].NodeName })
+	for _, n := range nodes {
+		tests := "-"
+		if n.TestsTotal > 0 {
+			tests = fmt.Sprintf("%d passed, %d failed, %d skipped", n.TestsOK, n.TestsKO, n.TestsSkipped)
+		}
+		fmt.Fprintf(buf, "| [%s](%s/node/%d) | %s | %s |\n", n.NodeName, runURL, n.NodeRunID, n.Status, tests)
+	}
+	return buf.String()
+}
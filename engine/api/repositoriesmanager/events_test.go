@@ -0,0 +1,30 @@
+package repositoriesmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestPullRequestComment(t *testing.T) {
+	e := sdk.EventWorkflowRun{
+		ProjectKey:   "PRJ",
+		WorkflowName: "wf",
+		Number:       5,
+		Status:       sdk.StatusFail,
+		Hash:         "c2",
+		Nodes: []sdk.EventWorkflowNodeRun{
+			{NodeRunID: 52, NodeName: "test", Status: sdk.StatusFail, TestsTotal: 4, TestsOK: 2, TestsKO: 1, TestsSkipped: 1},
+			{NodeRunID: 51, NodeName: "build", Status: sdk.StatusSuccess},
+		},
+	}
+
+	expected := "**CDS** workflow [wf #5](http://ui.cds.local/project/PRJ/workflow/wf/run/5) on commit c2: **Fail**\n\n" +
+		"| Pipeline | Status | Tests |\n" +
+		"| --- | --- | --- |\n" +
+		"| [build](http://ui.cds.local/project/PRJ/workflow/wf/run/5/node/51) | Success | - |\n" +
+		"| [test](http://ui.cds.local/project/PRJ/workflow/wf/run/5/node/52) | Fail | 2 passed, 1 failed, 1 skipped |\n"
+	assert.Equal(t, expected, pullRequestComment("http://ui.cds.local", e))
+}
@@ -0,0 +1,292 @@
+package repogit
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+	"github.com/ovh/cds/sdk/vcs"
+	"github.com/ovh/cds/sdk/vcs/git"
+)
+
+// GitClient implements CDS RepositoriesManagerClient interface with the git command line
+type GitClient struct {
+	URL                     string
+	PrivateKey              string
+	NoStrictHostKeyChecking bool
+}
+
+var (
+	mirrorsLocks    = map[string]*sync.Mutex{}
+	mirrorsLocksMux sync.Mutex
+)
+
+func lockMirror(path string) *sync.Mutex {
+	mirrorsLocksMux.Lock()
+	defer mirrorsLocksMux.Unlock()
+	l, ok := mirrorsLocks[path]
+	if !ok {
+		l = &sync.Mutex{}
+		mirrorsLocks[path] = l
+	}
+	l.Lock()
+	return l
+}
+
+func (c *GitClient) auth() *git.AuthOpts {
+	if c.PrivateKey == "" {
+		return nil
+	}
+	return &git.AuthOpts{PrivateKey: vcs.SSHKey{Filename: c.PrivateKey}}
+}
+
+func (c *GitClient) lsRemote(fullname string, heads bool) ([]git.Ref, error) {
+	return git.LsRemote(CloneURL(c.URL, fullname), c.auth(), &git.LsRemoteOpts{
+		Heads:                   heads,
+		NoStrictHostKeyChecking: c.NoStrictHostKeyChecking,
+	})
+}
+
+//mirror updates the local mirror of the repository and returns its path
+func (c *GitClient) mirror(fullname string) (string, error) {
+	cloneURL := CloneURL(c.URL, fullname)
+	p := filepath.Join(mirrorsDirectory, fmt.Sprintf("%x.git", sha1.Sum([]byte(cloneURL))))
+
+	l := lockMirror(p)
+	defer l.Unlock()
+
+	stderr := new(bytes.Buffer)
+	if err := git.Mirror(cloneURL, p, c.auth(), &git.MirrorOpts{NoStrictHostKeyChecking: c.NoStrictHostKeyChecking}, &git.OutputOpts{Stderr: stderr}); err != nil {
+		return "", fmt.Errorf("Unable to mirror %s: %s: %s", cloneURL, err, strings.TrimSpace(stderr.String()))
+	}
+	return p, nil
+}
+
+func (c *GitClient) log(fullname string, opts *git.LogOpts) ([]git.Commit, error) {
+	p, err := c.mirror(fullname)
+	if err != nil {
+		return nil, err
+	}
+	return git.Log(p, opts)
+}
+
+func toVCSCommit(gc git.Commit) sdk.VCSCommit {
+	return sdk.VCSCommit{
+		Hash:      gc.Hash,
+		Timestamp: gc.AuthorDate.Unix() * 1000,
+		Message:   gc.Message,
+		Author: sdk.VCSAuthor{
+			Name:        gc.AuthorName,
+			DisplayName: gc.AuthorName,
+			Email:       gc.AuthorEmail,
+		},
+	}
+}
+
+//Repos can't list the repositories of a git server, repositories have to be attached with their fullname
+func (c *GitClient) Repos() ([]sdk.VCSRepo, error) {
+	return []sdk.VCSRepo{}, nil
+}
+
+//RepoByFullname checks the repository is reachable and returns it
+func (c *GitClient) RepoByFullname(fullname string) (sdk.VCSRepo, error) {
+	if _, err := c.lsRemote(fullname, true); err != nil {
+		log.Warning("GitClient.RepoByFullname> %s", err)
+		return sdk.VCSRepo{}, sdk.ErrRepoNotFound
+	}
+
+	cloneURL := CloneURL(c.URL, fullname)
+	repo := sdk.VCSRepo{
+		Name:     path.Base(fullname),
+		Slug:     path.Base(fullname),
+		Fullname: fullname,
+		URL:      cloneURL,
+	}
+	if strings.HasPrefix(cloneURL, "https://") {
+		repo.HTTPCloneURL = cloneURL
+	} else {
+		repo.SSHCloneURL = cloneURL
+	}
+	return repo, nil
+}
+
+//Branches returns the branches of the repository, using git ls-remote
+func (c *GitClient) Branches(fullname string) ([]sdk.VCSBranch, error) {
+	refs, err := c.lsRemote(fullname, false)
+	if err != nil {
+		return nil, sdk.WrapError(err, "GitClient.Branches>")
+	}
+
+	var defaultBranch string
+	for _, r := range refs {
+		if r.Name == "HEAD" {
+			defaultBranch = r.Target
+		}
+	}
+
+	branches := []sdk.VCSBranch{}
+	for _, r := range refs {
+		if !strings.HasPrefix(r.Name, "refs/heads/") {
+			continue
+		}
+		branches = append(branches, sdk.VCSBranch{
+			ID:           r.Name,
+			DisplayID:    strings.TrimPrefix(r.Name, "refs/heads/"),
+			LatestCommit: r.Hash,
+			Default:      r.Name == defaultBranch,
+		})
+	}
+	return branches, nil
+}
+
+//Branch returns a branch of the repository
+func (c *GitClient) Branch(fullname, branchName string) (*sdk.VCSBranch, error) {
+	branches, err := c.Branches(fullname)
+	if err != nil {
+		return nil, err
+	}
+	for i := range branches {
+		if branches[i].DisplayID == branchName || branches[i].ID == branchName {
+			return &branches[i], nil
+		}
+	}
+	return nil, fmt.Errorf("GitClient.Branch> Cannot find branch %s", branchName)
+}
+
+//Commits returns the commits of a branch between two commits (since is excluded), using git log
+func (c *GitClient) Commits(repo, branch, since, until string) ([]sdk.VCSCommit, error) {
+	opts := &git.LogOpts{}
+	if until != "" {
+		opts.Revisions = append(opts.Revisions, until)
+	} else {
+		opts.Revisions = append(opts.Revisions, "refs/heads/"+strings.TrimPrefix(branch, "refs/heads/"))
+	}
+	if since != "" {
+		opts.Revisions = append(opts.Revisions, "^"+since)
+	} else {
+		opts.MaxCount = 100
+	}
+
+	gitCommits, err := c.log(repo, opts)
+	if err != nil {
+		return nil, sdk.WrapError(err, "GitClient.Commits>")
+	}
+
+	commits := make([]sdk.VCSCommit, 0, len(gitCommits))
+	for _, gc := range gitCommits {
+		commits = append(commits, toVCSCommit(gc))
+	}
+	return commits, nil
+}
+
+//Commit returns a commit of the repository, using git log
+func (c *GitClient) Commit(repo, hash string) (sdk.VCSCommit, error) {
+	gitCommits, err := c.log(repo, &git.LogOpts{Revisions: []string{hash}, MaxCount: 1})
+	if err != nil {
+		return sdk.VCSCommit{}, sdk.WrapError(err, "GitClient.Commit>")
+	}
+	if len(gitCommits) == 0 {
+		return sdk.VCSCommit{}, fmt.Errorf("GitClient.Commit> Cannot find commit %s", hash)
+	}
+	return toVCSCommit(gitCommits[0]), nil
+}
+
+//CreateHook is not implemented
+func (c *GitClient) CreateHook(repo, url string) error {
+	return fmt.Errorf("Not yet implemented on git")
+}
+
+//DeleteHook is not implemented
+func (c *GitClient) DeleteHook(repo, url string) error {
+	return fmt.Errorf("Not yet implemented on git")
+}
+
+//GetEvents returns a push event for each branch whose last commit has been committed after the reference date
+func (c *GitClient) GetEvents(repo string, dateRef time.Time) ([]interface{}, time.Duration, error) {
+	interval := 60 * time.Second
+
+	p, err := c.mirror(repo)
+	if err != nil {
+		return nil, interval, sdk.WrapError(err, "GitClient.GetEvents>")
+	}
+
+	refs, err := git.LsRemote(p, nil, &git.LsRemoteOpts{Heads: true})
+	if err != nil {
+		return nil, interval, sdk.WrapError(err, "GitClient.GetEvents>")
+	}
+
+	events := []interface{}{}
+	for _, r := range refs {
+		commits, err := git.Log(p, &git.LogOpts{Revisions: []string{r.Hash}, MaxCount: 1})
+		if err != nil || len(commits) == 0 {
+			log.Warning("GitClient.GetEvents> Unable to get commit %s of %s: %v", r.Hash, r.Name, err)
+			continue
+		}
+		if !commits[0].CommitterDate.After(dateRef) {
+			continue
+		}
+		events = append(events, sdk.VCSPushEvent{
+			Branch: sdk.VCSBranch{
+				ID:           r.Name,
+				DisplayID:    strings.TrimPrefix(r.Name, "refs/heads/"),
+				LatestCommit: r.Hash,
+			},
+			Commit: toVCSCommit(commits[0]),
+		})
+	}
+
+	return events, interval, nil
+}
+
+//PushEvents returns push events as commits
+func (c *GitClient) PushEvents(repo string, iEvents []interface{}) ([]sdk.VCSPushEvent, error) {
+	res := []sdk.VCSPushEvent{}
+	for _, i := range iEvents {
+		if e, ok := i.(sdk.VCSPushEvent); ok {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+//CreateEvents can't be detected, branch creations are seen as push events
+func (c *GitClient) CreateEvents(string, []interface{}) ([]sdk.VCSCreateEvent, error) {
+	return []sdk.VCSCreateEvent{}, nil
+}
+
+//DeleteEvents can't be detected
+func (c *GitClient) DeleteEvents(string, []interface{}) ([]sdk.VCSDeleteEvent, error) {
+	return []sdk.VCSDeleteEvent{}, nil
+}
+
+//PullRequestComment is not supported, there is no pull request on git
+func (c *GitClient) PullRequestComment(string, int64, string) error {
+	return fmt.Errorf("Not yet implemented on git")
+}
+
+//PullRequestEvents are not supported by git
+func (c *GitClient) PullRequestEvents(string, []interface{}) ([]sdk.VCSPullRequestEvent, error) {
+	return []sdk.VCSPullRequestEvent{}, nil
+}
+
+//SetStatus does nothing, there is no status on a git server
+func (c *GitClient) SetStatus(event sdk.Event) error {
+	return nil
+}
+
+//Release is not implemented
+func (c *GitClient) Release(repo, tagName, releaseTitle, releaseDescription string) (*sdk.VCSRelease, error) {
+	return nil, fmt.Errorf("Not implemented on git")
+}
+
+//UploadReleaseFile is not implemented
+func (c *GitClient) UploadReleaseFile(repo string, release *sdk.VCSRelease, runArtifact sdk.WorkflowNodeRunArtifact, file *bytes.Buffer) error {
+	return fmt.Errorf("Not implemented on git")
+}
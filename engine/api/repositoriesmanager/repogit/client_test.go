@@ -0,0 +1,121 @@
+package repogit
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func gitInDir(t *testing.T, dir string, args ...string) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=John Doe", "GIT_AUTHOR_EMAIL=john@example.com",
+		"GIT_COMMITTER_NAME=John Doe", "GIT_COMMITTER_EMAIL=john@example.com",
+	)
+	if out, err := c.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+func TestCloneURL(t *testing.T) {
+	assert.Equal(t, "ssh://git@git.local:2222/group/repo.git", CloneURL("ssh://git@git.local:2222/", "group/repo"))
+	assert.Equal(t, "git@git.local:group/repo.git", CloneURL("git@git.local:", "group/repo.git"))
+	assert.Equal(t, "https://git.local/repo.git", CloneURL("https://git.local", "repo"))
+}
+
+func TestGitClient(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmp, err := ioutil.TempDir("", "cds-repogit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	Init("http://api.cds.local", "http://ui.cds.local", filepath.Join(tmp, "mirrors"))
+
+	// The git server is a directory of bare repositories
+	server := filepath.Join(tmp, "server")
+	work := filepath.Join(tmp, "work")
+	assert.NoError(t, os.MkdirAll(filepath.Join(server, "group"), 0700))
+	assert.NoError(t, os.MkdirAll(work, 0700))
+	gitInDir(t, server, "init", "--quiet", "--bare", "group/repo.git")
+	gitInDir(t, work, "init", "--quiet")
+	gitInDir(t, work, "checkout", "--quiet", "-b", "master")
+	gitInDir(t, work, "commit", "--quiet", "--allow-empty", "-m", "first commit")
+	gitInDir(t, work, "commit", "--quiet", "--allow-empty", "-m", "second commit")
+	gitInDir(t, work, "push", "--quiet", filepath.Join(server, "group/repo.git"), "master", "master:feature")
+	gitInDir(t, filepath.Join(server, "group/repo.git"), "symbolic-ref", "HEAD", "refs/heads/master")
+
+	consumer := New(server)
+	token, u, err := consumer.AuthorizeRedirect()
+	assert.NoError(t, err)
+	assert.Contains(t, u, "http://api.cds.local/repositories_manager/oauth2/callback?")
+	_, _, err = consumer.AuthorizeToken(token, "wrong")
+	assert.Error(t, err)
+	accessToken, secret, err := consumer.AuthorizeToken(token, token)
+	assert.NoError(t, err)
+
+	client, err := consumer.GetAuthorized(accessToken, secret)
+	assert.NoError(t, err)
+
+	repo, err := client.RepoByFullname("group/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", repo.Name)
+	assert.Equal(t, filepath.Join(server, "group/repo.git"), repo.SSHCloneURL)
+
+	_, err = client.RepoByFullname("group/unknown")
+	assert.Equal(t, sdk.ErrRepoNotFound, err)
+
+	branches, err := client.Branches("group/repo")
+	assert.NoError(t, err)
+	assert.Len(t, branches, 2)
+	assert.Equal(t, "feature", branches[0].DisplayID)
+	assert.False(t, branches[0].Default)
+	assert.Equal(t, "master", branches[1].DisplayID)
+	assert.True(t, branches[1].Default)
+
+	master, err := client.Branch("group/repo", "master")
+	assert.NoError(t, err)
+
+	commits, err := client.Commits("group/repo", "master", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, master.LatestCommit, commits[0].Hash)
+	assert.Equal(t, "second commit", commits[0].Message)
+	assert.Equal(t, "john@example.com", commits[0].Author.Email)
+
+	commits, err = client.Commits("group/repo", "master", commits[1].Hash, master.LatestCommit)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+
+	commit, err := client.Commit("group/repo", master.LatestCommit)
+	assert.NoError(t, err)
+	assert.Equal(t, "second commit", commit.Message)
+
+	// Polling
+	events, _, err := client.GetEvents("group/repo", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+
+	gitInDir(t, work, "commit", "--quiet", "--allow-empty", "-m", "third commit")
+	gitInDir(t, work, "push", "--quiet", filepath.Join(server, "group/repo.git"), "master:feature")
+
+	events, _, err = client.GetEvents("group/repo", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	pushEvents, err := client.PushEvents("group/repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, pushEvents, 2)
+	for _, e := range pushEvents {
+		if e.Branch.DisplayID == "feature" {
+			assert.Equal(t, "third commit", e.Commit.Message)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repogit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+)
+
+//GitConsumer is a repositories manager for any git server, using the git command line
+type GitConsumer struct {
+	URL                     string `json:"url"`
+	PrivateKey              string `json:"private_key"`
+	WithPolling             bool   `json:"with-polling"`
+	NoStrictHostKeyChecking bool   `json:"no-strict-host-key-checking"`
+}
+
+//New creates a new GitConsumer. URL is the base URL of the repositories, ie. ssh://git@git.mycompany.com or git@git.mycompany.com:
+func New(URL string) *GitConsumer {
+	return &GitConsumer{URL: URL}
+}
+
+//Data returns a serilized version of specific data
+func (g *GitConsumer) Data() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+//AuthorizeRedirect returns the request token, and an URL to the API callback.
+//There is nothing to authorize on the git server, the authorization only links the project to the repositories manager
+func (g *GitConsumer) AuthorizeRedirect() (string, string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", "", err
+	}
+	requestToken := hex.EncodeToString(bs)
+
+	val := url.Values{}
+	val.Add("state", requestToken)
+	val.Add("code", requestToken)
+
+	return requestToken, fmt.Sprintf("%s/repositories_manager/oauth2/callback?%s", apiURL, val.Encode()), nil
+}
+
+//AuthorizeToken checks the code got on the authorize URL
+func (g *GitConsumer) AuthorizeToken(state, code string) (string, string, error) {
+	if state == "" || code != state {
+		return "", "", fmt.Errorf("Invalid authorization code")
+	}
+	return state, "", nil
+}
+
+//GetAuthorized returns an authorized client
+func (g *GitConsumer) GetAuthorized(accessToken, accessTokenSecret string) (sdk.RepositoriesManagerClient, error) {
+	return &GitClient{
+		URL:                     g.URL,
+		PrivateKey:              g.PrivateKey,
+		NoStrictHostKeyChecking: g.NoStrictHostKeyChecking,
+	}, nil
+}
+
+//HooksSupported returns true if the driver technically support hook
+func (g *GitConsumer) HooksSupported() bool {
+	return false
+}
+
+//PollingSupported returns true if the driver technically support polling
+func (g *GitConsumer) PollingSupported() bool {
+	return true
+}
+
+//CloneURL returns the clone URL of a repository from its fullname
+func CloneURL(baseURL, fullname string) string {
+	fullname = strings.TrimSuffix(fullname, ".git") + ".git"
+	if strings.HasSuffix(baseURL, ":") {
+		return baseURL + fullname
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + fullname
+}
@@ -0,0 +1,23 @@
+package repogit
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var (
+	apiURL            string
+	uiURL             string
+	mirrorsDirectory  string
+	defaultMirrorsDir = filepath.Join(os.TempDir(), "cds-git-mirrors")
+)
+
+// Init initializes repogit package
+func Init(apiurl, uiurl, mirrorsDir string) {
+	apiURL = apiurl
+	uiURL = uiurl
+	mirrorsDirectory = mirrorsDir
+	if mirrorsDirectory == "" {
+		mirrorsDirectory = defaultMirrorsDir
+	}
+}
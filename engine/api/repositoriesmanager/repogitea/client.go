@@ -0,0 +1,285 @@
+package repogitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+// GiteaClient is a gitea wrapper for CDS RepositoriesManagerClient interface
+type GiteaClient struct {
+	URL              string
+	AccessToken      string
+	DisableSetStatus bool
+}
+
+//User returns the user owning the access token
+func (c *GiteaClient) User() (User, error) {
+	var u User
+	err := c.get("/user", &u)
+	return u, err
+}
+
+func (r Repository) toVCSRepo() sdk.VCSRepo {
+	return sdk.VCSRepo{
+		ID:           fmt.Sprintf("%d", r.ID),
+		Name:         r.Name,
+		Slug:         r.Name,
+		Fullname:     r.FullName,
+		URL:          r.HTMLURL,
+		HTTPCloneURL: r.CloneURL,
+		SSHCloneURL:  r.SSHURL,
+	}
+}
+
+func (c *GiteaClient) repo(fullname string) (Repository, error) {
+	var r Repository
+	if err := c.get("/repos/"+fullname, &r); err != nil {
+		if e, ok := err.(Error); ok && e.StatusCode == http.StatusNotFound {
+			return r, sdk.ErrRepoNotFound
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+//Repos returns the repositories of the user
+func (c *GiteaClient) Repos() ([]sdk.VCSRepo, error) {
+	repos := []sdk.VCSRepo{}
+	known := map[int64]bool{}
+	err := c.getAll("/user/repos", func(body []byte) (int, int, error) {
+		page := []Repository{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return 0, 0, err
+		}
+		var added int
+		for _, r := range page {
+			if known[r.ID] {
+				continue
+			}
+			known[r.ID] = true
+			repos = append(repos, r.toVCSRepo())
+			added++
+		}
+		return len(page), added, nil
+	})
+	if err != nil {
+		return nil, sdk.WrapError(err, "GiteaClient.Repos> Unable to list repositories")
+	}
+	return repos, nil
+}
+
+//RepoByFullname returns the repo from its fullname
+func (c *GiteaClient) RepoByFullname(fullname string) (sdk.VCSRepo, error) {
+	r, err := c.repo(fullname)
+	if err != nil {
+		return sdk.VCSRepo{}, err
+	}
+	return r.toVCSRepo(), nil
+}
+
+func (b Branch) toVCSBranch(defaultBranch string) sdk.VCSBranch {
+	return sdk.VCSBranch{
+		ID:           b.Name,
+		DisplayID:    b.Name,
+		LatestCommit: b.Commit.ID,
+		Default:      b.Name == defaultBranch,
+	}
+}
+
+func (c *GiteaClient) branches(fullname string) ([]Branch, error) {
+	branches := []Branch{}
+	known := map[string]bool{}
+	err := c.getAll("/repos/"+fullname+"/branches", func(body []byte) (int, int, error) {
+		page := []Branch{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return 0, 0, err
+		}
+		var added int
+		for _, b := range page {
+			if known[b.Name] {
+				continue
+			}
+			known[b.Name] = true
+			branches = append(branches, b)
+			added++
+		}
+		return len(page), added, nil
+	})
+	return branches, err
+}
+
+//Branches retrieves the branches of a repository
+func (c *GiteaClient) Branches(fullname string) ([]sdk.VCSBranch, error) {
+	r, err := c.repo(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	giteaBranches, err := c.branches(fullname)
+	if err != nil {
+		return nil, sdk.WrapError(err, "GiteaClient.Branches> Unable to list branches of %s", fullname)
+	}
+
+	branches := make([]sdk.VCSBranch, 0, len(giteaBranches))
+	for _, b := range giteaBranches {
+		branches = append(branches, b.toVCSBranch(r.DefaultBranch))
+	}
+	return branches, nil
+}
+
+//Branch retrieves a branch of a repository
+func (c *GiteaClient) Branch(fullname, branchName string) (*sdk.VCSBranch, error) {
+	r, err := c.repo(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Branch
+	if err := c.get("/repos/"+fullname+"/branches/"+branchName, &b); err != nil {
+		return nil, sdk.WrapError(err, "GiteaClient.Branch> Cannot find branch %s", branchName)
+	}
+	branch := b.toVCSBranch(r.DefaultBranch)
+	return &branch, nil
+}
+
+func (gc Commit) toVCSCommit() sdk.VCSCommit {
+	commit := sdk.VCSCommit{
+		Hash:      gc.SHA,
+		Timestamp: gc.Commit.Author.Date.Unix() * 1000,
+		Message:   gc.Commit.Message,
+		Author: sdk.VCSAuthor{
+			Name:        gc.Commit.Author.Name,
+			DisplayName: gc.Commit.Author.Name,
+			Email:       gc.Commit.Author.Email,
+		},
+		URL: gc.HTMLURL,
+	}
+	if gc.Author != nil {
+		commit.Author.Name = gc.Author.Login
+		commit.Author.Avatar = gc.Author.AvatarURL
+	}
+	return commit
+}
+
+//maxCommitsPages limits the number of pages read when looking for the commits between two commits
+const maxCommitsPages = 10
+
+//Commits returns the commits of a branch between two commits (since is excluded)
+func (c *GiteaClient) Commits(repo, branch, since, until string) ([]sdk.VCSCommit, error) {
+	ref := until
+	if ref == "" {
+		ref = branch
+	}
+
+	commits := []sdk.VCSCommit{}
+	for page := 1; page <= maxCommitsPages; page++ {
+		giteaCommits := []Commit{}
+		path := fmt.Sprintf("/repos/%s/commits?sha=%s&page=%d&limit=%d", repo, url.QueryEscape(ref), page, pageSize)
+		if err := c.get(path, &giteaCommits); err != nil {
+			return nil, sdk.WrapError(err, "GiteaClient.Commits> Unable to list commits of %s", ref)
+		}
+		for _, gc := range giteaCommits {
+			if since != "" && gc.SHA == since {
+				return commits, nil
+			}
+			commits = append(commits, gc.toVCSCommit())
+		}
+		if since == "" || len(giteaCommits) < pageSize {
+			break
+		}
+	}
+	return commits, nil
+}
+
+//Commit retrieves a specific commit according to a hash
+func (c *GiteaClient) Commit(repo, hash string) (sdk.VCSCommit, error) {
+	var gc Commit
+	if err := c.get("/repos/"+repo+"/git/commits/"+hash, &gc); err != nil {
+		return sdk.VCSCommit{}, sdk.WrapError(err, "GiteaClient.Commit> Unable to get commit %s", hash)
+	}
+	return gc.toVCSCommit(), nil
+}
+
+func (c *GiteaClient) hooks(repo string) ([]Hook, error) {
+	hooks := []Hook{}
+	known := map[int64]bool{}
+	err := c.getAll("/repos/"+repo+"/hooks", func(body []byte) (int, int, error) {
+		page := []Hook{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return 0, 0, err
+		}
+		var added int
+		for _, h := range page {
+			if known[h.ID] {
+				continue
+			}
+			known[h.ID] = true
+			hooks = append(hooks, h)
+			added++
+		}
+		return len(page), added, nil
+	})
+	return hooks, err
+}
+
+//CreateHook creates a repository hook calling url on push events.
+//The hook uses the gogs payload, supported by Gitea and Gogs
+func (c *GiteaClient) CreateHook(repo, url string) error {
+	hooks, err := c.hooks(repo)
+	if err != nil {
+		return sdk.WrapError(err, "GiteaClient.CreateHook> Unable to list hooks of %s", repo)
+	}
+	for _, h := range hooks {
+		if h.Config.URL == url {
+			log.Info("GiteaClient.CreateHook> Hook already exists on %s", repo)
+			return nil
+		}
+	}
+
+	h := Hook{
+		Type:   "gogs",
+		Config: HookConfig{URL: url, ContentType: "json"},
+		Events: []string{"push"},
+		Active: true,
+	}
+	log.Info("GiteaClient.CreateHook> Ask Gitea to create Hook on %s: %s", repo, url)
+	if err := c.do(http.MethodPost, "/repos/"+repo+"/hooks", h, &h); err != nil {
+		return sdk.WrapError(err, "GiteaClient.CreateHook> Unable to create hook on %s", repo)
+	}
+	log.Info("GiteaClient.CreateHook> Hook %d created", h.ID)
+	return nil
+}
+
+//DeleteHook deletes the repository hooks calling url
+func (c *GiteaClient) DeleteHook(repo, url string) error {
+	hooks, err := c.hooks(repo)
+	if err != nil {
+		return sdk.WrapError(err, "GiteaClient.DeleteHook> Unable to list hooks of %s", repo)
+	}
+	for _, h := range hooks {
+		if h.Config.URL != url {
+			continue
+		}
+		log.Info("GiteaClient.DeleteHook> Ask Gitea to delete Hook %d on %s", h.ID, repo)
+		if err := c.do(http.MethodDelete, fmt.Sprintf("/repos/%s/hooks/%d", repo, h.ID), nil, nil); err != nil {
+			return sdk.WrapError(err, "GiteaClient.DeleteHook> Unable to delete hook %d on %s", h.ID, repo)
+		}
+	}
+	return nil
+}
+
+//Release is not implemented
+func (c *GiteaClient) Release(repo, tagName, releaseTitle, releaseDescription string) (*sdk.VCSRelease, error) {
+	return nil, fmt.Errorf("Not implemented on gitea")
+}
+
+//UploadReleaseFile is not implemented
+func (c *GiteaClient) UploadReleaseFile(repo string, release *sdk.VCSRelease, runArtifact sdk.WorkflowNodeRunArtifact, file *bytes.Buffer) error {
+	return fmt.Errorf("Not implemented on gitea")
+}
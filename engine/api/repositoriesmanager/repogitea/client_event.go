@@ -0,0 +1,213 @@
+package repogitea
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//GetEvents returns the branches and the pull requests updated after the reference date.
+//Gitea has no events API, a branch is updated if its last commit has been committed after the reference date
+func (c *GiteaClient) GetEvents(fullname string, dateRef time.Time) ([]interface{}, time.Duration, error) {
+	log.Debug("GiteaClient.GetEvents> loading events for %s after %v", fullname, dateRef)
+	interval := 60 * time.Second
+
+	branches, err := c.branches(fullname)
+	if err != nil {
+		return nil, interval, sdk.WrapError(err, "GiteaClient.GetEvents> Unable to list branches of %s", fullname)
+	}
+
+	events := []interface{}{}
+	for i := range branches {
+		if branches[i].Commit.Timestamp.After(dateRef) {
+			events = append(events, Event{Branch: &branches[i]})
+		}
+	}
+
+	//Pull requests are sorted by update date, the first page is enough
+	pulls := []PullRequest{}
+	if err := c.get(fmt.Sprintf("/repos/%s/pulls?state=all&sort=recentupdate&limit=%d", fullname, pageSize), &pulls); err != nil {
+		log.Warning("GiteaClient.GetEvents> Unable to list pull requests of %s: %s", fullname, err)
+	}
+	for i := range pulls {
+		if pulls[i].UpdatedAt.After(dateRef) {
+			events = append(events, Event{PullRequest: &pulls[i]})
+		}
+	}
+
+	return events, interval, nil
+}
+
+//PushEvents returns push events as commits
+func (c *GiteaClient) PushEvents(fullname string, iEvents []interface{}) ([]sdk.VCSPushEvent, error) {
+	r, err := c.repo(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []sdk.VCSPushEvent{}
+	for _, i := range iEvents {
+		e, ok := i.(Event)
+		if !ok || e.Branch == nil {
+			continue
+		}
+		res = append(res, sdk.VCSPushEvent{
+			Branch: e.Branch.toVCSBranch(r.DefaultBranch),
+			Commit: sdk.VCSCommit{
+				Hash:      e.Branch.Commit.ID,
+				Message:   e.Branch.Commit.Message,
+				Timestamp: e.Branch.Commit.Timestamp.Unix() * 1000,
+				URL:       e.Branch.Commit.URL,
+				Author: sdk.VCSAuthor{
+					Name:        e.Branch.Commit.Author.Username,
+					DisplayName: e.Branch.Commit.Author.Name,
+					Email:       e.Branch.Commit.Author.Email,
+				},
+			},
+		})
+	}
+	return res, nil
+}
+
+//CreateEvents can't be detected, branch creations are seen as push events
+func (c *GiteaClient) CreateEvents(string, []interface{}) ([]sdk.VCSCreateEvent, error) {
+	return []sdk.VCSCreateEvent{}, nil
+}
+
+//DeleteEvents can't be detected
+func (c *GiteaClient) DeleteEvents(string, []interface{}) ([]sdk.VCSDeleteEvent, error) {
+	return []sdk.VCSDeleteEvent{}, nil
+}
+
+//PullRequestEvents checks pull request events from a event list
+func (c *GiteaClient) PullRequestEvents(fullname string, iEvents []interface{}) ([]sdk.VCSPullRequestEvent, error) {
+	res := []sdk.VCSPullRequestEvent{}
+	for _, i := range iEvents {
+		e, ok := i.(Event)
+		if !ok || e.PullRequest == nil {
+			continue
+		}
+		pr := e.PullRequest
+		action := "opened"
+		if pr.State != "open" {
+			action = "closed"
+		}
+		head := sdk.VCSBranch{ID: pr.Head.Ref, DisplayID: pr.Head.Ref, LatestCommit: pr.Head.SHA}
+		res = append(res, sdk.VCSPullRequestEvent{
+			ID:     pr.Number,
+			Action: action,
+			URL:    pr.HTMLURL,
+			Ref:    fmt.Sprintf("refs/pull/%d/head", pr.Number),
+			User: sdk.VCSAuthor{
+				Name:        pr.User.Login,
+				DisplayName: pr.User.FullName,
+				Avatar:      pr.User.AvatarURL,
+			},
+			Head: sdk.VCSPushEvent{
+				Branch: head,
+				Commit: sdk.VCSCommit{Hash: pr.Head.SHA},
+			},
+			Base: sdk.VCSPushEvent{
+				Branch: sdk.VCSBranch{ID: pr.Base.Ref, DisplayID: pr.Base.Ref, LatestCommit: pr.Base.SHA},
+				Commit: sdk.VCSCommit{Hash: pr.Base.SHA},
+			},
+			Branch: head,
+		})
+	}
+	return res, nil
+}
+
+//SetStatus creates a commit status on Gitea for a pipeline build or a workflow node run
+func (c *GiteaClient) SetStatus(event sdk.Event) error {
+	log.Debug("gitea.SetStatus> receive: type:%s all: %+v", event.EventType, event)
+
+	var fullname, hash string
+	var status Status
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}):
+		var eventpb sdk.EventPipelineBuild
+		if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
+			log.Warning("Error during consumption: %s", err)
+			return err
+		}
+		fullname, hash = eventpb.RepositoryFullname, eventpb.Hash
+		status = Status{
+			State: getGiteaStateFromStatus(eventpb.Status),
+			TargetURL: fmt.Sprintf("%s/project/%s/application/%s/pipeline/%s/build/%d?envName=%s",
+				uiURL,
+				eventpb.ProjectKey,
+				eventpb.ApplicationName,
+				eventpb.PipelineName,
+				eventpb.BuildNumber,
+				url.QueryEscape(eventpb.EnvironmentName),
+			),
+			Description: fmt.Sprintf("Pipeline %s: %s", eventpb.PipelineName, eventpb.Status.String()),
+			Context:     fmt.Sprintf("continuous-delivery/CDS/%s", eventpb.PipelineName),
+		}
+	case fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}):
+		var eventnr sdk.EventWorkflowNodeRun
+		if err := mapstructure.Decode(event.Payload, &eventnr); err != nil {
+			log.Warning("Error during consumption: %s", err)
+			return err
+		}
+		fullname, hash = eventnr.RepositoryFullname, eventnr.Hash
+		status = Status{
+			State: getGiteaStateFromStatus(eventnr.Status),
+			TargetURL: fmt.Sprintf("%s/project/%s/workflow/%s/run/%d/node/%d",
+				uiURL,
+				eventnr.ProjectKey,
+				eventnr.WorkflowName,
+				eventnr.Number,
+				eventnr.NodeRunID,
+			),
+			Description: fmt.Sprintf("Pipeline %s: %s", eventnr.PipelineName, eventnr.Status.String()),
+			Context:     fmt.Sprintf("continuous-delivery/CDS/%s/%s", eventnr.WorkflowName, eventnr.NodeName),
+		}
+	default:
+		return nil
+	}
+
+	if c.DisableSetStatus {
+		log.Warning("⚠ Gitea statuses are disabled")
+		return nil
+	}
+
+	if status.State == "" || hash == "" {
+		return nil
+	}
+
+	log.Debug("SetStatus> hash:%s status:%+v", hash, status)
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", fullname, hash), status, nil); err != nil {
+		return sdk.WrapError(err, "SetStatus> err on gitea")
+	}
+	return nil
+}
+
+//PullRequestComment adds a comment on a pull request
+func (c *GiteaClient) PullRequestComment(fullname string, id int64, body string) error {
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", fullname, id), Comment{Body: body}, nil); err != nil {
+		return sdk.WrapError(err, "PullRequestComment> Unable to comment pull request %d of %s", id, fullname)
+	}
+	return nil
+}
+
+func getGiteaStateFromStatus(status sdk.Status) string {
+	switch status {
+	case sdk.StatusSuccess:
+		return "success"
+	case sdk.StatusFail:
+		return "failure"
+	case sdk.StatusBuilding, sdk.StatusWaiting, sdk.StatusWaitingApproval:
+		return "pending"
+	case sdk.StatusStopped:
+		return "error"
+	default:
+		return ""
+	}
+}
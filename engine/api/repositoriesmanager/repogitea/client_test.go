@@ -0,0 +1,271 @@
+package repogitea
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// fakeGitea is a local stand-in for a Gitea instance
+type fakeGitea struct {
+	*httptest.Server
+	paginate bool
+	hooks    []Hook
+	statuses map[string]Status
+	comments []Comment
+	queries  map[string]url.Values
+}
+
+func newFakeGitea(t *testing.T, paginate bool) *fakeGitea {
+	f := &fakeGitea{paginate: paginate, statuses: map[string]Status{}, queries: map[string]url.Values{}}
+	now := time.Now()
+
+	repos := []Repository{}
+	for i := 0; i < pageSize+1; i++ {
+		repos = append(repos, Repository{ID: int64(i + 1), Name: "repo", FullName: "john/repo", DefaultBranch: "master"})
+	}
+	commits := []Commit{}
+	for _, sha := range []string{"c3", "c2", "c1"} {
+		commits = append(commits, Commit{SHA: sha, Commit: RepoCommit{Message: "commit " + sha, Author: CommitUser{Name: "John Doe", Email: "john@example.com", Date: now}}, Author: &User{Login: "john"}})
+	}
+	branches := []Branch{
+		{Name: "master", Commit: PayloadCommit{ID: "c3", Message: "commit c3", Timestamp: now.Add(-time.Minute), Author: PayloadUser{Username: "john"}}},
+		{Name: "old", Commit: PayloadCommit{ID: "c0", Timestamp: now.Add(-48 * time.Hour)}},
+	}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token the-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		route := r.Method + " " + r.URL.Path
+		f.queries[route] = r.URL.Query()
+		var out interface{}
+		switch route {
+		case "GET /api/v1/user":
+			out = User{ID: 1, Login: "john"}
+		case "GET /api/v1/user/repos":
+			switch {
+			case !f.paginate:
+				out = repos
+			case r.FormValue("page") == "1":
+				out = repos[:pageSize]
+			default:
+				out = repos[pageSize:]
+			}
+		case "GET /api/v1/repos/john/repo":
+			out = repos[0]
+		case "GET /api/v1/repos/john/repo/branches":
+			out = branches
+		case "GET /api/v1/repos/john/repo/branches/master":
+			out = branches[0]
+		case "GET /api/v1/repos/john/repo/commits":
+			out = commits
+		case "GET /api/v1/repos/john/repo/git/commits/c2":
+			out = commits[1]
+		case "GET /api/v1/repos/john/repo/hooks":
+			out = f.hooks
+		case "POST /api/v1/repos/john/repo/hooks":
+			var h Hook
+			json.NewDecoder(r.Body).Decode(&h)
+			h.ID = int64(len(f.hooks) + 1)
+			f.hooks = append(f.hooks, h)
+			w.WriteHeader(http.StatusCreated)
+			out = h
+		case "DELETE /api/v1/repos/john/repo/hooks/1":
+			f.hooks = f.hooks[1:]
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "GET /api/v1/repos/john/repo/pulls":
+			out = []PullRequest{
+				{Number: 2, State: "open", HTMLURL: "http://gitea/john/repo/pulls/2", User: User{Login: "john"}, Head: PRBranchInfo{Ref: "feat", SHA: "c4"}, Base: PRBranchInfo{Ref: "master", SHA: "c3"}, UpdatedAt: now},
+				{Number: 1, State: "closed", UpdatedAt: now.Add(-48 * time.Hour)},
+			}
+		case "POST /api/v1/repos/john/repo/statuses/c3":
+			var s Status
+			json.NewDecoder(r.Body).Decode(&s)
+			f.statuses["c3"] = s
+			w.WriteHeader(http.StatusCreated)
+			out = s
+		case "POST /api/v1/repos/john/repo/issues/2/comments":
+			var cm Comment
+			json.NewDecoder(r.Body).Decode(&cm)
+			f.comments = append(f.comments, cm)
+			w.WriteHeader(http.StatusCreated)
+			out = cm
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			out = map[string]string{"message": "Not Found"}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	return f
+}
+
+func newTestClient(t *testing.T, f *fakeGitea) *GiteaClient {
+	consumer := New(f.URL + "/")
+	state, u, err := consumer.AuthorizeRedirect()
+	assert.NoError(t, err)
+	assert.Equal(t, f.URL+"/user/settings/applications", u)
+
+	_, _, err = consumer.AuthorizeToken(state, "wrong-token")
+	assert.Error(t, err)
+
+	token, secret, err := consumer.AuthorizeToken(state, "the-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "the-token", token)
+
+	client, err := consumer.GetAuthorized(token, secret)
+	assert.NoError(t, err)
+	return client.(*GiteaClient)
+}
+
+func TestGiteaClientRepositories(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	repos, err := c.Repos()
+	assert.NoError(t, err)
+	assert.Len(t, repos, pageSize+1)
+
+	// Gogs returns all the repositories on each page
+	f.paginate = false
+	repos, err = c.Repos()
+	assert.NoError(t, err)
+	assert.Len(t, repos, pageSize+1)
+
+	_, err = c.RepoByFullname("john/unknown")
+	assert.Equal(t, sdk.ErrRepoNotFound, err)
+
+	branches, err := c.Branches("john/repo")
+	assert.NoError(t, err)
+	assert.Len(t, branches, 2)
+	assert.True(t, branches[0].Default)
+	assert.Equal(t, "c3", branches[0].LatestCommit)
+
+	commits, err := c.Commits("john/repo", "master", "c1", "c3")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "c3", commits[0].Hash)
+	assert.Equal(t, "john", commits[0].Author.Name)
+	assert.Equal(t, "c3", f.queries["GET /api/v1/repos/john/repo/commits"].Get("sha"))
+
+	commit, err := c.Commit("john/repo", "c2")
+	assert.NoError(t, err)
+	assert.Equal(t, "commit c2", commit.Message)
+	assert.Equal(t, "john@example.com", commit.Author.Email)
+}
+
+func TestGiteaClientHooks(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	assert.NoError(t, c.CreateHook("john/repo", "http://cds.local/hook?uid=1"))
+	assert.NoError(t, c.CreateHook("john/repo", "http://cds.local/hook?uid=1"))
+	assert.Len(t, f.hooks, 1)
+	assert.Equal(t, "gogs", f.hooks[0].Type)
+	assert.Equal(t, "json", f.hooks[0].Config.ContentType)
+
+	assert.NoError(t, c.DeleteHook("john/repo", "http://cds.local/hook?uid=1"))
+	assert.Len(t, f.hooks, 0)
+
+	h, err := ParsePushHook([]byte(`{"ref":"refs/heads/feat/a","before":"0000000000000000000000000000000000000000","after":"c2","pusher":{"login":"john"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "feat/a", h.Branch())
+	assert.Equal(t, "c2", h.Hash())
+	assert.Equal(t, "john", h.Author())
+	assert.Equal(t, "ADD", h.ChangeType())
+}
+
+func TestGiteaClientEvents(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	events, _, err := c.GetEvents("john/repo", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	pushEvents, err := c.PushEvents("john/repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, pushEvents, 1)
+	assert.Equal(t, "master", pushEvents[0].Branch.DisplayID)
+	assert.Equal(t, "c3", pushEvents[0].Commit.Hash)
+
+	prEvents, err := c.PullRequestEvents("john/repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, prEvents, 1)
+	assert.Equal(t, "opened", prEvents[0].Action)
+	assert.Equal(t, int64(2), prEvents[0].ID)
+	assert.Equal(t, "refs/pull/2/head", prEvents[0].Ref)
+	assert.Equal(t, "feat", prEvents[0].Head.Branch.DisplayID)
+	assert.Equal(t, "master", prEvents[0].Base.Branch.DisplayID)
+}
+
+func TestGiteaClientSetStatus(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+	Init("http://api.cds.local", "http://ui.cds.local")
+
+	e := sdk.Event{
+		EventType: "sdk.EventPipelineBuild",
+		Payload: map[string]interface{}{
+			"ProjectKey":         "PRJ",
+			"ApplicationName":    "app",
+			"PipelineName":       "build",
+			"BuildNumber":        12,
+			"Status":             sdk.StatusSuccess,
+			"Hash":               "c3",
+			"RepositoryFullname": "john/repo",
+		},
+	}
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "success", f.statuses["c3"].State)
+	assert.Equal(t, "continuous-delivery/CDS/build", f.statuses["c3"].Context)
+}
+
+func TestGiteaClientSetWorkflowNodeRunStatus(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+	Init("http://api.cds.local", "http://ui.cds.local")
+
+	e := sdk.Event{
+		EventType: "sdk.EventWorkflowNodeRun",
+		Payload: map[string]interface{}{
+			"ProjectKey":         "PRJ",
+			"WorkflowName":       "wf",
+			"Number":             5,
+			"NodeRunID":          51,
+			"NodeName":           "build",
+			"PipelineName":       "build",
+			"Status":             sdk.StatusFail,
+			"Hash":               "c3",
+			"RepositoryFullname": "john/repo",
+		},
+	}
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "failure", f.statuses["c3"].State)
+	assert.Equal(t, "continuous-delivery/CDS/wf/build", f.statuses["c3"].Context)
+	assert.Equal(t, "http://ui.cds.local/project/PRJ/workflow/wf/run/5/node/51", f.statuses["c3"].TargetURL)
+}
+
+func TestGiteaClientPullRequestComment(t *testing.T) {
+	f := newFakeGitea(t, true)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	assert.NoError(t, c.PullRequestComment("john/repo", 2, "**CDS** build succeeded"))
+	assert.Len(t, f.comments, 1)
+	assert.Equal(t, "**CDS** build succeeded", f.comments[0].Body)
+}
@@ -0,0 +1,94 @@
+package repogitea
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//GiteaConsumer is a Gitea (or Gogs) repositories manager.
+//Projects are authorized with a personal access token generated on Gitea
+type GiteaConsumer struct {
+	URL              string `json:"url"`
+	WithHooks        bool   `json:"with-hooks"`
+	WithPolling      bool   `json:"with-polling"`
+	DisableSetStatus bool   `json:"-"`
+}
+
+//New creates a new GiteaConsumer
+func New(URL string) *GiteaConsumer {
+	return &GiteaConsumer{
+		URL: strings.TrimSuffix(URL, "/"),
+	}
+}
+
+//Data returns a serilized version of specific data
+func (g *GiteaConsumer) Data() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+//AuthorizeRedirect returns the request token, and the URL of the Gitea page where the user can generate an access token.
+//The access token is then sent as verifier to AuthorizeToken
+func (g *GiteaConsumer) AuthorizeRedirect() (string, string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(bs), g.URL + "/user/settings/applications", nil
+}
+
+//AuthorizeToken checks the access token given as verifier
+func (g *GiteaConsumer) AuthorizeToken(state, accessToken string) (string, string, error) {
+	c := &GiteaClient{URL: g.URL, AccessToken: accessToken}
+	u, err := c.User()
+	if err != nil {
+		return "", "", err
+	}
+	log.Debug("AuthorizeToken> Gitea access token of %s", u.Login)
+	return accessToken, state, nil
+}
+
+//keep client in memory
+var (
+	instancesAuthorizedClient    = map[string]*GiteaClient{}
+	instancesAuthorizedClientMux sync.Mutex
+)
+
+//GetAuthorized returns an authorized client
+func (g *GiteaConsumer) GetAuthorized(accessToken, accessTokenSecret string) (sdk.RepositoriesManagerClient, error) {
+	if accessToken == "" {
+		return nil, fmt.Errorf("Gitea access token is mandatory")
+	}
+
+	instancesAuthorizedClientMux.Lock()
+	defer instancesAuthorizedClientMux.Unlock()
+
+	key := g.URL + accessToken
+	c := instancesAuthorizedClient[key]
+	if c == nil {
+		c = &GiteaClient{
+			URL:              g.URL,
+			AccessToken:      accessToken,
+			DisableSetStatus: g.DisableSetStatus,
+		}
+		instancesAuthorizedClient[key] = c
+	}
+	return c, nil
+}
+
+//HooksSupported returns true if the driver technically support hook
+func (g *GiteaConsumer) HooksSupported() bool {
+	return true
+}
+
+//PollingSupported returns true if the driver technically support polling
+func (g *GiteaConsumer) PollingSupported() bool {
+	return true
+}
@@ -0,0 +1,25 @@
+package repogitea
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//Error wraps an error returned by the Gitea API
+type Error struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("Gitea error (%d): %s", e.StatusCode, e.Message)
+}
+
+//ErrorAPI returns an Error from a Gitea API response body
+func ErrorAPI(status int, body []byte) error {
+	e := Error{StatusCode: status}
+	if err := json.Unmarshal(body, &e); err != nil || e.Message == "" {
+		e.Message = string(body)
+	}
+	return e
+}
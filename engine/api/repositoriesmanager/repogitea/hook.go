@@ -0,0 +1,65 @@
+package repogitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//HookEventHeader is the header set by Gitea and Gogs on the webhooks calls
+const HookEventHeader = "X-Gogs-Event"
+
+//PushHookEvent is the value of HookEventHeader for push webhooks
+const PushHookEvent = "push"
+
+const nullHash = "0000000000000000000000000000000000000000"
+
+//PushHook is the payload of a push webhook
+type PushHook struct {
+	Ref    string `json:"ref"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Pusher User   `json:"pusher"`
+}
+
+//ParsePushHook parses the payload of a push webhook
+func ParsePushHook(data []byte) (*PushHook, error) {
+	h := &PushHook{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.Ref == "" {
+		return nil, fmt.Errorf("Invalid gitea push hook: no ref")
+	}
+	return h, nil
+}
+
+//Branch returns the name of the pushed branch
+func (h *PushHook) Branch() string {
+	return strings.TrimPrefix(h.Ref, "refs/heads/")
+}
+
+//Author returns the username of the pusher
+func (h *PushHook) Author() string {
+	if h.Pusher.Login != "" {
+		return h.Pusher.Login
+	}
+	return h.Pusher.Username
+}
+
+//Hash returns the commit pushed
+func (h *PushHook) Hash() string {
+	return h.After
+}
+
+//ChangeType returns ADD, UPDATE or DELETE from the commits before and after the push
+func (h *PushHook) ChangeType() string {
+	switch {
+	case h.Before == nullHash:
+		return "ADD"
+	case h.After == nullHash:
+		return "DELETE"
+	default:
+		return "UPDATE"
+	}
+}
@@ -0,0 +1,97 @@
+package repogitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/facebookgo/httpcontrol"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+var httpClient = &http.Client{
+	Transport: &httpcontrol.Transport{
+		RequestTimeout: time.Second * 30,
+		MaxTries:       5,
+	},
+}
+
+//do calls the Gitea API. The request body is marshalled from in, the response body is unmarshalled in out.
+func (c *GiteaClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	req, err := http.NewRequest(method, c.URL+"/api/v1"+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+c.AccessToken)
+
+	log.Debug("Gitea API>> %s %s", method, req.URL.String())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return sdk.ErrNoReposManagerClientAuth
+	case res.StatusCode >= 400:
+		return ErrorAPI(res.StatusCode, resBody)
+	}
+
+	if out != nil && len(resBody) > 0 {
+		if err := json.Unmarshal(resBody, out); err != nil {
+			return fmt.Errorf("Unable to parse gitea response %s: %s", string(resBody), err)
+		}
+	}
+	return nil
+}
+
+func (c *GiteaClient) get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+const pageSize = 50
+
+//getAll follows the Gitea pagination and calls fn with the body of each page.
+//fn returns the number of items of the page, and how many of them were not already known:
+//Gogs does not paginate and returns the same items on every page
+func (c *GiteaClient) getAll(path string, fn func(body []byte) (int, int, error)) error {
+	for page := 1; ; page++ {
+		var body json.RawMessage
+		if err := c.get(fmt.Sprintf("%s?page=%d&limit=%d", path, page, pageSize), &body); err != nil {
+			return err
+		}
+		n, added, err := fn(body)
+		if err != nil {
+			return err
+		}
+		if n < pageSize || added == 0 {
+			return nil
+		}
+	}
+}
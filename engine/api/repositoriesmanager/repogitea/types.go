@@ -0,0 +1,126 @@
+package repogitea
+
+import "time"
+
+//User represents a Gitea user
+type User struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Username  string `json:"username"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+//Repository represents a Gitea repository
+type Repository struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+//PayloadUser represents the author or the committer of a branch commit
+type PayloadUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+//PayloadCommit represents the last commit of a branch
+type PayloadCommit struct {
+	ID        string      `json:"id"`
+	Message   string      `json:"message"`
+	URL       string      `json:"url"`
+	Author    PayloadUser `json:"author"`
+	Committer PayloadUser `json:"committer"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+//Branch represents a Gitea branch
+type Branch struct {
+	Name   string        `json:"name"`
+	Commit PayloadCommit `json:"commit"`
+}
+
+//CommitUser represents the git author or committer of a commit
+type CommitUser struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+//RepoCommit is the git part of a commit
+type RepoCommit struct {
+	Message   string     `json:"message"`
+	Author    CommitUser `json:"author"`
+	Committer CommitUser `json:"committer"`
+}
+
+//CommitMeta references a commit
+type CommitMeta struct {
+	SHA string `json:"sha"`
+}
+
+//Commit represents a Gitea commit
+type Commit struct {
+	SHA     string       `json:"sha"`
+	HTMLURL string       `json:"html_url"`
+	Commit  RepoCommit   `json:"commit"`
+	Author  *User        `json:"author"`
+	Parents []CommitMeta `json:"parents"`
+}
+
+//HookConfig is the configuration of a hook
+type HookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+//Hook represents a Gitea repository hook
+type Hook struct {
+	ID     int64      `json:"id,omitempty"`
+	Type   string     `json:"type"`
+	Config HookConfig `json:"config"`
+	Events []string   `json:"events"`
+	Active bool       `json:"active"`
+}
+
+//PRBranchInfo represents the head or the base of a pull request
+type PRBranchInfo struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+//PullRequest represents a Gitea pull request
+type PullRequest struct {
+	Number    int64        `json:"number"`
+	HTMLURL   string       `json:"html_url"`
+	State     string       `json:"state"`
+	User      User         `json:"user"`
+	Head      PRBranchInfo `json:"head"`
+	Base      PRBranchInfo `json:"base"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+//Comment is the body sent to Gitea to comment an issue or a pull request
+type Comment struct {
+	Body string `json:"body"`
+}
+
+//Status is the body sent to Gitea to create a commit status
+type Status struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context"`
+}
+
+//Event is returned by GetEvents, it is either a branch updated or a pull request updated after the reference date
+type Event struct {
+	Branch      *Branch
+	PullRequest *PullRequest
+}
@@ -740,5 +740,67 @@ func (g *GithubClient) DeleteEvents(fullname string, iEvents []interface{}) ([]s
 
 //PullRequestEvents checks pull request events from a event list
 func (g *GithubClient) PullRequestEvents(fullname string, iEvents []interface{}) ([]sdk.VCSPullRequestEvent, error) {
-	return nil, nil
+	//Keep the last event of each pull request
+	last := map[int64]Event{}
+	for _, i := range iEvents {
+		e := i.(Event)
+		if e.Type != "PullRequestEvent" || e.Payload.PullRequest == nil {
+			continue
+		}
+		if l, has := last[e.Payload.PullRequest.Number]; !has || l.CreatedAt.Before(e.CreatedAt.Time) {
+			last[e.Payload.PullRequest.Number] = e
+		}
+	}
+
+	res := []sdk.VCSPullRequestEvent{}
+	for _, e := range last {
+		var action string
+		switch e.Payload.Action {
+		case "opened", "reopened":
+			action = "opened"
+		case "synchronize":
+			action = "synchronize"
+		case "closed":
+			action = "closed"
+		default:
+			continue
+		}
+
+		pr := e.Payload.PullRequest
+		head := sdk.VCSBranch{
+			ID:           pr.Head.Ref,
+			DisplayID:    pr.Head.Ref,
+			LatestCommit: pr.Head.SHA,
+		}
+		event := sdk.VCSPullRequestEvent{
+			ID:     pr.Number,
+			Action: action,
+			URL:    pr.HTMLURL,
+			Ref:    fmt.Sprintf("refs/pull/%d/head", pr.Number),
+			User: sdk.VCSAuthor{
+				Name:   pr.User.Login,
+				Avatar: pr.User.AvatarURL,
+			},
+			Head: sdk.VCSPushEvent{
+				Branch: head,
+				Commit: sdk.VCSCommit{Hash: pr.Head.SHA},
+			},
+			Base: sdk.VCSPushEvent{
+				Branch: sdk.VCSBranch{
+					ID:           pr.Base.Ref,
+					DisplayID:    pr.Base.Ref,
+					LatestCommit: pr.Base.SHA,
+				},
+				Commit: sdk.VCSCommit{Hash: pr.Base.SHA},
+			},
+			Branch: head,
+		}
+		if c, err := g.Commit(fullname, pr.Head.SHA); err == nil {
+			event.Head.Commit = c
+		}
+		res = append(res, event)
+	}
+
+	log.Debug("GithubClient.PullRequestEvents> found %d pull request events : %#v", len(res), res)
+	return res, nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 
 	"github.com/mitchellh/mapstructure"
@@ -17,9 +18,10 @@ import (
 //https://developer.github.com/v3/repos/statuses/#create-a-status
 func (g *GithubClient) SetStatus(event sdk.Event) error {
 	log.Debug("github.SetStatus> receive: type:%s all: %+v", event.EventType, event)
-	var eventpb sdk.EventPipelineBuild
 
-	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}), fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}):
+	default:
 		return nil
 	}
 
@@ -28,6 +30,11 @@ func (g *GithubClient) SetStatus(event sdk.Event) error {
 		return nil
 	}
 
+	if event.EventType == fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}) {
+		return g.setWorkflowNodeRunStatus(event)
+	}
+
+	var eventpb sdk.EventPipelineBuild
 	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
 		log.Warning("Error during consumption: %s", err)
 		return err
@@ -94,7 +101,58 @@ func (g *GithubClient) SetStatus(event sdk.Event) error {
 		Context:     context,
 	}
 
-	path := fmt.Sprintf("/repos/%s/statuses/%s", eventpb.RepositoryFullname, eventpb.Hash)
+	return g.createStatus(eventpb.RepositoryFullname, eventpb.Hash, ghStatus)
+}
+
+//setWorkflowNodeRunStatus creates the status of a workflow node run, its context is the workflow and the node
+func (g *GithubClient) setWorkflowNodeRunStatus(event sdk.Event) error {
+	var eventnr sdk.EventWorkflowNodeRun
+	if err := mapstructure.Decode(event.Payload, &eventnr); err != nil {
+		log.Warning("Error during consumption: %s", err)
+		return err
+	}
+
+	if eventnr.Hash == "" {
+		return nil
+	}
+
+	var status string
+	switch eventnr.Status {
+	case sdk.StatusWaiting, sdk.StatusWaitingApproval, sdk.StatusBuilding:
+		status = "pending"
+	case sdk.StatusSuccess:
+		status = "success"
+	case sdk.StatusFail:
+		status = "failure"
+	case sdk.StatusStopped:
+		status = "error"
+	default:
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/project/%s/workflow/%s/run/%d/node/%d",
+		uiURL,
+		eventnr.ProjectKey,
+		eventnr.WorkflowName,
+		eventnr.Number,
+		eventnr.NodeRunID,
+	)
+	if g.DisableStatusURL {
+		url = ""
+	}
+
+	ghStatus := CreateStatus{
+		Description: fmt.Sprintf("Pipeline %s: %s", eventnr.PipelineName, eventnr.Status.String()),
+		TargetURL:   url,
+		State:       status,
+		Context:     fmt.Sprintf("continuous-delivery/CDS/%s/%s", eventnr.WorkflowName, eventnr.NodeName),
+	}
+
+	return g.createStatus(eventnr.RepositoryFullname, eventnr.Hash, ghStatus)
+}
+
+func (g *GithubClient) createStatus(fullname, hash string, ghStatus CreateStatus) error {
+	path := fmt.Sprintf("/repos/%s/statuses/%s", fullname, hash)
 
 	b, err := json.Marshal(ghStatus)
 	if err != nil {
@@ -130,3 +188,25 @@ func (g *GithubClient) SetStatus(event sdk.Event) error {
 
 	return nil
 }
+
+//PullRequestComment adds a comment on a pull request
+//https://developer.github.com/v3/issues/comments/#create-a-comment
+func (g *GithubClient) PullRequestComment(fullname string, id int64, body string) error {
+	b, err := json.Marshal(IssueComment{Body: body})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", fullname, id)
+	res, err := g.post(path, "application/json", bytes.NewBuffer(b), false)
+	if err != nil {
+		return sdk.WrapError(err, "PullRequestComment> Unable to comment pull request %d of %s", id, fullname)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Unable to comment pull request %d of %s on github. Status code : %d - Body: %s", id, fullname, res.StatusCode, body)
+	}
+	return nil
+}
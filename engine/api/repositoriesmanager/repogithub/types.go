@@ -346,6 +346,9 @@ type Event struct {
 			Distinct bool   `json:"distinct"`
 			URL      string `json:"url"`
 		} `json:"commits"`
+		Action      string       `json:"action"`
+		Number      int64        `json:"number"`
+		PullRequest *PullRequest `json:"pull_request"`
 	} `json:"payload"`
 	Public    bool      `json:"public"`
 	CreatedAt Timestamp `json:"created_at"`
@@ -397,6 +400,30 @@ type Status struct {
 	} `json:"creator"`
 }
 
+//PullRequest represents a pull request from API
+type PullRequest struct {
+	Number  int64  `json:"number"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	} `json:"user"`
+	Head PullRequestRef `json:"head"`
+	Base PullRequestRef `json:"base"`
+}
+
+//PullRequestRef represents the head or the base of a pull request
+type PullRequestRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+//IssueComment represents the body sent to comment an issue or a pull request
+type IssueComment struct {
+	Body string `json:"body"`
+}
+
 //RateLimit represents Rate Limit API
 type RateLimit struct {
 	Resources struct {
@@ -0,0 +1,272 @@
+package repogitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+// GitlabClient is a gitlab wrapper for CDS RepositoriesManagerClient interface
+type GitlabClient struct {
+	URL              string
+	OAuthToken       string
+	DisableSetStatus bool
+}
+
+func (c *GitlabClient) host() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return c.URL
+	}
+	return u.Host
+}
+
+func (c *GitlabClient) project(fullname string) (Project, error) {
+	var p Project
+	if _, err := c.get(projectPath(fullname), &p); err != nil {
+		if e, ok := err.(Error); ok && e.StatusCode == http.StatusNotFound {
+			return p, sdk.ErrRepoNotFound
+		}
+		return p, err
+	}
+	return p, nil
+}
+
+func (p Project) toVCSRepo() sdk.VCSRepo {
+	return sdk.VCSRepo{
+		ID:           fmt.Sprintf("%d", p.ID),
+		Name:         p.Name,
+		Slug:         p.Path,
+		Fullname:     p.PathWithNamespace,
+		URL:          p.WebURL,
+		HTTPCloneURL: p.HTTPURLToRepo,
+		SSHCloneURL:  p.SSHURLToRepo,
+	}
+}
+
+//Repos returns the list of projects the user is member of
+func (c *GitlabClient) Repos() ([]sdk.VCSRepo, error) {
+	repos := []sdk.VCSRepo{}
+	err := c.getAll("/projects?membership=true&order_by=path&sort=asc", func(body []byte) error {
+		projects := []Project{}
+		if err := json.Unmarshal(body, &projects); err != nil {
+			return err
+		}
+		for _, p := range projects {
+			repos = append(repos, p.toVCSRepo())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sdk.WrapError(err, "GitlabClient.Repos> Unable to list projects")
+	}
+	return repos, nil
+}
+
+//RepoByFullname returns the repo from its fullname
+func (c *GitlabClient) RepoByFullname(fullname string) (sdk.VCSRepo, error) {
+	p, err := c.project(fullname)
+	if err != nil {
+		return sdk.VCSRepo{}, err
+	}
+	return p.toVCSRepo(), nil
+}
+
+func (b Branch) toVCSBranch(defaultBranch string) sdk.VCSBranch {
+	return sdk.VCSBranch{
+		ID:           b.Name,
+		DisplayID:    b.Name,
+		LatestCommit: b.Commit.ID,
+		Default:      b.Default || b.Name == defaultBranch,
+		Parents:      b.Commit.ParentIDs,
+	}
+}
+
+//Branches retrieves the branches of a project
+func (c *GitlabClient) Branches(fullname string) ([]sdk.VCSBranch, error) {
+	var branchesKey = cache.Key("reposmanager", "gitlab", c.host(), fullname, "branches")
+
+	branches := []sdk.VCSBranch{}
+	if cache.Get(branchesKey, &branches) && len(branches) > 0 {
+		return branches, nil
+	}
+
+	p, err := c.project(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.getAll(projectPath(fullname)+"/repository/branches", func(body []byte) error {
+		glBranches := []Branch{}
+		if err := json.Unmarshal(body, &glBranches); err != nil {
+			return err
+		}
+		for _, b := range glBranches {
+			branches = append(branches, b.toVCSBranch(p.DefaultBranch))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sdk.WrapError(err, "GitlabClient.Branches> Unable to list branches of %s", fullname)
+	}
+
+	cache.SetWithTTL(branchesKey, branches, 60)
+	return branches, nil
+}
+
+//Branch retrieves a branch of a project
+func (c *GitlabClient) Branch(fullname, branchName string) (*sdk.VCSBranch, error) {
+	p, err := c.project(fullname)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Branch
+	if _, err := c.get(projectPath(fullname)+"/repository/branches/"+url.PathEscape(branchName), &b); err != nil {
+		return nil, sdk.WrapError(err, "GitlabClient.Branch> Cannot find branch %s", branchName)
+	}
+
+	branch := b.toVCSBranch(p.DefaultBranch)
+	return &branch, nil
+}
+
+func (c *GitlabClient) toVCSCommit(fullname string, gc Commit) sdk.VCSCommit {
+	return sdk.VCSCommit{
+		Hash:      gc.ID,
+		Timestamp: gc.AuthoredDate.Unix() * 1000,
+		Message:   gc.Message,
+		Author: sdk.VCSAuthor{
+			Name:        gc.AuthorName,
+			DisplayName: gc.AuthorName,
+			Email:       gc.AuthorEmail,
+		},
+		URL: c.URL + "/" + fullname + "/commit/" + gc.ID,
+	}
+}
+
+//Commits returns the commits of a branch between two commits (since is excluded).
+//If since is empty, the last commits of the branch are returned
+func (c *GitlabClient) Commits(repo, branch, since, until string) ([]sdk.VCSCommit, error) {
+	log.Debug("GitlabClient.Commits> Looking for commits on repo %s since = %s until = %s", repo, since, until)
+
+	glCommits := []Commit{}
+	if since != "" {
+		to := until
+		if to == "" {
+			to = branch
+		}
+		var compare Compare
+		path := fmt.Sprintf("%s/repository/compare?from=%s&to=%s", projectPath(repo), url.QueryEscape(since), url.QueryEscape(to))
+		if _, err := c.get(path, &compare); err != nil {
+			return nil, sdk.WrapError(err, "GitlabClient.Commits> Unable to compare %s and %s", since, to)
+		}
+		//Gitlab returns the oldest commit first
+		for i := len(compare.Commits) - 1; i >= 0; i-- {
+			glCommits = append(glCommits, compare.Commits[i])
+		}
+	} else {
+		ref := until
+		if ref == "" {
+			ref = branch
+		}
+		path := fmt.Sprintf("%s/repository/commits?ref_name=%s", projectPath(repo), url.QueryEscape(ref))
+		if _, err := c.get(path, &glCommits); err != nil {
+			return nil, sdk.WrapError(err, "GitlabClient.Commits> Unable to list commits of %s", ref)
+		}
+	}
+
+	commits := make([]sdk.VCSCommit, 0, len(glCommits))
+	for _, gc := range glCommits {
+		commits = append(commits, c.toVCSCommit(repo, gc))
+	}
+	return commits, nil
+}
+
+//Commit retrieves a specific commit according to a hash
+func (c *GitlabClient) Commit(repo, hash string) (sdk.VCSCommit, error) {
+	var commitKey = cache.Key("reposmanager", "gitlab", c.host(), repo, hash)
+	var gc Commit
+
+	if !cache.Get(commitKey, &gc) || gc.ID == "" {
+		if _, err := c.get(projectPath(repo)+"/repository/commits/"+url.PathEscape(hash), &gc); err != nil {
+			return sdk.VCSCommit{}, sdk.WrapError(err, "GitlabClient.Commit> Unable to get commit %s", hash)
+		}
+		cache.SetWithTTL(commitKey, gc, -1)
+	}
+
+	return c.toVCSCommit(repo, gc), nil
+}
+
+func (c *GitlabClient) hooks(repo string) ([]Hook, error) {
+	hooks := []Hook{}
+	err := c.getAll(projectPath(repo)+"/hooks", func(body []byte) error {
+		page := []Hook{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		hooks = append(hooks, page...)
+		return nil
+	})
+	return hooks, err
+}
+
+//CreateHook creates a project hook calling url on push and merge requests events
+func (c *GitlabClient) CreateHook(repo, url string) error {
+	hooks, err := c.hooks(repo)
+	if err != nil {
+		return sdk.WrapError(err, "GitlabClient.CreateHook> Unable to list hooks of %s", repo)
+	}
+	for _, h := range hooks {
+		if h.URL == url {
+			log.Info("GitlabClient.CreateHook> Hook already exists on %s", repo)
+			return nil
+		}
+	}
+
+	h := Hook{
+		URL:                   url,
+		PushEvents:            true,
+		MergeRequestsEvents:   true,
+		EnableSSLVerification: true,
+	}
+	log.Info("GitlabClient.CreateHook> Ask Gitlab to create Hook on %s: %s", repo, url)
+	if _, err := c.do(http.MethodPost, projectPath(repo)+"/hooks", h, &h); err != nil {
+		return sdk.WrapError(err, "GitlabClient.CreateHook> Unable to create hook on %s", repo)
+	}
+	log.Info("GitlabClient.CreateHook> Hook %d created", h.ID)
+	return nil
+}
+
+//DeleteHook deletes the project hooks calling url
+func (c *GitlabClient) DeleteHook(repo, url string) error {
+	hooks, err := c.hooks(repo)
+	if err != nil {
+		return sdk.WrapError(err, "GitlabClient.DeleteHook> Unable to list hooks of %s", repo)
+	}
+	for _, h := range hooks {
+		if h.URL != url {
+			continue
+		}
+		log.Info("GitlabClient.DeleteHook> Ask Gitlab to delete Hook %d on %s", h.ID, repo)
+		if _, err := c.do(http.MethodDelete, fmt.Sprintf("%s/hooks/%d", projectPath(repo), h.ID), nil, nil); err != nil {
+			return sdk.WrapError(err, "GitlabClient.DeleteHook> Unable to delete hook %d on %s", h.ID, repo)
+		}
+	}
+	return nil
+}
+
+//Release is not implemented
+func (c *GitlabClient) Release(repo, tagName, releaseTitle, releaseDescription string) (*sdk.VCSRelease, error) {
+	return nil, fmt.Errorf("Not implemented on gitlab")
+}
+
+//UploadReleaseFile is not implemented
+func (c *GitlabClient) UploadReleaseFile(repo string, release *sdk.VCSRelease, runArtifact sdk.WorkflowNodeRunArtifact, file *bytes.Buffer) error {
+	return fmt.Errorf("Not implemented on gitlab")
+}
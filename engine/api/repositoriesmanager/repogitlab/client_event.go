@@ -0,0 +1,307 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//GetEvents returns the events of a project created after the reference date
+func (c *GitlabClient) GetEvents(fullname string, dateRef time.Time) ([]interface{}, time.Duration, error) {
+	log.Debug("GitlabClient.GetEvents> loading events for %s after %v", fullname, dateRef)
+	interval := 60 * time.Second
+
+	//Gitlab filters events on days, the events are then filtered on the exact date
+	after := dateRef.AddDate(0, 0, -1).Format("2006-01-02")
+	events := []interface{}{}
+	err := c.getAll(projectPath(fullname)+"/events?after="+after, func(body []byte) error {
+		page := []Event{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		for _, e := range page {
+			if e.CreatedAt.After(dateRef) {
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warning("GitlabClient.GetEvents> Error %s", err)
+		return nil, interval, err
+	}
+
+	return events, interval, nil
+}
+
+//branchEvents returns the last branch event of each branch matching the push action
+func branchEvents(iEvents []interface{}, action string) map[string]Event {
+	res := map[string]Event{}
+	for _, i := range iEvents {
+		e, ok := i.(Event)
+		if !ok || e.PushData == nil || e.PushData.RefType != "branch" || e.PushData.Action != action {
+			continue
+		}
+		if l, has := res[e.PushData.Ref]; !has || l.CreatedAt.Before(e.CreatedAt) {
+			res[e.PushData.Ref] = e
+		}
+	}
+	return res
+}
+
+func (c *GitlabClient) pushEvent(fullname string, e Event) (*sdk.VCSPushEvent, error) {
+	branch, err := c.Branch(fullname, e.PushData.Ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := c.Commit(fullname, e.PushData.CommitTo)
+	if err != nil {
+		return nil, err
+	}
+	commit.Author.Name = e.Author.Username
+	commit.Author.Avatar = e.Author.AvatarURL
+	return &sdk.VCSPushEvent{Branch: *branch, Commit: commit}, nil
+}
+
+//PushEvents returns push events as commits
+func (c *GitlabClient) PushEvents(fullname string, iEvents []interface{}) ([]sdk.VCSPushEvent, error) {
+	res := []sdk.VCSPushEvent{}
+	for b, e := range branchEvents(iEvents, "pushed") {
+		event, err := c.pushEvent(fullname, e)
+		if err != nil {
+			log.Warning("GitlabClient.PushEvents> Unable to get push on branch %s in %s : %s", b, fullname, err)
+			continue
+		}
+		res = append(res, *event)
+	}
+	return res, nil
+}
+
+//CreateEvents checks create events from a event list
+func (c *GitlabClient) CreateEvents(fullname string, iEvents []interface{}) ([]sdk.VCSCreateEvent, error) {
+	res := []sdk.VCSCreateEvent{}
+	for b, e := range branchEvents(iEvents, "created") {
+		event, err := c.pushEvent(fullname, e)
+		if err != nil {
+			log.Warning("GitlabClient.CreateEvents> Unable to get branch %s in %s : %s", b, fullname, err)
+			continue
+		}
+		res = append(res, sdk.VCSCreateEvent(*event))
+	}
+	return res, nil
+}
+
+//DeleteEvents checks delete events from a event list
+func (c *GitlabClient) DeleteEvents(fullname string, iEvents []interface{}) ([]sdk.VCSDeleteEvent, error) {
+	res := []sdk.VCSDeleteEvent{}
+	for b := range branchEvents(iEvents, "removed") {
+		res = append(res, sdk.VCSDeleteEvent{
+			Branch: sdk.VCSBranch{
+				ID:        b,
+				DisplayID: b,
+			},
+		})
+	}
+	log.Debug("GitlabClient.DeleteEvents> found %d delete events : %#v", len(res), res)
+	return res, nil
+}
+
+//PullRequestEvents checks merge requests events from a event list.
+//A push on the source branch of an opened merge request synchronizes it.
+func (c *GitlabClient) PullRequestEvents(fullname string, iEvents []interface{}) ([]sdk.VCSPullRequestEvent, error) {
+	//Keep the last event of each merge request
+	last := map[int64]Event{}
+	for _, i := range iEvents {
+		e, ok := i.(Event)
+		if !ok || e.TargetType != "MergeRequest" {
+			continue
+		}
+		if l, has := last[e.TargetIID]; !has || l.CreatedAt.Before(e.CreatedAt) {
+			last[e.TargetIID] = e
+		}
+	}
+
+	res := []sdk.VCSPullRequestEvent{}
+	for iid, e := range last {
+		var action string
+		switch e.ActionName {
+		case "opened", "reopened":
+			action = "opened"
+		case "closed", "merged", "accepted":
+			action = "closed"
+		default:
+			continue
+		}
+
+		var mr MergeRequest
+		if _, err := c.get(fmt.Sprintf("%s/merge_requests/%d", projectPath(fullname), iid), &mr); err != nil {
+			log.Warning("GitlabClient.PullRequestEvents> Unable to get merge request %d in %s : %s", iid, fullname, err)
+			continue
+		}
+		res = append(res, c.pullRequestEvent(fullname, mr, action))
+	}
+
+	pushed := branchEvents(iEvents, "pushed")
+	if len(pushed) == 0 {
+		return res, nil
+	}
+
+	err := c.getAll(projectPath(fullname)+"/merge_requests?state=opened", func(body []byte) error {
+		page := []MergeRequest{}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		for _, mr := range page {
+			if _, has := pushed[mr.SourceBranch]; !has {
+				continue
+			}
+			if _, has := last[mr.IID]; has {
+				continue
+			}
+			res = append(res, c.pullRequestEvent(fullname, mr, "synchronize"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sdk.WrapError(err, "GitlabClient.PullRequestEvents> Unable to list opened merge requests of %s", fullname)
+	}
+	return res, nil
+}
+
+func (c *GitlabClient) pullRequestEvent(fullname string, mr MergeRequest, action string) sdk.VCSPullRequestEvent {
+	event := sdk.VCSPullRequestEvent{
+		ID:     mr.IID,
+		Action: action,
+		URL:    mr.WebURL,
+		Ref:    fmt.Sprintf("refs/merge-requests/%d/head", mr.IID),
+		User: sdk.VCSAuthor{
+			Name:        mr.Author.Username,
+			DisplayName: mr.Author.Name,
+			Avatar:      mr.Author.AvatarURL,
+		},
+		Head: sdk.VCSPushEvent{
+			Branch: sdk.VCSBranch{
+				ID:           mr.SourceBranch,
+				DisplayID:    mr.SourceBranch,
+				LatestCommit: mr.SHA,
+			},
+			Commit: sdk.VCSCommit{Hash: mr.SHA},
+		},
+		Base: sdk.VCSPushEvent{
+			Branch: sdk.VCSBranch{
+				ID:        mr.TargetBranch,
+				DisplayID: mr.TargetBranch,
+			},
+		},
+	}
+	if commit, err := c.Commit(fullname, mr.SHA); err == nil {
+		event.Head.Commit = commit
+	}
+	if b, err := c.Branch(fullname, mr.TargetBranch); err == nil {
+		event.Base.Branch = *b
+		event.Base.Commit.Hash = b.LatestCommit
+	}
+	event.Branch = event.Head.Branch
+	return event
+}
+
+//SetStatus creates a commit status on Gitlab for a pipeline build or a workflow node run
+//https://docs.gitlab.com/ce/api/commits.html#post-the-build-status-to-a-commit
+func (c *GitlabClient) SetStatus(event sdk.Event) error {
+	log.Debug("gitlab.SetStatus> receive: type:%s all: %+v", event.EventType, event)
+
+	var fullname, hash string
+	var status CommitStatus
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}):
+		var eventpb sdk.EventPipelineBuild
+		if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
+			log.Warning("Error during consumption: %s", err)
+			return err
+		}
+		fullname, hash = eventpb.RepositoryFullname, eventpb.Hash
+		status = CommitStatus{
+			State: getGitlabStateFromStatus(eventpb.Status),
+			Name:  fmt.Sprintf("continuous-delivery/CDS/%s", eventpb.PipelineName),
+			TargetURL: fmt.Sprintf("%s/project/%s/application/%s/pipeline/%s/build/%d?envName=%s",
+				uiURL,
+				eventpb.ProjectKey,
+				eventpb.ApplicationName,
+				eventpb.PipelineName,
+				eventpb.BuildNumber,
+				url.QueryEscape(eventpb.EnvironmentName),
+			),
+			Description: fmt.Sprintf("Pipeline %s: %s", eventpb.PipelineName, eventpb.Status.String()),
+		}
+	case fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}):
+		var eventnr sdk.EventWorkflowNodeRun
+		if err := mapstructure.Decode(event.Payload, &eventnr); err != nil {
+			log.Warning("Error during consumption: %s", err)
+			return err
+		}
+		fullname, hash = eventnr.RepositoryFullname, eventnr.Hash
+		status = CommitStatus{
+			State: getGitlabStateFromStatus(eventnr.Status),
+			Name:  fmt.Sprintf("continuous-delivery/CDS/%s/%s", eventnr.WorkflowName, eventnr.NodeName),
+			TargetURL: fmt.Sprintf("%s/project/%s/workflow/%s/run/%d/node/%d",
+				uiURL,
+				eventnr.ProjectKey,
+				eventnr.WorkflowName,
+				eventnr.Number,
+				eventnr.NodeRunID,
+			),
+			Description: fmt.Sprintf("Pipeline %s: %s", eventnr.PipelineName, eventnr.Status.String()),
+		}
+	default:
+		return nil
+	}
+
+	if c.DisableSetStatus {
+		log.Warning("⚠ Gitlab statuses are disabled")
+		return nil
+	}
+
+	if status.State == "" || hash == "" {
+		return nil
+	}
+
+	path := fmt.Sprintf("%s/statuses/%s", projectPath(fullname), url.PathEscape(hash))
+	log.Debug("SetStatus> hash:%s status:%+v", hash, status)
+	if _, err := c.do(http.MethodPost, path, status, nil); err != nil {
+		return sdk.WrapError(err, "SetStatus> err on gitlab")
+	}
+	return nil
+}
+
+//PullRequestComment adds a note on a merge request
+func (c *GitlabClient) PullRequestComment(fullname string, id int64, body string) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(fullname), id)
+	if _, err := c.do(http.MethodPost, path, Note{Body: body}, nil); err != nil {
+		return sdk.WrapError(err, "PullRequestComment> Unable to comment merge request %d of %s", id, fullname)
+	}
+	return nil
+}
+
+func getGitlabStateFromStatus(status sdk.Status) string {
+	switch status {
+	case sdk.StatusSuccess:
+		return "success"
+	case sdk.StatusFail:
+		return "failed"
+	case sdk.StatusBuilding:
+		return "running"
+	case sdk.StatusWaiting, sdk.StatusWaitingApproval:
+		return "pending"
+	case sdk.StatusStopped:
+		return "canceled"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,338 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// fakeGitlab is a local stand-in for a Gitlab instance
+type fakeGitlab struct {
+	*httptest.Server
+	hooks    []Hook
+	statuses map[string]CommitStatus
+	events   []Event
+	notes    []Note
+	queries  map[string]url.Values
+}
+
+func newFakeGitlab(t *testing.T) *fakeGitlab {
+	f := &fakeGitlab{statuses: map[string]CommitStatus{}, queries: map[string]url.Values{}}
+	now := time.Now()
+	f.events = []Event{
+		{ActionName: "pushed to", CreatedAt: now.Add(-2 * time.Hour), PushData: &PushData{Action: "pushed", RefType: "branch", Ref: "master", CommitTo: "old"}},
+		{ActionName: "pushed to", CreatedAt: now.Add(-time.Minute), PushData: &PushData{Action: "pushed", RefType: "branch", Ref: "master", CommitTo: "c2"}},
+		{ActionName: "pushed to", CreatedAt: now.Add(-2 * time.Minute), PushData: &PushData{Action: "pushed", RefType: "branch", Ref: "master", CommitTo: "c1"}},
+		{ActionName: "pushed new", CreatedAt: now.Add(-time.Minute), Author: User{Username: "john"}, PushData: &PushData{Action: "created", RefType: "branch", Ref: "feat", CommitTo: "c2"}},
+		{ActionName: "deleted", CreatedAt: now.Add(-time.Minute), PushData: &PushData{Action: "removed", RefType: "branch", Ref: "old-feat", CommitFrom: "c0"}},
+		{ActionName: "pushed new", CreatedAt: now.Add(-time.Minute), PushData: &PushData{Action: "created", RefType: "tag", Ref: "v1.0"}},
+		{ActionName: "opened", CreatedAt: now.Add(-time.Minute), TargetType: "MergeRequest", TargetIID: 3},
+	}
+
+	commits := map[string]Commit{
+		"c1": {ID: "c1", Message: "first", AuthorName: "John Doe", AuthorEmail: "john@example.com", AuthoredDate: now.Add(-time.Hour)},
+		"c2": {ID: "c2", Message: "second", AuthorName: "John Doe", AuthorEmail: "john@example.com", AuthoredDate: now},
+	}
+	project := Project{ID: 42, Name: "My Repo", Path: "my-repo", PathWithNamespace: "group/my-repo", DefaultBranch: "master"}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			if r.FormValue("client_id") != "cds" || r.FormValue("client_secret") != "s3cr3t" || r.FormValue("code") != "the-code" || r.FormValue("grant_type") != "authorization_code" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "the-token", "token_type": "bearer"})
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer the-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		route := r.Method + " " + r.URL.EscapedPath()
+		f.queries[route] = r.URL.Query()
+		var out interface{}
+		switch route {
+		case "GET /api/v4/projects":
+			if r.FormValue("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				out = []Project{project}
+			} else {
+				out = []Project{{ID: 43, Path: "other", PathWithNamespace: "group/other"}}
+			}
+		case "GET /api/v4/projects/group%2Fmy-repo":
+			out = project
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/branches":
+			out = []Branch{{Name: "master", Commit: commits["c2"]}, {Name: "feat", Commit: commits["c2"]}}
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/branches/master":
+			out = Branch{Name: "master", Commit: commits["c2"]}
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/branches/feat":
+			out = Branch{Name: "feat", Commit: commits["c2"]}
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/commits":
+			out = []Commit{commits["c2"], commits["c1"]}
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/compare":
+			out = Compare{Commits: []Commit{commits["c1"], commits["c2"]}}
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/commits/c1":
+			out = commits["c1"]
+		case "GET /api/v4/projects/group%2Fmy-repo/repository/commits/c2":
+			out = commits["c2"]
+		case "GET /api/v4/projects/group%2Fmy-repo/hooks":
+			out = f.hooks
+		case "POST /api/v4/projects/group%2Fmy-repo/hooks":
+			var h Hook
+			json.NewDecoder(r.Body).Decode(&h)
+			h.ID = int64(len(f.hooks) + 1)
+			f.hooks = append(f.hooks, h)
+			w.WriteHeader(http.StatusCreated)
+			out = h
+		case "DELETE /api/v4/projects/group%2Fmy-repo/hooks/1":
+			f.hooks = f.hooks[1:]
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "GET /api/v4/projects/group%2Fmy-repo/events":
+			out = f.events
+		case "GET /api/v4/projects/group%2Fmy-repo/merge_requests/3":
+			out = MergeRequest{IID: 3, State: "opened", SourceBranch: "feat", TargetBranch: "master", SHA: "c2", WebURL: "http://gitlab/mr/3", Author: User{Username: "john"}}
+		case "GET /api/v4/projects/group%2Fmy-repo/merge_requests":
+			out = []MergeRequest{
+				{IID: 3, State: "opened", SourceBranch: "feat", TargetBranch: "master", SHA: "c2"},
+				{IID: 4, State: "opened", SourceBranch: "master", TargetBranch: "feat", SHA: "c2", WebURL: "http://gitlab/mr/4"},
+			}
+		case "POST /api/v4/projects/group%2Fmy-repo/merge_requests/3/notes":
+			var n Note
+			json.NewDecoder(r.Body).Decode(&n)
+			f.notes = append(f.notes, n)
+			w.WriteHeader(http.StatusCreated)
+			out = n
+		case "POST /api/v4/projects/group%2Fmy-repo/statuses/c2":
+			var s CommitStatus
+			json.NewDecoder(r.Body).Decode(&s)
+			f.statuses["c2"] = s
+			w.WriteHeader(http.StatusCreated)
+			out = s
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			out = map[string]string{"message": "404 Not Found"}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	return f
+}
+
+func newTestClient(t *testing.T, f *fakeGitlab) *GitlabClient {
+	consumer := New(f.URL+"/", "cds", "s3cr3t", "http://cds.local/repositories_manager/oauth2/callback")
+
+	state, u, err := consumer.AuthorizeRedirect()
+	assert.NoError(t, err)
+	authorizeURL, err := url.Parse(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "/oauth/authorize", authorizeURL.Path)
+	assert.Equal(t, "cds", authorizeURL.Query().Get("client_id"))
+	assert.Equal(t, "code", authorizeURL.Query().Get("response_type"))
+	assert.Equal(t, state, authorizeURL.Query().Get("state"))
+
+	_, _, err = consumer.AuthorizeToken(state, "wrong-code")
+	assert.Error(t, err)
+
+	token, secret, err := consumer.AuthorizeToken(state, "the-code")
+	assert.NoError(t, err)
+	assert.Equal(t, "the-token", token)
+	assert.Equal(t, state, secret)
+
+	client, err := consumer.GetAuthorized(token, secret)
+	assert.NoError(t, err)
+	return client.(*GitlabClient)
+}
+
+func TestGitlabClientRepositories(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	repos, err := c.Repos()
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, "group/my-repo", repos[0].Fullname)
+	assert.Equal(t, "true", f.queries["GET /api/v4/projects"].Get("membership"))
+
+	_, err = c.RepoByFullname("group/unknown")
+	assert.Equal(t, sdk.ErrRepoNotFound, err)
+
+	branches, err := c.Branches("group/my-repo")
+	assert.NoError(t, err)
+	assert.Len(t, branches, 2)
+	assert.True(t, branches[0].Default)
+	assert.False(t, branches[1].Default)
+	assert.Equal(t, "c2", branches[1].LatestCommit)
+
+	commits, err := c.Commits("group/my-repo", "master", "c0", "c2")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "c2", commits[0].Hash)
+	assert.Equal(t, "c0", f.queries["GET /api/v4/projects/group%2Fmy-repo/repository/compare"].Get("from"))
+
+	commits, err = c.Commits("group/my-repo", "master", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "master", f.queries["GET /api/v4/projects/group%2Fmy-repo/repository/commits"].Get("ref_name"))
+
+	commit, err := c.Commit("group/my-repo", "c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commit.Message)
+	assert.Equal(t, "john@example.com", commit.Author.Email)
+	assert.Equal(t, f.URL+"/group/my-repo/commit/c1", commit.URL)
+}
+
+func TestGitlabClientHooks(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	assert.NoError(t, c.CreateHook("group/my-repo", "http://cds.local/hook?uid=1"))
+	assert.NoError(t, c.CreateHook("group/my-repo", "http://cds.local/hook?uid=1"))
+	assert.Len(t, f.hooks, 1)
+	assert.True(t, f.hooks[0].PushEvents)
+	assert.True(t, f.hooks[0].MergeRequestsEvents)
+
+	assert.NoError(t, c.DeleteHook("group/my-repo", "http://cds.local/hook?uid=1"))
+	assert.Len(t, f.hooks, 0)
+
+	h, err := ParsePushHook([]byte(`{"object_kind":"push","before":"c1","after":"c2","ref":"refs/heads/feat/a","user_username":"john"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "feat/a", h.Branch())
+	assert.Equal(t, "c2", h.Hash())
+	assert.Equal(t, "john", h.Author())
+	assert.Equal(t, "UPDATE", h.ChangeType())
+
+	h, err = ParsePushHook([]byte(`{"object_kind":"push","before":"c1","after":"0000000000000000000000000000000000000000","ref":"refs/heads/feat/a"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE", h.ChangeType())
+
+	_, err = ParsePushHook([]byte(`{"object_kind":"merge_request"}`))
+	assert.Error(t, err)
+}
+
+func TestGitlabClientEvents(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	events, _, err := c.GetEvents("group/my-repo", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 6)
+
+	pushEvents, err := c.PushEvents("group/my-repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, pushEvents, 1)
+	assert.Equal(t, "master", pushEvents[0].Branch.DisplayID)
+	assert.Equal(t, "c2", pushEvents[0].Commit.Hash)
+
+	createEvents, err := c.CreateEvents("group/my-repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, createEvents, 1)
+	assert.Equal(t, "feat", createEvents[0].Branch.DisplayID)
+	assert.Equal(t, "john", createEvents[0].Commit.Author.Name)
+
+	deleteEvents, err := c.DeleteEvents("group/my-repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, deleteEvents, 1)
+	assert.Equal(t, "old-feat", deleteEvents[0].Branch.DisplayID)
+
+	prEvents, err := c.PullRequestEvents("group/my-repo", events)
+	assert.NoError(t, err)
+	assert.Len(t, prEvents, 2)
+	assert.Equal(t, "opened", prEvents[0].Action)
+	assert.Equal(t, int64(3), prEvents[0].ID)
+	assert.Equal(t, "refs/merge-requests/3/head", prEvents[0].Ref)
+	assert.Equal(t, "feat", prEvents[0].Head.Branch.DisplayID)
+	assert.Equal(t, "master", prEvents[0].Base.Branch.DisplayID)
+	assert.Equal(t, "c2", prEvents[0].Head.Commit.Hash)
+	assert.Equal(t, "synchronize", prEvents[1].Action)
+	assert.Equal(t, int64(4), prEvents[1].ID)
+	assert.Equal(t, "master", prEvents[1].Head.Branch.DisplayID)
+	assert.Equal(t, "opened", f.queries["GET /api/v4/projects/group%2Fmy-repo/merge_requests"].Get("state"))
+}
+
+func TestGitlabClientSetStatus(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+	Init("http://api.cds.local", "http://ui.cds.local")
+
+	e := sdk.Event{
+		EventType: "sdk.EventPipelineBuild",
+		Payload: map[string]interface{}{
+			"ProjectKey":         "PRJ",
+			"ApplicationName":    "app",
+			"PipelineName":       "build",
+			"BuildNumber":        12,
+			"Status":             sdk.StatusBuilding,
+			"Hash":               "c2",
+			"RepositoryFullname": "group/my-repo",
+		},
+	}
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "running", f.statuses["c2"].State)
+	assert.Equal(t, "continuous-delivery/CDS/build", f.statuses["c2"].Name)
+	assert.Equal(t, "http://ui.cds.local/project/PRJ/application/app/pipeline/build/build/12?envName=", f.statuses["c2"].TargetURL)
+
+	e.Payload["Status"] = sdk.StatusFail
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "failed", f.statuses["c2"].State)
+
+	c.DisableSetStatus = true
+	e.Payload["Status"] = sdk.StatusSuccess
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "failed", f.statuses["c2"].State)
+}
+
+func TestGitlabClientSetWorkflowNodeRunStatus(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+	Init("http://api.cds.local", "http://ui.cds.local")
+
+	e := sdk.Event{
+		EventType: "sdk.EventWorkflowNodeRun",
+		Payload: map[string]interface{}{
+			"ProjectKey":         "PRJ",
+			"WorkflowName":       "wf",
+			"Number":             5,
+			"NodeRunID":          51,
+			"NodeName":           "build",
+			"PipelineName":       "build",
+			"Status":             sdk.StatusWaitingApproval,
+			"Hash":               "c2",
+			"RepositoryFullname": "group/my-repo",
+		},
+	}
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "pending", f.statuses["c2"].State)
+	assert.Equal(t, "continuous-delivery/CDS/wf/build", f.statuses["c2"].Name)
+	assert.Equal(t, "http://ui.cds.local/project/PRJ/workflow/wf/run/5/node/51", f.statuses["c2"].TargetURL)
+
+	e.Payload["Status"] = sdk.StatusSuccess
+	assert.NoError(t, c.SetStatus(e))
+	assert.Equal(t, "success", f.statuses["c2"].State)
+}
+
+func TestGitlabClientPullRequestComment(t *testing.T) {
+	f := newFakeGitlab(t)
+	defer f.Close()
+	c := newTestClient(t, f)
+
+	assert.NoError(t, c.PullRequestComment("group/my-repo", 3, "**CDS** build succeeded"))
+	assert.Len(t, f.notes, 1)
+	assert.Equal(t, "**CDS** build succeeded", f.notes[0].Body)
+
+	assert.Error(t, c.PullRequestComment("group/my-repo", 404, "lost"))
+}
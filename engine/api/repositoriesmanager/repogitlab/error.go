@@ -0,0 +1,29 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//Error wraps an error returned by the Gitlab API
+type Error struct {
+	StatusCode int         `json:"-"`
+	Message    interface{} `json:"message"`
+	Err        string      `json:"error"`
+}
+
+func (e Error) Error() string {
+	if e.Message != nil {
+		return fmt.Sprintf("Gitlab error (%d): %v", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("Gitlab error (%d): %s", e.StatusCode, e.Err)
+}
+
+//ErrorAPI returns an Error from a Gitlab API response body
+func ErrorAPI(status int, body []byte) error {
+	e := Error{StatusCode: status}
+	if err := json.Unmarshal(body, &e); err != nil || (e.Message == nil && e.Err == "") {
+		e.Err = string(body)
+	}
+	return e
+}
@@ -0,0 +1,70 @@
+package repogitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//HookEventHeader is the header set by Gitlab on the webhooks calls
+const HookEventHeader = "X-Gitlab-Event"
+
+//PushHookEvent is the value of HookEventHeader for push webhooks
+const PushHookEvent = "Push Hook"
+
+const nullHash = "0000000000000000000000000000000000000000"
+
+//PushHook is the payload of a push webhook
+type PushHook struct {
+	ObjectKind   string `json:"object_kind"`
+	Before       string `json:"before"`
+	After        string `json:"after"`
+	Ref          string `json:"ref"`
+	UserName     string `json:"user_name"`
+	UserUsername string `json:"user_username"`
+	Project      struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+//ParsePushHook parses the payload of a push webhook
+func ParsePushHook(data []byte) (*PushHook, error) {
+	h := &PushHook{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.ObjectKind != "push" {
+		return nil, fmt.Errorf("Unsupported gitlab hook %s", h.ObjectKind)
+	}
+	return h, nil
+}
+
+//Branch returns the name of the pushed branch
+func (h *PushHook) Branch() string {
+	return strings.TrimPrefix(h.Ref, "refs/heads/")
+}
+
+//Author returns the username of the pusher
+func (h *PushHook) Author() string {
+	if h.UserUsername != "" {
+		return h.UserUsername
+	}
+	return h.UserName
+}
+
+//Hash returns the commit pushed
+func (h *PushHook) Hash() string {
+	return h.After
+}
+
+//ChangeType returns the type of the ref change as sent by stash: ADD, UPDATE or DELETE
+func (h *PushHook) ChangeType() string {
+	switch {
+	case h.Before == nullHash:
+		return "ADD"
+	case h.After == nullHash:
+		return "DELETE"
+	default:
+		return "UPDATE"
+	}
+}
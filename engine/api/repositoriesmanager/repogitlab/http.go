@@ -0,0 +1,133 @@
+package repogitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/facebookgo/httpcontrol"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+var httpClient = &http.Client{
+	Transport: &httpcontrol.Transport{
+		RequestTimeout: time.Second * 30,
+		MaxTries:       5,
+	},
+}
+
+func postForm(path string, data url.Values) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(data.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	return res.StatusCode, body, err
+}
+
+//projectPath returns the API path of a project from its fullname
+func projectPath(fullname string) string {
+	return "/projects/" + url.PathEscape(fullname)
+}
+
+func (c *GitlabClient) apiURL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.URL + "/api/v4" + path
+}
+
+//do calls the Gitlab API. The request body is marshalled from in, the response body is unmarshalled in out.
+func (c *GitlabClient) do(method, path string, in, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	req, err := http.NewRequest(method, c.apiURL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.OAuthToken)
+
+	log.Debug("Gitlab API>> %s %s", method, req.URL.String())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return nil, sdk.ErrNoReposManagerClientAuth
+	case res.StatusCode >= 400:
+		return nil, ErrorAPI(res.StatusCode, resBody)
+	}
+
+	if out != nil && len(resBody) > 0 {
+		if err := json.Unmarshal(resBody, out); err != nil {
+			return nil, fmt.Errorf("Unable to parse gitlab response %s: %s", string(resBody), err)
+		}
+	}
+	return res.Header, nil
+}
+
+func (c *GitlabClient) get(path string, out interface{}) (http.Header, error) {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+//getAll follows the Gitlab pagination and calls fn with the body of each page
+func (c *GitlabClient) getAll(path string, fn func(body []byte) error) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	page := 1
+	for {
+		var body json.RawMessage
+		headers, err := c.get(fmt.Sprintf("%s%sper_page=100&page=%d", path, sep, page), &body)
+		if err != nil {
+			return err
+		}
+		if err := fn(body); err != nil {
+			return err
+		}
+		next, err := strconv.Atoi(headers.Get("X-Next-Page"))
+		if err != nil || next <= page {
+			return nil
+		}
+		page = next
+	}
+}
@@ -0,0 +1,105 @@
+package repogitlab
+
+import "time"
+
+//Project represents a Gitlab project
+type Project struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+//Branch represents a Gitlab branch
+type Branch struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+	Commit  Commit `json:"commit"`
+}
+
+//Commit represents a Gitlab commit
+type Commit struct {
+	ID           string    `json:"id"`
+	ShortID      string    `json:"short_id"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthorEmail  string    `json:"author_email"`
+	AuthoredDate time.Time `json:"authored_date"`
+	ParentIDs    []string  `json:"parent_ids"`
+}
+
+//Compare represents the result of a comparison between two refs
+type Compare struct {
+	Commits []Commit `json:"commits"`
+}
+
+//User represents a Gitlab user
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+//Hook represents a Gitlab project hook
+type Hook struct {
+	ID                    int64  `json:"id,omitempty"`
+	URL                   string `json:"url"`
+	PushEvents            bool   `json:"push_events"`
+	MergeRequestsEvents   bool   `json:"merge_requests_events"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+}
+
+//Event represents an event of a Gitlab project
+type Event struct {
+	ActionName  string    `json:"action_name"`
+	TargetID    int64     `json:"target_id"`
+	TargetIID   int64     `json:"target_iid"`
+	TargetType  string    `json:"target_type"`
+	TargetTitle string    `json:"target_title"`
+	CreatedAt   time.Time `json:"created_at"`
+	Author      User      `json:"author"`
+	PushData    *PushData `json:"push_data"`
+}
+
+//PushData is the payload of push events
+type PushData struct {
+	CommitCount int    `json:"commit_count"`
+	Action      string `json:"action"`
+	RefType     string `json:"ref_type"`
+	CommitFrom  string `json:"commit_from"`
+	CommitTo    string `json:"commit_to"`
+	Ref         string `json:"ref"`
+	CommitTitle string `json:"commit_title"`
+}
+
+//MergeRequest represents a Gitlab merge request
+type MergeRequest struct {
+	ID           int64  `json:"id"`
+	IID          int64  `json:"iid"`
+	State        string `json:"state"`
+	Title        string `json:"title"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+	WebURL       string `json:"web_url"`
+	Author       User   `json:"author"`
+}
+
+//Note is the body sent to Gitlab to comment a merge request
+type Note struct {
+	Body string `json:"body"`
+}
+
+//CommitStatus is the body sent to Gitlab to create a commit status
+type CommitStatus struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
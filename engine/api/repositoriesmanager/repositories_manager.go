@@ -11,7 +11,10 @@ import (
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/engine/api/database"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogit"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitea"
 	"github.com/ovh/cds/engine/api/repositoriesmanager/repogithub"
+	"github.com/ovh/cds/engine/api/repositoriesmanager/repogitlab"
 	"github.com/ovh/cds/engine/api/repositoriesmanager/repostash"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
@@ -30,9 +33,14 @@ type InitializeOpts struct {
 	DisableStashSetStatus  bool
 	DisableGithubSetStatus bool
 	DisableGithubStatusURL bool
+	DisableGitlabSetStatus bool
+	DisableGiteaSetStatus  bool
 	GithubSecret           string
+	GitlabSecret           string
 	StashPrivateKey        string
 	StashConsumerKey       string
+	GitPrivateKey          string
+	GitMirrorsDirectory    string
 }
 
 //Initialize initialize private keys
@@ -41,6 +49,9 @@ func Initialize(o InitializeOpts) error {
 	options = o
 	repogithub.Init(o.APIBaseURL, o.UIBaseURL)
 	repostash.Init(o.APIBaseURL, o.UIBaseURL)
+	repogitlab.Init(o.APIBaseURL, o.UIBaseURL)
+	repogitea.Init(o.APIBaseURL, o.UIBaseURL)
+	repogit.Init(o.APIBaseURL, o.UIBaseURL, o.GitMirrorsDirectory)
 
 	_db := database.DB()
 	if _db == nil {
@@ -69,6 +80,21 @@ func Initialize(o InitializeOpts) error {
 					// GithubSecret is already the real secret, not a path to a file
 					found = true
 				}
+			case sdk.Gitlab:
+				if o.GitlabSecret != "" {
+					log.Info("RepositoriesManager> Found a client-secret for %s", rm.Name)
+					found = true
+				}
+			case sdk.Gitea:
+				// Gitea projects are authorized with personal access tokens
+				found = true
+			case sdk.Git:
+				if o.GitPrivateKey != "" {
+					log.Info("RepositoriesManager> Found a key for %s", rm.Name)
+					rmSecrets["privatekey"] = o.GitPrivateKey
+				}
+				// A key is not needed for public repositories over https
+				found = true
 			}
 
 			if found {
@@ -200,6 +226,119 @@ func New(t sdk.RepositoriesManagerType, id int64, name, URL string, args map[str
 			PollingSupported: *withPolling && github.PollingSupported(),
 		}
 
+		return &rm, nil
+	case sdk.Gitlab:
+		var gitlab *repogitlab.GitlabConsumer
+		withHook, withPolling := true, true
+
+		//Check if it isn't coming from the DB
+		if id == 0 || consumerData == "" {
+			//Check args
+			if args["client-id"] == "" || options.GitlabSecret == "" {
+				return nil, fmt.Errorf("client-id args and client-secret (in cds configuration) are mandatory to connect to gitlab : %v", args)
+			}
+			gitlab = repogitlab.New(URL, args["client-id"], options.GitlabSecret, options.APIBaseURL+"/repositories_manager/oauth2/callback")
+			if b, err := strconv.ParseBool(args["with-hooks"]); err == nil {
+				withHook = b
+			}
+			if b, err := strconv.ParseBool(args["with-polling"]); err == nil {
+				withPolling = b
+			}
+		} else {
+			//It's coming from the database, we just have to unmarshal data from the DB to get consumerData
+			var data repogitlab.GitlabConsumer
+			if err := json.Unmarshal([]byte(consumerData), &data); err != nil {
+				log.Warning("New> Error %s", err)
+				return nil, err
+			}
+			gitlab = repogitlab.New(URL, data.ClientID, options.GitlabSecret, options.APIBaseURL+"/repositories_manager/oauth2/callback")
+			withHook = data.WithHooks
+			withPolling = data.WithPolling
+		}
+
+		gitlab.DisableSetStatus = options.DisableGitlabSetStatus
+		gitlab.WithHooks = withHook
+		gitlab.WithPolling = withPolling
+
+		rm := sdk.RepositoriesManager{
+			ID:               id,
+			Consumer:         gitlab,
+			Name:             name,
+			URL:              gitlab.URL,
+			Type:             sdk.Gitlab,
+			HooksSupported:   withHook && gitlab.HooksSupported(),
+			PollingSupported: withPolling && gitlab.PollingSupported(),
+		}
+		return &rm, nil
+	case sdk.Gitea:
+		var gitea *repogitea.GiteaConsumer
+		withHook, withPolling := true, true
+
+		//Check if it isn't coming from the DB
+		if id == 0 || consumerData == "" {
+			gitea = repogitea.New(URL)
+			if b, err := strconv.ParseBool(args["with-hooks"]); err == nil {
+				withHook = b
+			}
+			if b, err := strconv.ParseBool(args["with-polling"]); err == nil {
+				withPolling = b
+			}
+		} else {
+			//It's coming from the database, we just have to unmarshal data from the DB to get consumerData
+			var data repogitea.GiteaConsumer
+			if err := json.Unmarshal([]byte(consumerData), &data); err != nil {
+				log.Warning("New> Error %s", err)
+				return nil, err
+			}
+			gitea = repogitea.New(URL)
+			withHook = data.WithHooks
+			withPolling = data.WithPolling
+		}
+
+		gitea.DisableSetStatus = options.DisableGiteaSetStatus
+		gitea.WithHooks = withHook
+		gitea.WithPolling = withPolling
+
+		rm := sdk.RepositoriesManager{
+			ID:               id,
+			Consumer:         gitea,
+			Name:             name,
+			URL:              gitea.URL,
+			Type:             sdk.Gitea,
+			HooksSupported:   withHook && gitea.HooksSupported(),
+			PollingSupported: withPolling && gitea.PollingSupported(),
+		}
+		return &rm, nil
+	case sdk.Git:
+		git := repogit.New(URL)
+		git.WithPolling = true
+
+		//Check if it isn't coming from the DB
+		if id == 0 || consumerData == "" {
+			if b, err := strconv.ParseBool(args["with-polling"]); err == nil {
+				git.WithPolling = b
+			}
+			if b, err := strconv.ParseBool(args["no-strict-host-key-checking"]); err == nil {
+				git.NoStrictHostKeyChecking = b
+			}
+		} else {
+			//It's coming from the database, we just have to unmarshal data from the DB to get consumerData
+			if err := json.Unmarshal([]byte(consumerData), git); err != nil {
+				log.Warning("New> Error %s", err)
+				return nil, err
+			}
+			git.URL = URL
+		}
+
+		rm := sdk.RepositoriesManager{
+			ID:               id,
+			Consumer:         git,
+			Name:             name,
+			URL:              URL,
+			Type:             sdk.Git,
+			HooksSupported:   git.HooksSupported(),
+			PollingSupported: git.WithPolling && git.PollingSupported(),
+		}
 		return &rm, nil
 	}
 	return nil, fmt.Errorf("Unknown type %s. Cannot instanciate repositories manager t=%s id=%d name=%s url=%s args=%s consumerData=%s", t, t, id, name, URL, args, consumerData)
@@ -226,8 +365,24 @@ func initRepositoriesManager(db gorp.SqlExecutor, rm *sdk.RepositoriesManager, d
 		return nil
 	}
 
-	if rm.Type == sdk.Github {
-		// nothing to do here for github
+	if rm.Type == sdk.Git {
+		privateKey := secrets["privatekey"]
+		if privateKey == "" {
+			return nil
+		}
+		path := filepath.Join(directory, fmt.Sprintf("%s.%s", rm.Name, "privateKey"))
+		log.Info("RepositoriesManager> Writing git private key %s", path)
+		if err := ioutil.WriteFile(path, []byte(privateKey), 0600); err != nil {
+			log.Warning("RepositoriesManager> Unable to write git private key %s : %s", path, err)
+			return err
+		}
+		git := rm.Consumer.(*repogit.GitConsumer)
+		git.PrivateKey = path
+		return Update(db, rm)
+	}
+
+	if rm.Type == sdk.Github || rm.Type == sdk.Gitlab || rm.Type == sdk.Gitea {
+		// nothing to do here for github, gitlab and gitea
 		return nil
 	}
 	return fmt.Errorf("Unsupported repositories manager : %s: %s", rm.Name, rm.Type)
@@ -370,9 +370,10 @@ const (
 //SetStatus set build status on stash
 func (s *StashClient) SetStatus(event sdk.Event) error {
 	log.Debug("process> receive: type:%s all: %+v", event.EventType, event)
-	var eventpb sdk.EventPipelineBuild
 
-	if event.EventType != fmt.Sprintf("%T", sdk.EventPipelineBuild{}) {
+	switch event.EventType {
+	case fmt.Sprintf("%T", sdk.EventPipelineBuild{}), fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}):
+	default:
 		return nil
 	}
 
@@ -381,6 +382,11 @@ func (s *StashClient) SetStatus(event sdk.Event) error {
 		return nil
 	}
 
+	if event.EventType == fmt.Sprintf("%T", sdk.EventWorkflowNodeRun{}) {
+		return s.setWorkflowNodeRunStatus(event)
+	}
+
+	var eventpb sdk.EventPipelineBuild
 	if err := mapstructure.Decode(event.Payload, &eventpb); err != nil {
 		log.Warning("Error during consumption: %s", err)
 		return err
@@ -424,11 +430,55 @@ func (s *StashClient) SetStatus(event sdk.Event) error {
 	return nil
 }
 
+//setWorkflowNodeRunStatus sets the status of a workflow node run, its key is the workflow and the node
+func (s *StashClient) setWorkflowNodeRunStatus(event sdk.Event) error {
+	var eventnr sdk.EventWorkflowNodeRun
+	if err := mapstructure.Decode(event.Payload, &eventnr); err != nil {
+		log.Warning("Error during consumption: %s", err)
+		return err
+	}
+
+	if eventnr.Hash == "" {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s-%s-%s",
+		eventnr.ProjectKey,
+		eventnr.WorkflowName,
+		eventnr.NodeName,
+	)
+
+	status := stash.Status{
+		Key:   key,
+		Name:  fmt.Sprintf("%s%d", key, eventnr.Number),
+		State: getBitbucketStateFromStatus(eventnr.Status),
+		URL: fmt.Sprintf("%s/project/%s/workflow/%s/run/%d/node/%d",
+			uiURL,
+			eventnr.ProjectKey,
+			eventnr.WorkflowName,
+			eventnr.Number,
+			eventnr.NodeRunID,
+		),
+	}
+
+	log.Debug("SetStatus> hash:%s status:%+v", eventnr.Hash, status)
+	if err := s.client.Commits.SetStatus(eventnr.Hash, status); err != nil {
+		return fmt.Errorf("SetStatus> err on bitbucket: %ss", err)
+	}
+
+	return nil
+}
+
+//PullRequestComment is not implemented
+func (s *StashClient) PullRequestComment(string, int64, string) error {
+	return fmt.Errorf("Not implemented on stash")
+}
+
 func getBitbucketStateFromStatus(status sdk.Status) string {
 	switch status {
 	case sdk.StatusSuccess:
 		return successful
-	case sdk.StatusWaiting:
+	case sdk.StatusWaiting, sdk.StatusWaitingApproval:
 		return inProgress
 	case sdk.StatusBuilding:
 		return inProgress
@@ -0,0 +1,224 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//rotationTable is a table holding secrets
+type rotationTable struct {
+	name   string
+	column string
+	//text is true if the column is a TEXT, and not a BYTEA
+	text bool
+	//variable is true if the column is a JSON variable, with its value encrypted and base64 encoded if it is a secret
+	variable bool
+}
+
+//label is the name of the table in the progress of the rotation
+func (t rotationTable) label() string {
+	if t.variable {
+		return t.name + "." + t.column
+	}
+	return t.name
+}
+
+var rotationTables = []rotationTable{
+	{name: "project_variable", column: "cipher_value"},
+	{name: "application_variable", column: "cipher_value"},
+	{name: "environment_variable", column: "cipher_value"},
+	{name: "project_key", column: "private", text: true},
+	{name: "application_key", column: "private", text: true},
+	{name: "environment_key", column: "private", text: true},
+	{name: "project_variable_audit", column: "variable_before", text: true, variable: true},
+	{name: "project_variable_audit", column: "variable_after", text: true, variable: true},
+	{name: "application_variable_audit", column: "variable_before", text: true, variable: true},
+	{name: "application_variable_audit", column: "variable_after", text: true, variable: true},
+	{name: "environment_variable_audit", column: "variable_before", text: true, variable: true},
+	{name: "environment_variable_audit", column: "variable_after", text: true, variable: true},
+}
+
+var rotationCacheKey = cache.Key("secret", "rotation")
+
+//rotationTTL is the time, in seconds, a running rotation is kept without progress: the rotation refreshes it after
+//each batch, and a rotation stopped by a restart of the API doesn't prevent the next ones
+const rotationTTL = 300
+
+//rotating is set while this API runs a rotation
+var rotating int32
+
+//LoadRotation returns the progress of the last rotation
+func LoadRotation() (*sdk.SecretRotation, bool) {
+	r := &sdk.SecretRotation{}
+	if !cache.Get(rotationCacheKey, r) {
+		return nil, false
+	}
+	return r, true
+}
+
+//StartRotation starts the encryption of all the secrets with the last key of the keyring, in background
+func StartRotation(DBFunc func() *gorp.DbMap, batchSize int) (*sdk.SecretRotation, error) {
+	if currentVersion == 0 {
+		return nil, sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("no keyring configured"))
+	}
+	if r, ok := LoadRotation(); ok && r.Running {
+		return nil, sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("a rotation is already running"))
+	}
+	if !atomic.CompareAndSwapInt32(&rotating, 0, 1) {
+		return nil, sdk.NewError(sdk.ErrWrongRequest, fmt.Errorf("a rotation is already running"))
+	}
+
+	r := &sdk.SecretRotation{
+		Running:    true,
+		KeyVersion: currentVersion,
+		Start:      time.Now(),
+		Tables:     make([]sdk.SecretRotationTable, len(rotationTables)),
+	}
+	for i, t := range rotationTables {
+		r.Tables[i].Name = t.label()
+	}
+	cache.SetWithTTL(rotationCacheKey, r, rotationTTL)
+	res := *r
+	res.Tables = append([]sdk.SecretRotationTable{}, r.Tables...)
+
+	go func() {
+		if err := rotate(DBFunc(), r, batchSize); err != nil {
+			log.Error("secret.Rotation> %s", err)
+			r.Error = err.Error()
+		}
+		r.Running = false
+		r.End = time.Now()
+		cache.SetWithTTL(rotationCacheKey, r, -1)
+		atomic.StoreInt32(&rotating, 0)
+	}()
+	return &res, nil
+}
+
+func rotate(db *gorp.DbMap, r *sdk.SecretRotation, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	for i, t := range rotationTables {
+		progress := &r.Tables[i]
+		total, err := db.SelectInt(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NOT NULL", t.name, t.column))
+		if err != nil {
+			return sdk.WrapError(err, "secret.rotate> Unable to count %s", t.name)
+		}
+		progress.Total = total
+		cache.SetWithTTL(rotationCacheKey, r, rotationTTL)
+
+		var lastID int64
+		for {
+			n, last, err := rotateBatch(db, t, lastID, batchSize, progress)
+			if err != nil {
+				return err
+			}
+			cache.SetWithTTL(rotationCacheKey, r, rotationTTL)
+			if n < batchSize {
+				break
+			}
+			lastID = last
+		}
+		log.Info("secret.rotate> %d/%d secrets of %s encrypted with key %d", progress.Rotated, progress.Done, t.label(), r.KeyVersion)
+	}
+	return nil
+}
+
+//rotateBatch encrypts again the secrets of a batch of rows in a transaction, and returns the number of rows
+//and the last id of the batch
+func rotateBatch(db *gorp.DbMap, t rotationTable, lastID int64, batchSize int, progress *sdk.SecretRotationTable) (int, int64, error) {
+	tx, errB := db.Begin()
+	if errB != nil {
+		return 0, 0, sdk.WrapError(errB, "secret.rotateBatch> Unable to start a transaction")
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("SELECT id, %s FROM %s WHERE id > $1 AND %s IS NOT NULL ORDER BY id LIMIT $2 FOR UPDATE", t.column, t.name, t.column)
+	rows, err := tx.Query(query, lastID, batchSize)
+	if err != nil {
+		return 0, 0, sdk.WrapError(err, "secret.rotateBatch> Unable to load %s", t.name)
+	}
+	type row struct {
+		id   int64
+		data []byte
+	}
+	batch := []row{}
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.data); err != nil {
+			rows.Close()
+			return 0, 0, sdk.WrapError(err, "secret.rotateBatch> Unable to scan %s", t.name)
+		}
+		batch = append(batch, r)
+	}
+	rows.Close()
+
+	var rotated int64
+	reencrypt := Reencrypt
+	if t.variable {
+		reencrypt = reencryptVariable
+	}
+	for _, r := range batch {
+		ct, ok, err := reencrypt(r.data)
+		if err != nil {
+			return 0, 0, sdk.WrapError(err, "secret.rotateBatch> Unable to encrypt %s %d", t.name, r.id)
+		}
+		if !ok {
+			continue
+		}
+		var value interface{} = ct
+		if t.text {
+			value = string(ct)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = $2 WHERE id = $1", t.name, t.column), r.id, value); err != nil {
+			return 0, 0, sdk.WrapError(err, "secret.rotateBatch> Unable to update %s %d", t.name, r.id)
+		}
+		rotated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, sdk.WrapError(err, "secret.rotateBatch> Unable to commit")
+	}
+
+	progress.Done += int64(len(batch))
+	progress.Rotated += rotated
+	if len(batch) == 0 {
+		return 0, lastID, nil
+	}
+	return len(batch), batch[len(batch)-1].id, nil
+}
+
+//reencryptVariable encrypts again with the last key the value of a JSON variable, if it is a secret
+func reencryptVariable(data []byte) ([]byte, bool, error) {
+	v := sdk.Variable{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false, err
+	}
+	if !sdk.NeedPlaceholder(v.Type) {
+		return data, false, nil
+	}
+	//The value has not been encrypted
+	ct, err := base64.StdEncoding.DecodeString(v.Value)
+	if err != nil {
+		return data, false, nil
+	}
+	ct, ok, err := Reencrypt(ct)
+	if err != nil || !ok {
+		return data, false, err
+	}
+	v.Value = base64.StdEncoding.EncodeToString(ct)
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
@@ -1,6 +1,7 @@
 package secret
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/hmac"
@@ -9,6 +10,7 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/ovh/cds/sdk"
@@ -25,8 +27,11 @@ const (
 )
 
 var (
-	key    []byte
-	prefix = "3DICC3It"
+	key             []byte
+	keyring         map[int][]byte
+	currentVersion  int
+	prefix          = "3DICC3It"
+	versionedPrefix = "3DICC3Iv"
 )
 
 type Secret struct {
@@ -40,6 +45,37 @@ func Init(cipherKey string) {
 	key = []byte(cipherKey)
 }
 
+// InitKeyring sets the versioned keys, each entry of the keyring is "<version>:<key>" with a version greater than 0.
+// The key with the greatest version encrypts, all the keys and the key set by Init() decrypt.
+func InitKeyring(entries []string) error {
+	ring := map[int][]byte{}
+	var last int
+	for _, e := range entries {
+		i := strings.Index(e, ":")
+		if i <= 0 {
+			return fmt.Errorf("invalid keyring entry, expected <version>:<key>")
+		}
+		version, err := strconv.Atoi(e[:i])
+		if err != nil || version <= 0 {
+			return fmt.Errorf("invalid keyring version %s", e[:i])
+		}
+		if _, ok := ring[version]; ok {
+			return fmt.Errorf("duplicate keyring version %d", version)
+		}
+		k := []byte(e[i+1:])
+		if len(k) != ckeySize {
+			return fmt.Errorf("invalid key %d, expected %d characters", version, ckeySize)
+		}
+		ring[version] = k
+		if version > last {
+			last = version
+		}
+	}
+	keyring = ring
+	currentVersion = last
+	return nil
+}
+
 // Create new secret client
 func New(token, addr string) (*Secret, error) {
 	client, err := vault.NewClient(vault.DefaultConfig())
@@ -75,13 +111,30 @@ func (secret *Secret) GetFromVault(s string) (string, error) {
 }
 
 // Encrypt data using aes+hmac algorithm
-// Init() must be called before any encryption
+// Init() must be called before any encryption.
+// With a keyring, the data is encrypted with its last key, and the ciphertext records the version of this key
 func Encrypt(data []byte) ([]byte, error) {
+	if currentVersion > 0 {
+		ct, err := encrypt(keyring[currentVersion], data)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(fmt.Sprintf("%s%d:", versionedPrefix, currentVersion)), ct...), nil
+	}
+
 	// Check key is ready
 	if key == nil {
 		log.Error("Missing key, init failed?")
 		return nil, sdk.ErrSecretKeyFetchFailed
 	}
+	ct, err := encrypt(key, data)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(prefix), ct...), nil
+}
+
+func encrypt(key, data []byte) ([]byte, error) {
 	// generate nonce
 	nonce := make([]byte, nonceSize)
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
@@ -100,25 +153,30 @@ func Encrypt(data []byte) ([]byte, error) {
 	h := hmac.New(sha256.New, key[ckeySize:])
 	ct = append(nonce, ct...)
 	h.Write(ct)
-	ct = h.Sum(ct)
-
-	return append([]byte(prefix), ct...), nil
+	return h.Sum(ct), nil
 }
 
 // Decrypt data using aes+hmac algorithm
-// Init() must be called before any decryption
+// Init() must be called before any decryption.
+// The data is decrypted with the key of the keyring which encrypted it
 func Decrypt(data []byte) ([]byte, error) {
-
-	if !strings.HasPrefix(string(data), prefix) {
+	version, ct, ok := parse(data)
+	if !ok {
 		return data, nil
 	}
-	data = []byte(strings.TrimPrefix(string(data), prefix))
 
-	if key == nil {
-		log.Error("Missing key, init failed?")
+	k := key
+	if version > 0 {
+		k = keyring[version]
+	}
+	if k == nil {
+		log.Error("Missing key %d, init failed?", version)
 		return nil, sdk.ErrSecretKeyFetchFailed
 	}
+	return decrypt(k, ct)
+}
 
+func decrypt(key, data []byte) ([]byte, error) {
 	if len(data) < (nonceSize + macSize) {
 		log.Error("cannot decrypt secret, got invalid data")
 		return nil, sdk.ErrInvalidSecretFormat
@@ -146,6 +204,50 @@ func Decrypt(data []byte) ([]byte, error) {
 	return out, nil
 }
 
+//parse returns the version of the key which encrypted the data, 0 for the legacy key, and the ciphertext.
+//It returns false if the data is not encrypted.
+func parse(data []byte) (int, []byte, bool) {
+	if bytes.HasPrefix(data, []byte(prefix)) {
+		return 0, data[len(prefix):], true
+	}
+	if !bytes.HasPrefix(data, []byte(versionedPrefix)) {
+		return 0, nil, false
+	}
+	data = data[len(versionedPrefix):]
+	i := bytes.IndexByte(data, ':')
+	if i <= 0 {
+		return 0, nil, false
+	}
+	version, err := strconv.Atoi(string(data[:i]))
+	if err != nil || version <= 0 {
+		return 0, nil, false
+	}
+	return version, data[i+1:], true
+}
+
+//KeyVersion returns the version of the key used to encrypt, 0 if there is no keyring
+func KeyVersion() int {
+	return currentVersion
+}
+
+//Reencrypt encrypts again the data with the last key of the keyring. It returns false if the data is not encrypted,
+//or is already encrypted with the last key.
+func Reencrypt(data []byte) ([]byte, bool, error) {
+	version, _, ok := parse(data)
+	if !ok || version == currentVersion {
+		return data, false, nil
+	}
+	clear, err := Decrypt(data)
+	if err != nil {
+		return nil, false, err
+	}
+	ct, err := Encrypt(clear)
+	if err != nil {
+		return nil, false, err
+	}
+	return ct, true, nil
+}
+
 //DecryptVariable decrypts variable value using aes+hmac algorithm
 func DecryptVariable(v *sdk.Variable) error {
 	if !sdk.NeedPlaceholder(v.Type) {
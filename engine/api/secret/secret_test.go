@@ -3,6 +3,8 @@ package secret
 import (
 	"bytes"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"testing"
 
 	"github.com/ovh/cds/sdk"
@@ -99,3 +101,137 @@ func TestDecryptS(t *testing.T) {
 	}
 
 }
+
+func TestKeyring(t *testing.T) {
+	key = []byte("78eKVxCGLm6gwoH9LAQ15ZD5AOABo1Xf")
+	defer InitKeyring(nil)
+	data := []byte("Hello world !")
+
+	legacy, err := Encrypt(data)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %s", err)
+	}
+
+	if err := InitKeyring([]string{"1:12345678901234567890123456789012"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+	v1, err := Encrypt(data)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %s", err)
+	}
+	if !bytes.HasPrefix(v1, []byte(versionedPrefix+"1:")) {
+		t.Fatalf("Ciphertext should record the key version, got %q", v1[:12])
+	}
+
+	if err := InitKeyring([]string{"2:abcdefghijklmnopqrstuvwxyz012345", "1:12345678901234567890123456789012"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+	if KeyVersion() != 2 {
+		t.Fatalf("Key version should be 2, got %d", KeyVersion())
+	}
+
+	for _, ct := range [][]byte{legacy, v1} {
+		clear, err := Decrypt(ct)
+		if err != nil {
+			t.Fatalf("Decrypt failed: %s", err)
+		}
+		if bytes.Compare(clear, data) != 0 {
+			t.Fatalf("Fail: Expected '%s', got '%s'", data, clear)
+		}
+
+		v2, ok, err := Reencrypt(ct)
+		if err != nil || !ok {
+			t.Fatalf("Reencrypt failed: %v %s", ok, err)
+		}
+		if !bytes.HasPrefix(v2, []byte(versionedPrefix+"2:")) {
+			t.Fatalf("Ciphertext should be encrypted with key 2, got %q", v2[:12])
+		}
+		clear, err = Decrypt(v2)
+		if err != nil || bytes.Compare(clear, data) != 0 {
+			t.Fatalf("Decrypt failed: %s %s", clear, err)
+		}
+		if _, ok, _ := Reencrypt(v2); ok {
+			t.Fatalf("Reencrypt should not encrypt again data encrypted with the last key")
+		}
+	}
+
+	if _, ok, _ := Reencrypt(data); ok {
+		t.Fatalf("Reencrypt should not encrypt clear data")
+	}
+
+	//A key removed from the keyring can not decrypt anymore
+	if err := InitKeyring([]string{"2:abcdefghijklmnopqrstuvwxyz012345"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+	if _, err := Decrypt(v1); err == nil {
+		t.Fatalf("Decrypt should have failed")
+	}
+
+	for _, invalid := range [][]string{{"abc"}, {"0:12345678901234567890123456789012"}, {"1:short"}, {"1:12345678901234567890123456789012", "1:abcdefghijklmnopqrstuvwxyz012345"}} {
+		if err := InitKeyring(invalid); err == nil {
+			t.Fatalf("InitKeyring should have failed with %v", invalid)
+		}
+	}
+}
+
+func TestReencryptVariable(t *testing.T) {
+	defer InitKeyring(nil)
+	if err := InitKeyring([]string{"1:12345678901234567890123456789012"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+
+	//The audits of the variables store their secret value encrypted and base64 encoded
+	ct, err := Encrypt([]byte("my password"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %s", err)
+	}
+	audit, _ := json.Marshal(sdk.Variable{Name: "password", Type: sdk.SecretVariable, Value: base64.StdEncoding.EncodeToString(ct)})
+	clearAudit, _ := json.Marshal(sdk.Variable{Name: "name", Type: sdk.StringVariable, Value: "my name"})
+
+	if err := InitKeyring([]string{"2:abcdefghijklmnopqrstuvwxyz012345", "1:12345678901234567890123456789012"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+	if _, ok, err := reencryptVariable(clearAudit); ok || err != nil {
+		t.Fatalf("reencryptVariable should not encrypt a clear variable: %v %s", ok, err)
+	}
+	rotated, ok, err := reencryptVariable(audit)
+	if err != nil || !ok {
+		t.Fatalf("reencryptVariable failed: %v %s", ok, err)
+	}
+	if _, ok, _ := reencryptVariable(rotated); ok {
+		t.Fatalf("reencryptVariable should not encrypt again a variable encrypted with the last key")
+	}
+
+	//Nothing is left encrypted with the old key
+	if err := InitKeyring([]string{"2:abcdefghijklmnopqrstuvwxyz012345"}); err != nil {
+		t.Fatalf("InitKeyring failed: %s", err)
+	}
+	v := sdk.Variable{}
+	if err := json.Unmarshal(rotated, &v); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+	ct, err = base64.StdEncoding.DecodeString(v.Value)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %s", err)
+	}
+	clear, err := Decrypt(ct)
+	if err != nil || string(clear) != "my password" {
+		t.Fatalf("Decrypt failed: %s %s", clear, err)
+	}
+}
+
+func TestRotationTables(t *testing.T) {
+	//All the tables holding encrypted secrets are rotated
+	for _, table := range []string{"project_variable", "application_variable", "environment_variable", "project_key", "application_key", "environment_key",
+		"project_variable_audit.variable_before", "project_variable_audit.variable_after",
+		"application_variable_audit.variable_before", "application_variable_audit.variable_after",
+		"environment_variable_audit.variable_before", "environment_variable_audit.variable_after"} {
+		var found bool
+		for _, r := range rotationTables {
+			found = found || r.label() == table
+		}
+		if !found {
+			t.Fatalf("%s is not rotated", table)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ovh/cds/engine/api/auth"
 	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/group"
 	"github.com/ovh/cds/engine/api/mail"
 	"github.com/ovh/cds/engine/api/sessionstore"
@@ -251,11 +252,14 @@ func ResetUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *busine
 	return WriteJSON(w, r, userDb, http.StatusCreated)
 }
 
-//AuthModeHandler returns the auth mode : local ok ldap
+//AuthModeHandler returns the auth mode : local, ldap or oidc
 func AuthModeHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	mode := "local"
-	if _, ldap := router.authDriver.(*auth.LDAPClient); ldap {
+	switch router.authDriver.(type) {
+	case *auth.LDAPClient:
 		mode = "ldap"
+	case *auth.OIDCClient:
+		mode = "oidc"
 	}
 	res := map[string]string{
 		"auth_mode": mode,
@@ -380,6 +384,98 @@ func LoginUser(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *busine
 	return WriteJSON(w, r, response, http.StatusOK)
 }
 
+// oidcLoginHandler redirects the user to the OpenID Connect provider
+func oidcLoginHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	oidc, ok := router.authDriver.(*auth.OIDCClient)
+	if !ok {
+		return sdk.ErrNotFound
+	}
+
+	state, errs := sessionstore.NewSessionKey()
+	if errs != nil {
+		return sdk.WrapError(errs, "oidcLoginHandler> Cannot generate state")
+	}
+	nonce, errn := sessionstore.NewSessionKey()
+	if errn != nil {
+		return sdk.WrapError(errn, "oidcLoginHandler> Cannot generate nonce")
+	}
+	verifier, errv := auth.NewOIDCVerifier()
+	if errv != nil {
+		return sdk.WrapError(errv, "oidcLoginHandler> Cannot generate code verifier")
+	}
+
+	data := map[string]string{
+		"nonce":    string(nonce),
+		"verifier": verifier,
+	}
+	cache.SetWithTTL(cache.Key("auth", "oidc", string(state)), data, 600)
+	oidc.SetStateCookie(w, string(state), 600*time.Second)
+
+	http.Redirect(w, r, oidc.AuthCodeURL(string(state), string(nonce), verifier), http.StatusFound)
+	return nil
+}
+
+// oidcCallbackHandler checks the authorization code returned by the OpenID Connect provider and creates a new session
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	oidc, ok := router.authDriver.(*auth.OIDCClient)
+	if !ok {
+		return sdk.ErrNotFound
+	}
+
+	if cberr := r.FormValue("error"); cberr != "" {
+		return sdk.WrapError(sdk.ErrUnauthorized, "oidcCallbackHandler> %s: %s", cberr, r.FormValue("error_description"))
+	}
+
+	state := r.FormValue("state")
+	code := r.FormValue("code")
+	if state == "" || code == "" {
+		return sdk.WrapError(sdk.ErrWrongRequest, "oidcCallbackHandler> Missing code or state")
+	}
+
+	// The state is bound to the browser which has started the login, and can be used only once
+	if !oidc.CheckStateCookie(w, r, state) {
+		return sdk.WrapError(sdk.ErrUnauthorized, "oidcCallbackHandler> State does not match the login")
+	}
+	key := cache.Key("auth", "oidc", state)
+	data := map[string]string{}
+	if !cache.Get(key, &data) {
+		return sdk.WrapError(sdk.ErrUnauthorized, "oidcCallbackHandler> Unknown state")
+	}
+	cache.Delete(key)
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return sdk.WrapError(errb, "oidcCallbackHandler> Cannot start transaction")
+	}
+	defer tx.Rollback()
+
+	u, erra := oidc.AuthentifyCode(tx, code, data["verifier"], data["nonce"])
+	if erra != nil {
+		return sdk.WrapError(erra, "oidcCallbackHandler> Login failed")
+	}
+
+	if err := group.CheckUserInDefaultGroup(tx, u.ID); err != nil {
+		log.Warning("oidcCallbackHandler> Error while check user in default group:%s\n", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "oidcCallbackHandler> Cannot commit transaction")
+	}
+
+	sessionKey, errs := auth.NewSession(router.authDriver, u)
+	if errs != nil {
+		return sdk.WrapError(errs, "oidcCallbackHandler> Error while creating new session")
+	}
+	w.Header().Set(sdk.SessionTokenHeader, string(sessionKey))
+
+	response := sdk.UserAPIResponse{
+		User:  *u,
+		Token: string(sessionKey),
+	}
+	response.User.Auth = sdk.Auth{}
+	return WriteJSON(w, r, response, http.StatusOK)
+}
+
 func importUsersHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	var users = []sdk.User{}
 	if err := UnmarshalBody(r, &users); err != nil {
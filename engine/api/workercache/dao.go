@@ -0,0 +1,96 @@
+package workercache
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+const selectQuery = `SELECT id, project_id, cache_key, size, created, last_used FROM worker_cache`
+
+func scan(s interface {
+	Scan(dest ...interface{}) error
+}) (*sdk.WorkerCache, error) {
+	var c sdk.WorkerCache
+	if err := s.Scan(&c.ID, &c.ProjectID, &c.Key, &c.Size, &c.Created, &c.LastUsed); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+//Load loads a cache of a project by its key
+func Load(db gorp.SqlExecutor, projectID int64, key string) (*sdk.WorkerCache, error) {
+	c, err := scan(db.QueryRow(selectQuery+" WHERE project_id = $1 AND cache_key = $2", projectID, key))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrNotFound
+	}
+	if err != nil {
+		return nil, sdk.WrapError(err, "Load> Unable to load cache %s of project %d", key, projectID)
+	}
+	return c, nil
+}
+
+//LoadByPrefix loads the latest cache of a project with a key starting with prefix
+func LoadByPrefix(db gorp.SqlExecutor, projectID int64, prefix string) (*sdk.WorkerCache, error) {
+	//_ is a valid character in keys, but a wildcard for LIKE
+	like := strings.Replace(prefix, "_", `\_`, -1) + "%"
+	c, err := scan(db.QueryRow(selectQuery+` WHERE project_id = $1 AND cache_key LIKE $2 ESCAPE '\' ORDER BY created DESC LIMIT 1`, projectID, like))
+	if err == sql.ErrNoRows {
+		return nil, sdk.ErrNotFound
+	}
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadByPrefix> Unable to load cache %s* of project %d", prefix, projectID)
+	}
+	return c, nil
+}
+
+//LoadAll loads all the caches of a project, the most recently used first
+func LoadAll(db gorp.SqlExecutor, projectID int64) ([]sdk.WorkerCache, error) {
+	rows, err := db.Query(selectQuery+" WHERE project_id = $1 ORDER BY last_used DESC, id DESC", projectID)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadAll> Unable to load caches of project %d", projectID)
+	}
+	defer rows.Close()
+
+	caches := []sdk.WorkerCache{}
+	for rows.Next() {
+		c, err := scan(rows)
+		if err != nil {
+			return nil, sdk.WrapError(err, "LoadAll> Unable to scan cache")
+		}
+		caches = append(caches, *c)
+	}
+	return caches, nil
+}
+
+//Insert records a cache, it replaces the cache with the same key
+func Insert(db gorp.SqlExecutor, c *sdk.WorkerCache) error {
+	if _, err := db.Exec("DELETE FROM worker_cache WHERE project_id = $1 AND cache_key = $2", c.ProjectID, c.Key); err != nil {
+		return sdk.WrapError(err, "Insert> Unable to delete previous cache %s", c.Key)
+	}
+
+	query := `INSERT INTO worker_cache (project_id, cache_key, size) VALUES ($1, $2, $3) RETURNING id, created, last_used`
+	if err := db.QueryRow(query, c.ProjectID, c.Key, c.Size).Scan(&c.ID, &c.Created, &c.LastUsed); err != nil {
+		return sdk.WrapError(err, "Insert> Unable to insert cache %s", c.Key)
+	}
+	return nil
+}
+
+//UpdateLastUsed marks a cache as used now
+func UpdateLastUsed(db gorp.SqlExecutor, c *sdk.WorkerCache) error {
+	if err := db.QueryRow("UPDATE worker_cache SET last_used = LOCALTIMESTAMP WHERE id = $1 RETURNING last_used", c.ID).Scan(&c.LastUsed); err != nil {
+		return sdk.WrapError(err, "UpdateLastUsed> Unable to update cache %d", c.ID)
+	}
+	return nil
+}
+
+//Delete deletes a cache
+func Delete(db gorp.SqlExecutor, id int64) error {
+	if _, err := db.Exec("DELETE FROM worker_cache WHERE id = $1", id); err != nil {
+		return sdk.WrapError(err, "Delete> Unable to delete cache %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package workercache
+
+import (
+	"io"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//Save stores the content of a cache in the objectstore and records it.
+//If quota is not 0, the least recently used caches of the project are deleted to keep the size of its caches under quota.
+func Save(db *gorp.DbMap, c *sdk.WorkerCache, content io.ReadCloser, quota int64) error {
+	if quota > 0 && c.Size > quota {
+		return sdk.WrapError(sdk.ErrWorkerCacheTooLarge, "Save> Cache %s is %d bytes, quota is %d bytes", c.Key, c.Size, quota)
+	}
+
+	if _, err := objectstore.StoreArtifact(c, content); err != nil {
+		return sdk.WrapError(err, "Save> Cannot store cache %s", c.Key)
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return sdk.WrapError(errb, "Save> Cannot begin tx")
+	}
+	defer tx.Rollback()
+
+	if err := Insert(tx, c); err != nil {
+		return sdk.WrapError(err, "Save>")
+	}
+
+	evicted, err := evict(tx, c.ProjectID, quota)
+	if err != nil {
+		return sdk.WrapError(err, "Save> Cannot apply quota of project %d", c.ProjectID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "Save> Cannot commit tx")
+	}
+
+	for i := range evicted {
+		log.Info("workercache.Save> Cache %s of project %d evicted", evicted[i].Key, evicted[i].ProjectID)
+		if err := objectstore.DeleteArtifact(&evicted[i]); err != nil {
+			log.Warning("workercache.Save> Cannot delete cache %s from store: %s", evicted[i].Key, err)
+		}
+	}
+	return nil
+}
+
+//Fetch returns the content of a cache and marks it as used
+func Fetch(db gorp.SqlExecutor, c *sdk.WorkerCache) (io.ReadCloser, error) {
+	if err := UpdateLastUsed(db, c); err != nil {
+		return nil, sdk.WrapError(err, "Fetch>")
+	}
+	content, err := objectstore.FetchArtifact(c)
+	if err != nil {
+		return nil, sdk.WrapError(err, "Fetch> Cannot fetch cache %s", c.Key)
+	}
+	return content, nil
+}
+
+//evict deletes the least recently used caches of a project which exceed its quota, and returns them
+func evict(db gorp.SqlExecutor, projectID int64, quota int64) ([]sdk.WorkerCache, error) {
+	if quota <= 0 {
+		return nil, nil
+	}
+
+	caches, err := LoadAll(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	evicted := []sdk.WorkerCache{}
+	var size int64
+	for _, c := range caches {
+		if size+c.Size <= quota {
+			size += c.Size
+			continue
+		}
+		if err := Delete(db, c.ID); err != nil {
+			return nil, err
+		}
+		evicted = append(evicted, c)
+	}
+	return evicted, nil
+}
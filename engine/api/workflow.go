@@ -7,8 +7,10 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/project"
 	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/engine/api/workflowhook"
 	"github.com/ovh/cds/sdk"
 )
 
@@ -22,19 +24,27 @@ func getWorkflowsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap,
 		return err
 	}
 
-	return WriteJSON(w, r, ws, http.StatusOK)
+	res := make([]sdk.Workflow, 0, len(ws))
+	for _, wf := range ws {
+		if permission.AccessToWorkflow(wf.ID, c.User, permission.PermissionRead) {
+			res = append(res, wf)
+		}
+	}
+
+	return WriteJSON(w, r, res, http.StatusOK)
 }
 
 // getWorkflowHandler returns a full workflow
 func getWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	w1, err := workflow.Load(db, key, name, c.User)
 	if err != nil {
 		return err
 	}
+	workflowhook.SetWebHooksURL(w1)
 	return WriteJSON(w, r, w1, http.StatusOK)
 }
 
@@ -84,7 +94,7 @@ func postWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap,
 func putWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	p, errP := project.Load(db, key, c.User)
 	if errP != nil {
@@ -136,7 +146,7 @@ func putWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap,
 func deleteWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	p, errP := project.Load(db, key, c.User)
 	if errP != nil {
@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/event"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//ApproveNodeRun records the approval or the rejection of a node run waiting for approval by a user.
+//A rejected node run is stopped. Once it has got enough approvals, the node run is executed.
+func ApproveNodeRun(db gorp.SqlExecutor, wr *sdk.WorkflowRun, nodeRunID int64, u *sdk.User, approved bool, comment string) (*sdk.WorkflowNodeRun, error) {
+	if err := lockRun(db, wr.ID); err != nil {
+		return nil, sdk.WrapError(err, "workflow.ApproveNodeRun>")
+	}
+
+	nodeRun, errl := LoadAndLockNodeRunByID(db, nodeRunID)
+	if errl != nil {
+		return nil, sdk.WrapError(errl, "workflow.ApproveNodeRun> Unable to lock node run %d", nodeRunID)
+	}
+	if nodeRun.WorkflowRunID != wr.ID {
+		return nil, sdk.WrapError(sdk.ErrWorkflowNodeNotFound, "workflow.ApproveNodeRun> Node run %d is not part of workflow run %d", nodeRunID, wr.ID)
+	}
+	if nodeRun.Status != sdk.StatusWaitingApproval.String() {
+		return nil, sdk.ErrWorkflowNodeRunNotWaitingApproval
+	}
+
+	node := wr.Workflow.GetNode(nodeRun.WorkflowNodeID)
+	if node == nil || node.Context == nil || !node.Context.Approval.IsRequired() {
+		return nil, sdk.WrapError(sdk.ErrWorkflowNodeNotFound, "workflow.ApproveNodeRun> Unable to find approval settings of node %d", nodeRun.WorkflowNodeID)
+	}
+
+	if nodeRun.Approval(u.Username) != nil {
+		return nil, sdk.ErrWorkflowNodeRunAlreadyApproved
+	}
+
+	log.Debug("workflow.ApproveNodeRun> [#%d.%d] nodeRunID=%d approved=%t by %s", nodeRun.Number, nodeRun.SubNumber, nodeRun.ID, approved, u.Username)
+
+	approval := sdk.WorkflowNodeRunApproval{
+		Username: u.Username,
+		Approved: approved,
+		Comment:  comment,
+		Date:     time.Now(),
+	}
+	nodeRun.Approvals = append(nodeRun.Approvals, approval)
+	nodeRun.LastModified = time.Now()
+
+	msg := sdk.MsgWorkflowNodeApproved
+	switch {
+	case !approved:
+		msg = sdk.MsgWorkflowNodeRejected
+		nodeRun.Status = sdk.StatusStopped.String()
+		nodeRun.Done = time.Now()
+	case nodeRun.IsApproved(node.Context.Approval):
+		nodeRun.Status = sdk.StatusWaiting.String()
+	}
+
+	if err := UpdateNodeRun(db, nodeRun); err != nil {
+		return nil, sdk.WrapError(err, "workflow.ApproveNodeRun> Unable to update node run %d", nodeRun.ID)
+	}
+
+	AddWorkflowRunInfo(wr, sdk.SpawnMsg{
+		ID:   msg.ID,
+		Args: []interface{}{node.Pipeline.Name, u.Username, comment},
+	})
+	if err := updateWorkflowRun(db, wr); err != nil {
+		return nil, sdk.WrapError(err, "workflow.ApproveNodeRun> Unable to update workflow run %d", wr.ID)
+	}
+
+	event.PublishWorkflowNodeRunApproval(wr, nodeRun, &approval)
+
+	//Execute the node run !
+	if nodeRun.Status == sdk.StatusWaiting.String() {
+		if err := execute(db, nodeRun); err != nil {
+			return nil, sdk.WrapError(err, "workflow.ApproveNodeRun> Unable to execute node run %d", nodeRun.ID)
+		}
+	}
+
+	return nodeRun, nil
+}
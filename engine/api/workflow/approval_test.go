@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/go-gorp/gorp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/bootstrap"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/test/assets"
+	"github.com/ovh/cds/sdk"
+)
+
+func insertTestWorkflowWithApproval(t *testing.T, db *gorp.DbMap, u *sdk.User, proj *sdk.Project, nbApprovers int) *sdk.Workflow {
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	s := sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip.ID
+	pipeline.InsertStage(db, s)
+	j := &sdk.Job{
+		Enabled: true,
+		Action: sdk.Action{
+			Enabled: true,
+		},
+	}
+	pipeline.InsertJob(db, j, s.ID, &pip)
+	s.Jobs = append(s.Jobs, *j)
+	pip.Stages = append(pip.Stages, *s)
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+			Context: &sdk.WorkflowNodeContext{
+				Approval: &sdk.WorkflowNodeApproval{NbApprovers: nbApprovers},
+			},
+		},
+	}
+	test.NoError(t, Insert(db, &w, u))
+	w1, err := Load(db, proj.Key, "test_1", u)
+	test.NoError(t, err)
+	return w1
+}
+
+func TestApproveNodeRun(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+	u, _ := assets.InsertAdminUser(db)
+	u2, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	w1 := insertTestWorkflowWithApproval(t, db, u, proj, 2)
+	assert.Equal(t, 2, w1.Root.Context.Approval.NbApprovers)
+
+	_, err := ManualRun(db, w1, &sdk.WorkflowNodeRunManual{User: *u})
+	test.NoError(t, err)
+
+	wr, err := LoadLastRun(db, proj.Key, "test_1")
+	test.NoError(t, err)
+	nodeRun := wr.WorkflowNodeRuns[w1.RootID][0]
+	assert.Equal(t, sdk.StatusWaitingApproval.String(), nodeRun.Status)
+
+	jobs, err := LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 0)
+
+	nr, err := ApproveNodeRun(db, wr, nodeRun.ID, u, true, "first")
+	test.NoError(t, err)
+	assert.Equal(t, sdk.StatusWaitingApproval.String(), nr.Status)
+
+	//Only one decision by user
+	_, err = ApproveNodeRun(db, wr, nodeRun.ID, u, true, "again")
+	assert.Equal(t, sdk.ErrWorkflowNodeRunAlreadyApproved, err)
+
+	nr, err = ApproveNodeRun(db, wr, nodeRun.ID, u2, true, "second")
+	test.NoError(t, err)
+	assert.Equal(t, sdk.StatusBuilding.String(), nr.Status)
+	assert.Len(t, nr.Approvals, 2)
+
+	jobs, err = LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 1)
+}
+
+func TestRejectNodeRun(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	w1 := insertTestWorkflowWithApproval(t, db, u, proj, 1)
+
+	_, err := ManualRun(db, w1, &sdk.WorkflowNodeRunManual{User: *u})
+	test.NoError(t, err)
+
+	wr, err := LoadLastRun(db, proj.Key, "test_1")
+	test.NoError(t, err)
+	nodeRun := wr.WorkflowNodeRuns[w1.RootID][0]
+
+	nr, err := ApproveNodeRun(db, wr, nodeRun.ID, u, false, "not today")
+	test.NoError(t, err)
+	assert.Equal(t, sdk.StatusStopped.String(), nr.Status)
+	assert.Equal(t, "not today", nr.Approvals[0].Comment)
+
+	_, err = ApproveNodeRun(db, wr, nodeRun.ID, u, true, "")
+	assert.Equal(t, sdk.ErrWorkflowNodeRunNotWaitingApproval, err)
+
+	//A rejected node run can't be restarted
+	_, err = RestartFailedJobs(db, w1, wr.Number, &sdk.WorkflowNodeRunManual{User: *u}, nodeRun.ID)
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/project"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
@@ -68,6 +69,21 @@ func LoadByID(db gorp.SqlExecutor, id int64, u *sdk.User) (*sdk.Workflow, error)
 	return res, nil
 }
 
+// LoadByHookUUID loads the workflow which contains the hook identified by its uuid
+func LoadByHookUUID(db gorp.SqlExecutor, uuid string) (*sdk.Workflow, error) {
+	query := `
+		select workflow.* 
+		from workflow
+		join workflow_node on workflow_node.workflow_id = workflow.id
+		join workflow_node_hook on workflow_node_hook.workflow_node_id = workflow_node.id
+		where workflow_node_hook.uuid = $1`
+	res, err := load(db, nil, query, uuid)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadByHookUUID> Unable to load workflow for hook %s", uuid)
+	}
+	return res, nil
+}
+
 func load(db gorp.SqlExecutor, u *sdk.User, query string, args ...interface{}) (*sdk.Workflow, error) {
 	t0 := time.Now()
 	dbRes := Workflow{}
@@ -91,6 +107,10 @@ func load(db gorp.SqlExecutor, u *sdk.User, query string, args ...interface{}) (
 
 	res.Joins = joins
 
+	if err := LoadGroups(db, &res); err != nil {
+		return nil, sdk.WrapError(err, "Load> Unable to load workflow groups")
+	}
+
 	delta := time.Since(t0).Seconds()
 
 	log.Debug("Load> Load workflow (%s/%s)%d took %.3f seconds", res.ProjectKey, res.Name, res.ID, delta)
@@ -118,8 +138,12 @@ func Insert(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) error {
 		return err
 	}
 
+	if err := limitPriority(db, w); err != nil {
+		return err
+	}
+
 	w.LastModified = time.Now()
-	if err := db.QueryRow("INSERT INTO workflow (name, description, project_id) VALUES ($1, $2, $3) RETURNING id", w.Name, w.Description, w.ProjectID).Scan(&w.ID); err != nil {
+	if err := db.QueryRow("INSERT INTO workflow (name, description, project_id, priority) VALUES ($1, $2, $3, $4) RETURNING id", w.Name, w.Description, w.ProjectID, w.Priority).Scan(&w.ID); err != nil {
 		return sdk.WrapError(err, "Insert> Unable to insert workflow %s/%s", w.ProjectKey, w.Name)
 	}
 
@@ -127,6 +151,11 @@ func Insert(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) error {
 		return sdk.ErrWorkflowInvalidRoot
 	}
 
+	if err := insertGroups(db, w); err != nil {
+		return sdk.WrapError(err, "Insert> Unable to insert workflow groups")
+	}
+
+	checkHooksUUID(w, nil)
 	if err := insertNode(db, w, w.Root, u, false); err != nil {
 		return sdk.WrapError(err, "Insert> Unable to insert workflow root node")
 	}
@@ -151,6 +180,8 @@ func Update(db gorp.SqlExecutor, w *sdk.Workflow, oldWorkflow *sdk.Workflow, u *
 		return err
 	}
 
+	checkHooksUUID(w, oldWorkflow)
+
 	// Delete all OLD JOIN
 	for _, j := range oldWorkflow.Joins {
 		if err := deleteJoin(db, j); err != nil {
@@ -183,6 +214,10 @@ func Update(db gorp.SqlExecutor, w *sdk.Workflow, oldWorkflow *sdk.Workflow, u *
 		}
 	}
 
+	if err := limitPriority(db, w); err != nil {
+		return err
+	}
+
 	w.LastModified = time.Now()
 	dbw := Workflow(*w)
 	if _, err := db.Update(&dbw); err != nil {
@@ -192,6 +227,45 @@ func Update(db gorp.SqlExecutor, w *sdk.Workflow, oldWorkflow *sdk.Workflow, u *
 	return updateLastModified(db, w, u)
 }
 
+//checkHooksUUID keeps the UUID of a hook only if the same node of the old workflow has this hook, so that a hook
+//can't take the UUID, and the public URL, of the hook of another workflow. The nodes are matched by ID, or by name
+//for the imported workflows. The hooks without UUID get a new one when they are inserted.
+func checkHooksUUID(w *sdk.Workflow, oldWorkflow *sdk.Workflow) {
+	oldNodesByID := map[int64]*sdk.WorkflowNode{}
+	oldNodesByName := map[string]*sdk.WorkflowNode{}
+	if oldWorkflow != nil {
+		for _, n := range allNodes(oldWorkflow) {
+			oldNodesByID[n.ID] = n
+			oldNodesByName[n.Name] = n
+		}
+	}
+
+	for _, n := range allNodes(w) {
+		oldNode, ok := oldNodesByID[n.ID]
+		if n.ID == 0 || !ok {
+			oldNode = oldNodesByName[n.Name]
+		}
+		for i := range n.Hooks {
+			h := &n.Hooks[i]
+			if h.UUID == "" {
+				continue
+			}
+			var found bool
+			if oldNode != nil {
+				for _, oldH := range oldNode.Hooks {
+					if oldH.UUID == h.UUID {
+						found = true
+						break
+					}
+				}
+			}
+			if !found {
+				h.UUID = ""
+			}
+		}
+	}
+}
+
 // Delete workflow
 func Delete(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) error {
 	//Detach root from workflow
@@ -235,7 +309,22 @@ func updateLastModified(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) error
 
 // HasAccessTo checks if user has full r, rx or rwx access to the workflow
 func HasAccessTo(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) (bool, error) {
-	return true, nil
+	return permission.AccessToWorkflow(w.ID, u, permission.PermissionRead), nil
+}
+
+//limitPriority lowers the priority of the workflow to the highest priority allowed by an administrator on its project
+func limitPriority(db gorp.SqlExecutor, w *sdk.Workflow) error {
+	if w.Priority <= 0 {
+		return nil
+	}
+	max, err := db.SelectInt("select max_workflow_priority from project where id = $1", w.ProjectID)
+	if err != nil {
+		return sdk.WrapError(err, "limitPriority> Unable to load project %d", w.ProjectID)
+	}
+	if int64(w.Priority) > max {
+		w.Priority = int(max)
+	}
+	return nil
 }
 
 // IsValid cheks workflow validity
@@ -313,5 +402,41 @@ func IsValid(db gorp.SqlExecutor, w *sdk.Workflow, u *sdk.User) error {
 		}
 	}
 
+	//Checks conditions expressions
+	if w.Root != nil {
+		if err := checkNodeConditions(w.Root); err != nil {
+			return sdk.NewError(sdk.ErrWorkflowInvalid, err)
+		}
+	}
+	for _, j := range w.Joins {
+		for _, t := range j.Triggers {
+			if err := sdk.WorkflowCheckConditionsSyntax(t.Conditions); err != nil {
+				return sdk.NewError(sdk.ErrWorkflowInvalid, err)
+			}
+			if err := checkNodeConditions(&t.WorkflowDestNode); err != nil {
+				return sdk.NewError(sdk.ErrWorkflowInvalid, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//checkNodeConditions checks the conditions of the hooks and the triggers of a node and its children
+func checkNodeConditions(n *sdk.WorkflowNode) error {
+	for _, h := range n.Hooks {
+		if err := sdk.WorkflowCheckConditionsSyntax(h.Conditions); err != nil {
+			return err
+		}
+	}
+	for i := range n.Triggers {
+		t := &n.Triggers[i]
+		if err := sdk.WorkflowCheckConditionsSyntax(t.Conditions); err != nil {
+			return err
+		}
+		if err := checkNodeConditions(&t.WorkflowDestNode); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -34,13 +34,15 @@ func insertHook(db gorp.SqlExecutor, node *sdk.WorkflowNode, hook *sdk.WorkflowN
 
 	//TODO Check configuration of the hook vs the model
 
-	uuid, erruuid := sessionstore.NewSessionKey()
-	if erruuid != nil {
-		return sdk.WrapError(erruuid, "insertHook> Unable to load model %d", hook.WorkflowHookModelID)
+	//Keep the UUID of an existing hook, so that its public URL does not change. It has been checked by checkHooksUUID
+	if hook.UUID == "" {
+		uuid, erruuid := sessionstore.NewSessionKey()
+		if erruuid != nil {
+			return sdk.WrapError(erruuid, "insertHook> Unable to generate uuid for model %d", hook.WorkflowHookModelID)
+		}
+		hook.UUID = string(uuid)
 	}
 
-	hook.UUID = string(uuid)
-
 	dbhook := NodeHook(*hook)
 	if err := db.Insert(&dbhook); err != nil {
 		return sdk.WrapError(err, "insertHook> Unable to insert hook")
@@ -99,19 +101,67 @@ func (r *NodeHook) PostGet(db gorp.SqlExecutor) error {
 
 func loadHooks(db gorp.SqlExecutor, node *sdk.WorkflowNode) ([]sdk.WorkflowNodeHook, error) {
 	res := []NodeHook{}
-	if _, err := db.Select(&res, "select id, uuid, workflow_hook_model_id from workflow_node_hook where workflow_node_id = $1", node.ID); err != nil {
+	if _, err := db.Select(&res, "select id, uuid, workflow_node_id, workflow_hook_model_id from workflow_node_hook where workflow_node_id = $1", node.ID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, sdk.WrapError(err, "loadHooks")
 	}
+	return postLoadHooks(db, res)
+}
+
+// LoadHookByUUID loads a hook given its uuid
+func LoadHookByUUID(db gorp.SqlExecutor, uuid string) (*sdk.WorkflowNodeHook, error) {
+	res := []NodeHook{}
+	if _, err := db.Select(&res, "select id, uuid, workflow_node_id, workflow_hook_model_id from workflow_node_hook where uuid = $1", uuid); err != nil {
+		return nil, sdk.WrapError(err, "LoadHookByUUID> Unable to load hook %s", uuid)
+	}
+	if len(res) == 0 {
+		return nil, sdk.ErrNoHook
+	}
+	hooks, err := postLoadHooks(db, res)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadHookByUUID")
+	}
+	return &hooks[0], nil
+}
 
-	nodes := []sdk.WorkflowNodeHook{}
+// LoadHooksByModelName loads all the hooks based on a hook model
+func LoadHooksByModelName(db gorp.SqlExecutor, name string) ([]sdk.WorkflowNodeHook, error) {
+	res := []NodeHook{}
+	query := `
+		select workflow_node_hook.id, workflow_node_hook.uuid, workflow_node_hook.workflow_node_id, workflow_node_hook.workflow_hook_model_id 
+		from workflow_node_hook
+		join workflow_hook_model on workflow_hook_model.id = workflow_node_hook.workflow_hook_model_id
+		where workflow_hook_model.name = $1`
+	if _, err := db.Select(&res, query, name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, sdk.WrapError(err, "LoadHooksByModelName> Unable to load hooks for model %s", name)
+	}
+	return postLoadHooks(db, res)
+}
+
+func postLoadHooks(db gorp.SqlExecutor, res []NodeHook) ([]sdk.WorkflowNodeHook, error) {
+	models := map[int64]sdk.WorkflowHookModel{}
+	hooks := []sdk.WorkflowNodeHook{}
 	for i := range res {
 		if err := res[i].PostGet(db); err != nil {
-			return nil, sdk.WrapError(err, "loadHooks")
+			return nil, sdk.WrapError(err, "postLoadHooks")
+		}
+		h := sdk.WorkflowNodeHook(res[i])
+		m, ok := models[h.WorkflowHookModelID]
+		if !ok {
+			model, errm := LoadHookModelByID(db, h.WorkflowHookModelID)
+			if errm != nil {
+				return nil, sdk.WrapError(errm, "postLoadHooks> Unable to load model %d", h.WorkflowHookModelID)
+			}
+			m = *model
+			models[m.ID] = m
 		}
-		nodes = append(nodes, sdk.WorkflowNodeHook(res[i]))
+		h.WorkflowHookModel = m
+		hooks = append(hooks, h)
 	}
-	return nodes, nil
+	return hooks, nil
 }
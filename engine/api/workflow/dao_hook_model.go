@@ -17,6 +17,9 @@ var (
 		Identifier: "github.com/ovh/cds/hook/builtin/webhook",
 		Name:       "WebHook",
 		Icon:       "",
+		DefaultConfig: sdk.WorkflowNodeHookConfig{
+			sdk.WorkflowNodeHookConfigWebHookURL: "",
+		},
 	}
 
 	GitPollerModel = &sdk.WorkflowHookModel{
@@ -25,6 +28,10 @@ var (
 		Identifier: "github.com/ovh/cds/hook/builtin/poller",
 		Name:       "Git Repository Poller",
 		Icon:       "",
+		DefaultConfig: sdk.WorkflowNodeHookConfig{
+			sdk.WorkflowNodeHookConfigDelay:        "60",
+			sdk.WorkflowNodeHookConfigPullRequests: "false",
+		},
 	}
 
 	SchedulerModel = &sdk.WorkflowHookModel{
@@ -33,6 +40,11 @@ var (
 		Identifier: "github.com/ovh/cds/hook/builtin/scheduler",
 		Name:       "Scheduler",
 		Icon:       "",
+		DefaultConfig: sdk.WorkflowNodeHookConfig{
+			sdk.WorkflowNodeHookConfigCron:     "0 * * * *",
+			sdk.WorkflowNodeHookConfigTimezone: "UTC",
+			sdk.WorkflowNodeHookConfigPayload:  "{}",
+		},
 	}
 
 	builtinModels = []*sdk.WorkflowHookModel{
@@ -99,6 +111,12 @@ func CreateBuiltinWorkflowHookModels(db *gorp.DbMap) error {
 			if err := InsertHookModel(tx, h); err != nil {
 				return sdk.WrapError(err, "CreateBuiltinWorkflowHookModels")
 			}
+			continue
+		}
+
+		//Keep the builtin models up to date
+		if err := UpdateHookModel(tx, h); err != nil {
+			return sdk.WrapError(err, "CreateBuiltinWorkflowHookModels")
 		}
 	}
 	return tx.Commit()
@@ -0,0 +1,108 @@
+package workflow
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database/gorpmapping"
+	"github.com/ovh/cds/sdk"
+)
+
+//LoadJobCache loads a job cache entry of a project by its key
+func LoadJobCache(db gorp.SqlExecutor, projectID int64, key string) (*sdk.JobCacheEntry, error) {
+	query := `SELECT id, project_id, cache_key, workflow_name, run_number, workflow_node_run_id, artifacts, variables, created
+		FROM workflow_job_cache
+		WHERE project_id = $1 AND cache_key = $2`
+
+	var e sdk.JobCacheEntry
+	var artifacts, variables sql.NullString
+	if err := db.QueryRow(query, projectID, key).Scan(&e.ID, &e.ProjectID, &e.Key, &e.WorkflowName, &e.RunNumber, &e.WorkflowNodeRunID, &artifacts, &variables, &e.Created); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sdk.ErrNotFound
+		}
+		return nil, sdk.WrapError(err, "LoadJobCache> Unable to load cache %s of project %d", key, projectID)
+	}
+	if err := gorpmapping.JSONNullString(artifacts, &e.Artifacts); err != nil {
+		return nil, sdk.WrapError(err, "LoadJobCache> Unable to read artifacts of cache %s", key)
+	}
+	if err := gorpmapping.JSONNullString(variables, &e.Variables); err != nil {
+		return nil, sdk.WrapError(err, "LoadJobCache> Unable to read variables of cache %s", key)
+	}
+	return &e, nil
+}
+
+//InsertJobCache records a job cache entry, it replaces the entry with the same key
+func InsertJobCache(db gorp.SqlExecutor, e *sdk.JobCacheEntry) error {
+	artifacts, err := gorpmapping.JSONToNullString(e.Artifacts)
+	if err != nil {
+		return sdk.WrapError(err, "InsertJobCache> Unable to marshal artifacts")
+	}
+	variables, err := gorpmapping.JSONToNullString(e.Variables)
+	if err != nil {
+		return sdk.WrapError(err, "InsertJobCache> Unable to marshal variables")
+	}
+
+	if _, err := db.Exec("DELETE FROM workflow_job_cache WHERE project_id = $1 AND cache_key = $2", e.ProjectID, e.Key); err != nil {
+		return sdk.WrapError(err, "InsertJobCache> Unable to delete previous cache %s", e.Key)
+	}
+
+	query := `INSERT INTO workflow_job_cache (project_id, cache_key, workflow_name, run_number, workflow_node_run_id, artifacts, variables)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created`
+	if err := db.QueryRow(query, e.ProjectID, e.Key, e.WorkflowName, e.RunNumber, e.WorkflowNodeRunID, artifacts, variables).Scan(&e.ID, &e.Created); err != nil {
+		return sdk.WrapError(err, "InsertJobCache> Unable to insert cache %s", e.Key)
+	}
+	return nil
+}
+
+//DeleteJobCache deletes a job cache entry
+func DeleteJobCache(db gorp.SqlExecutor, id int64) error {
+	if _, err := db.Exec("DELETE FROM workflow_job_cache WHERE id = $1", id); err != nil {
+		return sdk.WrapError(err, "DeleteJobCache> Unable to delete cache %d", id)
+	}
+	return nil
+}
+
+//IsJobCacheValid returns true if all the artifacts of a job cache entry still exist
+func IsJobCacheValid(db gorp.SqlExecutor, e *sdk.JobCacheEntry) (bool, error) {
+	if len(e.Artifacts) == 0 {
+		return true, nil
+	}
+
+	ids := make([]string, len(e.Artifacts))
+	for i := range e.Artifacts {
+		ids[i] = strconv.FormatInt(e.Artifacts[i].ID, 10)
+	}
+	n, err := db.SelectInt("SELECT COUNT(id) FROM workflow_node_run_artifacts WHERE id = ANY(string_to_array($1, ',')::bigint[])", strings.Join(ids, ","))
+	if err != nil {
+		return false, sdk.WrapError(err, "IsJobCacheValid> Unable to count artifacts of cache %s", e.Key)
+	}
+	return int(n) == len(e.Artifacts), nil
+}
+
+//JobCacheArtifacts returns the artifacts of a node run which have been uploaded by a job, given their names and tags
+func JobCacheArtifacts(db gorp.SqlExecutor, nodeRunID int64, uploaded []sdk.WorkflowNodeRunArtifact) ([]sdk.WorkflowNodeRunArtifact, error) {
+	arts, err := loadArtifactByNodeRunID(db, nodeRunID)
+	if err != nil {
+		return nil, sdk.WrapError(err, "JobCacheArtifacts> Unable to load artifacts of node run %d", nodeRunID)
+	}
+
+	res := []sdk.WorkflowNodeRunArtifact{}
+	for _, u := range uploaded {
+		var found bool
+		//The last upload of an artifact wins
+		for i := len(arts) - 1; i >= 0; i-- {
+			if arts[i].Name == u.Name && arts[i].Tag == u.Tag {
+				res = append(res, arts[i])
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, sdk.WrapError(sdk.ErrNotFound, "JobCacheArtifacts> Unable to find artifact %s with tag %s on node run %d", u.Name, u.Tag, nodeRunID)
+		}
+	}
+	return res, nil
+}
@@ -42,7 +42,9 @@ func insertNode(db gorp.SqlExecutor, w *sdk.Workflow, n *sdk.WorkflowNode, u *sd
 		n.PipelineID = n.Pipeline.ID
 	}
 
-	if n.Name == "" {
+	//The node name is computed from the pipeline name only when it is not provided
+	computeName := n.Name == ""
+	if computeName {
 		n.Name = n.Pipeline.Name
 	}
 
@@ -75,7 +77,7 @@ func insertNode(db gorp.SqlExecutor, w *sdk.Workflow, n *sdk.WorkflowNode, u *sd
 		n.Name = pip.Name
 	}
 
-	if nb > 0 {
+	if computeName && nb > 0 {
 		n.Name = fmt.Sprintf("%s_%d", n.Name, nb+1)
 	}
 
@@ -122,6 +124,8 @@ type sqlContext struct {
 	EnvID                     sql.NullInt64  `db:"environment_id"`
 	DefaultPayload            sql.NullString `db:"default_payload"`
 	DefaultPipelineParameters sql.NullString `db:"default_pipeline_parameters"`
+	ProtectedManualRun        bool           `db:"protected_manual_run"`
+	Approval                  sql.NullString `db:"approval"`
 }
 
 func insertNodeContext(db gorp.SqlExecutor, c *sdk.WorkflowNodeContext) error {
@@ -132,6 +136,7 @@ func insertNodeContext(db gorp.SqlExecutor, c *sdk.WorkflowNodeContext) error {
 	var sqlContext = sqlContext{}
 	sqlContext.ID = c.ID
 	sqlContext.WorkflowNodeID = c.WorkflowNodeID
+	sqlContext.ProtectedManualRun = c.ProtectedManualRun
 
 	// Set ApplicationID in context
 	if c.ApplicationID != 0 {
@@ -161,6 +166,15 @@ func insertNodeContext(db gorp.SqlExecutor, c *sdk.WorkflowNodeContext) error {
 		sqlContext.DefaultPipelineParameters = sql.NullString{String: string(b), Valid: true}
 	}
 
+	// Set Approval in context
+	if c.Approval.IsRequired() {
+		b, errM := json.Marshal(c.Approval)
+		if errM != nil {
+			return sdk.WrapError(errM, "InsertOrUpdateNode> Unable to marshall workflow node context(%d) approval", c.ID)
+		}
+		sqlContext.Approval = sql.NullString{String: string(b), Valid: true}
+	}
+
 	if _, err := db.Update(&sqlContext); err != nil {
 		return sdk.WrapError(err, "InsertOrUpdateNode> Unable to update workflow node context(%d)", c.ID)
 	}
@@ -229,9 +243,10 @@ func loadNodeContext(db gorp.SqlExecutor, wn *sdk.WorkflowNode, u *sdk.User) (*s
 
 	var sqlContext = sqlContext{}
 	if err := db.SelectOne(&sqlContext,
-		"select application_id, environment_id, default_payload, default_pipeline_parameters from workflow_node_context where id = $1", ctx.ID); err != nil {
+		"select application_id, environment_id, default_payload, default_pipeline_parameters, protected_manual_run, approval from workflow_node_context where id = $1", ctx.ID); err != nil {
 		return nil, err
 	}
+	ctx.ProtectedManualRun = sqlContext.ProtectedManualRun
 	if sqlContext.AppID.Valid {
 		ctx.ApplicationID = sqlContext.AppID.Int64
 	}
@@ -253,6 +268,14 @@ func loadNodeContext(db gorp.SqlExecutor, wn *sdk.WorkflowNode, u *sdk.User) (*s
 		}
 	}
 
+	//Unmarshal approval
+	if sqlContext.Approval.Valid {
+		ctx.Approval = &sdk.WorkflowNodeApproval{}
+		if err := json.Unmarshal([]byte(sqlContext.Approval.String), ctx.Approval); err != nil {
+			return nil, sdk.WrapError(err, "loadNodeContext> Unable to unmarshall context %d approval", ctx.ID)
+		}
+	}
+
 	//Load the application in the context
 	if ctx.ApplicationID != 0 {
 		app, err := application.LoadByID(db, ctx.ApplicationID, u, application.LoadOptions.WithRepositoryManager)
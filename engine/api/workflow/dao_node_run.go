@@ -93,10 +93,11 @@ type sqlNodeRun struct {
 	Tests              sql.NullString `db:"tests"`
 	Commits            sql.NullString `db:"commits"`
 	Stages             sql.NullString `db:"stages"`
+	Approvals          sql.NullString `db:"approvals"`
 }
 
 //PostInsert is a db hook on WorkflowNodeRun in table workflow_node_run
-//it stores columns hook_event, manual, trigger_id, payload, pipeline_parameters, tests, commits
+//it stores columns hook_event, manual, trigger_id, payload, pipeline_parameters, tests, commits, approvals
 func (r *NodeRun) PostInsert(db gorp.SqlExecutor) error {
 	var rr = sqlNodeRun{ID: r.ID}
 	if r.Stages != nil {
@@ -162,6 +163,13 @@ func (r *NodeRun) PostInsert(db gorp.SqlExecutor) error {
 		}
 		rr.Commits = s
 	}
+	if r.Approvals != nil {
+		s, err := gorpmapping.JSONToNullString(r.Approvals)
+		if err != nil {
+			return sdk.WrapError(err, "NodeRun.PostInsert> unable to get json from approvals")
+		}
+		rr.Approvals = s
+	}
 	if n, err := db.Update(&rr); err != nil {
 		return sdk.WrapError(err, "NodeRun.PostInsert> unable to update workflow_node_run id=%d", rr.ID)
 	} else if n == 0 {
@@ -204,6 +212,9 @@ func (r *NodeRun) PostGet(db gorp.SqlExecutor) error {
 	if err := gorpmapping.JSONNullString(rr.Commits, &r.Commits); err != nil {
 		return sdk.WrapError(err, "NodeRun.PostGet> Error loading node run %d", r.ID)
 	}
+	if err := gorpmapping.JSONNullString(rr.Approvals, &r.Approvals); err != nil {
+		return sdk.WrapError(err, "NodeRun.PostGet> Error loading node run %d", r.ID)
+	}
 	if rr.HookEvent.Valid {
 		r.HookEvent = new(sdk.WorkflowNodeRunHookEvent)
 	}
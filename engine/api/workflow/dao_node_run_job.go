@@ -14,7 +14,7 @@ import (
 	"github.com/ovh/cds/sdk"
 )
 
-// LoadNodeJobRunQueue load all workflow_node_run_job accessible
+// LoadNodeJobRunQueue load all workflow_node_run_job accessible, in the order they should be run
 func LoadNodeJobRunQueue(db gorp.SqlExecutor, groupsID []int64, since *time.Time, statuses ...string) ([]sdk.WorkflowNodeJobRun, error) {
 	if since == nil {
 		since = new(time.Time)
@@ -59,14 +59,37 @@ func LoadNodeJobRunQueue(db gorp.SqlExecutor, groupsID []int64, since *time.Time
 	}
 
 	jobs := make([]sdk.WorkflowNodeJobRun, len(sqlJobs))
+	projectIDs := []int64{}
+	projects := map[int64]bool{}
+	nodeRunIDs := []int64{}
+	nodeRuns := map[int64]bool{}
 	for i := range sqlJobs {
 		if err := sqlJobs[i].PostGet(db); err != nil {
 			return nil, sdk.WrapError(err, "workflow.LoadNodeJobRun> Unable to load job runs")
 		}
 		jobs[i] = sdk.WorkflowNodeJobRun(sqlJobs[i])
+		if !projects[jobs[i].ProjectID] {
+			projects[jobs[i].ProjectID] = true
+			projectIDs = append(projectIDs, jobs[i].ProjectID)
+		}
+		if jobs[i].Job.Matrix != nil && jobs[i].Job.Matrix.MaxParallel > 0 && !nodeRuns[jobs[i].WorkflowNodeRunID] {
+			nodeRuns[jobs[i].WorkflowNodeRunID] = true
+			nodeRunIDs = append(nodeRunIDs, jobs[i].WorkflowNodeRunID)
+		}
 	}
 
-	return jobs, nil
+	matrixRunning, err := loadMatrixQueueSlots(db, nodeRunIDs)
+	if err != nil {
+		return nil, sdk.WrapError(err, "workflow.LoadNodeJobRun> Unable to load job runs")
+	}
+	limitMatrixParallelism(jobs, matrixRunning)
+
+	running, caps, err := loadProjectsQueueSlots(db, projectIDs)
+	if err != nil {
+		return nil, sdk.WrapError(err, "workflow.LoadNodeJobRun> Unable to load job runs")
+	}
+
+	return scheduleNodeJobRunQueue(jobs, running, caps), nil
 }
 
 //LoadNodeJobRun load a NodeJobRun given its ID
@@ -107,6 +130,13 @@ func DeleteNodeJobRuns(db gorp.SqlExecutor, nodeID int64) error {
 	return err
 }
 
+//DeleteNodeJobRun deletes a workflow_node_run_job
+func DeleteNodeJobRun(db gorp.SqlExecutor, id int64) error {
+	query := `delete from workflow_node_run_job where id = $1`
+	_, err := db.Exec(query, id)
+	return err
+}
+
 //UpdateNodeJobRun updates a workflow_node_run_job
 func UpdateNodeJobRun(db gorp.SqlExecutor, j *sdk.WorkflowNodeJobRun) error {
 	dbj := JobRun(*j)
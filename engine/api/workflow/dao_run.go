@@ -111,6 +111,12 @@ func updateTags(db gorp.SqlExecutor, r *Run) error {
 	return nil
 }
 
+//UpdateRunTags stores the tags of a workflow run
+func UpdateRunTags(db gorp.SqlExecutor, r *sdk.WorkflowRun) error {
+	runDB := Run(*r)
+	return updateTags(db, &runDB)
+}
+
 // LoadLastRun returns the last run for a workflow
 func LoadLastRun(db gorp.SqlExecutor, projectkey, workflowname string) (*sdk.WorkflowRun, error) {
 	query := `select workflow_run.*
@@ -949,3 +949,83 @@ func TestInsertSimpleWorkflowWithHook(t *testing.T) {
 
 	test.NoError(t, Delete(db, &w, u))
 }
+
+func TestInsertWorkflowWithPriority(t *testing.T) {
+	db := test.SetupPG(t)
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Priority:   1000,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+		},
+	}
+
+	// The project has no priority
+	test.NoError(t, Insert(db, &w, u))
+	assert.Equal(t, 0, w.Priority)
+
+	_, err := db.Exec("update project set max_workflow_priority = 10 where id = $1", proj.ID)
+	test.NoError(t, err)
+
+	w1, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+	w1.Priority = 1000
+	test.NoError(t, Update(db, w1, &w, u))
+
+	w2, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+	assert.Equal(t, 10, w2.Priority)
+}
+
+func Test_checkHooksUUID(t *testing.T) {
+	hook := func(uuid string) []sdk.WorkflowNodeHook {
+		return []sdk.WorkflowNodeHook{{UUID: uuid}}
+	}
+	oldW := &sdk.Workflow{
+		Root: &sdk.WorkflowNode{
+			ID:    1,
+			Name:  "build",
+			Hooks: hook("uuid-build"),
+			Triggers: []sdk.WorkflowNodeTrigger{
+				{WorkflowDestNode: sdk.WorkflowNode{ID: 2, Name: "deploy", Hooks: hook("uuid-deploy")}},
+			},
+		},
+	}
+
+	w := &sdk.Workflow{
+		Root: &sdk.WorkflowNode{
+			ID:    1,
+			Name:  "build-renamed",
+			Hooks: hook("uuid-build"),
+			Triggers: []sdk.WorkflowNodeTrigger{
+				{WorkflowDestNode: sdk.WorkflowNode{Name: "deploy", Hooks: hook("uuid-deploy")}},
+				{WorkflowDestNode: sdk.WorkflowNode{ID: 3, Name: "test", Hooks: hook("uuid-build")}},
+				{WorkflowDestNode: sdk.WorkflowNode{Name: "other", Hooks: hook("uuid-of-another-workflow")}},
+			},
+		},
+	}
+	checkHooksUUID(w, oldW)
+	assert.Equal(t, "uuid-build", w.Root.Hooks[0].UUID, "the node is matched by its ID")
+	assert.Equal(t, "uuid-deploy", w.Root.Triggers[0].WorkflowDestNode.Hooks[0].UUID, "the node is matched by its name")
+	assert.Empty(t, w.Root.Triggers[1].WorkflowDestNode.Hooks[0].UUID, "the hook is not on the same node")
+	assert.Empty(t, w.Root.Triggers[2].WorkflowDestNode.Hooks[0].UUID, "the hook is not in the old workflow")
+
+	// a new workflow can't reuse any UUID
+	w = &sdk.Workflow{Root: &sdk.WorkflowNode{Name: "build", Hooks: hook("uuid-build")}}
+	checkHooksUUID(w, nil)
+	assert.Empty(t, w.Root.Hooks[0].UUID)
+}
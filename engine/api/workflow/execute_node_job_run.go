@@ -37,7 +37,7 @@ func UpdateNodeJobRunStatus(db gorp.SqlExecutor, job *sdk.WorkflowNodeJobRun, st
 		job.Start = time.Now()
 		job.Status = status.String()
 
-	case sdk.StatusFail, sdk.StatusSuccess, sdk.StatusDisabled, sdk.StatusSkipped:
+	case sdk.StatusFail, sdk.StatusTimeout, sdk.StatusSuccess, sdk.StatusDisabled, sdk.StatusSkipped:
 		if currentStatus != string(sdk.StatusWaiting) && currentStatus != string(sdk.StatusBuilding) && status != sdk.StatusDisabled && status != sdk.StatusSkipped {
 			log.Debug("workflow.UpdateNodeJobRunStatus> Status is %s, cannot update %d to %s", currentStatus, job.ID, status)
 			// too late, Nate
@@ -138,6 +138,13 @@ func TakeNodeJobRun(db gorp.SqlExecutor, id int64, workerModel string, workerNam
 		return nil, sdk.WrapError(sdk.ErrAlreadyTaken, "TakeNodeJobRun> job %d is not waiting status. Current status:%s", id, job.Status)
 	}
 
+	if err := checkProjectQueueSlot(db, job); err != nil {
+		return nil, sdk.WrapError(err, "TakeNodeJobRun> Cannot take job %d", id)
+	}
+	if err := checkMatrixQueueSlot(db, job); err != nil {
+		return nil, sdk.WrapError(err, "TakeNodeJobRun> Cannot take job %d", id)
+	}
+
 	job.Model = workerModel
 	job.Job.WorkerName = workerName
 	job.Job.WorkerID = workerID
@@ -319,5 +326,7 @@ func AddLog(db gorp.SqlExecutor, job *sdk.WorkflowNodeJobRun, logs *sdk.Log) err
 			return sdk.WrapError(err, "AddLog> Cannot update log")
 		}
 	}
+
+	publishLog(logs)
 	return nil
 }
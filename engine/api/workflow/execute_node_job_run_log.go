@@ -2,13 +2,30 @@ package workflow
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/go-gorp/gorp"
 	"github.com/golang/protobuf/ptypes"
+
+	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
 )
 
+//LogsChannel is the cache channel on which the logs sent by the workers are published
+var LogsChannel = cache.Key("workflow", "logs")
+
+//publishLog publishes the received lines of a step, so they can be streamed to the users following the job
+func publishLog(l *sdk.Log) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		log.Warning("publishLog> Unable to marshal log: %v", err)
+		return
+	}
+	cache.Publish(LogsChannel, string(b))
+}
+
 //LoadStepLogs load logs (workflow_node_run_job_logs) for a job (workflow_node_run_job) for a specific step_order
 func LoadStepLogs(db gorp.SqlExecutor, id int64, order int64) (*sdk.Log, error) {
 	query := `
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-gorp/gorp"
@@ -64,6 +65,7 @@ func execute(db gorp.SqlExecutor, n *sdk.WorkflowNodeRun) (err error) {
 		return nil
 	}
 
+	var previousStatus = n.Status
 	var newStatus = n.Status
 
 	//If no stages ==> success
@@ -84,13 +86,21 @@ func execute(db gorp.SqlExecutor, n *sdk.WorkflowNodeRun) (err error) {
 			stage.Status = sdk.StatusWaiting
 			//Add job to Queue
 			//Insert data in workflow_node_run_job
-			if err := addJobsToQueue(db, stage, n); err != nil {
+			if err := addJobsToQueue(db, stage, n, stage.Jobs); err != nil {
 				return err
 			}
 			if stage.Status == sdk.StatusSkipped || stage.Status == sdk.StatusDisabled {
 				continue
 			}
-			break
+			//The jobs needing jobs which are already disabled or failed are queued now
+			if _, err := addJobsWithDoneNeedsToQueue(db, stage, n); err != nil {
+				return err
+			}
+			//Without any job to run, no worker will ever start the stage: it is synchronized now
+			if isStageRunning(stage) {
+				break
+			}
+			stage.Status = sdk.StatusBuilding
 		}
 
 		//If stage is waiting, nothing to do
@@ -102,7 +112,7 @@ func execute(db gorp.SqlExecutor, n *sdk.WorkflowNodeRun) (err error) {
 			newStatus = sdk.StatusBuilding.String()
 
 			var end bool
-			end, errSync := syncStage(db, stage)
+			end, errSync := syncStage(db, n, stage)
 			if errSync != nil {
 				return errSync
 			}
@@ -137,6 +147,10 @@ func execute(db gorp.SqlExecutor, n *sdk.WorkflowNodeRun) (err error) {
 		return sdk.WrapError(err, "workflow.execute> Unable to reload workflow run id=%d", n.WorkflowRunID)
 	}
 
+	if n.Status != previousStatus {
+		event.PublishWorkflowNodeRun(updatedWorkflowRun, n)
+	}
+
 	// If pipeline build succeed, reprocess the workflow (in the same transaction)
 	if n.Status == sdk.StatusSuccess.String() {
 		if err := processWorkflowRun(db, updatedWorkflowRun, nil, nil, nil); err != nil {
@@ -150,12 +164,18 @@ func execute(db gorp.SqlExecutor, n *sdk.WorkflowNodeRun) (err error) {
 		if err := DeleteNodeJobRuns(db, n.ID); err != nil {
 			return sdk.WrapError(err, "workflow.execute> Unable to delete node %d job runs ", n.ID)
 		}
+
+		if !isRunning(updatedWorkflowRun) {
+			event.PublishWorkflowRun(updatedWorkflowRun)
+		}
 	}
 
 	return nil
 }
 
-func addJobsToQueue(db gorp.SqlExecutor, stage *sdk.Stage, run *sdk.WorkflowNodeRun) error {
+//addJobsToQueue queues the jobs of the stage. The jobs which need other jobs of the stage are queued by syncStage
+//once their needs are done.
+func addJobsToQueue(db gorp.SqlExecutor, stage *sdk.Stage, run *sdk.WorkflowNodeRun, jobs []sdk.Job) error {
 	log.Debug("addJobsToQueue> add %d in stage %s", run.ID, stage.Name)
 
 	conditionsOK, err := sdk.WorkflowCheckConditions(stage.Conditions(), run.BuildParameters)
@@ -171,67 +191,104 @@ func addJobsToQueue(db gorp.SqlExecutor, stage *sdk.Stage, run *sdk.WorkflowNode
 		stage.Status = sdk.StatusDisabled
 	}
 
+	//The jobs are scheduled with the priority of the workflow, see LoadNodeJobRunQueue
+	var projectID int64
+	var priority int
+	query := `select workflow.project_id, workflow.priority
+	from workflow
+	join workflow_run on workflow_run.workflow_id = workflow.id
+	where workflow_run.id = $1`
+	if err := db.QueryRow(query, run.WorkflowRunID).Scan(&projectID, &priority); err != nil {
+		return sdk.WrapError(err, "addJobsToQueue> Unable to load workflow of run %d", run.WorkflowRunID)
+	}
+
 	//Browse the jobs
-	for _, job := range stage.Jobs {
-		//Process variables for the jobs
-		jobParams, errParam := getNodeJobRunParameters(db, job, run, stage)
-
-		//Create the job run
-		job := sdk.WorkflowNodeJobRun{
-			WorkflowNodeRunID: run.ID,
-			Start:             time.Time{},
-			Queued:            time.Now(),
-			Status:            sdk.StatusWaiting.String(),
-			Parameters:        jobParams,
-			Job: sdk.ExecutedJob{
-				Job: job,
-			},
-		}
-
-		if !stage.Enabled || !job.Job.Enabled {
-			job.Status = sdk.StatusDisabled.String()
-		} else if !conditionsOK {
-			job.Status = sdk.StatusSkipped.String()
-		}
-
-		if errParam != nil {
-			job.Status = sdk.StatusFail.String()
-
-			errm, ok := errParam.(*sdk.MultiError)
-			spawnInfos := sdk.SpawnMsg{
-				ID: sdk.MsgSpawnInfoJobError.ID,
+	for i := range jobs {
+		needs, need := jobNeedsStatus(jobs[i], stage)
+		if conditionsOK && stage.Enabled && needs == sdk.StatusWaiting {
+			continue
+		}
+
+		//A matrix job runs once per combination of its matrix
+		expanded, errMatrix := jobs[i].ExpandMatrix()
+		if errMatrix != nil {
+			expanded = []sdk.Job{jobs[i]}
+		}
+
+		for _, job := range expanded {
+			//Process variables for the jobs
+			jobParams, errParam := getNodeJobRunParameters(db, job, run, stage)
+
+			//Create the job run
+			job := sdk.WorkflowNodeJobRun{
+				WorkflowNodeRunID: run.ID,
+				Start:             time.Time{},
+				Queued:            time.Now(),
+				Status:            sdk.StatusWaiting.String(),
+				Parameters:        jobParams,
+				Job: sdk.ExecutedJob{
+					Job: job,
+				},
+				ProjectID: projectID,
+				Priority:  priority,
 			}
 
-			if ok {
-				for _, e := range *errm {
-					spawnInfos.Args = append(spawnInfos.Args, e.Error())
-				}
-			} else {
-				spawnInfos.Args = []interface{}{errParam.Error()}
+			if !stage.Enabled || !job.Job.Enabled {
+				job.Status = sdk.StatusDisabled.String()
+			} else if !conditionsOK {
+				job.Status = sdk.StatusSkipped.String()
+			} else if needs == sdk.StatusFail {
+				job.Status = sdk.StatusSkipped.String()
+				job.SpawnInfos = []sdk.SpawnInfo{{
+					APITime:    time.Now(),
+					Message:    sdk.SpawnMsg{ID: sdk.MsgSpawnInfoJobNeedsFailed.ID, Args: []interface{}{need}},
+					RemoteTime: time.Now(),
+				}}
 			}
 
-			job.SpawnInfos = []sdk.SpawnInfo{sdk.SpawnInfo{
-				APITime:    time.Now(),
-				Message:    spawnInfos,
-				RemoteTime: time.Now(),
-			}}
+			if errMatrix != nil || errParam != nil {
+				job.Status = sdk.StatusFail.String()
+				job.SpawnInfos = []sdk.SpawnInfo{sdk.SpawnInfo{
+					APITime:    time.Now(),
+					Message:    jobErrorSpawnMsg(errMatrix, errParam),
+					RemoteTime: time.Now(),
+				}}
+			}
 
-		}
+			//Insert in database
+			if err := insertWorkflowNodeJobRun(db, &job); err != nil {
+				return sdk.WrapError(err, "addJobsToQueue> Unable to insert in table workflow_node_run_job")
+			}
 
-		//Insert in database
-		if err := insertWorkflowNodeJobRun(db, &job); err != nil {
-			return sdk.WrapError(err, "addJobsToQueue> Unable to insert in table workflow_node_run_job")
+			//Put the job run in database
+			event.PublishJobRun(run, &job)
+			stage.RunJobs = append(stage.RunJobs, job)
 		}
-
-		//Put the job run in database
-		event.PublishJobRun(run, &job)
-		stage.RunJobs = append(stage.RunJobs, job)
 	}
 
 	return nil
 }
 
-func syncStage(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
+//jobErrorSpawnMsg returns the spawn message of a job which can't be run, with the error of its matrix
+//and the errors of its parameters
+func jobErrorSpawnMsg(errs ...error) sdk.SpawnMsg {
+	msgs := []string{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if errm, ok := err.(*sdk.MultiError); ok {
+			for _, e := range *errm {
+				msgs = append(msgs, e.Error())
+			}
+			continue
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return sdk.SpawnMsg{ID: sdk.MsgSpawnInfoJobError.ID, Args: []interface{}{strings.Join(msgs, ", ")}}
+}
+
+func syncStage(db gorp.SqlExecutor, run *sdk.WorkflowNodeRun, stage *sdk.Stage) (bool, error) {
 	stageEnd := true
 	finalStatus := sdk.StatusBuilding
 
@@ -263,6 +320,23 @@ func syncStage(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
 			}
 		}
 	}
+
+	//Stop the other combinations of a fail fast matrix as soon as one of them has failed
+	stopped, errStop := stopFailFastMatrixJobs(db, stage)
+	if errStop != nil {
+		return stageEnd, errStop
+	}
+
+	//Queue the jobs whose needs are done
+	queued, errQueue := addJobsWithDoneNeedsToQueue(db, stage, run)
+	if errQueue != nil {
+		return stageEnd, errQueue
+	}
+
+	if stopped || queued || stageEnd {
+		stageEnd = !isStageRunning(stage)
+	}
+
 	if stageEnd || len(stage.RunJobs) == 0 {
 		if len(stage.PipelineBuildJobs) == 0 {
 			finalStatus = sdk.StatusSuccess
@@ -277,10 +351,17 @@ func syncStage(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
 					finalStatus = sdk.StatusDisabled
 				}
 			case sdk.StatusSkipped.String():
+				//The result of a job restored from the cache is a success
+				if isJobRunRestoredFromCache(runJob) {
+					if finalStatus != sdk.StatusFail {
+						finalStatus = sdk.StatusSuccess
+					}
+					continue
+				}
 				if finalStatus == sdk.StatusBuilding || finalStatus == sdk.StatusDisabled {
 					finalStatus = sdk.StatusSkipped
 				}
-			case sdk.StatusFail.String():
+			case sdk.StatusFail.String(), sdk.StatusTimeout.String():
 				finalStatus = sdk.StatusFail
 				break finalStageLoop
 			case sdk.StatusSuccess.String():
@@ -295,6 +376,141 @@ func syncStage(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
 	return stageEnd, nil
 }
 
+//isJobRunRestoredFromCache returns true if the job has been skipped because its result has been restored from the cache
+func isJobRunRestoredFromCache(runJob sdk.WorkflowNodeJobRun) bool {
+	if runJob.Status != sdk.StatusSkipped.String() {
+		return false
+	}
+	for _, info := range runJob.SpawnInfos {
+		if info.Message.ID == sdk.MsgSpawnInfoJobCached.ID {
+			return true
+		}
+	}
+	return false
+}
+
+//jobNeedsStatus returns Success if all the jobs needed by j have succeeded, are disabled or have been restored from the cache,
+//Waiting if one of them is not done and Fail, with the name of the job, if one of them has not succeeded
+func jobNeedsStatus(j sdk.Job, stage *sdk.Stage) (sdk.Status, string) {
+	status := sdk.StatusSuccess
+	for _, name := range j.Needs {
+		var needed *sdk.Job
+		for i := range stage.Jobs {
+			if stage.Jobs[i].Action.Name == name {
+				needed = &stage.Jobs[i]
+				break
+			}
+		}
+		if needed == nil {
+			return sdk.StatusFail, name
+		}
+
+		if !isJobQueued(*needed, stage) {
+			status = sdk.StatusWaiting
+			continue
+		}
+		for _, runJob := range stage.RunJobs {
+			if runJob.Job.PipelineActionID != needed.PipelineActionID {
+				continue
+			}
+			switch {
+			case runJob.Status == sdk.StatusSuccess.String(), runJob.Status == sdk.StatusDisabled.String():
+			case isJobRunRestoredFromCache(runJob):
+			case runJob.Status == sdk.StatusWaiting.String(), runJob.Status == sdk.StatusBuilding.String():
+				status = sdk.StatusWaiting
+			default:
+				return sdk.StatusFail, name
+			}
+		}
+	}
+	return status, ""
+}
+
+//isStageRunning returns true if a job of the stage is waiting, building or waiting for its needs
+func isStageRunning(stage *sdk.Stage) bool {
+	for _, runJob := range stage.RunJobs {
+		if runJob.Status == sdk.StatusBuilding.String() || runJob.Status == sdk.StatusWaiting.String() {
+			return true
+		}
+	}
+	//The jobs still waiting for their needs are not in the queue yet
+	for _, j := range stage.Jobs {
+		if !isJobQueued(j, stage) {
+			if needs, _ := jobNeedsStatus(j, stage); needs == sdk.StatusWaiting {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//isJobQueued returns true if the job, or one of the combinations of its matrix, has been queued
+func isJobQueued(j sdk.Job, stage *sdk.Stage) bool {
+	for _, runJob := range stage.RunJobs {
+		if runJob.Job.PipelineActionID == j.PipelineActionID {
+			return true
+		}
+	}
+	return false
+}
+
+//addJobsWithDoneNeedsToQueue queues the jobs of the stage whose needs are done, until no other job can be queued
+func addJobsWithDoneNeedsToQueue(db gorp.SqlExecutor, stage *sdk.Stage, run *sdk.WorkflowNodeRun) (bool, error) {
+	var queued bool
+	for {
+		jobs := []sdk.Job{}
+		for _, j := range stage.Jobs {
+			if len(j.Needs) == 0 || isJobQueued(j, stage) {
+				continue
+			}
+			if needs, _ := jobNeedsStatus(j, stage); needs != sdk.StatusWaiting {
+				jobs = append(jobs, j)
+			}
+		}
+		if len(jobs) == 0 {
+			return queued, nil
+		}
+		if err := addJobsToQueue(db, stage, run, jobs); err != nil {
+			return queued, sdk.WrapError(err, "addJobsWithDoneNeedsToQueue> Unable to queue jobs")
+		}
+		queued = true
+	}
+}
+
+//stopFailFastMatrixJobs stops the waiting and building combinations of the fail fast matrix jobs which have a failed combination.
+//The stopped jobs are removed from the queue so the workers which hold them cancel their steps.
+func stopFailFastMatrixJobs(db gorp.SqlExecutor, stage *sdk.Stage) (bool, error) {
+	failed := map[int64]bool{}
+	for _, runJob := range stage.RunJobs {
+		if runJob.Job.Matrix == nil || !runJob.Job.Matrix.FailFast {
+			continue
+		}
+		if runJob.Status == sdk.StatusFail.String() || runJob.Status == sdk.StatusTimeout.String() {
+			failed[runJob.Job.PipelineActionID] = true
+		}
+	}
+	if len(failed) == 0 {
+		return false, nil
+	}
+
+	var stopped bool
+	now := time.Now()
+	for i := range stage.RunJobs {
+		runJob := &stage.RunJobs[i]
+		if !failed[runJob.Job.PipelineActionID] || (runJob.Status != sdk.StatusWaiting.String() && runJob.Status != sdk.StatusBuilding.String()) {
+			continue
+		}
+		if err := DeleteNodeJobRun(db, runJob.ID); err != nil {
+			return stopped, sdk.WrapError(err, "stopFailFastMatrixJobs> Unable to delete job run %d", runJob.ID)
+		}
+		log.Debug("stopFailFastMatrixJobs> stop job %s (%d)", runJob.Job.Action.Name, runJob.ID)
+		runJob.Status = sdk.StatusStopped.String()
+		runJob.Done = now
+		stopped = true
+	}
+	return stopped, nil
+}
+
 //NodeBuildParameters returns build_parameters for a node given its id
 func NodeBuildParameters(proj *sdk.Project, wf *sdk.Workflow, wr *sdk.WorkflowRun, id int64, u *sdk.User) ([]sdk.Parameter, error) {
 	refNode := wf.GetNode(id)
@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_jobNeedsStatus(t *testing.T) {
+	job := func(id int64, name string, needs ...string) sdk.Job {
+		return sdk.Job{PipelineActionID: id, Action: sdk.Action{Name: name}, Needs: needs}
+	}
+	runJob := func(j sdk.Job, status sdk.Status) sdk.WorkflowNodeJobRun {
+		return sdk.WorkflowNodeJobRun{Status: status.String(), Job: sdk.ExecutedJob{Job: j}}
+	}
+
+	test, lint, pkg := job(1, "test"), job(2, "lint"), job(3, "package", "test", "lint")
+	stage := &sdk.Stage{Jobs: []sdk.Job{test, lint, pkg, job(4, "deploy", "build")}}
+
+	// the jobs needed are not queued yet
+	status, _ := jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusWaiting, status)
+
+	stage.RunJobs = []sdk.WorkflowNodeJobRun{runJob(test, sdk.StatusSuccess), runJob(lint, sdk.StatusBuilding)}
+	status, _ = jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusWaiting, status)
+
+	stage.RunJobs[1].Status = sdk.StatusDisabled.String()
+	status, _ = jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusSuccess, status)
+
+	// a job restored from the cache is skipped with its result
+	stage.RunJobs[1].Status = sdk.StatusSkipped.String()
+	stage.RunJobs[1].SpawnInfos = []sdk.SpawnInfo{{Message: sdk.SpawnMsg{ID: sdk.MsgSpawnInfoJobCached.ID}}}
+	status, _ = jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusSuccess, status)
+
+	stage.RunJobs[1].SpawnInfos = nil
+	status, _ = jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusFail, status)
+
+	stage.RunJobs[1].Status = sdk.StatusFail.String()
+	status, need := jobNeedsStatus(pkg, stage)
+	assert.Equal(t, sdk.StatusFail, status)
+	assert.Equal(t, "lint", need)
+
+	// a job without needs can always start
+	status, _ = jobNeedsStatus(test, stage)
+	assert.Equal(t, sdk.StatusSuccess, status)
+
+	// a job needing an unknown job can never start
+	status, need = jobNeedsStatus(stage.Jobs[3], stage)
+	assert.Equal(t, sdk.StatusFail, status)
+	assert.Equal(t, "build", need)
+}
+
+func Test_isStageRunning(t *testing.T) {
+	test := sdk.Job{PipelineActionID: 1, Action: sdk.Action{Name: "test"}}
+	pkg := sdk.Job{PipelineActionID: 2, Action: sdk.Action{Name: "package"}, Needs: []string{"test"}}
+	stage := &sdk.Stage{Jobs: []sdk.Job{pkg, test}}
+
+	// package is waiting for its needs
+	assert.True(t, isStageRunning(stage))
+
+	stage.RunJobs = []sdk.WorkflowNodeJobRun{{Status: sdk.StatusWaiting.String(), Job: sdk.ExecutedJob{Job: test}}}
+	assert.True(t, isStageRunning(stage))
+
+	// test has been queued as disabled after package: package can be queued, nothing else runs
+	stage.RunJobs[0].Status = sdk.StatusDisabled.String()
+	assert.False(t, isStageRunning(stage))
+
+	stage.RunJobs = append(stage.RunJobs, sdk.WorkflowNodeJobRun{Status: sdk.StatusSkipped.String(), Job: sdk.ExecutedJob{Job: pkg}})
+	assert.False(t, isStageRunning(stage))
+}
+
+func Test_syncStageWithCachedJobs(t *testing.T) {
+	cached := sdk.WorkflowNodeJobRun{
+		Status: sdk.StatusSkipped.String(),
+		SpawnInfos: []sdk.SpawnInfo{
+			{Message: sdk.SpawnMsg{ID: sdk.MsgSpawnInfoJobCached.ID, Args: []interface{}{"build", 1}}},
+		},
+	}
+	failed := sdk.WorkflowNodeJobRun{Status: sdk.StatusFail.String()}
+
+	stage := &sdk.Stage{Enabled: true, RunJobs: []sdk.WorkflowNodeJobRun{cached, cached}}
+	end, err := syncStage(nil, &sdk.WorkflowNodeRun{}, stage)
+	assert.NoError(t, err)
+	assert.True(t, end)
+	assert.Equal(t, sdk.StatusSuccess, stage.Status)
+
+	stage = &sdk.Stage{Enabled: true, RunJobs: []sdk.WorkflowNodeJobRun{failed, cached}}
+	_, err = syncStage(nil, &sdk.WorkflowNodeRun{}, stage)
+	assert.NoError(t, err)
+	assert.Equal(t, sdk.StatusFail, stage.Status)
+
+	assert.True(t, isJobRunRestoredFromCache(cached))
+	assert.False(t, isJobRunRestoredFromCache(sdk.WorkflowNodeJobRun{Status: sdk.StatusSkipped.String()}))
+}
+
+func Test_jobErrorSpawnMsg(t *testing.T) {
+	job := sdk.Job{Action: sdk.Action{Name: "build"}, Matrix: &sdk.JobMatrix{Variables: map[string][]string{"go": nil}}}
+	_, errMatrix := job.ExpandMatrix()
+	assert.Error(t, errMatrix)
+
+	msg := jobErrorSpawnMsg(errMatrix, nil)
+	assert.Equal(t, sdk.MsgSpawnInfoJobError.ID, msg.ID)
+	assert.Len(t, msg.Args, 1)
+	assert.Contains(t, msg.Args[0], "job build: variable go has no value")
+
+	errParam := &sdk.MultiError{}
+	errParam.Append(fmt.Errorf("unknown variable foo"))
+	msg = jobErrorSpawnMsg(errMatrix, errParam)
+	assert.Len(t, msg.Args, 1)
+	assert.Contains(t, msg.Args[0], "variable go has no value")
+	assert.Contains(t, msg.Args[0], "unknown variable foo")
+}
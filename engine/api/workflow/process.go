@@ -7,6 +7,7 @@ import (
 	"github.com/fsamin/go-dump"
 	"github.com/go-gorp/gorp"
 
+	"github.com/ovh/cds/engine/api/event"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
 )
@@ -70,6 +71,7 @@ func processWorkflowRun(db gorp.SqlExecutor, w *sdk.WorkflowRun, hookEvent *sdk.
 					//Check conditions
 					var params = nodeRun.BuildParameters
 					//Define specific destination parameters
+					sdk.AddParameter(&params, "workflow."+node.Name+".status", sdk.StringParameter, nodeRun.Status)
 					sdk.AddParameter(&params, "cds.dest.pipeline", sdk.StringParameter, t.WorkflowDestNode.Pipeline.Name)
 					if t.WorkflowDestNode.Context.Application != nil {
 						sdk.AddParameter(&params, "cds.dest.application", sdk.StringParameter, t.WorkflowDestNode.Context.Application.Name)
@@ -158,6 +160,9 @@ func processWorkflowRun(db gorp.SqlExecutor, w *sdk.WorkflowRun, hookEvent *sdk.
 			nodeRunIDs = append(nodeRunIDs, nodeRun.ID)
 			//Merge build parameters from all sources
 			sourcesParams = sdk.ParametersMapMerge(sourcesParams, sdk.ParametersToMap(nodeRun.BuildParameters))
+			if node := w.Workflow.GetNode(nodeRun.WorkflowNodeID); node != nil {
+				sourcesParams["workflow."+node.Name+".status"] = nodeRun.Status
+			}
 		}
 
 		//All the sources are completed
@@ -319,11 +324,16 @@ func processWorkflowNodeRun(db gorp.SqlExecutor, w *sdk.WorkflowRun, n *sdk.Work
 	}
 	for _, p := range jobParams {
 		switch p.Name {
-		case "git.hash", "git.branch", "git.tag", "git.author":
+		case "git.hash", "git.branch", "git.tag", "git.author", "cds.pr.id":
 			w.Tag(p.Name, p.Value)
 		}
 	}
 
+	//The node run waits for the approvals before being executed
+	if n.Context != nil && n.Context.Approval.IsRequired() {
+		run.Status = sdk.StatusWaitingApproval.String()
+	}
+
 	if err := insertWorkflowNodeRun(db, run); err != nil {
 		return sdk.WrapError(err, "processWorkflowNodeRun> unable to insert run")
 	}
@@ -334,11 +344,25 @@ func processWorkflowNodeRun(db gorp.SqlExecutor, w *sdk.WorkflowRun, n *sdk.Work
 	}
 	w.WorkflowNodeRuns[run.WorkflowNodeID] = append(w.WorkflowNodeRuns[run.WorkflowNodeID], *run)
 
+	if run.Status == sdk.StatusWaitingApproval.String() {
+		AddWorkflowRunInfo(w, sdk.SpawnMsg{
+			ID:   sdk.MsgWorkflowNodeWaitingApproval.ID,
+			Args: []interface{}{n.Pipeline.Name, n.Context.Approval.NbApprovers},
+		})
+	}
+
 	//Update the workflow run
 	if err := updateWorkflowRun(db, w); err != nil {
 		return sdk.WrapError(err, "processWorkflowNodeRun> unable to update workflow run")
 	}
 
+	event.PublishWorkflowNodeRun(w, run)
+
+	if run.Status == sdk.StatusWaitingApproval.String() {
+		event.PublishWorkflowNodeRunApproval(w, run, nil)
+		return nil
+	}
+
 	//Execute the node run !
 	if err := execute(db, run); err != nil {
 		return sdk.WrapError(err, "processWorkflowNodeRun> unable to execute workflow run")
@@ -347,6 +371,138 @@ func processWorkflowNodeRun(db gorp.SqlExecutor, w *sdk.WorkflowRun, n *sdk.Work
 	return nil
 }
 
+//processWorkflowNodeRunRestart creates a new subnumber of a failed or stopped node run.
+//Successful stages and jobs are kept, only failed and stopped jobs are requeued.
+func processWorkflowNodeRunRestart(db gorp.SqlExecutor, w *sdk.WorkflowRun, previous *sdk.WorkflowNodeRun, m *sdk.WorkflowNodeRunManual) error {
+	if previous.Status != sdk.StatusFail.String() && previous.Status != sdk.StatusStopped.String() {
+		return sdk.ErrWorkflowNodeRunNotRestartable
+	}
+
+	//A node run which has not been approved must be run again to ask for approval
+	var approval *sdk.WorkflowNodeApproval
+	if node := w.Workflow.GetNode(previous.WorkflowNodeID); node != nil && node.Context != nil {
+		approval = node.Context.Approval
+	}
+	if !previous.IsApproved(approval) {
+		return sdk.ErrWorkflowNodeRunNotRestartable
+	}
+
+	//The new subnumber must not be used by any node run of the workflow run
+	var subnumber int64
+	for _, nodeRuns := range w.WorkflowNodeRuns {
+		for _, nodeRun := range nodeRuns {
+			if nodeRun.SubNumber >= subnumber {
+				subnumber = nodeRun.SubNumber + 1
+			}
+		}
+	}
+
+	log.Debug("processWorkflowNodeRunRestart> Restart [#%d.%d]%s.%d as subnumber %d", w.Number, previous.SubNumber, w.Workflow.Name, previous.WorkflowNodeID, subnumber)
+
+	if m != nil {
+		m.Payload = previous.Payload
+		m.PipelineParameters = previous.PipelineParameters
+	}
+
+	run := &sdk.WorkflowNodeRun{
+		LastModified:       time.Now(),
+		Start:              time.Now(),
+		Number:             w.Number,
+		SubNumber:          subnumber,
+		WorkflowRunID:      w.ID,
+		WorkflowNodeID:     previous.WorkflowNodeID,
+		Status:             string(sdk.StatusWaiting),
+		SourceNodeRuns:     previous.SourceNodeRuns,
+		HookEvent:          previous.HookEvent,
+		Manual:             m,
+		Payload:            previous.Payload,
+		PipelineParameters: previous.PipelineParameters,
+		BuildParameters:    previous.BuildParameters,
+		Commits:            previous.Commits,
+		Approvals:          previous.Approvals,
+	}
+	if m == nil {
+		run.Manual = previous.Manual
+	}
+
+	//Keep the successful stages, restart the first failed one and reset the others
+	stages := make([]sdk.Stage, len(previous.Stages))
+	copy(stages, previous.Stages)
+	restartIndex := -1
+	for i := range stages {
+		s := &stages[i]
+		if restartIndex == -1 {
+			switch s.Status {
+			case sdk.StatusSuccess, sdk.StatusSkipped, sdk.StatusDisabled:
+				continue
+			}
+			restartIndex = i
+			s.Status = sdk.StatusWaiting
+			continue
+		}
+		s.Status = ""
+		s.RunJobs = nil
+	}
+	if restartIndex == -1 && len(stages) > 0 {
+		return sdk.ErrWorkflowNodeRunNotRestartable
+	}
+	run.Stages = stages
+
+	if err := insertWorkflowNodeRun(db, run); err != nil {
+		return sdk.WrapError(err, "processWorkflowNodeRunRestart> unable to insert run")
+	}
+
+	//Reuse the artifacts of the previous node run
+	for _, a := range previous.Artifacts {
+		a.ID = 0
+		a.WorkflowNodeRunID = run.ID
+		if err := InsertArtifact(db, &a); err != nil {
+			return sdk.WrapError(err, "processWorkflowNodeRunRestart> unable to copy artifact %s", a.Name)
+		}
+		run.Artifacts = append(run.Artifacts, a)
+	}
+
+	//Requeue the failed and stopped jobs of the restarted stage
+	if restartIndex != -1 {
+		stage := &run.Stages[restartIndex]
+		jobsToRestart := []sdk.Job{}
+		runJobs := []sdk.WorkflowNodeJobRun{}
+		for _, rj := range stage.RunJobs {
+			switch {
+			case rj.Status == sdk.StatusSuccess.String(), rj.Status == sdk.StatusDisabled.String():
+				runJobs = append(runJobs, rj)
+			//The jobs skipped because of their needs are restarted with the jobs they need
+			case rj.Status == sdk.StatusSkipped.String() && len(rj.Job.Needs) == 0:
+				runJobs = append(runJobs, rj)
+			default:
+				jobsToRestart = append(jobsToRestart, rj.Job.Job)
+			}
+		}
+		//A stage may have been stopped before its jobs were queued
+		if len(stage.RunJobs) == 0 {
+			jobsToRestart = stage.Jobs
+		}
+
+		stage.RunJobs = runJobs
+		if err := addJobsToQueue(db, stage, run, jobsToRestart); err != nil {
+			return sdk.WrapError(err, "processWorkflowNodeRunRestart> unable to requeue jobs")
+		}
+	}
+
+	//Update workflow run
+	w.WorkflowNodeRuns[run.WorkflowNodeID] = append(w.WorkflowNodeRuns[run.WorkflowNodeID], *run)
+	if err := updateWorkflowRun(db, w); err != nil {
+		return sdk.WrapError(err, "processWorkflowNodeRunRestart> unable to update workflow run")
+	}
+
+	//Execute the node run !
+	if err := execute(db, run); err != nil {
+		return sdk.WrapError(err, "processWorkflowNodeRunRestart> unable to execute workflow run")
+	}
+
+	return nil
+}
+
 // AddWorkflowRunInfo add WorkflowRunInfo on a WorkflowRun
 func AddWorkflowRunInfo(run *sdk.WorkflowRun, infos ...sdk.SpawnMsg) {
 	for _, i := range infos {
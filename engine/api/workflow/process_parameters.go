@@ -18,6 +18,9 @@ func getNodeJobRunParameters(db gorp.SqlExecutor, j sdk.Job, run *sdk.WorkflowNo
 
 	tmp["cds.stage"] = stage.Name
 	tmp["cds.job"] = j.Action.Name
+	for k, v := range j.MatrixValues {
+		tmp["cds.matrix."+k] = v
+	}
 	errm := &sdk.MultiError{}
 
 	for k, v := range tmp {
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//isRunning returns true if one of the node runs of the workflow run is not over
+func isRunning(wr *sdk.WorkflowRun) bool {
+	for _, nodeRuns := range wr.WorkflowNodeRuns {
+		for _, nodeRun := range nodeRuns {
+			if sdk.Status(nodeRun.Status).IsRunning() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//hasTag returns true if the workflow run has been tagged with the value
+func hasTag(wr *sdk.WorkflowRun, tag, value string) bool {
+	for _, t := range wr.Tags {
+		if t.Tag != tag {
+			continue
+		}
+		for _, v := range strings.Split(t.Value, ",") {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//loadRunsByTag loads the runs of a workflow tagged with the value
+func loadRunsByTag(db gorp.SqlExecutor, workflowID int64, tag, value string) ([]sdk.WorkflowRun, error) {
+	query := `select distinct workflow_run.id
+	from workflow_run
+	join workflow_run_tag on workflow_run_tag.workflow_run_id = workflow_run.id
+	where workflow_run.workflow_id = $1
+	and workflow_run_tag.tag = $2
+	and workflow_run_tag.value = $3`
+
+	var ids []int64
+	if _, err := db.Select(&ids, query, workflowID, tag, value); err != nil {
+		return nil, sdk.WrapError(err, "loadRunsByTag> Unable to load runs with tag %s=%s", tag, value)
+	}
+
+	runs := make([]sdk.WorkflowRun, 0, len(ids))
+	for _, id := range ids {
+		wr, err := LoadRunByID(db, id)
+		if err != nil {
+			return nil, sdk.WrapError(err, "loadRunsByTag> Unable to load run %d", id)
+		}
+		runs = append(runs, *wr)
+	}
+	return runs, nil
+}
+
+//pullRequestRuns returns true if one of the runs of the pull request has built the commit,
+//and the runs of the previous commits which are still running
+func pullRequestRuns(runs []sdk.WorkflowRun, hash string) (bool, []sdk.WorkflowRun) {
+	outdated := []sdk.WorkflowRun{}
+	for i := range runs {
+		if hasTag(&runs[i], "git.hash", hash) {
+			return true, nil
+		}
+		if isRunning(&runs[i]) {
+			outdated = append(outdated, runs[i])
+		}
+	}
+	return false, outdated
+}
+
+//stopOutdatedPullRequestRuns stops the runs of the previous commits of a pull request.
+//It returns true if the commit has already been built.
+func stopOutdatedPullRequestRuns(db gorp.SqlExecutor, w *sdk.Workflow, prID, hash string) (bool, error) {
+	runs, err := loadRunsByTag(db, w.ID, "cds.pr.id", prID)
+	if err != nil {
+		return false, sdk.WrapError(err, "stopOutdatedPullRequestRuns>")
+	}
+
+	built, outdated := pullRequestRuns(runs, hash)
+	if built {
+		return true, nil
+	}
+
+	for i := range outdated {
+		wr := &outdated[i]
+		log.Info("stopOutdatedPullRequestRuns> Stopping run %d of workflow %s/%s, pull request %s has been updated with %s", wr.Number, w.ProjectKey, w.Name, prID, hash)
+		AddWorkflowRunInfo(wr, sdk.SpawnMsg{
+			ID:   sdk.MsgWorkflowRunOutdated.ID,
+			Args: []interface{}{prID, hash},
+		})
+		//A run which can't be stopped now will end by itself. Its stop is rolled back alone: a failed statement,
+		//ie. the lock of the run, would abort the whole transaction of the new run
+		if _, err := db.Exec("SAVEPOINT stop_outdated_run"); err != nil {
+			return false, sdk.WrapError(err, "stopOutdatedPullRequestRuns> Unable to create savepoint")
+		}
+		if err := StopWorkflowRun(db, wr, nil); err != nil {
+			log.Warning("stopOutdatedPullRequestRuns> Unable to stop run %d of workflow %s/%s: %s", wr.Number, w.ProjectKey, w.Name, err)
+			if _, err := db.Exec("ROLLBACK TO SAVEPOINT stop_outdated_run"); err != nil {
+				return false, sdk.WrapError(err, "stopOutdatedPullRequestRuns> Unable to rollback to savepoint")
+			}
+			continue
+		}
+		if _, err := db.Exec("RELEASE SAVEPOINT stop_outdated_run"); err != nil {
+			return false, sdk.WrapError(err, "stopOutdatedPullRequestRuns> Unable to release savepoint")
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,37 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func TestPullRequestRuns(t *testing.T) {
+	newRun := func(number int64, hash string, status sdk.Status) sdk.WorkflowRun {
+		wr := sdk.WorkflowRun{
+			Number:           number,
+			WorkflowNodeRuns: map[int64][]sdk.WorkflowNodeRun{1: {{Status: status.String()}}},
+		}
+		wr.Tag("cds.pr.id", "3")
+		wr.Tag("git.hash", hash)
+		return wr
+	}
+
+	runs := []sdk.WorkflowRun{
+		newRun(1, "c1", sdk.StatusSuccess),
+		newRun(2, "c2", sdk.StatusBuilding),
+		newRun(3, "c3", sdk.StatusWaitingApproval),
+	}
+
+	built, outdated := pullRequestRuns(runs, "c4")
+	assert.False(t, built)
+	assert.Len(t, outdated, 2)
+	assert.Equal(t, int64(2), outdated[0].Number)
+	assert.Equal(t, int64(3), outdated[1].Number)
+
+	built, outdated = pullRequestRuns(runs, "c2")
+	assert.True(t, built)
+	assert.Empty(t, outdated)
+}
@@ -0,0 +1,247 @@
+package workflow
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// scheduleNodeJobRunQueue orders the queue by priority, then by fair share between the projects, then by queued date.
+// The share of a job is the number of jobs of its project which are building or ahead of it in the queue, so that a
+// project with hundreds of waiting jobs does not starve the others. The jobs which would exceed the concurrency cap of
+// their project, or the max parallel of their matrix, are waiting for a slot, they are moved at the end of the queue.
+func scheduleNodeJobRunQueue(jobs []sdk.WorkflowNodeJobRun, running map[int64]int, caps map[int64]int) []sdk.WorkflowNodeJobRun {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		return jobs[i].Queued.Before(jobs[j].Queued)
+	})
+
+	shares := make(map[int64]int, len(jobs))
+	counts := make(map[int64]int)
+	for _, j := range jobs {
+		shares[j.ID] = running[j.ProjectID] + counts[j.ProjectID]
+		counts[j.ProjectID]++
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		if shares[jobs[i].ID] != shares[jobs[j].ID] {
+			return shares[jobs[i].ID] < shares[jobs[j].ID]
+		}
+		return jobs[i].Queued.Before(jobs[j].Queued)
+	})
+
+	res := make([]sdk.WorkflowNodeJobRun, 0, len(jobs))
+	waiting := []sdk.WorkflowNodeJobRun{}
+	slots := make(map[int64]int)
+	for _, j := range jobs {
+		if j.WaitingForSlot {
+			waiting = append(waiting, j)
+			continue
+		}
+		max := caps[j.ProjectID]
+		if j.Status == sdk.StatusWaiting.String() && max > 0 {
+			if running[j.ProjectID]+slots[j.ProjectID] >= max {
+				j.WaitingForSlot = true
+				waiting = append(waiting, j)
+				continue
+			}
+			slots[j.ProjectID]++
+		}
+		res = append(res, j)
+	}
+	return append(res, waiting...)
+}
+
+// loadProjectsQueueSlots returns the number of building jobs and the concurrency cap of the projects
+func loadProjectsQueueSlots(db gorp.SqlExecutor, projectIDs []int64) (map[int64]int, map[int64]int, error) {
+	running := map[int64]int{}
+	caps := map[int64]int{}
+	if len(projectIDs) == 0 {
+		return running, caps, nil
+	}
+
+	var ids string
+	for i, id := range projectIDs {
+		if i > 0 {
+			ids += ","
+		}
+		ids += fmt.Sprintf("%d", id)
+	}
+
+	query := `select project_id, count(id) from workflow_node_run_job
+	where project_id = ANY(string_to_array($1, ',')::bigint[]) and status = $2
+	group by project_id`
+	rows, err := db.Query(query, ids, sdk.StatusBuilding.String())
+	if err != nil {
+		return nil, nil, sdk.WrapError(err, "loadProjectsQueueSlots> Unable to count building jobs")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, nil, sdk.WrapError(err, "loadProjectsQueueSlots> Unable to count building jobs")
+		}
+		running[id] = n
+	}
+
+	query = `select id, max_concurrent_jobs from project
+	where id = ANY(string_to_array($1, ',')::bigint[]) and max_concurrent_jobs > 0`
+	rowsCaps, err := db.Query(query, ids)
+	if err != nil {
+		return nil, nil, sdk.WrapError(err, "loadProjectsQueueSlots> Unable to load projects")
+	}
+	defer rowsCaps.Close()
+	for rowsCaps.Next() {
+		var id int64
+		var max int
+		if err := rowsCaps.Scan(&id, &max); err != nil {
+			return nil, nil, sdk.WrapError(err, "loadProjectsQueueSlots> Unable to load projects")
+		}
+		caps[id] = max
+	}
+
+	return running, caps, nil
+}
+
+// checkProjectQueueSlot returns sdk.ErrJobWaitingForSlot if the project of the job has reached its concurrency cap.
+// The check is serialized per project until the end of the transaction so that two workers can't take the last slot.
+func checkProjectQueueSlot(db gorp.SqlExecutor, job *sdk.WorkflowNodeJobRun) error {
+	var max int
+	if err := db.QueryRow("select max_concurrent_jobs from project where id = $1", job.ProjectID).Scan(&max); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return sdk.WrapError(err, "checkProjectQueueSlot> Unable to load project %d", job.ProjectID)
+	}
+	if max <= 0 {
+		return nil
+	}
+
+	if _, err := db.Exec("select pg_advisory_xact_lock($1)", job.ProjectID); err != nil {
+		return sdk.WrapError(err, "checkProjectQueueSlot> Unable to lock project %d", job.ProjectID)
+	}
+
+	var n int
+	query := "select count(id) from workflow_node_run_job where project_id = $1 and status = $2"
+	if err := db.QueryRow(query, job.ProjectID, sdk.StatusBuilding.String()).Scan(&n); err != nil {
+		return sdk.WrapError(err, "checkProjectQueueSlot> Unable to count building jobs")
+	}
+	if n >= max {
+		return sdk.ErrJobWaitingForSlot
+	}
+	return nil
+}
+
+// matrixKey identifies the jobs expanded from the same matrix job in a node run
+type matrixKey struct {
+	nodeRunID        int64
+	pipelineActionID int64
+}
+
+func newMatrixKey(j *sdk.WorkflowNodeJobRun) matrixKey {
+	return matrixKey{nodeRunID: j.WorkflowNodeRunID, pipelineActionID: j.Job.PipelineActionID}
+}
+
+// limitMatrixParallelism marks the waiting jobs which would exceed the max parallel of their matrix as waiting for a slot.
+// running is the number of building jobs of each matrix, the combinations are started in their order of expansion.
+func limitMatrixParallelism(jobs []sdk.WorkflowNodeJobRun, running map[matrixKey]int) {
+	order := make([]int, len(jobs))
+	for i := range jobs {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return jobs[order[i]].ID < jobs[order[j]].ID })
+
+	slots := make(map[matrixKey]int)
+	for _, i := range order {
+		j := &jobs[i]
+		if j.Status != sdk.StatusWaiting.String() || j.Job.Matrix == nil || j.Job.Matrix.MaxParallel <= 0 {
+			continue
+		}
+		k := newMatrixKey(j)
+		if running[k]+slots[k] >= j.Job.Matrix.MaxParallel {
+			j.WaitingForSlot = true
+			continue
+		}
+		slots[k]++
+	}
+}
+
+// loadMatrixQueueSlots returns the number of building jobs of the matrix jobs of the node runs
+func loadMatrixQueueSlots(db gorp.SqlExecutor, nodeRunIDs []int64) (map[matrixKey]int, error) {
+	running := map[matrixKey]int{}
+	if len(nodeRunIDs) == 0 {
+		return running, nil
+	}
+
+	var ids string
+	for i, id := range nodeRunIDs {
+		if i > 0 {
+			ids += ","
+		}
+		ids += fmt.Sprintf("%d", id)
+	}
+
+	query := `select workflow_node_run_id, (job->>'pipeline_action_id')::bigint, count(id) from workflow_node_run_job
+	where workflow_node_run_id = ANY(string_to_array($1, ',')::bigint[]) and status = $2
+	group by workflow_node_run_id, job->>'pipeline_action_id'`
+	rows, err := db.Query(query, ids, sdk.StatusBuilding.String())
+	if err != nil {
+		return nil, sdk.WrapError(err, "loadMatrixQueueSlots> Unable to count building jobs")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k matrixKey
+		var n int
+		if err := rows.Scan(&k.nodeRunID, &k.pipelineActionID, &n); err != nil {
+			return nil, sdk.WrapError(err, "loadMatrixQueueSlots> Unable to count building jobs")
+		}
+		running[k] = n
+	}
+	return running, nil
+}
+
+// checkMatrixQueueSlot returns sdk.ErrJobWaitingForSlot if the matrix of the job has reached its max parallel.
+// The check is serialized per matrix until the end of the transaction so that two workers can't take the last slot.
+func checkMatrixQueueSlot(db gorp.SqlExecutor, job *sdk.WorkflowNodeJobRun) error {
+	if job.Job.Matrix == nil || job.Job.Matrix.MaxParallel <= 0 {
+		return nil
+	}
+
+	if _, err := db.Exec("select pg_advisory_xact_lock(hashtext($1))", fmt.Sprintf("matrix-%d-%d", job.WorkflowNodeRunID, job.Job.PipelineActionID)); err != nil {
+		return sdk.WrapError(err, "checkMatrixQueueSlot> Unable to lock matrix of job %d", job.ID)
+	}
+
+	var n int
+	query := `select count(id) from workflow_node_run_job
+	where workflow_node_run_id = $1 and status = $2 and (job->>'pipeline_action_id')::bigint = $3`
+	if err := db.QueryRow(query, job.WorkflowNodeRunID, sdk.StatusBuilding.String(), job.Job.PipelineActionID).Scan(&n); err != nil {
+		return sdk.WrapError(err, "checkMatrixQueueSlot> Unable to count building jobs")
+	}
+	if n >= job.Job.Matrix.MaxParallel {
+		return sdk.ErrJobWaitingForSlot
+	}
+	return nil
+}
+
+// UpdateNodeJobRunPriority overrides the priority of a waiting job
+func UpdateNodeJobRunPriority(db gorp.SqlExecutor, id int64, priority int) error {
+	query := "update workflow_node_run_job set priority = $2 where id = $1 and status = $3"
+	res, err := db.Exec(query, id, priority, sdk.StatusWaiting.String())
+	if err != nil {
+		return sdk.WrapError(err, "UpdateNodeJobRunPriority> Unable to update job %d", id)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sdk.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_scheduleNodeJobRunQueue(t *testing.T) {
+	t0 := time.Now()
+	job := func(id, projectID int64, priority int) sdk.WorkflowNodeJobRun {
+		return sdk.WorkflowNodeJobRun{
+			ID:        id,
+			ProjectID: projectID,
+			Priority:  priority,
+			Status:    sdk.StatusWaiting.String(),
+			Queued:    t0.Add(time.Duration(id) * time.Second),
+		}
+	}
+	ids := func(jobs []sdk.WorkflowNodeJobRun) []int64 {
+		res := []int64{}
+		for _, j := range jobs {
+			res = append(res, j.ID)
+		}
+		return res
+	}
+
+	// Project 1 has queued many jobs first, project 2 is not starved
+	jobs := []sdk.WorkflowNodeJobRun{job(1, 1, 0), job(2, 1, 0), job(3, 1, 0), job(4, 2, 0), job(5, 2, 0)}
+	res := scheduleNodeJobRunQueue(jobs, nil, nil)
+	assert.Equal(t, []int64{1, 4, 2, 5, 3}, ids(res))
+
+	// Project 2 already has two jobs building
+	jobs = []sdk.WorkflowNodeJobRun{job(1, 1, 0), job(2, 1, 0), job(3, 1, 0), job(4, 2, 0), job(5, 2, 0)}
+	res = scheduleNodeJobRunQueue(jobs, map[int64]int{2: 2}, nil)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids(res))
+
+	// The priority comes first
+	jobs = []sdk.WorkflowNodeJobRun{job(1, 1, 0), job(2, 1, 0), job(3, 2, 0), job(4, 2, 10)}
+	res = scheduleNodeJobRunQueue(jobs, nil, nil)
+	assert.Equal(t, []int64{4, 1, 2, 3}, ids(res))
+
+	// Project 1 can run 2 jobs and one is building
+	jobs = []sdk.WorkflowNodeJobRun{job(1, 1, 0), job(2, 1, 0), job(3, 1, 0), job(4, 2, 0)}
+	res = scheduleNodeJobRunQueue(jobs, map[int64]int{1: 1}, map[int64]int{1: 2})
+	assert.Equal(t, []int64{4, 1, 2, 3}, ids(res))
+	assert.False(t, res[0].WaitingForSlot)
+	assert.False(t, res[1].WaitingForSlot)
+	assert.True(t, res[2].WaitingForSlot)
+	assert.True(t, res[3].WaitingForSlot)
+}
+
+func Test_limitMatrixParallelism(t *testing.T) {
+	matrix := &sdk.JobMatrix{Variables: map[string][]string{"go": {"1.8", "1.9", "1.10"}}, MaxParallel: 2}
+	job := func(id, nodeRunID, pipelineActionID int64, m *sdk.JobMatrix) sdk.WorkflowNodeJobRun {
+		return sdk.WorkflowNodeJobRun{
+			ID:                id,
+			ProjectID:         1,
+			WorkflowNodeRunID: nodeRunID,
+			Status:            sdk.StatusWaiting.String(),
+			Job: sdk.ExecutedJob{
+				Job: sdk.Job{PipelineActionID: pipelineActionID, Matrix: m},
+			},
+		}
+	}
+
+	// 3 combinations of a matrix of node run 1, one combination of node run 2 is already building
+	jobs := []sdk.WorkflowNodeJobRun{job(3, 1, 10, matrix), job(1, 1, 10, matrix), job(2, 1, 10, matrix), job(4, 2, 10, matrix), job(5, 2, 10, matrix), job(6, 1, 11, nil)}
+	limitMatrixParallelism(jobs, map[matrixKey]int{{nodeRunID: 2, pipelineActionID: 10}: 1})
+	waiting := map[int64]bool{}
+	for _, j := range jobs {
+		waiting[j.ID] = j.WaitingForSlot
+	}
+	assert.Equal(t, map[int64]bool{1: false, 2: false, 3: true, 4: false, 5: true, 6: false}, waiting)
+
+	// The jobs waiting for the matrix don't take the slots of the project
+	res := scheduleNodeJobRunQueue(jobs, nil, map[int64]int{1: 3})
+	for _, j := range res[:3] {
+		assert.False(t, j.WaitingForSlot)
+	}
+	for _, j := range res[3:] {
+		assert.True(t, j.WaitingForSlot)
+	}
+	ids := []int64{}
+	for _, j := range res {
+		ids = append(ids, j.ID)
+	}
+	assert.Equal(t, []int64{1, 2, 4, 3, 5, 6}, ids)
+}
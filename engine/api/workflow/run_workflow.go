@@ -3,14 +3,101 @@ package workflow
 import (
 	"time"
 
+	"github.com/fsamin/go-dump"
 	"github.com/go-gorp/gorp"
 
 	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
 )
 
 //RunFromHook is the entry point to trigger a workflow from a hook
+//It returns a nil workflow run if the hook conditions are not satisfied
 func RunFromHook(db gorp.SqlExecutor, w *sdk.Workflow, e *sdk.WorkflowNodeRunHookEvent) (*sdk.WorkflowRun, error) {
-	return nil, nil
+	hooks := w.GetHooks()
+	h, ok := hooks[e.WorkflowNodeHookUUID]
+	if !ok {
+		return nil, sdk.WrapError(sdk.ErrNoHook, "RunFromHook> Unable to find hook %s in workflow %s/%s", e.WorkflowNodeHookUUID, w.ProjectKey, w.Name)
+	}
+	e.WorkflowNodeHookID = h.ID
+
+	node := w.GetNode(h.WorkflowNodeID)
+	if node == nil {
+		return nil, sdk.WrapError(sdk.ErrWorkflowNodeNotFound, "RunFromHook> Unable to find node %d", h.WorkflowNodeID)
+	}
+
+	//Default values are taken from the node context
+	if e.Payload == nil && node.Context != nil {
+		e.Payload = node.Context.DefaultPayload
+	}
+	if e.PipelineParameters == nil && node.Context != nil {
+		e.PipelineParameters = node.Context.DefaultPipelineParameters
+	}
+
+	//Check the hook conditions against the payload
+	m, errm := dump.ToMap(e.Payload, dump.WithDefaultLowerCaseFormatter())
+	if errm != nil {
+		return nil, sdk.WrapError(errm, "RunFromHook> Unable to compute hook payload")
+	}
+	conditionsOK, errc := sdk.WorkflowCheckConditions(h.Conditions, sdk.ParametersFromMap(m))
+	if errc != nil {
+		return nil, sdk.WrapError(errc, "RunFromHook> Unable to check hook conditions")
+	}
+	if !conditionsOK {
+		log.Debug("RunFromHook> Conditions of hook %s are not satisfied", h.UUID)
+		return nil, nil
+	}
+
+	//A pull request is built once per commit, the runs of its previous commits are outdated
+	if prID := m["cds.pr.id"]; prID != "" && h.WorkflowNodeID == w.RootID {
+		built, err := stopOutdatedPullRequestRuns(db, w, prID, m["git.hash"])
+		if err != nil {
+			return nil, sdk.WrapError(err, "RunFromHook> Unable to stop outdated runs of pull request %s", prID)
+		}
+		if built {
+			log.Debug("RunFromHook> Commit %s of pull request %s has already been built", m["git.hash"], prID)
+			return nil, nil
+		}
+	}
+
+	lastWorkflowRun, err := LoadLastRun(db, w.ProjectKey, w.Name)
+	if err != nil {
+		if err != sdk.ErrWorkflowNotFound {
+			return nil, sdk.WrapError(err, "RunFromHook> Unable to load last run")
+		}
+	}
+
+	//If the hook is not on the root, the last run is triggered from the hooked node
+	if h.WorkflowNodeID != w.RootID {
+		if lastWorkflowRun == nil {
+			return nil, sdk.WrapError(sdk.ErrWorkflowNotFound, "RunFromHook> Unable to run workflow %s/%s from node %d without previous run", w.ProjectKey, w.Name, node.ID)
+		}
+
+		if err := processWorkflowRun(db, lastWorkflowRun, e, nil, &node.ID); err != nil {
+			return nil, sdk.WrapError(err, "RunFromHook> Unable to process workflow run")
+		}
+
+		return LoadRunByIDAndProjectKey(db, w.ProjectKey, lastWorkflowRun.ID)
+	}
+
+	var number = int64(1)
+	if lastWorkflowRun != nil {
+		number = lastWorkflowRun.Number + 1
+	}
+
+	wr := &sdk.WorkflowRun{
+		Number:       number,
+		Workflow:     *w,
+		WorkflowID:   w.ID,
+		Start:        time.Now(),
+		LastModified: time.Now(),
+		ProjectID:    w.ProjectID,
+	}
+
+	if err := insertWorkflowRun(db, wr); err != nil {
+		return nil, sdk.WrapError(err, "RunFromHook> Unable to run workflow %s/%s from hook %s", w.ProjectKey, w.Name, h.UUID)
+	}
+
+	return wr, processWorkflowRun(db, wr, e, nil, nil)
 }
 
 //ManualRunFromNode is the entry point to trigger manually a piece of an existing run workflow
@@ -32,6 +119,38 @@ func ManualRunFromNode(db gorp.SqlExecutor, w *sdk.Workflow, number int64, e *sd
 	return lastWorkflowRun, nil
 }
 
+//RestartFailedJobs is the entry point to restart manually only the failed jobs of a node run.
+//It creates a new subnumber which reuses the results of the successful stages and jobs.
+func RestartFailedJobs(db gorp.SqlExecutor, w *sdk.Workflow, number int64, e *sdk.WorkflowNodeRunManual, nodeRunID int64) (*sdk.WorkflowRun, error) {
+	lastWorkflowRun, err := LoadRun(db, w.ProjectKey, w.Name, number)
+	if err != nil {
+		return nil, sdk.WrapError(err, "RestartFailedJobs> Unable to load last run")
+	}
+
+	var nodeRun *sdk.WorkflowNodeRun
+	for _, nodeRuns := range lastWorkflowRun.WorkflowNodeRuns {
+		for i := range nodeRuns {
+			if nodeRuns[i].ID == nodeRunID {
+				nodeRun = &nodeRuns[i]
+			}
+		}
+	}
+	if nodeRun == nil {
+		return nil, sdk.WrapError(sdk.ErrWorkflowNodeNotFound, "RestartFailedJobs> Unable to find node run %d", nodeRunID)
+	}
+
+	if err := processWorkflowNodeRunRestart(db, lastWorkflowRun, nodeRun, e); err != nil {
+		return nil, sdk.WrapError(err, "RestartFailedJobs> Unable to restart node run %d", nodeRunID)
+	}
+
+	lastWorkflowRun, err = LoadRunByIDAndProjectKey(db, w.ProjectKey, lastWorkflowRun.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return lastWorkflowRun, nil
+}
+
 //ManualRun is the entry point to trigger a workflow manually
 func ManualRun(db gorp.SqlExecutor, w *sdk.Workflow, e *sdk.WorkflowNodeRunManual) (*sdk.WorkflowRun, error) {
 	lastWorkflowRun, err := LoadLastRun(db, w.ProjectKey, w.Name)
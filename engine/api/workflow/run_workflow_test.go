@@ -477,3 +477,180 @@ func TestManualRun3(t *testing.T) {
 		assert.Equal(t, "job20", jobs[0].Job.Job.Action.Name)
 	}
 }
+
+func TestRunFromHook(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+	test.NoError(t, CreateBuiltinWorkflowHookModels(db))
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	s := sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip.ID
+	pipeline.InsertStage(db, s)
+	j := &sdk.Job{
+		Enabled: true,
+		Action: sdk.Action{
+			Enabled: true,
+		},
+	}
+	pipeline.InsertJob(db, j, s.ID, &pip)
+	s.Jobs = append(s.Jobs, *j)
+	pip.Stages = append(pip.Stages, *s)
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+			Hooks: []sdk.WorkflowNodeHook{
+				{
+					WorkflowHookModel: *WebHookModel,
+					Conditions: []sdk.WorkflowTriggerCondition{
+						{
+							Variable: "git.branch",
+							Operator: "eq",
+							Value:    "master",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	test.NoError(t, Insert(db, &w, u))
+	w1, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+	assert.Len(t, w1.Root.Hooks, 1)
+	uuid := w1.Root.Hooks[0].UUID
+
+	//The hook conditions are not satisfied
+	wr, err := RunFromHook(db, w1, &sdk.WorkflowNodeRunHookEvent{
+		WorkflowNodeHookUUID: uuid,
+		Payload:              map[string]string{"git.branch": "feat/foo"},
+	})
+	test.NoError(t, err)
+	assert.Nil(t, wr)
+
+	wr, err = RunFromHook(db, w1, &sdk.WorkflowNodeRunHookEvent{
+		WorkflowNodeHookUUID: uuid,
+		Payload:              map[string]string{"git.branch": "master"},
+	})
+	test.NoError(t, err)
+	assert.NotNil(t, wr)
+	assert.Equal(t, int64(1), wr.Number)
+
+	//Unknown hook
+	_, err = RunFromHook(db, w1, &sdk.WorkflowNodeRunHookEvent{WorkflowNodeHookUUID: "unknown"})
+	assert.Error(t, err)
+
+	//The hook uuid must be kept on update
+	test.NoError(t, Update(db, w1, w1, u))
+	w2, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+	assert.Equal(t, uuid, w2.Root.Hooks[0].UUID)
+}
+
+func TestRestartFailedJobs(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	s := sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip.ID
+	pipeline.InsertStage(db, s)
+	for _, name := range []string{"job 1", "job 2"} {
+		j := &sdk.Job{
+			Enabled: true,
+			Action: sdk.Action{
+				Name:    name,
+				Enabled: true,
+			},
+		}
+		pipeline.InsertJob(db, j, s.ID, &pip)
+		s.Jobs = append(s.Jobs, *j)
+	}
+	pip.Stages = append(pip.Stages, *s)
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+		},
+	}
+
+	test.NoError(t, Insert(db, &w, u))
+	w1, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+
+	_, err = ManualRun(db, w1, &sdk.WorkflowNodeRunManual{User: *u})
+	test.NoError(t, err)
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	Scheduler(c, func() *gorp.DbMap { return db })
+
+	time.Sleep(2 * time.Second)
+
+	jobs, err := LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 2)
+
+	//The first job succeeds, the second one fails
+	for i := range jobs {
+		test.NoError(t, UpdateNodeJobRunStatus(db, &jobs[i], sdk.StatusBuilding))
+	}
+	test.NoError(t, UpdateNodeJobRunStatus(db, &jobs[0], sdk.StatusSuccess))
+	test.NoError(t, UpdateNodeJobRunStatus(db, &jobs[1], sdk.StatusFail))
+
+	wr, err := LoadLastRun(db, proj.Key, "test_1")
+	test.NoError(t, err)
+	failedRun := wr.WorkflowNodeRuns[w1.RootID][0]
+	assert.Equal(t, sdk.StatusFail.String(), failedRun.Status)
+
+	//Only the failed job is requeued
+	wr, err = RestartFailedJobs(db, w1, wr.Number, &sdk.WorkflowNodeRunManual{User: *u}, failedRun.ID)
+	test.NoError(t, err)
+
+	var restartedRun *sdk.WorkflowNodeRun
+	for i := range wr.WorkflowNodeRuns[w1.RootID] {
+		if wr.WorkflowNodeRuns[w1.RootID][i].ID != failedRun.ID {
+			restartedRun = &wr.WorkflowNodeRuns[w1.RootID][i]
+		}
+	}
+	assert.NotNil(t, restartedRun)
+	assert.Equal(t, int64(1), restartedRun.SubNumber)
+	assert.Equal(t, sdk.StatusWaiting.String(), restartedRun.Status)
+	assert.Len(t, restartedRun.Stages[0].RunJobs, 2)
+
+	jobs, err = LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "job 2", jobs[0].Job.Action.Name)
+
+	//A node run which is not failed cannot be restarted
+	_, err = RestartFailedJobs(db, w1, wr.Number, &sdk.WorkflowNodeRunManual{User: *u}, restartedRun.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/event"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//StopWorkflowRun stops all the waiting, waiting for approval and building node runs of a workflow run
+func StopWorkflowRun(db gorp.SqlExecutor, wr *sdk.WorkflowRun, u *sdk.User) error {
+	if err := lockRun(db, wr.ID); err != nil {
+		return sdk.WrapError(err, "workflow.StopWorkflowRun>")
+	}
+
+	for _, nodeRuns := range wr.WorkflowNodeRuns {
+		for i := range nodeRuns {
+			if err := stopWorkflowNodeRun(db, wr, &nodeRuns[i], u); err != nil {
+				return sdk.WrapError(err, "workflow.StopWorkflowRun> Unable to stop node run %d", nodeRuns[i].ID)
+			}
+		}
+	}
+
+	if err := updateWorkflowRun(db, wr); err != nil {
+		return sdk.WrapError(err, "workflow.StopWorkflowRun> Unable to update workflow run %d", wr.ID)
+	}
+	return nil
+}
+
+//StopWorkflowNodeRun stops a waiting or building node run of a workflow run
+func StopWorkflowNodeRun(db gorp.SqlExecutor, wr *sdk.WorkflowRun, nodeRunID int64, u *sdk.User) error {
+	var nodeRun *sdk.WorkflowNodeRun
+	for _, nodeRuns := range wr.WorkflowNodeRuns {
+		for i := range nodeRuns {
+			if nodeRuns[i].ID == nodeRunID {
+				nodeRun = &nodeRuns[i]
+				break
+			}
+		}
+	}
+	if nodeRun == nil {
+		return sdk.WrapError(sdk.ErrWorkflowNodeNotFound, "workflow.StopWorkflowNodeRun> Unable to find node run %d", nodeRunID)
+	}
+
+	if err := lockRun(db, wr.ID); err != nil {
+		return sdk.WrapError(err, "workflow.StopWorkflowNodeRun>")
+	}
+
+	if err := stopWorkflowNodeRun(db, wr, nodeRun, u); err != nil {
+		return sdk.WrapError(err, "workflow.StopWorkflowNodeRun> Unable to stop node run %d", nodeRunID)
+	}
+
+	if err := updateWorkflowRun(db, wr); err != nil {
+		return sdk.WrapError(err, "workflow.StopWorkflowNodeRun> Unable to update workflow run %d", wr.ID)
+	}
+	return nil
+}
+
+func lockRun(db gorp.SqlExecutor, id int64) error {
+	if _, err := db.Exec("select workflow_run.* from workflow_run where id = $1 for update nowait", id); err != nil {
+		return fmt.Errorf("Unable to take lock on workflow_run ID=%d (%v)", id, err)
+	}
+	return nil
+}
+
+//stopWorkflowNodeRun set the node run, its stages and its jobs at status Stopped.
+//The jobs are removed from the queue so the workers which hold them cancel their steps.
+func stopWorkflowNodeRun(db gorp.SqlExecutor, wr *sdk.WorkflowRun, n *sdk.WorkflowNodeRun, u *sdk.User) error {
+	if n.Status != sdk.StatusWaiting.String() && n.Status != sdk.StatusBuilding.String() && n.Status != sdk.StatusWaitingApproval.String() {
+		return nil
+	}
+
+	//Reload the node run with a lock to get its real status
+	nodeRun, errl := LoadAndLockNodeRunByID(db, n.ID)
+	if errl != nil {
+		return sdk.WrapError(errl, "stopWorkflowNodeRun> Unable to lock node run %d", n.ID)
+	}
+	if nodeRun.Status != sdk.StatusWaiting.String() && nodeRun.Status != sdk.StatusBuilding.String() && nodeRun.Status != sdk.StatusWaitingApproval.String() {
+		*n = *nodeRun
+		return nil
+	}
+
+	log.Debug("stopWorkflowNodeRun> Stopping [#%d.%d] runID=%d nodeRunID=%d", nodeRun.Number, nodeRun.SubNumber, nodeRun.WorkflowRunID, nodeRun.ID)
+
+	now := time.Now()
+	for i := range nodeRun.Stages {
+		stage := &nodeRun.Stages[i]
+		if stage.Status != sdk.StatusWaiting && stage.Status != sdk.StatusBuilding {
+			continue
+		}
+
+		for j := range stage.RunJobs {
+			runJob := &stage.RunJobs[j]
+			if runJob.Status != sdk.StatusWaiting.String() && runJob.Status != sdk.StatusBuilding.String() {
+				continue
+			}
+
+			//Keep the step status sent by the worker
+			if runJobDB, err := LoadNodeJobRun(db, runJob.ID); err == nil {
+				runJob.Start = runJobDB.Start
+				runJob.Model = runJobDB.Model
+				runJob.Job = runJobDB.Job
+				runJob.SpawnInfos = runJobDB.SpawnInfos
+			}
+
+			runJob.Status = sdk.StatusStopped.String()
+			runJob.Done = now
+			event.PublishJobRun(nodeRun, runJob)
+		}
+		stage.Status = sdk.StatusStopped
+	}
+
+	nodeRun.Status = sdk.StatusStopped.String()
+	nodeRun.Done = now
+	if err := UpdateNodeRun(db, nodeRun); err != nil {
+		return sdk.WrapError(err, "stopWorkflowNodeRun> Unable to update node run %d", nodeRun.ID)
+	}
+	event.PublishWorkflowNodeRun(wr, nodeRun)
+
+	//Remove the jobs from the queue
+	if err := DeleteNodeJobRuns(db, nodeRun.ID); err != nil {
+		return sdk.WrapError(err, "stopWorkflowNodeRun> Unable to delete node %d job runs", nodeRun.ID)
+	}
+
+	var pipName string
+	if node := wr.Workflow.GetNode(nodeRun.WorkflowNodeID); node != nil {
+		pipName = node.Pipeline.Name
+	}
+	var username string
+	if u != nil {
+		username = u.Username
+	}
+	AddWorkflowRunInfo(wr, sdk.SpawnMsg{
+		ID:   sdk.MsgWorkflowNodeStop.ID,
+		Args: []interface{}{pipName, username},
+	})
+
+	*n = *nodeRun
+	return nil
+}
@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/bootstrap"
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/test/assets"
+	"github.com/ovh/cds/sdk"
+)
+
+func TestStopWorkflowRun(t *testing.T) {
+	db := test.SetupPG(t, bootstrap.InitiliazeDB)
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	s := sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip.ID
+	pipeline.InsertStage(db, s)
+	j := &sdk.Job{
+		Enabled: true,
+		Action: sdk.Action{
+			Enabled: true,
+		},
+	}
+	pipeline.InsertJob(db, j, s.ID, &pip)
+	s.Jobs = append(s.Jobs, *j)
+
+	pip.Stages = append(pip.Stages, *s)
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+		},
+	}
+
+	test.NoError(t, Insert(db, &w, u))
+	w1, err := Load(db, key, "test_1", u)
+	test.NoError(t, err)
+
+	_, err = ManualRun(db, w1, &sdk.WorkflowNodeRunManual{User: *u})
+	test.NoError(t, err)
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	Scheduler(c, func() *gorp.DbMap { return db })
+
+	time.Sleep(2 * time.Second)
+
+	jobs, err := LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	wr, err := LoadLastRun(db, proj.Key, "test_1")
+	test.NoError(t, err)
+	test.NoError(t, StopWorkflowRun(db, wr, u))
+
+	wr, err = LoadLastRun(db, proj.Key, "test_1")
+	test.NoError(t, err)
+	nodeRun := wr.WorkflowNodeRuns[w1.RootID][0]
+	assert.Equal(t, sdk.StatusStopped.String(), nodeRun.Status)
+	assert.Equal(t, sdk.StatusStopped, nodeRun.Stages[0].Status)
+	assert.Equal(t, sdk.StatusStopped.String(), nodeRun.Stages[0].RunJobs[0].Status)
+
+	jobs, err = LoadNodeJobRunQueue(db, []int64{proj.ProjectGroups[0].Group.ID}, nil)
+	test.NoError(t, err)
+	assert.Len(t, jobs, 0)
+}
@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/sdk"
+)
+
+// LoadPermissions loads all workflows where group has access
+func LoadPermissions(db gorp.SqlExecutor, g *sdk.Group) error {
+	query := `
+		SELECT project.projectkey, workflow.name, workflow.id, workflow_group.role
+		FROM workflow
+		JOIN workflow_group ON workflow_group.workflow_id = workflow.id
+		JOIN project ON workflow.project_id = project.id
+		WHERE workflow_group.group_id = $1
+		ORDER BY workflow.name ASC`
+	rows, err := db.Query(query, g.ID)
+	if err != nil {
+		return sdk.WrapError(err, "LoadPermissions> Unable to load workflows of group %d", g.ID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w sdk.Workflow
+		var perm int
+		if err := rows.Scan(&w.ProjectKey, &w.Name, &w.ID, &perm); err != nil {
+			return sdk.WrapError(err, "LoadPermissions> Unable to scan workflows of group %d", g.ID)
+		}
+		g.WorkflowGroups = append(g.WorkflowGroups, sdk.WorkflowGroup{
+			Workflow:   w,
+			Permission: perm,
+		})
+	}
+	return nil
+}
+
+// LoadGroups loads the groups of the workflow
+func LoadGroups(db gorp.SqlExecutor, w *sdk.Workflow) error {
+	query := `
+		SELECT "group".id, "group".name, workflow_group.role
+		FROM "group"
+		JOIN workflow_group ON workflow_group.group_id = "group".id
+		WHERE workflow_group.workflow_id = $1
+		ORDER BY "group".name ASC`
+	rows, err := db.Query(query, w.ID)
+	if err != nil {
+		return sdk.WrapError(err, "LoadGroups> Unable to load groups of workflow %d", w.ID)
+	}
+	defer rows.Close()
+
+	w.Groups = []sdk.GroupPermission{}
+	for rows.Next() {
+		var gp sdk.GroupPermission
+		if err := rows.Scan(&gp.Group.ID, &gp.Group.Name, &gp.Permission); err != nil {
+			return sdk.WrapError(err, "LoadGroups> Unable to scan groups of workflow %d", w.ID)
+		}
+		w.Groups = append(w.Groups, gp)
+	}
+	return nil
+}
+
+// insertGroups links the groups to a new workflow. Without any given group, the workflow gets the groups of its project
+func insertGroups(db gorp.SqlExecutor, w *sdk.Workflow) error {
+	if len(w.Groups) == 0 {
+		if err := group.InsertProjectGroupsInWorkflow(db, w.ID, w.ProjectID); err != nil {
+			return sdk.WrapError(err, "insertGroups> Unable to insert project groups in workflow %d", w.ID)
+		}
+		return LoadGroups(db, w)
+	}
+
+	for i := range w.Groups {
+		gp := &w.Groups[i]
+		if gp.Group.ID == 0 {
+			g, err := group.LoadGroup(db, gp.Group.Name)
+			if err != nil {
+				return sdk.WrapError(err, "insertGroups> Unable to load group %s", gp.Group.Name)
+			}
+			gp.Group.ID = g.ID
+		}
+		if err := group.InsertGroupInWorkflow(db, w.ID, gp.Group.ID, gp.Permission); err != nil {
+			return sdk.WrapError(err, "insertGroups> Unable to insert group %s in workflow %d", gp.Group.Name, w.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package workflow
+
+import (
+	"github.com/go-gorp/gorp"
+	"github.com/pkg/errors"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/sdk"
+)
+
+//Import inserts or updates (if force is true) a workflow in a project.
+//The project has to be loaded with its pipelines, applications and environments: the nodes of the workflow
+//reference them only by their names.
+func Import(db gorp.SqlExecutor, proj *sdk.Project, w *sdk.Workflow, force bool, u *sdk.User) error {
+	w.ProjectID = proj.ID
+	w.ProjectKey = proj.Key
+
+	if w.Root == nil {
+		return sdk.ErrWorkflowInvalidRoot
+	}
+
+	nodes := allNodes(w)
+	for _, n := range nodes {
+		if err := resolveNode(db, proj, n); err != nil {
+			return sdk.WrapError(err, "workflow.Import> Unable to import node %s", n.Name)
+		}
+	}
+
+	oldW, errL := Load(db, proj.Key, w.Name, u)
+	if errL != nil {
+		if errors.Cause(errL) != sdk.ErrWorkflowNotFound {
+			return sdk.WrapError(errL, "workflow.Import> Unable to load workflow %s", w.Name)
+		}
+		if err := Insert(db, w, u); err != nil {
+			return sdk.WrapError(err, "workflow.Import> Unable to insert workflow %s", w.Name)
+		}
+		return nil
+	}
+
+	if !force {
+		return sdk.ErrWorkflowAlreadyExists
+	}
+
+	//Keep the UUID of the existing hooks, they are matched by node name and hook model
+	oldNodes := map[string]*sdk.WorkflowNode{}
+	for _, n := range allNodes(oldW) {
+		oldNodes[n.Name] = n
+	}
+	for _, n := range nodes {
+		oldNode, ok := oldNodes[n.Name]
+		if !ok {
+			continue
+		}
+		used := map[string]bool{}
+		for i := range n.Hooks {
+			h := &n.Hooks[i]
+			for _, oldH := range oldNode.Hooks {
+				if !used[oldH.UUID] && oldH.WorkflowHookModel.Name == h.WorkflowHookModel.Name {
+					h.UUID = oldH.UUID
+					used[oldH.UUID] = true
+					break
+				}
+			}
+		}
+	}
+
+	w.ID = oldW.ID
+	w.RootID = oldW.RootID
+	if err := Update(db, w, oldW, u); err != nil {
+		return sdk.WrapError(err, "workflow.Import> Unable to update workflow %s", w.Name)
+	}
+	return nil
+}
+
+//allNodes returns all the nodes of a workflow: the root, the nodes it triggers and the nodes triggered by the joins
+func allNodes(w *sdk.Workflow) []*sdk.WorkflowNode {
+	nodes := []*sdk.WorkflowNode{}
+	var browse func(n *sdk.WorkflowNode)
+	browse = func(n *sdk.WorkflowNode) {
+		nodes = append(nodes, n)
+		for i := range n.Triggers {
+			browse(&n.Triggers[i].WorkflowDestNode)
+		}
+	}
+
+	if w.Root != nil {
+		browse(w.Root)
+	}
+	for i := range w.Joins {
+		for j := range w.Joins[i].Triggers {
+			browse(&w.Joins[i].Triggers[j].WorkflowDestNode)
+		}
+	}
+	return nodes
+}
+
+//resolveNode sets the pipeline, the application and the environment of the node from their names
+func resolveNode(db gorp.SqlExecutor, proj *sdk.Project, n *sdk.WorkflowNode) error {
+	var pip *sdk.Pipeline
+	for i := range proj.Pipelines {
+		if proj.Pipelines[i].Name == n.Pipeline.Name {
+			pip = &proj.Pipelines[i]
+			break
+		}
+	}
+	if pip == nil {
+		return sdk.WrapError(sdk.ErrPipelineNotFound, "resolveNode> Unable to find pipeline %s", n.Pipeline.Name)
+	}
+	n.Pipeline = *pip
+	n.PipelineID = pip.ID
+
+	if n.Context == nil {
+		return nil
+	}
+
+	if n.Context.Application != nil {
+		var app *sdk.Application
+		for i := range proj.Applications {
+			if proj.Applications[i].Name == n.Context.Application.Name {
+				app = &proj.Applications[i]
+				break
+			}
+		}
+		if app == nil {
+			return sdk.WrapError(sdk.ErrApplicationNotFound, "resolveNode> Unable to find application %s", n.Context.Application.Name)
+		}
+		n.Context.Application = app
+		n.Context.ApplicationID = app.ID
+	}
+
+	if n.Context.Environment != nil {
+		var env *sdk.Environment
+		for i := range proj.Environments {
+			if proj.Environments[i].Name == n.Context.Environment.Name {
+				env = &proj.Environments[i]
+				break
+			}
+		}
+		if env == nil {
+			return sdk.WrapError(sdk.ErrNoEnvironment, "resolveNode> Unable to find environment %s", n.Context.Environment.Name)
+		}
+		n.Context.Environment = env
+		n.Context.EnvironmentID = env.ID
+	}
+
+	if len(n.Context.DefaultPipelineParameters) == 0 {
+		return nil
+	}
+
+	//Keep the type of the pipeline parameters
+	params, errP := pipeline.GetAllParametersInPipeline(db, pip.ID)
+	if errP != nil {
+		return sdk.WrapError(errP, "resolveNode> Unable to load parameters of pipeline %s", pip.Name)
+	}
+	for i := range n.Context.DefaultPipelineParameters {
+		p := &n.Context.DefaultPipelineParameters[i]
+		for _, pp := range params {
+			if pp.Name == p.Name {
+				p.Type = pp.Type
+				p.Description = pp.Description
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/test/assets"
+	"github.com/ovh/cds/sdk"
+)
+
+func TestImport(t *testing.T) {
+	db := test.SetupPG(t)
+	u, _ := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	for _, name := range []string{"build", "deploy"} {
+		pip := sdk.Pipeline{
+			ProjectID:  proj.ID,
+			ProjectKey: proj.Key,
+			Name:       name,
+			Type:       sdk.BuildPipeline,
+		}
+		test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+		proj.Pipelines = append(proj.Pipelines, pip)
+	}
+
+	//The nodes only reference the pipelines by their names
+	newWorkflow := func() *sdk.Workflow {
+		return &sdk.Workflow{
+			Name: "test_import",
+			Root: &sdk.WorkflowNode{
+				Name:     "build",
+				Ref:      "build",
+				Pipeline: sdk.Pipeline{Name: "build"},
+				Triggers: []sdk.WorkflowNodeTrigger{
+					{
+						WorkflowDestNode: sdk.WorkflowNode{
+							Name:     "deploy",
+							Ref:      "deploy",
+							Pipeline: sdk.Pipeline{Name: "deploy"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	test.NoError(t, Import(db, proj, newWorkflow(), false, u))
+
+	w, err := Load(db, key, "test_import", u)
+	test.NoError(t, err)
+	assert.Equal(t, "build", w.Root.Name)
+	assert.Equal(t, proj.Pipelines[0].ID, w.Root.PipelineID)
+	if !assert.Len(t, w.Root.Triggers, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "deploy", w.Root.Triggers[0].WorkflowDestNode.Name)
+	assert.Equal(t, proj.Pipelines[1].ID, w.Root.Triggers[0].WorkflowDestNode.PipelineID)
+
+	//Without force, an existing workflow is not updated
+	err = Import(db, proj, newWorkflow(), false, u)
+	assert.Equal(t, sdk.ErrWorkflowAlreadyExists, errors.Cause(err))
+
+	test.NoError(t, Import(db, proj, newWorkflow(), true, u))
+
+	w1, err := Load(db, key, "test_import", u)
+	test.NoError(t, err)
+	assert.Equal(t, w.ID, w1.ID)
+
+	//Unknown pipeline
+	wrong := newWorkflow()
+	wrong.Name = "test_import_wrong"
+	wrong.Root.Pipeline.Name = "unknown"
+	assert.Error(t, Import(db, proj, wrong, false, u))
+}
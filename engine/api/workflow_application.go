@@ -19,7 +19,7 @@ import (
 func releaseApplicationWorkflowHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 	nodeRunID, errN := requestVarInt(r, "id")
 	if errN != nil {
 		return errN
@@ -104,5 +104,11 @@ func releaseApplicationWorkflowHandler(w http.ResponseWriter, r *http.Request, d
 		}
 	}
 
+	//The released runs are never purged
+	workflowRun.Tag(sdk.WorkflowRunTagRelease, req.TagName)
+	if err := workflow.UpdateRunTags(db, workflowRun); err != nil {
+		return sdk.WrapError(err, "releaseApplicationWorkflowHandler> Cannot tag workflow run %d", workflowRun.ID)
+	}
+
 	return nil
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// getWorkflowExportHandler returns a workflow as code
+func getWorkflowExportHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = "yaml"
+	}
+	f, errF := exportentities.GetFormat(format)
+	if errF != nil {
+		return sdk.WrapError(sdk.ErrWrongRequest, "getWorkflowExportHandler> Unable to get format : %s", errF)
+	}
+
+	wf, errW := workflow.Load(db, key, name, c.User)
+	if errW != nil {
+		return sdk.WrapError(errW, "getWorkflowExportHandler> Cannot load workflow %s", name)
+	}
+
+	e, errE := exportentities.NewWorkflow(*wf)
+	if errE != nil {
+		return sdk.WrapError(errE, "getWorkflowExportHandler> Unable to export workflow %s", name)
+	}
+
+	btes, errM := exportentities.Marshal(e, f)
+	if errM != nil {
+		return sdk.WrapError(errM, "getWorkflowExportHandler> Unable to marshal workflow %s", name)
+	}
+
+	switch f {
+	case exportentities.FormatJSON:
+		w.Header().Add("Content-Type", "application/json")
+	case exportentities.FormatYAML:
+		w.Header().Add("Content-Type", "application/x-yaml")
+	default:
+		w.Header().Add("Content-Type", "text/plain")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(btes)
+	return nil
+}
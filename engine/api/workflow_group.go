@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/group"
+	"github.com/ovh/cds/engine/api/permission"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+)
+
+func isValidWorkflowPermission(p int) bool {
+	switch p {
+	case permission.PermissionRead, permission.PermissionReadExecute, permission.PermissionReadExecuteApprove, permission.PermissionReadWriteExecute:
+		return true
+	}
+	return false
+}
+
+// checkWorkflowKeepsWriteGroup checks that another group than groupID keeps the write permission on the workflow
+func checkWorkflowKeepsWriteGroup(db gorp.SqlExecutor, wf *sdk.Workflow, groupID int64) error {
+	permissions, err := group.LoadAllWorkflowGroupByRole(db, wf.ID, permission.PermissionReadWriteExecute)
+	if err != nil {
+		return sdk.WrapError(err, "checkWorkflowKeepsWriteGroup> Cannot load groups for workflow %s", wf.Name)
+	}
+	if len(permissions) == 1 && permissions[0].Group.ID == groupID {
+		return sdk.WrapError(sdk.ErrGroupNeedWrite, "checkWorkflowKeepsWriteGroup> Cannot remove write permission of the last group on workflow %s", wf.Name)
+	}
+	return nil
+}
+
+func postWorkflowGroupHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+
+	var gp sdk.GroupPermission
+	if err := UnmarshalBody(r, &gp); err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot unmarshal request")
+	}
+	if !isValidWorkflowPermission(gp.Permission) {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowGroupHandler> Invalid permission %d", gp.Permission)
+	}
+
+	wf, err := workflow.Load(db, key, name, c.User)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot load workflow %s", name)
+	}
+
+	g, err := group.LoadGroup(db, gp.Group.Name)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot find %s", gp.Group.Name)
+	}
+
+	inWorkflow, err := group.CheckGroupInWorkflow(db, wf.ID, g.ID)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot check if group %s is already in workflow %s", g.Name, wf.Name)
+	}
+	if inWorkflow {
+		return sdk.WrapError(sdk.ErrGroupExists, "postWorkflowGroupHandler> Group %s already in workflow %s", g.Name, wf.Name)
+	}
+
+	if err := group.InsertGroupInWorkflow(db, wf.ID, g.ID, gp.Permission); err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot add group %s in workflow %s", g.Name, wf.Name)
+	}
+
+	if err := workflow.LoadGroups(db, wf); err != nil {
+		return sdk.WrapError(err, "postWorkflowGroupHandler> Cannot load workflow groups")
+	}
+	return WriteJSON(w, r, wf.Groups, http.StatusOK)
+}
+
+func putWorkflowGroupHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	groupName := vars["groupName"]
+
+	var gp sdk.GroupPermission
+	if err := UnmarshalBody(r, &gp); err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot unmarshal request")
+	}
+	if !isValidWorkflowPermission(gp.Permission) {
+		return sdk.WrapError(sdk.ErrWrongRequest, "putWorkflowGroupHandler> Invalid permission %d", gp.Permission)
+	}
+
+	wf, err := workflow.Load(db, key, name, c.User)
+	if err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot load workflow %s", name)
+	}
+
+	g, err := group.LoadGroup(db, groupName)
+	if err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot find %s", groupName)
+	}
+
+	inWorkflow, err := group.CheckGroupInWorkflow(db, wf.ID, g.ID)
+	if err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot check if group %s is in workflow %s", g.Name, wf.Name)
+	}
+	if !inWorkflow {
+		return sdk.WrapError(sdk.ErrGroupNotFound, "putWorkflowGroupHandler> Group %s not in workflow %s", g.Name, wf.Name)
+	}
+
+	if gp.Permission != permission.PermissionReadWriteExecute {
+		if err := checkWorkflowKeepsWriteGroup(db, wf, g.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := group.UpdateGroupRoleInWorkflow(db, wf.ID, g.ID, gp.Permission); err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot update group %s in workflow %s", g.Name, wf.Name)
+	}
+
+	if err := workflow.LoadGroups(db, wf); err != nil {
+		return sdk.WrapError(err, "putWorkflowGroupHandler> Cannot load workflow groups")
+	}
+	return WriteJSON(w, r, wf.Groups, http.StatusOK)
+}
+
+func deleteWorkflowGroupHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	groupName := vars["groupName"]
+
+	wf, err := workflow.Load(db, key, name, c.User)
+	if err != nil {
+		return sdk.WrapError(err, "deleteWorkflowGroupHandler> Cannot load workflow %s", name)
+	}
+
+	g, err := group.LoadGroup(db, groupName)
+	if err != nil {
+		return sdk.WrapError(err, "deleteWorkflowGroupHandler> Cannot find %s", groupName)
+	}
+
+	if err := checkWorkflowKeepsWriteGroup(db, wf, g.ID); err != nil {
+		return err
+	}
+
+	if err := group.DeleteGroupFromWorkflow(db, wf.ID, g.ID); err != nil {
+		return sdk.WrapError(err, "deleteWorkflowGroupHandler> Cannot delete group %s from workflow %s", g.Name, wf.Name)
+	}
+
+	if err := workflow.LoadGroups(db, wf); err != nil {
+		return sdk.WrapError(err, "deleteWorkflowGroupHandler> Cannot load workflow groups")
+	}
+	return WriteJSON(w, r, wf.Groups, http.StatusOK)
+}
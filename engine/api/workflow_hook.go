@@ -1,13 +1,19 @@
 package main
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/fsamin/go-dump"
 	"github.com/go-gorp/gorp"
 	"github.com/gorilla/mux"
 
 	"github.com/ovh/cds/engine/api/businesscontext"
 	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/engine/api/workflowhook"
 	"github.com/ovh/cds/sdk"
 )
 
@@ -75,3 +81,57 @@ func putWorkflowHookModelHandler(w http.ResponseWriter, r *http.Request, db *gor
 
 	return WriteJSON(w, r, m, http.StatusOK)
 }
+
+// postWorkflowWebHookHandler is the public handler called by third parties on a workflow webhook.
+// The body and the query parameters are flattened in the payload of the triggered workflow run
+func postWorkflowWebHookHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+
+	h, err := workflow.LoadHookByUUID(db, uuid)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowWebHookHandler> Unable to load hook %s", uuid)
+	}
+	if h.WorkflowHookModel.Name != workflow.WebHookModel.Name {
+		return sdk.WrapError(sdk.ErrNoHook, "postWorkflowWebHookHandler> Hook %s is not a webhook", uuid)
+	}
+
+	data, errr := ioutil.ReadAll(r.Body)
+	if errr != nil {
+		return sdk.ErrWrongRequest
+	}
+
+	payload := map[string]string{}
+	if len(data) > 0 {
+		var body interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowWebHookHandler> Unable to read body: %s", err)
+		}
+		m, errd := dump.ToMap(body, dump.WithDefaultLowerCaseFormatter())
+		if errd != nil {
+			return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowWebHookHandler> Unable to compute payload: %s", errd)
+		}
+		//Skip dump technical keys such as __len__ and __type__
+		for k, v := range m {
+			if strings.HasSuffix(k, "__") {
+				continue
+			}
+			payload[k] = v
+		}
+	}
+
+	for k := range r.URL.Query() {
+		payload[k] = r.URL.Query().Get(k)
+	}
+
+	e := &sdk.WorkflowNodeHookExecution{
+		WorkflowNodeHookUUID: uuid,
+		ExecutionPlannedDate: time.Now(),
+		Payload:              payload,
+	}
+	if err := workflowhook.InsertExecution(db, e); err != nil {
+		return sdk.WrapError(err, "postWorkflowWebHookHandler> Unable to insert execution")
+	}
+
+	return WriteJSON(w, r, e, http.StatusAccepted)
+}
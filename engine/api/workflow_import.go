@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+	"github.com/hashicorp/hcl"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/project"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/engine/api/workflowhook"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/exportentities"
+)
+
+// postWorkflowImportHandler creates or updates (with forceUpdate) a workflow from its code
+func postWorkflowImportHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	format := r.FormValue("format")
+	forceUpdate := FormBool(r, "forceUpdate")
+
+	proj, errp := project.Load(db, key, c.User, project.LoadOptions.WithPipelines, project.LoadOptions.WithApplications, project.LoadOptions.WithEnvironments)
+	if errp != nil {
+		return sdk.WrapError(errp, "postWorkflowImportHandler> Unable to load project %s", key)
+	}
+
+	// Get body
+	data, errRead := ioutil.ReadAll(r.Body)
+	if errRead != nil {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowImportHandler> Unable to read body")
+	}
+
+	// Compute format
+	f, errF := exportentities.GetFormat(format)
+	if errF != nil {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowImportHandler> Unable to get format : %s", errF)
+	}
+
+	// Parse the workflow. JSON is not parsed with hcl, which does not decode the arrays of objects
+	payload := &exportentities.Workflow{}
+	var errorParse error
+	switch f {
+	case exportentities.FormatJSON:
+		errorParse = json.Unmarshal(data, payload)
+	case exportentities.FormatHCL:
+		errorParse = hcl.Unmarshal(data, payload)
+	case exportentities.FormatYAML:
+		errorParse = yaml.Unmarshal(data, payload)
+	default:
+		errorParse = exportentities.ErrUnsupportedFormat
+	}
+	if errorParse != nil {
+		return sdk.WrapError(sdk.NewError(sdk.ErrWrongRequest, errorParse), "postWorkflowImportHandler> Cannot parse workflow")
+	}
+
+	wf, errW := payload.Workflow()
+	if errW != nil {
+		return sdk.WrapError(sdk.NewError(sdk.ErrWorkflowInvalid, errW), "postWorkflowImportHandler> Unable to parse workflow %s", payload.Name)
+	}
+
+	tx, errT := db.Begin()
+	if errT != nil {
+		return sdk.WrapError(errT, "postWorkflowImportHandler> Cannot start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := workflow.Import(tx, proj, wf, forceUpdate, c.User); err != nil {
+		return sdk.WrapError(err, "postWorkflowImportHandler> Unable to import workflow %s", wf.Name)
+	}
+
+	if err := project.UpdateLastModified(tx, c.User, proj); err != nil {
+		return sdk.WrapError(err, "postWorkflowImportHandler> Unable to update project")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "postWorkflowImportHandler> Cannot commit transaction")
+	}
+
+	wf1, errl := workflow.LoadByID(db, wf.ID, c.User)
+	if errl != nil {
+		return sdk.WrapError(errl, "postWorkflowImportHandler> Cannot load workflow")
+	}
+	workflowhook.SetWebHooksURL(wf1)
+
+	return WriteJSON(w, r, wf1, http.StatusOK)
+}
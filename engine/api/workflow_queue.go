@@ -15,6 +15,7 @@ import (
 
 	"github.com/ovh/cds/engine/api/artifact"
 	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/cache"
 	"github.com/ovh/cds/engine/api/objectstore"
 	"github.com/ovh/cds/engine/api/worker"
 	"github.com/ovh/cds/engine/api/workflow"
@@ -154,6 +155,28 @@ func postBookWorkflowJobHandler(w http.ResponseWriter, r *http.Request, db *gorp
 	return WriteJSON(w, r, nil, http.StatusOK)
 }
 
+func putWorkflowJobPriorityHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	id, errc := requestVarInt(r, "id")
+	if errc != nil {
+		return sdk.WrapError(errc, "putWorkflowJobPriorityHandler> invalid id")
+	}
+
+	job := sdk.WorkflowNodeJobRun{}
+	if err := UnmarshalBody(r, &job); err != nil {
+		return sdk.WrapError(err, "putWorkflowJobPriorityHandler> cannot unmarshal request")
+	}
+
+	if err := workflow.UpdateNodeJobRunPriority(db, id, job.Priority); err != nil {
+		return sdk.WrapError(err, "putWorkflowJobPriorityHandler> Cannot update priority of job %d", id)
+	}
+
+	j, err := workflow.LoadNodeJobRun(db, id)
+	if err != nil {
+		return sdk.WrapError(err, "putWorkflowJobPriorityHandler> job not found")
+	}
+	return WriteJSON(w, r, j, http.StatusOK)
+}
+
 func getWorkflowJobHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	id, errc := requestVarInt(r, "id")
 	if errc != nil {
@@ -549,3 +572,105 @@ func postWorkflowJobArtifactHandler(w http.ResponseWriter, r *http.Request, db *
 	}
 	return nil
 }
+
+//postWorkflowJobArtifactWithTempURLHandler returns a temporary url to upload an artifact directly on the objectstore.
+//The artifact is recorded only when the worker calls postWorkflowJobArtifactWithTempURLCallbackHandler.
+func postWorkflowJobArtifactWithTempURLHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	if !objectstore.TemporaryURLSupported() {
+		return sdk.WrapError(sdk.ErrNotImplemented, "postWorkflowJobArtifactWithTempURLHandler> Temporary urls are not supported by the objectstore")
+	}
+
+	id, errI := requestVarInt(r, "permID")
+	if errI != nil {
+		return sdk.WrapError(sdk.ErrInvalidID, "postWorkflowJobArtifactWithTempURLHandler> Invalid node job run ID")
+	}
+	tag := mux.Vars(r)["tag"]
+
+	var art sdk.WorkflowNodeRunArtifact
+	if err := UnmarshalBody(r, &art); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobArtifactWithTempURLHandler> Cannot read artifact")
+	}
+	if art.Name == "" {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowJobArtifactWithTempURLHandler> Artifact name is not set")
+	}
+	if max := objectstore.TemporaryURLMaxSize(); max > 0 && art.Size > max {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkflowJobArtifactWithTempURLHandler> Artifact %s is too large to be uploaded with a temporary url: %d bytes", art.Name, art.Size)
+	}
+
+	nodeJobRun, errJ := workflow.LoadNodeJobRun(db, id)
+	if errJ != nil {
+		return sdk.WrapError(errJ, "postWorkflowJobArtifactWithTempURLHandler> Cannot load node job run")
+	}
+
+	nodeRun, errR := workflow.LoadNodeRunByID(db, nodeJobRun.WorkflowNodeRunID)
+	if errR != nil {
+		return sdk.WrapError(errR, "postWorkflowJobArtifactWithTempURLHandler> Cannot load node run")
+	}
+
+	hash, errG := generateHash()
+	if errG != nil {
+		return sdk.WrapError(errG, "postWorkflowJobArtifactWithTempURLHandler> Could not generate hash")
+	}
+	secretKey, errS := generateHash()
+	if errS != nil {
+		return sdk.WrapError(errS, "postWorkflowJobArtifactWithTempURLHandler> Could not generate secret key")
+	}
+
+	art.Tag = tag
+	art.DownloadHash = hash
+	art.WorkflowNodeRunID = nodeRun.ID
+	art.WorkflowID = nodeRun.WorkflowRunID
+	art.Created = time.Now()
+	art.TempURLSecretKey = secretKey
+
+	tempURL, objectPath, errU := objectstore.StoreArtifactURL(&art)
+	if errU != nil {
+		return sdk.WrapError(errU, "postWorkflowJobArtifactWithTempURLHandler> Cannot get temporary url")
+	}
+	art.ObjectPath = objectPath
+
+	//The upload may last longer than the validity of the url
+	cache.SetWithTTL(tempURLArtifactCacheKey(id, tag, art.Name), art, 24*60*60)
+
+	art.TempURL = tempURL
+	return WriteJSON(w, r, art, http.StatusOK)
+}
+
+//postWorkflowJobArtifactWithTempURLCallbackHandler records an artifact uploaded with a temporary url
+func postWorkflowJobArtifactWithTempURLCallbackHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	id, errI := requestVarInt(r, "permID")
+	if errI != nil {
+		return sdk.WrapError(sdk.ErrInvalidID, "postWorkflowJobArtifactWithTempURLCallbackHandler> Invalid node job run ID")
+	}
+	tag := mux.Vars(r)["tag"]
+
+	var art sdk.WorkflowNodeRunArtifact
+	if err := UnmarshalBody(r, &art); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobArtifactWithTempURLCallbackHandler> Cannot read artifact")
+	}
+
+	key := tempURLArtifactCacheKey(id, tag, art.Name)
+	var cachedArt sdk.WorkflowNodeRunArtifact
+	if !cache.Get(key, &cachedArt) {
+		return sdk.WrapError(sdk.ErrNotFound, "postWorkflowJobArtifactWithTempURLCallbackHandler> Unable to find artifact %s", art.Name)
+	}
+	if art.TempURLSecretKey == "" || art.TempURLSecretKey != cachedArt.TempURLSecretKey {
+		return sdk.WrapError(sdk.ErrForbidden, "postWorkflowJobArtifactWithTempURLCallbackHandler> Invalid secret key for artifact %s", art.Name)
+	}
+	cache.Delete(key)
+
+	//Only the checksum and the size come from the worker, the location of the object comes from the cache
+	cachedArt.Size = art.Size
+	cachedArt.Perm = art.Perm
+	cachedArt.MD5sum = art.MD5sum
+	cachedArt.TempURLSecretKey = ""
+	if err := workflow.InsertArtifact(db, &cachedArt); err != nil {
+		_ = objectstore.DeleteArtifact(&cachedArt)
+		return sdk.WrapError(err, "postWorkflowJobArtifactWithTempURLCallbackHandler> Cannot insert artifact")
+	}
+	return WriteJSON(w, r, cachedArt, http.StatusOK)
+}
+
+func tempURLArtifactCacheKey(nodeJobRunID int64, tag, name string) string {
+	return cache.Key("workflows", "artifacts", "tempurl", strconv.FormatInt(nodeJobRunID, 10), tag, name)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/artifact"
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//jobCacheKeyRegexp matches the sha256 keys computed by the workers
+var jobCacheKeyRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+//loadCachedJob loads a node job run with a cached job, its node run and its workflow run
+func loadCachedJob(db gorp.SqlExecutor, r *http.Request) (*sdk.WorkflowNodeJobRun, *sdk.WorkflowNodeRun, *sdk.WorkflowRun, string, error) {
+	id, errI := requestVarInt(r, "permID")
+	if errI != nil {
+		return nil, nil, nil, "", sdk.WrapError(sdk.ErrInvalidID, "loadCachedJob> Invalid node job run ID")
+	}
+
+	key := mux.Vars(r)["key"]
+	if !jobCacheKeyRegexp.MatchString(key) {
+		return nil, nil, nil, "", sdk.WrapError(sdk.ErrWrongRequest, "loadCachedJob> Invalid cache key %s", key)
+	}
+
+	job, errJ := workflow.LoadNodeJobRun(db, id)
+	if errJ != nil {
+		return nil, nil, nil, "", sdk.WrapError(errJ, "loadCachedJob> Cannot load node job run %d", id)
+	}
+	if !job.Job.IsCached() {
+		return nil, nil, nil, "", sdk.WrapError(sdk.ErrForbidden, "loadCachedJob> Cache is not enabled on job %s", job.Job.Action.Name)
+	}
+
+	nodeRun, errR := workflow.LoadNodeRunByID(db, job.WorkflowNodeRunID)
+	if errR != nil {
+		return nil, nil, nil, "", sdk.WrapError(errR, "loadCachedJob> Cannot load node run %d", job.WorkflowNodeRunID)
+	}
+
+	wr, errW := workflow.LoadRunByID(db, nodeRun.WorkflowRunID)
+	if errW != nil {
+		return nil, nil, nil, "", sdk.WrapError(errW, "loadCachedJob> Cannot load workflow run %d", nodeRun.WorkflowRunID)
+	}
+	return job, nodeRun, wr, key, nil
+}
+
+//postWorkflowJobCacheHandler records the artifacts and the variables of a successful job in the cache
+func postWorkflowJobCacheHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	var in sdk.JobCacheEntry
+	if err := UnmarshalBody(r, &in); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheHandler> Cannot read cache entry")
+	}
+
+	_, nodeRun, wr, key, err := loadCachedJob(db, r)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheHandler>")
+	}
+
+	arts, errA := workflow.JobCacheArtifacts(db, nodeRun.ID, in.Artifacts)
+	if errA != nil {
+		return sdk.WrapError(errA, "postWorkflowJobCacheHandler> Cannot load artifacts of job")
+	}
+
+	e := sdk.JobCacheEntry{
+		ProjectID:         wr.ProjectID,
+		Key:               key,
+		WorkflowName:      wr.Workflow.Name,
+		RunNumber:         wr.Number,
+		WorkflowNodeRunID: nodeRun.ID,
+		Artifacts:         arts,
+		Variables:         in.Variables,
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return sdk.WrapError(errb, "postWorkflowJobCacheHandler> Cannot begin tx")
+	}
+	defer tx.Rollback()
+
+	if err := workflow.InsertJobCache(tx, &e); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheHandler> Cannot record cache")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheHandler> Cannot commit tx")
+	}
+	return WriteJSON(w, r, e, http.StatusOK)
+}
+
+//postWorkflowJobCacheRestoreHandler restores the artifacts of a cache entry on the node run of a job and returns the variables to export.
+//It returns a 404 if there is no usable cache entry for the key.
+func postWorkflowJobCacheRestoreHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	job, nodeRun, wr, key, err := loadCachedJob(db, r)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheRestoreHandler>")
+	}
+
+	e, errL := workflow.LoadJobCache(db, wr.ProjectID, key)
+	if errL != nil {
+		return sdk.WrapError(errL, "postWorkflowJobCacheRestoreHandler> Cannot load cache")
+	}
+
+	//The artifacts of the cached job may have been purged since
+	valid, errV := workflow.IsJobCacheValid(db, e)
+	if errV != nil {
+		return sdk.WrapError(errV, "postWorkflowJobCacheRestoreHandler> Cannot check cache")
+	}
+	if !valid {
+		log.Info("postWorkflowJobCacheRestoreHandler> Deleting cache %s, its artifacts have been purged", key)
+		if err := workflow.DeleteJobCache(db, e.ID); err != nil {
+			return sdk.WrapError(err, "postWorkflowJobCacheRestoreHandler>")
+		}
+		return sdk.WrapError(sdk.ErrNotFound, "postWorkflowJobCacheRestoreHandler> Cache %s is not valid anymore", key)
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return sdk.WrapError(errb, "postWorkflowJobCacheRestoreHandler> Cannot begin tx")
+	}
+	defer tx.Rollback()
+
+	restored := make([]sdk.WorkflowNodeRunArtifact, 0, len(e.Artifacts))
+	for i := range e.Artifacts {
+		art, err := restoreJobCacheArtifact(tx, &e.Artifacts[i], nodeRun)
+		if err != nil {
+			//Do not leave the already restored artifacts in the objectstore
+			for j := range restored {
+				_ = objectstore.DeleteArtifact(&restored[j])
+			}
+			return sdk.WrapError(err, "postWorkflowJobCacheRestoreHandler> Cannot restore artifact %s", e.Artifacts[i].Name)
+		}
+		restored = append(restored, *art)
+	}
+
+	infos := []sdk.SpawnInfo{{
+		RemoteTime: time.Now(),
+		Message:    sdk.SpawnMsg{ID: sdk.MsgSpawnInfoJobCached.ID, Args: []interface{}{e.WorkflowName, e.RunNumber}},
+	}}
+	if _, err := workflow.AddSpawnInfosNodeJobRun(tx, job.ID, infos); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheRestoreHandler> Cannot save spawn info on job %d", job.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "postWorkflowJobCacheRestoreHandler> Cannot commit tx")
+	}
+
+	e.Artifacts = restored
+	return WriteJSON(w, r, e, http.StatusOK)
+}
+
+//restoreJobCacheArtifact copies a cached artifact on a node run
+func restoreJobCacheArtifact(db gorp.SqlExecutor, cached *sdk.WorkflowNodeRunArtifact, nodeRun *sdk.WorkflowNodeRun) (*sdk.WorkflowNodeRunArtifact, error) {
+	hash, errG := generateHash()
+	if errG != nil {
+		return nil, sdk.WrapError(errG, "restoreJobCacheArtifact> Could not generate hash")
+	}
+
+	content, errF := objectstore.FetchArtifact(cached)
+	if errF != nil {
+		return nil, sdk.WrapError(errF, "restoreJobCacheArtifact> Cannot fetch artifact %d", cached.ID)
+	}
+	defer content.Close()
+
+	art := sdk.WorkflowNodeRunArtifact{
+		Name:              cached.Name,
+		Tag:               cached.Tag,
+		DownloadHash:      hash,
+		Size:              cached.Size,
+		Perm:              cached.Perm,
+		MD5sum:            cached.MD5sum,
+		WorkflowNodeRunID: nodeRun.ID,
+		WorkflowID:        nodeRun.WorkflowRunID,
+		Created:           time.Now(),
+	}
+	if err := artifact.SaveWorkflowFile(&art, content); err != nil {
+		return nil, sdk.WrapError(err, "restoreJobCacheArtifact> Cannot save artifact in store")
+	}
+	if err := workflow.InsertArtifact(db, &art); err != nil {
+		_ = objectstore.DeleteArtifact(&art)
+		return nil, sdk.WrapError(err, "restoreJobCacheArtifact> Cannot insert artifact")
+	}
+	return &art, nil
+}
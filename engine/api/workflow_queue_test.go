@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -94,8 +95,8 @@ func test_runWorkflow(t *testing.T, db *gorp.DbMap, testName string) test_runWor
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
 	}
 	uri := router.getRoute("POST", postWorkflowRunHandler, vars)
 	test.NotEmpty(t, uri)
@@ -227,9 +228,9 @@ func Test_postTakeWorkflowJobHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the worker
@@ -263,9 +264,9 @@ func Test_postBookWorkflowJobHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the hatchery
@@ -290,9 +291,9 @@ func Test_postWorkflowJobResultHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the worker
@@ -313,9 +314,9 @@ func Test_postWorkflowJobResultHandler(t *testing.T) {
 	assert.Equal(t, 200, rec.Code)
 
 	vars = map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"permID":         fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"permID":           fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Send logs
@@ -358,9 +359,9 @@ func Test_postWorkflowJobTestsResultsHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the worker
@@ -470,9 +471,9 @@ func Test_postWorkflowJobVariableHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the worker
@@ -493,9 +494,9 @@ func Test_postWorkflowJobVariableHandler(t *testing.T) {
 	assert.Equal(t, 200, rec.Code)
 
 	vars = map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"permID":         fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"permID":           fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Send result
@@ -534,9 +535,9 @@ func Test_postWorkflowJobArtifactHandler(t *testing.T) {
 
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"id":             fmt.Sprintf("%d", ctx.job.ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
 	}
 
 	//Register the worker
@@ -593,10 +594,10 @@ func Test_postWorkflowJobArtifactHandler(t *testing.T) {
 
 	//Prepare request
 	vars = map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"number":         fmt.Sprintf("%d", updatedNodeRun.Number),
-		"id":             fmt.Sprintf("%d", wNodeJobRun.WorkflowNodeRunID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"number":           fmt.Sprintf("%d", updatedNodeRun.Number),
+		"id":               fmt.Sprintf("%d", wNodeJobRun.WorkflowNodeRunID),
 	}
 	uri = router.getRoute("GET", getWorkflowNodeRunArtifactsHandler, vars)
 	test.NotEmpty(t, uri)
@@ -613,9 +614,9 @@ func Test_postWorkflowJobArtifactHandler(t *testing.T) {
 	// Download artifact
 	//Prepare request
 	vars = map[string]string{
-		"permProjectKey": ctx.project.Key,
-		"workflowName":   ctx.workflow.Name,
-		"artifactId":     fmt.Sprintf("%d", arts[0].ID),
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"artifactId":       fmt.Sprintf("%d", arts[0].ID),
 	}
 	uri = router.getRoute("GET", getDownloadArtifactHandler, vars)
 	test.NotEmpty(t, uri)
@@ -629,6 +630,125 @@ func Test_postWorkflowJobArtifactHandler(t *testing.T) {
 	assert.Equal(t, 200, rec.Code)
 	assert.Equal(t, "Hi, I am foo", string(body))
 }
+func Test_postWorkflowJobArtifactWithTempURLHandler(t *testing.T) {
+	db := test.SetupPG(t)
+	ctx := test_runWorkflow(t, db, "/Test_postWorkflowJobArtifactWithTempURLHandler")
+	test_getWorkflowJob(t, db, &ctx)
+	assert.NotNil(t, ctx.job)
+
+	// Init store, the temporary urls are computed without any call to the storage
+	cfg := objectstore.Config{
+		Kind: objectstore.S3,
+		Options: objectstore.ConfigOptions{
+			S3: objectstore.ConfigOptionsS3{
+				Endpoint:        "http://localhost:9000",
+				Bucket:          "cds",
+				AccessKeyID:     "key",
+				SecretAccessKey: "secret",
+				PathStyle:       true,
+			},
+		},
+	}
+	test.NoError(t, objectstore.Initialize(context.Background(), cfg))
+	defer objectstore.Initialize(context.Background(), objectstore.Config{
+		Kind: objectstore.Filesystem,
+		Options: objectstore.ConfigOptions{
+			Filesystem: objectstore.ConfigOptionsFilesystem{
+				Basedir: path.Join(os.TempDir(), "store"),
+			},
+		},
+	})
+
+	//Register the worker
+	test_registerWorker(t, db, &ctx)
+
+	//Take
+	uri := router.getRoute("POST", postTakeWorkflowJobHandler, map[string]string{
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"id":               fmt.Sprintf("%d", ctx.job.ID),
+	})
+	test.NotEmpty(t, uri)
+	req := assets.NewAuthentifiedRequestFromWorker(t, ctx.worker, "POST", uri, worker.TakeForm{BookedJobID: ctx.job.ID, Time: time.Now()})
+	rec := httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	vars := map[string]string{
+		"tag":    "latest",
+		"permID": fmt.Sprintf("%d", ctx.job.ID),
+	}
+
+	//Get the upload url
+	uri = router.getRoute("POST", postWorkflowJobArtifactWithTempURLHandler, vars)
+	test.NotEmpty(t, uri)
+
+	//The artifacts too large for a single PUT are refused
+	req = assets.NewAuthentifiedRequestFromWorker(t, ctx.worker, "POST", uri, sdk.WorkflowNodeRunArtifact{Name: "myartifact", Size: objectstore.TemporaryURLMaxSize() + 1, Perm: 7, MD5sum: "123"})
+	rec = httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+
+	req = assets.NewAuthentifiedRequestFromWorker(t, ctx.worker, "POST", uri, sdk.WorkflowNodeRunArtifact{Name: "myartifact", Size: 12, Perm: 7, MD5sum: "123"})
+	rec = httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	if !assert.Equal(t, 200, rec.Code) {
+		t.FailNow()
+	}
+
+	var art sdk.WorkflowNodeRunArtifact
+	test.NoError(t, json.Unmarshal(rec.Body.Bytes(), &art))
+	assert.True(t, strings.HasPrefix(art.TempURL, "http://localhost:9000/cds/"))
+	assert.Contains(t, art.TempURL, "X-Amz-Signature=")
+	test.NotEmpty(t, art.TempURLSecretKey)
+
+	//Nothing is recorded before the callback
+	nodeRun, errN := workflow.LoadNodeRunByID(db, ctx.job.WorkflowNodeRunID)
+	test.NoError(t, errN)
+	assert.Len(t, nodeRun.Artifacts, 0)
+
+	//The callback needs the secret key
+	uri = router.getRoute("POST", postWorkflowJobArtifactWithTempURLCallbackHandler, vars)
+	test.NotEmpty(t, uri)
+	wrongArt := art
+	wrongArt.TempURLSecretKey = "wrong"
+	req = assets.NewAuthentifiedRequestFromWorker(t, ctx.worker, "POST", uri, wrongArt)
+	rec = httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	assert.Equal(t, 403, rec.Code)
+
+	req = assets.NewAuthentifiedRequestFromWorker(t, ctx.worker, "POST", uri, art)
+	rec = httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	nodeRun, errN = workflow.LoadNodeRunByID(db, ctx.job.WorkflowNodeRunID)
+	test.NoError(t, errN)
+	if !assert.Len(t, nodeRun.Artifacts, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "myartifact", nodeRun.Artifacts[0].Name)
+	assert.Equal(t, "123", nodeRun.Artifacts[0].MD5sum)
+	assert.Equal(t, art.ObjectPath, nodeRun.Artifacts[0].ObjectPath)
+
+	//Get the download url
+	uri = router.getRoute("GET", getDownloadArtifactWithTempURLHandler, map[string]string{
+		"permProjectKey":   ctx.project.Key,
+		"permWorkflowName": ctx.workflow.Name,
+		"artifactId":       fmt.Sprintf("%d", nodeRun.Artifacts[0].ID),
+	})
+	test.NotEmpty(t, uri)
+	req = assets.NewAuthentifiedRequest(t, ctx.user, ctx.password, "GET", uri, nil)
+	rec = httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	var dlArt sdk.WorkflowNodeRunArtifact
+	test.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dlArt))
+	assert.Contains(t, dlArt.TempURL, "X-Amz-Signature=")
+	assert.Empty(t, dlArt.TempURLSecretKey)
+}
+
 func Test_getWorkflowJobArtifactsHandler(t *testing.T) {
 	//db := test.SetupPG(t)
 	//ctx := runWorkflow(t, db, "Test_postWorkflowJobRequirementsErrorHandler")
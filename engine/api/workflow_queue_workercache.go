@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorilla/mux"
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/workercache"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+)
+
+//loadWorkerCacheRequest returns the project of the node job run and the cache key of a request
+func loadWorkerCacheRequest(db gorp.SqlExecutor, r *http.Request) (int64, string, error) {
+	id, errI := requestVarInt(r, "permID")
+	if errI != nil {
+		return 0, "", sdk.WrapError(sdk.ErrInvalidID, "loadWorkerCacheRequest> Invalid node job run ID")
+	}
+
+	key := mux.Vars(r)["key"]
+	if !sdk.IsValidWorkerCacheKey(key) {
+		return 0, "", sdk.WrapError(sdk.ErrInvalidWorkerCacheKey, "loadWorkerCacheRequest> Invalid cache key %s", key)
+	}
+
+	job, errJ := workflow.LoadNodeJobRun(db, id)
+	if errJ != nil {
+		return 0, "", sdk.WrapError(errJ, "loadWorkerCacheRequest> Cannot load node job run %d", id)
+	}
+
+	nodeRun, errR := workflow.LoadNodeRunByID(db, job.WorkflowNodeRunID)
+	if errR != nil {
+		return 0, "", sdk.WrapError(errR, "loadWorkerCacheRequest> Cannot load node run %d", job.WorkflowNodeRunID)
+	}
+
+	wr, errW := workflow.LoadRunByID(db, nodeRun.WorkflowRunID)
+	if errW != nil {
+		return 0, "", sdk.WrapError(errW, "loadWorkerCacheRequest> Cannot load workflow run %d", nodeRun.WorkflowRunID)
+	}
+	return wr.ProjectID, key, nil
+}
+
+//postWorkerCacheHandler saves the cache archive sent by a worker
+func postWorkerCacheHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	projectID, key, err := loadWorkerCacheRequest(db, r)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkerCacheHandler>")
+	}
+
+	if r.ContentLength < 0 {
+		return sdk.WrapError(sdk.ErrWrongRequest, "postWorkerCacheHandler> Content-Length is required")
+	}
+
+	cache := sdk.WorkerCache{
+		ProjectID: projectID,
+		Key:       key,
+		Size:      r.ContentLength,
+	}
+	quota := viper.GetInt64(viperWorkflowsCacheQuota) * 1024 * 1024
+	if err := workercache.Save(db, &cache, r.Body, quota); err != nil {
+		return sdk.WrapError(err, "postWorkerCacheHandler> Cannot save cache %s", key)
+	}
+	return WriteJSON(w, r, cache, http.StatusOK)
+}
+
+//postWorkerCacheRestoreHandler returns the cache saved under the key, or else the latest cache matching one of the prefixes of the body
+func postWorkerCacheRestoreHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	projectID, key, err := loadWorkerCacheRequest(db, r)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkerCacheRestoreHandler>")
+	}
+
+	var prefixes []string
+	if err := UnmarshalBody(r, &prefixes); err != nil {
+		return sdk.WrapError(err, "postWorkerCacheRestoreHandler> Cannot read prefixes")
+	}
+
+	cache, errL := workercache.Load(db, projectID, key)
+	for i := 0; errL == sdk.ErrNotFound && i < len(prefixes); i++ {
+		if !sdk.IsValidWorkerCacheKey(prefixes[i]) {
+			return sdk.WrapError(sdk.ErrInvalidWorkerCacheKey, "postWorkerCacheRestoreHandler> Invalid cache prefix %s", prefixes[i])
+		}
+		cache, errL = workercache.LoadByPrefix(db, projectID, prefixes[i])
+	}
+	if errL != nil {
+		return sdk.WrapError(errL, "postWorkerCacheRestoreHandler> Cannot load cache %s", key)
+	}
+	return WriteJSON(w, r, cache, http.StatusOK)
+}
+
+//postWorkerCacheDownloadHandler streams the cache archive saved under the key
+func postWorkerCacheDownloadHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	projectID, key, err := loadWorkerCacheRequest(db, r)
+	if err != nil {
+		return sdk.WrapError(err, "postWorkerCacheDownloadHandler>")
+	}
+
+	cache, errL := workercache.Load(db, projectID, key)
+	if errL != nil {
+		return sdk.WrapError(errL, "postWorkerCacheDownloadHandler> Cannot load cache %s", key)
+	}
+
+	content, errF := workercache.Fetch(db, cache)
+	if errF != nil {
+		return sdk.WrapError(errF, "postWorkerCacheDownloadHandler>")
+	}
+	defer content.Close()
+
+	w.Header().Add("Content-Type", "application/octet-stream")
+	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", cache.GetName()))
+	if _, err := io.Copy(w, content); err != nil {
+		return sdk.WrapError(err, "postWorkerCacheDownloadHandler> Cannot stream cache %s", key)
+	}
+	return nil
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/ovh/cds/engine/api/artifact"
 	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/objectstore"
+	"github.com/ovh/cds/engine/api/permission"
 	"github.com/ovh/cds/engine/api/workflow"
 	"github.com/ovh/cds/sdk"
 	"github.com/ovh/cds/sdk/log"
@@ -56,7 +58,7 @@ func getWorkflowRunsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 	}
 
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 	runs, offset, limit, count, err := workflow.LoadRuns(db, key, name, offset, limit)
 	if err != nil {
 		return sdk.WrapError(err, "getWorkflowRunsHandler> Unable to load workflow runs")
@@ -72,8 +74,8 @@ func getWorkflowRunsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 	if len(runs) < count {
 		baseLinkURL := router.url +
 			router.getRoute("GET", getWorkflowRunsHandler, map[string]string{
-				"permProjectKey": key,
-				"workflowName":   name,
+				"permProjectKey":   key,
+				"permWorkflowName": name,
 			})
 		code = http.StatusPartialContent
 
@@ -133,7 +135,7 @@ func getWorkflowRunsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 func getLatestWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 	run, err := workflow.LoadLastRun(db, key, name)
 	if err != nil {
 		return sdk.WrapError(err, "getLatestWorkflowRunHandler> Unable to load last workflow run")
@@ -145,7 +147,7 @@ func getLatestWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gor
 func getWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 	number, err := requestVarInt(r, "number")
 	if err != nil {
 		return err
@@ -161,7 +163,7 @@ func getWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMa
 func getWorkflowNodeRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 	number, err := requestVarInt(r, "number")
 	if err != nil {
 		return err
@@ -178,6 +180,226 @@ func getWorkflowNodeRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.
 	return WriteJSON(w, r, run, http.StatusOK)
 }
 
+func stopWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	number, err := requestVarInt(r, "number")
+	if err != nil {
+		return err
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return errb
+	}
+	defer tx.Rollback()
+
+	run, errl := workflow.LoadRun(tx, key, name, number)
+	if errl != nil {
+		return sdk.WrapError(errl, "stopWorkflowRunHandler> Unable to load workflow run")
+	}
+
+	if err := workflow.StopWorkflowRun(tx, run, c.User); err != nil {
+		return sdk.WrapError(err, "stopWorkflowRunHandler> Unable to stop workflow run")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "stopWorkflowRunHandler> Unable to commit transaction")
+	}
+
+	run.Translate(r.Header.Get("Accept-Language"))
+	return WriteJSON(w, r, run, http.StatusOK)
+}
+
+func stopWorkflowNodeRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	number, err := requestVarInt(r, "number")
+	if err != nil {
+		return err
+	}
+	id, err := requestVarInt(r, "id")
+	if err != nil {
+		return err
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return errb
+	}
+	defer tx.Rollback()
+
+	run, errl := workflow.LoadRun(tx, key, name, number)
+	if errl != nil {
+		return sdk.WrapError(errl, "stopWorkflowNodeRunHandler> Unable to load workflow run")
+	}
+
+	if err := workflow.StopWorkflowNodeRun(tx, run, id, c.User); err != nil {
+		return sdk.WrapError(err, "stopWorkflowNodeRunHandler> Unable to stop workflow node run")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "stopWorkflowNodeRunHandler> Unable to commit transaction")
+	}
+
+	nodeRun, errn := workflow.LoadNodeRun(db, key, name, number, id)
+	if errn != nil {
+		return sdk.WrapError(errn, "stopWorkflowNodeRunHandler> Unable to load workflow node run")
+	}
+	nodeRun.Translate(r.Header.Get("Accept-Language"))
+	return WriteJSON(w, r, nodeRun, http.StatusOK)
+}
+
+func postWorkflowNodeRunRestartHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	number, err := requestVarInt(r, "number")
+	if err != nil {
+		return err
+	}
+	id, err := requestVarInt(r, "id")
+	if err != nil {
+		return err
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return errb
+	}
+	defer tx.Rollback()
+
+	wf, errl := workflow.Load(tx, key, name, c.User)
+	if errl != nil {
+		return sdk.WrapError(errl, "postWorkflowNodeRunRestartHandler> Unable to load workflow")
+	}
+
+	lastRun, errlr := workflow.LoadRun(tx, key, name, number)
+	if errlr != nil {
+		return sdk.WrapError(errlr, "postWorkflowNodeRunRestartHandler> Unable to load workflow run")
+	}
+	nodeRun, errnr := workflow.LoadNodeRun(tx, key, name, number, id)
+	if errnr != nil {
+		return sdk.WrapError(errnr, "postWorkflowNodeRunRestartHandler> Unable to load node run")
+	}
+	if err := checkManualRunPermission(wf, lastRun.Workflow.GetNode(nodeRun.WorkflowNodeID), c.User); err != nil {
+		return sdk.WrapError(err, "postWorkflowNodeRunRestartHandler> Unable to restart node run")
+	}
+
+	wr, errr := workflow.RestartFailedJobs(tx, wf, number, &sdk.WorkflowNodeRunManual{User: *c.User}, id)
+	if errr != nil {
+		return sdk.WrapError(errr, "postWorkflowNodeRunRestartHandler> Unable to restart workflow node run")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "postWorkflowNodeRunRestartHandler> Unable to commit transaction")
+	}
+
+	wr.Translate(r.Header.Get("Accept-Language"))
+	return WriteJSON(w, r, wr, http.StatusOK)
+}
+
+// checkManualRunPermission checks that the user is allowed to manually run the node of the workflow
+func checkManualRunPermission(wf *sdk.Workflow, n *sdk.WorkflowNode, u *sdk.User) error {
+	if n == nil {
+		return sdk.ErrWorkflowNodeNotFound
+	}
+	if n.Context == nil || !n.Context.ProtectedManualRun {
+		return nil
+	}
+	if !permission.AccessToWorkflow(wf.ID, u, permission.PermissionReadExecuteApprove) {
+		return sdk.WrapError(sdk.ErrForbidden, "checkManualRunPermission> %s is not allowed to run the protected node %s", u.Username, n.Name)
+	}
+	return nil
+}
+
+type postWorkflowNodeRunApprovalHandlerOption struct {
+	Approved bool   `json:"approved"`
+	Comment  string `json:"comment"`
+}
+
+func postWorkflowNodeRunApprovalHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+	number, err := requestVarInt(r, "number")
+	if err != nil {
+		return err
+	}
+	id, err := requestVarInt(r, "id")
+	if err != nil {
+		return err
+	}
+
+	opts := &postWorkflowNodeRunApprovalHandlerOption{}
+	if err := UnmarshalBody(r, opts); err != nil {
+		return err
+	}
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return errb
+	}
+	defer tx.Rollback()
+
+	run, errl := workflow.LoadRun(tx, key, name, number)
+	if errl != nil {
+		return sdk.WrapError(errl, "postWorkflowNodeRunApprovalHandler> Unable to load workflow run")
+	}
+	nodeRun, errnr := workflow.LoadNodeRun(tx, key, name, number, id)
+	if errnr != nil {
+		return sdk.WrapError(errnr, "postWorkflowNodeRunApprovalHandler> Unable to load node run")
+	}
+	if err := checkApprovalPermission(run.WorkflowID, run.Workflow.GetNode(nodeRun.WorkflowNodeID), c.User); err != nil {
+		return sdk.WrapError(err, "postWorkflowNodeRunApprovalHandler> Unable to approve node run")
+	}
+
+	if _, err := workflow.ApproveNodeRun(tx, run, id, c.User, opts.Approved, opts.Comment); err != nil {
+		return sdk.WrapError(err, "postWorkflowNodeRunApprovalHandler> Unable to approve workflow node run")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sdk.WrapError(err, "postWorkflowNodeRunApprovalHandler> Unable to commit transaction")
+	}
+
+	nodeRun, errn := workflow.LoadNodeRun(db, key, name, number, id)
+	if errn != nil {
+		return sdk.WrapError(errn, "postWorkflowNodeRunApprovalHandler> Unable to load workflow node run")
+	}
+	nodeRun.Translate(r.Header.Get("Accept-Language"))
+	return WriteJSON(w, r, nodeRun, http.StatusOK)
+}
+
+// checkApprovalPermission checks that the user is allowed to approve the node of the workflow.
+// The user must belong to one of the approval groups, or have the approve permission if there is no group.
+func checkApprovalPermission(workflowID int64, n *sdk.WorkflowNode, u *sdk.User) error {
+	if n == nil {
+		return sdk.ErrWorkflowNodeNotFound
+	}
+	if n.Context == nil || !n.Context.Approval.IsRequired() {
+		return sdk.ErrWorkflowNodeRunNotWaitingApproval
+	}
+	if u.Admin {
+		return nil
+	}
+	if len(n.Context.Approval.Groups) == 0 {
+		if !permission.AccessToWorkflow(workflowID, u, permission.PermissionReadExecuteApprove) {
+			return sdk.WrapError(sdk.ErrForbidden, "checkApprovalPermission> %s is not allowed to approve the node %s", u.Username, n.Name)
+		}
+		return nil
+	}
+	for _, g := range u.Groups {
+		for _, name := range n.Context.Approval.Groups {
+			if g.Name == name {
+				return nil
+			}
+		}
+	}
+	return sdk.WrapError(sdk.ErrForbidden, "checkApprovalPermission> %s is not a member of the approval groups of node %s", u.Username, n.Name)
+}
+
 type postWorkflowRunHandlerOption struct {
 	Hook       *sdk.WorkflowNodeRunHookEvent `json:"hook,omitempty"`
 	Manual     *sdk.WorkflowNodeRunManual    `json:"manual,omitempty"`
@@ -188,7 +410,7 @@ type postWorkflowRunHandlerOption struct {
 func postWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	tx, errb := db.Begin()
 	if errb != nil {
@@ -258,6 +480,19 @@ func postWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 
 		log.Debug("Manual run: %#v", opts.Manual)
 
+		//Nodes of a previous run are checked against the workflow of this run
+		runWf := wf
+		if lastRun != nil {
+			runWf = &lastRun.Workflow
+		}
+		n := runWf.Root
+		if opts.FromNodeID != nil {
+			n = runWf.GetNode(*opts.FromNodeID)
+		}
+		if err := checkManualRunPermission(wf, n, c.User); err != nil {
+			return sdk.WrapError(err, "postWorkflowRunHandler> Unable to run workflow")
+		}
+
 		//Manual run
 		if lastRun != nil {
 			if opts.FromNodeID == nil {
@@ -282,6 +517,11 @@ func postWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 		return sdk.WrapError(err, "postWorkflowRunHandler> Unable to run workflow")
 	}
 
+	//The hook conditions are not satisfied
+	if wr == nil {
+		return WriteJSON(w, r, nil, http.StatusNoContent)
+	}
+
 	wr.Translate(r.Header.Get("Accept-Language"))
 	return WriteJSON(w, r, wr, http.StatusOK)
 }
@@ -289,7 +529,7 @@ func postWorkflowRunHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbM
 func getWorkflowNodeRunArtifactsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	number, errNu := requestVarInt(r, "number")
 	if errNu != nil {
@@ -311,7 +551,7 @@ func getWorkflowNodeRunArtifactsHandler(w http.ResponseWriter, r *http.Request,
 func getDownloadArtifactHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	id, errI := requestVarInt(r, "artifactId")
 	if errI != nil {
@@ -337,10 +577,44 @@ func getDownloadArtifactHandler(w http.ResponseWriter, r *http.Request, db *gorp
 	return nil
 }
 
+//getDownloadArtifactWithTempURLHandler returns the artifact with a temporary url to download it directly from the objectstore
+func getDownloadArtifactWithTempURLHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	key := vars["permProjectKey"]
+	name := vars["permWorkflowName"]
+
+	id, errI := requestVarInt(r, "artifactId")
+	if errI != nil {
+		return sdk.WrapError(sdk.ErrInvalidID, "getDownloadArtifactWithTempURLHandler> Invalid artifact ID")
+	}
+
+	if !objectstore.TemporaryURLSupported() {
+		return sdk.WrapError(sdk.ErrNotImplemented, "getDownloadArtifactWithTempURLHandler> Temporary urls are not supported by the objectstore")
+	}
+
+	work, errW := workflow.Load(db, key, name, c.User)
+	if errW != nil {
+		return sdk.WrapError(errW, "getDownloadArtifactWithTempURLHandler> Cannot load workflow")
+	}
+
+	art, errA := workflow.LoadArtifactByIDs(db, work.ID, id)
+	if errA != nil {
+		return sdk.WrapError(errA, "getDownloadArtifactWithTempURLHandler> Cannot load artifact %d", id)
+	}
+
+	tempURL, errU := objectstore.FetchArtifactURL(art)
+	if errU != nil {
+		return sdk.WrapError(errU, "getDownloadArtifactWithTempURLHandler> Cannot get temporary url of artifact %s", art.Name)
+	}
+	art.TempURL = tempURL
+
+	return WriteJSON(w, r, art, http.StatusOK)
+}
+
 func getWorkflowRunArtifactsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	number, errNu := requestVarInt(r, "number")
 	if errNu != nil {
@@ -371,7 +645,7 @@ func getWorkflowRunArtifactsHandler(w http.ResponseWriter, r *http.Request, db *
 func getWorkflowNodeRunJobStepHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	projectKey := vars["permProjectKey"]
-	workflowName := vars["workflowName"]
+	workflowName := vars["permWorkflowName"]
 	number, errN := requestVarInt(r, "number")
 	if errN != nil {
 		return sdk.WrapError(errN, "getWorkflowNodeRunJobStepHandler> Number: invalid number")
@@ -444,7 +718,7 @@ stageLoop:
 func getWorkflowRunTagsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	projectKey := vars["permProjectKey"]
-	workflowName := vars["workflowName"]
+	workflowName := vars["permWorkflowName"]
 
 	res, err := workflow.GetTagsAndValue(db, projectKey, workflowName)
 	if err != nil {
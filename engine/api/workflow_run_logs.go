@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/gorilla/mux"
+
+	"github.com/ovh/cds/engine/api/businesscontext"
+	"github.com/ovh/cds/engine/api/cache"
+	"github.com/ovh/cds/engine/api/sessionstore"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+// WorkflowLogsBrokerSubscribe is a client following the logs of a job
+type WorkflowLogsBrokerSubscribe struct {
+	UUID     string
+	RunJobID int64
+	Queue    chan sdk.Log
+}
+
+// WorkflowLogsBroker dispatches the logs received from the workers to the clients following the jobs
+type WorkflowLogsBroker struct {
+	clients       map[string]*WorkflowLogsBrokerSubscribe
+	newClients    chan *WorkflowLogsBrokerSubscribe
+	closedClients chan *WorkflowLogsBrokerSubscribe
+	messages      chan string
+	done          <-chan struct{}
+}
+
+var workflowLogsBroker *WorkflowLogsBroker
+
+// InitWorkflowLogsBroker starts the broker of the workflow logs
+func InitWorkflowLogsBroker(c context.Context) {
+	workflowLogsBroker = &WorkflowLogsBroker{
+		clients:       make(map[string]*WorkflowLogsBrokerSubscribe),
+		newClients:    make(chan *WorkflowLogsBrokerSubscribe),
+		closedClients: make(chan *WorkflowLogsBrokerSubscribe),
+		messages:      make(chan string),
+		done:          c.Done(),
+	}
+
+	go workflowLogsBroker.subscribe(c)
+	go workflowLogsBroker.Start(c)
+}
+
+// subscribe reads all the logs published on the cache
+func (b *WorkflowLogsBroker) subscribe(c context.Context) {
+	pubSub := cache.Subscribe(workflow.LogsChannel)
+	for {
+		msg, err := cache.GetMessageFromSubscription(c, pubSub)
+		if c.Err() != nil {
+			log.Error("WorkflowLogsBroker.subscribe> Exiting: %v", c.Err())
+			return
+		}
+		if err != nil {
+			log.Warning("WorkflowLogsBroker.subscribe> Cannot get message %s: %s", msg, err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		if msg == "" {
+			continue
+		}
+		b.messages <- msg
+	}
+}
+
+// Start the broker
+func (b *WorkflowLogsBroker) Start(c context.Context) {
+	for {
+		select {
+		case <-c.Done():
+			for k, s := range b.clients {
+				delete(b.clients, k)
+				close(s.Queue)
+			}
+			return
+		case s := <-b.newClients:
+			b.clients[s.UUID] = s
+		case s := <-b.closedClients:
+			if _, ok := b.clients[s.UUID]; ok {
+				delete(b.clients, s.UUID)
+				close(s.Queue)
+			}
+		case msg := <-b.messages:
+			var l sdk.Log
+			if err := json.Unmarshal([]byte(msg), &l); err != nil {
+				log.Warning("WorkflowLogsBroker.Start> Cannot unmarshal message: %s", msg)
+				continue
+			}
+			for _, s := range b.clients {
+				if s.RunJobID != l.PipelineBuildJobID {
+					continue
+				}
+				//Never wait for a slow client
+				select {
+				case s.Queue <- l:
+				default:
+					log.Warning("WorkflowLogsBroker.Start> Client %s is too slow, log of job %d skipped", s.UUID, s.RunJobID)
+				}
+			}
+		}
+	}
+}
+
+func (b *WorkflowLogsBroker) register(runJobID int64) (*WorkflowLogsBrokerSubscribe, error) {
+	uuid, errS := sessionstore.NewSessionKey()
+	if errS != nil {
+		return nil, sdk.WrapError(errS, "WorkflowLogsBroker.register> Cannot generate UUID")
+	}
+	s := &WorkflowLogsBrokerSubscribe{
+		UUID:     string(uuid),
+		RunJobID: runJobID,
+		Queue:    make(chan sdk.Log, 1000),
+	}
+	select {
+	case b.newClients <- s:
+	case <-b.done:
+		return nil, sdk.WrapError(sdk.ErrUnknownError, "WorkflowLogsBroker.register> Broker is stopped")
+	}
+	return s, nil
+}
+
+func (b *WorkflowLogsBroker) unregister(s *WorkflowLogsBrokerSubscribe) {
+	select {
+	case b.closedClients <- s:
+	case <-b.done:
+	}
+}
+
+// getWorkflowNodeRunJobLogsHandler streams the logs of all the steps of a job as Server-Sent Events.
+// With follow, the stream stays open until the end of the job.
+func getWorkflowNodeRunJobLogsHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
+	vars := mux.Vars(r)
+	projectKey := vars["permProjectKey"]
+	workflowName := vars["permWorkflowName"]
+	number, errN := requestVarInt(r, "number")
+	if errN != nil {
+		return sdk.WrapError(errN, "getWorkflowNodeRunJobLogsHandler> Number: invalid number")
+	}
+	nodeRunID, errNI := requestVarInt(r, "id")
+	if errNI != nil {
+		return sdk.WrapError(errNI, "getWorkflowNodeRunJobLogsHandler> id: invalid number")
+	}
+	runJobID, errJ := requestVarInt(r, "runJobId")
+	if errJ != nil {
+		return sdk.WrapError(errJ, "getWorkflowNodeRunJobLogsHandler> runJobId: invalid number")
+	}
+	follow := FormBool(r, "follow")
+
+	// Check nodeRunID is link to workflow
+	nodeRun, errNR := workflow.LoadNodeRun(db, projectKey, workflowName, number, nodeRunID)
+	if errNR != nil {
+		return sdk.WrapError(errNR, "getWorkflowNodeRunJobLogsHandler> Cannot find nodeRun %d/%d for workflow %s in project %s", nodeRunID, number, workflowName, projectKey)
+	}
+
+	var runJob *sdk.WorkflowNodeJobRun
+stageLoop:
+	for _, s := range nodeRun.Stages {
+		for i := range s.RunJobs {
+			if s.RunJobs[i].ID == runJobID {
+				runJob = &s.RunJobs[i]
+				break stageLoop
+			}
+		}
+	}
+	if runJob == nil {
+		return sdk.WrapError(sdk.ErrNotFound, "getWorkflowNodeRunJobLogsHandler> Cannot find job %d in nodeRun %d/%d for workflow %s in project %s", runJobID, nodeRunID, number, workflowName, projectKey)
+	}
+
+	// Make sure that the writer supports flushing.
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return nil
+	}
+
+	// Subscribe before loading the stored logs, so no line is lost
+	var sub *WorkflowLogsBrokerSubscribe
+	if follow && isJobRunning(db, runJob) {
+		var errR error
+		sub, errR = workflowLogsBroker.register(runJobID)
+		if errR != nil {
+			return errR
+		}
+		defer workflowLogsBroker.unregister(sub)
+	}
+
+	logs, errL := workflow.LoadLogs(db, runJobID)
+	if errL != nil {
+		return sdk.WrapError(errL, "getWorkflowNodeRunJobLogsHandler> Cannot load logs for runJob %d", runJobID)
+	}
+
+	// Set the headers related to event streaming.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	//sent keeps the length of the logs sent for each step
+	sent := map[int64]int{}
+	lastModified := map[int64]*timestamp.Timestamp{}
+	for i := range logs {
+		l := &logs[i]
+		if err := writeLogEvent(w, l); err != nil {
+			return err
+		}
+		sent[l.StepOrder] = len(l.Val)
+		lastModified[l.StepOrder] = l.LastModified
+	}
+	if sub == nil {
+		return writeLogEndEvent(w, f)
+	}
+	f.Flush()
+
+	tick := time.NewTicker(2 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-w.(http.CloseNotifier).CloseNotify():
+			return nil
+		case l, open := <-sub.Queue:
+			if !open {
+				return nil
+			}
+			//Skip the lines already sent with the stored logs
+			if isTimestampNotAfter(l.LastModified, lastModified[l.StepOrder]) {
+				continue
+			}
+			if err := writeLogEvent(w, &l); err != nil {
+				return err
+			}
+			sent[l.StepOrder] += len(l.Val)
+			f.Flush()
+		case <-tick.C:
+			if isJobRunning(db, runJob) {
+				continue
+			}
+			//The job is over, send the lines which have not been received yet
+			logs, errL := workflow.LoadLogs(db, runJobID)
+			if errL != nil {
+				return sdk.WrapError(errL, "getWorkflowNodeRunJobLogsHandler> Cannot load logs for runJob %d", runJobID)
+			}
+			for i := range logs {
+				l := &logs[i]
+				if len(l.Val) <= sent[l.StepOrder] {
+					continue
+				}
+				l.Val = l.Val[sent[l.StepOrder]:]
+				if err := writeLogEvent(w, l); err != nil {
+					return err
+				}
+			}
+			return writeLogEndEvent(w, f)
+		}
+	}
+}
+
+// isJobRunning reloads the job to check its status, a job which has been removed from the queue is over
+func isJobRunning(db gorp.SqlExecutor, runJob *sdk.WorkflowNodeJobRun) bool {
+	j, err := workflow.LoadNodeJobRun(db, runJob.ID)
+	if err != nil {
+		return false
+	}
+	return j.Status == sdk.StatusWaiting.String() || j.Status == sdk.StatusBuilding.String()
+}
+
+func isTimestampNotAfter(t, ref *timestamp.Timestamp) bool {
+	if t == nil || ref == nil {
+		return false
+	}
+	if t.Seconds != ref.Seconds {
+		return t.Seconds < ref.Seconds
+	}
+	return t.Nanos <= ref.Nanos
+}
+
+func writeLogEvent(w http.ResponseWriter, l *sdk.Log) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return sdk.WrapError(err, "writeLogEvent> Unable to marshal log")
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+		return sdk.WrapError(err, "writeLogEvent> Unable to write log")
+	}
+	return nil
+}
+
+//writeLogEndEvent tells the client that all the logs have been sent, a stream cut without it has to be resumed
+func writeLogEndEvent(w http.ResponseWriter, f http.Flusher) error {
+	if _, err := fmt.Fprint(w, "event: end\ndata: {}\n\n"); err != nil {
+		return sdk.WrapError(err, "writeLogEndEvent> Unable to write end event")
+	}
+	f.Flush()
+	return nil
+}
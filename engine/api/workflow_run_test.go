@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -104,8 +105,8 @@ func Test_getWorkflowRunsHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
 	}
 	uri := router.getRoute("GET", getWorkflowRunsHandler, vars)
 	test.NotEmpty(t, uri)
@@ -235,8 +236,8 @@ func Test_getLatestWorkflowRunHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
 	}
 	uri := router.getRoute("GET", getLatestWorkflowRunHandler, vars)
 	test.NotEmpty(t, uri)
@@ -351,9 +352,9 @@ func Test_getWorkflowRunHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
-		"number":         "9",
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
+		"number":           "9",
 	}
 	uri := router.getRoute("GET", getWorkflowRunHandler, vars)
 	test.NotEmpty(t, uri)
@@ -457,10 +458,10 @@ func Test_getWorkflowNodeRunHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
-		"number":         fmt.Sprintf("%d", lastrun.Number),
-		"id":             fmt.Sprintf("%d", lastrun.WorkflowNodeRuns[w1.RootID][0].ID),
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
+		"number":           fmt.Sprintf("%d", lastrun.Number),
+		"id":               fmt.Sprintf("%d", lastrun.WorkflowNodeRuns[w1.RootID][0].ID),
 	}
 	uri := router.getRoute("GET", getWorkflowNodeRunHandler, vars)
 	test.NotEmpty(t, uri)
@@ -548,8 +549,8 @@ func Test_postWorkflowRunHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
 	}
 	uri := router.getRoute("POST", postWorkflowRunHandler, vars)
 	test.NotEmpty(t, uri)
@@ -676,12 +677,12 @@ func Test_getWorkflowNodeRunJobStepHandler(t *testing.T) {
 	router.init()
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   w1.Name,
-		"number":         fmt.Sprintf("%d", lastrun.Number),
-		"id":             fmt.Sprintf("%d", lastrun.WorkflowNodeRuns[w1.RootID][0].ID),
-		"runJobId":       fmt.Sprintf("%d", jobRun.ID),
-		"stepOrder":      "1",
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
+		"number":           fmt.Sprintf("%d", lastrun.Number),
+		"id":               fmt.Sprintf("%d", lastrun.WorkflowNodeRuns[w1.RootID][0].ID),
+		"runJobId":         fmt.Sprintf("%d", jobRun.ID),
+		"stepOrder":        "1",
 	}
 	uri := router.getRoute("GET", getWorkflowNodeRunJobStepHandler, vars)
 	test.NotEmpty(t, uri)
@@ -697,3 +698,128 @@ func Test_getWorkflowNodeRunJobStepHandler(t *testing.T) {
 	assert.Equal(t, "My Log", stepState.StepLogs.Val)
 	assert.Equal(t, sdk.StatusBuilding, stepState.Status)
 }
+
+func Test_getWorkflowNodeRunJobLogsHandler(t *testing.T) {
+	db := test.SetupPG(t)
+	u, pass := assets.InsertAdminUser(db)
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	//First pipeline
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	s := sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip.ID
+	pipeline.InsertStage(db, s)
+	j := &sdk.Job{
+		Enabled: true,
+		Action: sdk.Action{
+			Enabled: true,
+		},
+	}
+	pipeline.InsertJob(db, j, s.ID, &pip)
+	s.Jobs = append(s.Jobs, *j)
+
+	pip.Stages = append(pip.Stages, *s)
+
+	//Second pipeline
+	pip2 := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip2",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip2, u))
+	s = sdk.NewStage("stage 1")
+	s.Enabled = true
+	s.PipelineID = pip2.ID
+	pipeline.InsertStage(db, s)
+	j = &sdk.Job{
+		Enabled: true,
+		Action: sdk.Action{
+			Enabled: true,
+		},
+	}
+	pipeline.InsertJob(db, j, s.ID, &pip2)
+	s.Jobs = append(s.Jobs, *j)
+
+	w := sdk.Workflow{
+		Name:       "test_1",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+			Triggers: []sdk.WorkflowNodeTrigger{
+				{
+					WorkflowDestNode: sdk.WorkflowNode{
+						Pipeline: pip,
+					},
+				},
+			},
+		},
+	}
+
+	test.NoError(t, workflow.Insert(db, &w, u))
+	w1, err := workflow.Load(db, key, "test_1", u)
+	test.NoError(t, err)
+
+	_, err = workflow.ManualRun(db, w1, &sdk.WorkflowNodeRunManual{
+		User: *u,
+	})
+	test.NoError(t, err)
+
+	c, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	workflow.Scheduler(c, func() *gorp.DbMap { return db })
+	time.Sleep(2 * time.Second)
+
+	lastrun, err := workflow.LoadLastRun(db, proj.Key, w1.Name)
+
+	// Update step status
+	jobRun := &lastrun.WorkflowNodeRuns[w1.RootID][0].Stages[0].RunJobs[0]
+	log := &sdk.Log{
+		StepOrder: 1,
+		Val:       "My Log",
+	}
+	// Add log
+	errAL := workflow.AddLog(db, jobRun, log)
+	test.NoError(t, errAL)
+
+	// Init router
+	router = newRouter(auth.TestLocalAuth(t), mux.NewRouter(), "/Test_getWorkflowNodeRunJobLogsHandler")
+	router.init()
+	//Prepare request
+	vars := map[string]string{
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": w1.Name,
+		"number":           fmt.Sprintf("%d", lastrun.Number),
+		"id":               fmt.Sprintf("%d", lastrun.WorkflowNodeRuns[w1.RootID][0].ID),
+		"runJobId":         fmt.Sprintf("%d", jobRun.ID),
+	}
+	uri := router.getRoute("GET", getWorkflowNodeRunJobLogsHandler, vars)
+	test.NotEmpty(t, uri)
+	req := assets.NewAuthentifiedRequest(t, u, pass, "GET", uri, vars)
+
+	//Do the request
+	rec := httptest.NewRecorder()
+	router.mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	test.Equal(t, 2, len(events))
+	assert.True(t, strings.HasPrefix(events[0], "data: "))
+	assert.Equal(t, "event: end\ndata: {}", events[1])
+
+	l := sdk.Log{}
+	test.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &l))
+	assert.Equal(t, "My Log", l.Val)
+	assert.Equal(t, int64(1), l.StepOrder)
+}
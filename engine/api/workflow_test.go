@@ -55,8 +55,8 @@ func Test_getWorkflowHandler(t *testing.T) {
 	proj := assets.InsertTestProject(t, db, key, key, u)
 	//Prepare request
 	vars := map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   "workflow1",
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": "workflow1",
 	}
 	uri := router.getRoute("GET", getWorkflowHandler, vars)
 	test.NotEmpty(t, uri)
@@ -182,8 +182,8 @@ func Test_putWorkflowHandler(t *testing.T) {
 
 	//Prepare request
 	vars = map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   "Name",
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": "Name",
 	}
 	uri = router.getRoute("PUT", putWorkflowHandler, vars)
 	test.NotEmpty(t, uri)
@@ -252,8 +252,8 @@ func Test_deleteWorkflowHandler(t *testing.T) {
 	test.NoError(t, json.Unmarshal(w.Body.Bytes(), &workflow))
 
 	vars = map[string]string{
-		"permProjectKey": proj.Key,
-		"workflowName":   "Name",
+		"permProjectKey":   proj.Key,
+		"permWorkflowName": "Name",
 	}
 	uri = router.getRoute("DELETE", deleteWorkflowHandler, vars)
 	test.NotEmpty(t, uri)
@@ -15,7 +15,7 @@ import (
 func getWorkflowTriggerConditionHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	id, errID := requestVarInt(r, "nodeID")
 	if errID != nil {
@@ -44,13 +44,7 @@ func getWorkflowTriggerConditionHandler(w http.ResponseWriter, r *http.Request,
 		return sdk.WrapError(errr, "getWorkflowTriggerConditionHandler> Unable to load build parameters")
 	}
 
-	data := struct {
-		Operators      map[string]string `json:"operators"`
-		ConditionNames []string          `json:"names"`
-	}{
-		Operators: sdk.WorkflowConditionsOperators,
-	}
-
+	data := newWorkflowTriggerConditions()
 	for _, p := range params {
 		data.ConditionNames = append(data.ConditionNames, p.Name)
 	}
@@ -67,14 +61,16 @@ func getWorkflowTriggerConditionHandler(w http.ResponseWriter, r *http.Request,
 	if refNode.Context != nil && refNode.Context.Environment != nil {
 		data.ConditionNames = append(data.ConditionNames, "cds.dest.environment")
 	}
+	data.ConditionNames = append(data.ConditionNames, "workflow."+refNode.Name+".status")
 
+	data.checkExpression(r.FormValue("expression"))
 	return WriteJSON(w, r, data, http.StatusOK)
 }
 
 func getWorkflowTriggerJoinConditionHandler(w http.ResponseWriter, r *http.Request, db *gorp.DbMap, c *businesscontext.Ctx) error {
 	vars := mux.Vars(r)
 	key := vars["permProjectKey"]
-	name := vars["workflowName"]
+	name := vars["permWorkflowName"]
 
 	id, errID := requestVarInt(r, "joinID")
 	if errID != nil {
@@ -103,13 +99,7 @@ func getWorkflowTriggerJoinConditionHandler(w http.ResponseWriter, r *http.Reque
 		return sdk.ErrWorkflowNodeJoinNotFound
 	}
 
-	data := struct {
-		Operators      map[string]string `json:"operators"`
-		ConditionNames []string          `json:"names"`
-	}{
-		Operators: sdk.WorkflowConditionsOperators,
-	}
-
+	data := newWorkflowTriggerConditions()
 	allparams := map[string]string{}
 	for _, i := range j.SourceNodeIDs {
 		params, errp := workflow.NodeBuildParameters(proj, wf, wr, i, c.User)
@@ -117,11 +107,57 @@ func getWorkflowTriggerJoinConditionHandler(w http.ResponseWriter, r *http.Reque
 			return sdk.WrapError(errr, "getWorkflowTriggerJoinConditionHandler> Unable to load build parameters")
 		}
 		allparams = sdk.ParametersMapMerge(allparams, sdk.ParametersToMap(params))
+		if n := wf.GetNode(i); n != nil {
+			allparams["workflow."+n.Name+".status"] = ""
+		}
 	}
 
 	for k := range allparams {
 		data.ConditionNames = append(data.ConditionNames, k)
 	}
 
+	data.checkExpression(r.FormValue("expression"))
 	return WriteJSON(w, r, data, http.StatusOK)
 }
+
+//workflowTriggerConditions describes what can be used in trigger conditions, and the result of the validation of an expression
+type workflowTriggerConditions struct {
+	Operators        map[string]string `json:"operators"`
+	ConditionNames   []string          `json:"names"`
+	Functions        map[string]string `json:"functions"`
+	Expression       string            `json:"expression,omitempty"`
+	Valid            *bool             `json:"valid,omitempty"`
+	Error            string            `json:"error,omitempty"`
+	UnknownVariables []string          `json:"unknown_variables,omitempty"`
+}
+
+func newWorkflowTriggerConditions() *workflowTriggerConditions {
+	return &workflowTriggerConditions{
+		Operators: sdk.WorkflowConditionsOperators,
+		Functions: sdk.ConditionExpressionFunctions,
+	}
+}
+
+//checkExpression validates the expression and lists the variables which are not known in the condition names
+func (d *workflowTriggerConditions) checkExpression(expr string) {
+	if expr == "" {
+		return
+	}
+	d.Expression = expr
+	e, err := sdk.ParseConditionExpression(expr)
+	valid := err == nil
+	d.Valid = &valid
+	if err != nil {
+		d.Error = err.Error()
+		return
+	}
+	names := make(map[string]bool, len(d.ConditionNames))
+	for _, n := range d.ConditionNames {
+		names[n] = true
+	}
+	for _, v := range e.Variables() {
+		if !names[v] {
+			d.UnknownVariables = append(d.UnknownVariables, v)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package workflowhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//Cleaner is the cleaner main goroutine
+func Cleaner(c context.Context, DBFunc func() *gorp.DbMap, nbToKeep int) {
+	tick := time.NewTicker(10 * time.Minute).C
+	for {
+		select {
+		case <-c.Done():
+			if c.Err() != nil {
+				log.Error("Exiting workflowhook.Cleaner: %v", c.Err())
+				return
+			}
+		case <-tick:
+			if _, err := CleanerRun(DBFunc(), nbToKeep); err != nil {
+				log.Warning("workflowhook.Cleaner> Error : %s", err)
+				continue
+			}
+		}
+	}
+}
+
+//CleanerRun is the core function of the cleaner goroutine. It deletes executions of removed hooks, and keeps only
+//the last nbToKeep executions of each hook
+func CleanerRun(db *gorp.DbMap, nbToKeep int) (int, error) {
+	log.Debug("CleanerRun> Deleting old hook executions...")
+
+	tx, errb := db.Begin()
+	if errb != nil {
+		return 0, sdk.WrapError(errb, "CleanerRun> Unable to start a transaction")
+	}
+	defer tx.Rollback()
+
+	//Starting with exclusive lock on the table
+	if err := LockExecutions(tx); err != nil {
+		log.Debug("CleanerRun> Unable to take lock : %s", err)
+		return 0, nil
+	}
+
+	n, errd := DeleteOrphanExecutions(tx)
+	if errd != nil {
+		return 0, sdk.WrapError(errd, "CleanerRun> Unable to delete orphan executions")
+	}
+	nbDeleted := int(n)
+
+	for _, m := range []*sdk.WorkflowHookModel{workflow.WebHookModel, workflow.SchedulerModel, workflow.GitPollerModel} {
+		hooks, errl := workflow.LoadHooksByModelName(tx, m.Name)
+		if errl != nil {
+			return 0, sdk.WrapError(errl, "CleanerRun> Unable to load hooks")
+		}
+
+		for _, h := range hooks {
+			exs, err := LoadPastExecutions(tx, h.UUID)
+			if err != nil {
+				return 0, sdk.WrapError(err, "CleanerRun> Unable to load hook executions")
+			}
+
+			for i := 0; i < len(exs)-nbToKeep; i++ {
+				if err := DeleteExecution(tx, &exs[i]); err != nil {
+					log.Error("CleanerRun> Unable to delete execution %d err:%s", exs[i].ID, err)
+					continue
+				}
+				nbDeleted++
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, sdk.WrapError(err, "CleanerRun> Unable to commit a transaction")
+	}
+
+	return nbDeleted, nil
+}
@@ -0,0 +1,117 @@
+package workflowhook
+
+import (
+	"database/sql"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/sdk"
+)
+
+//InsertExecution inserts a workflow node hook execution
+func InsertExecution(db gorp.SqlExecutor, e *sdk.WorkflowNodeHookExecution) error {
+	de := Execution(*e)
+	if err := db.Insert(&de); err != nil {
+		return sdk.WrapError(err, "InsertExecution> Unable to insert execution for hook %s", e.WorkflowNodeHookUUID)
+	}
+	*e = sdk.WorkflowNodeHookExecution(de)
+	return nil
+}
+
+//UpdateExecution updates a workflow node hook execution
+func UpdateExecution(db gorp.SqlExecutor, e *sdk.WorkflowNodeHookExecution) error {
+	de := Execution(*e)
+	if n, err := db.Update(&de); err != nil {
+		return sdk.WrapError(err, "UpdateExecution> Unable to update execution %d", e.ID)
+	} else if n == 0 {
+		return sdk.ErrNotFound
+	}
+	*e = sdk.WorkflowNodeHookExecution(de)
+	return nil
+}
+
+//DeleteExecution deletes a workflow node hook execution
+func DeleteExecution(db gorp.SqlExecutor, e *sdk.WorkflowNodeHookExecution) error {
+	de := Execution(*e)
+	if n, err := db.Delete(&de); err != nil {
+		return sdk.WrapError(err, "DeleteExecution> Unable to delete execution %d", e.ID)
+	} else if n == 0 {
+		return sdk.ErrNotFound
+	}
+	return nil
+}
+
+func loadExecutions(db gorp.SqlExecutor, query string, args ...interface{}) ([]sdk.WorkflowNodeHookExecution, error) {
+	des := []Execution{}
+	if _, err := db.Select(&des, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	es := make([]sdk.WorkflowNodeHookExecution, len(des))
+	for i := range des {
+		es[i] = sdk.WorkflowNodeHookExecution(des[i])
+	}
+	return es, nil
+}
+
+//LoadExecutions loads all executions of a hook
+func LoadExecutions(db gorp.SqlExecutor, uuid string) ([]sdk.WorkflowNodeHookExecution, error) {
+	es, err := loadExecutions(db, "select * from workflow_node_hook_execution where workflow_node_hook_uuid = $1 order by execution_planned_date desc", uuid)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadExecutions> Unable to load executions for hook %s", uuid)
+	}
+	return es, nil
+}
+
+//LoadLastExecution loads the last planned execution of a hook
+func LoadLastExecution(db gorp.SqlExecutor, uuid string) (*sdk.WorkflowNodeHookExecution, error) {
+	es, err := loadExecutions(db, "select * from workflow_node_hook_execution where workflow_node_hook_uuid = $1 order by execution_planned_date desc limit 1", uuid)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadLastExecution> Unable to load last execution for hook %s", uuid)
+	}
+	if len(es) == 0 {
+		return nil, nil
+	}
+	return &es[0], nil
+}
+
+//LoadPastExecutions loads all executed executions of a hook, oldest first
+func LoadPastExecutions(db gorp.SqlExecutor, uuid string) ([]sdk.WorkflowNodeHookExecution, error) {
+	es, err := loadExecutions(db, "select * from workflow_node_hook_execution where workflow_node_hook_uuid = $1 and executed = true order by execution_date asc", uuid)
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadPastExecutions> Unable to load past executions for hook %s", uuid)
+	}
+	return es, nil
+}
+
+//LoadPendingExecutions loads all the executions which have to be executed
+func LoadPendingExecutions(db gorp.SqlExecutor) ([]sdk.WorkflowNodeHookExecution, error) {
+	es, err := loadExecutions(db, "select * from workflow_node_hook_execution where executed = false and execution_planned_date <= now() order by execution_planned_date asc")
+	if err != nil {
+		return nil, sdk.WrapError(err, "LoadPendingExecutions> Unable to load pending executions")
+	}
+	return es, nil
+}
+
+//DeleteOrphanExecutions deletes all executions of hooks which don't exist anymore
+func DeleteOrphanExecutions(db gorp.SqlExecutor) (int64, error) {
+	res, err := db.Exec("delete from workflow_node_hook_execution where workflow_node_hook_uuid not in (select uuid from workflow_node_hook)")
+	if err != nil {
+		return 0, sdk.WrapError(err, "DeleteOrphanExecutions> Unable to delete executions")
+	}
+	return res.RowsAffected()
+}
+
+//LockExecutions locks table workflow_node_hook_execution
+func LockExecutions(db gorp.SqlExecutor) error {
+	_, err := db.Exec("LOCK TABLE workflow_node_hook_execution IN ACCESS EXCLUSIVE MODE NOWAIT")
+	return err
+}
+
+//LockExecution locks a pending execution. It returns false if the execution has already been executed
+func LockExecution(db gorp.SqlExecutor, id int64) (bool, error) {
+	n, err := db.SelectInt("select count(id) from (select id from workflow_node_hook_execution where id = $1 and executed = false for update nowait) as e", id)
+	return n == 1, err
+}
@@ -0,0 +1,235 @@
+package workflowhook
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/repositoriesmanager"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+var skipCommitRegexp = regexp.MustCompile(".*\\[ci skip\\].*|.*\\[cd skip\\].*")
+
+//Executer is the goroutine which runs the workflows from the pending hook executions
+func Executer(c context.Context, DBFunc func() *gorp.DbMap) {
+	tick := time.NewTicker(5 * time.Second).C
+	for {
+		select {
+		case <-c.Done():
+			if c.Err() != nil {
+				log.Error("Exiting workflowhook.Executer: %v", c.Err())
+				return
+			}
+		case <-tick:
+			exs, err := ExecuterRun(DBFunc())
+			if err != nil {
+				log.Warning("workflowhook.Executer> Error : %s", err)
+				continue
+			}
+			if len(exs) > 0 {
+				log.Debug("workflowhook.Executer> %d hook executions have been processed", len(exs))
+			}
+		}
+	}
+}
+
+//ExecuterRun is the core function of Executer goroutine
+func ExecuterRun(db *gorp.DbMap) ([]sdk.WorkflowNodeHookExecution, error) {
+	exs, err := LoadPendingExecutions(db)
+	if err != nil {
+		return nil, sdk.WrapError(err, "ExecuterRun> Unable to load pending executions")
+	}
+
+	done := []sdk.WorkflowNodeHookExecution{}
+	for i := range exs {
+		e := &exs[i]
+		ok, err := executerRun(db, e)
+		if err != nil {
+			log.Warning("ExecuterRun> Unable to process execution %d of hook %s: %s", e.ID, e.WorkflowNodeHookUUID, err)
+			//Keep the error on the execution, it won't be retried
+			t := time.Now()
+			e.ExecutionDate = &t
+			e.Executed = true
+			e.Error = err.Error()
+			if err := UpdateExecution(db, e); err != nil {
+				log.Error("ExecuterRun> Unable to update execution %d: %s", e.ID, err)
+			}
+		}
+		if ok {
+			done = append(done, *e)
+		}
+	}
+
+	return done, nil
+}
+
+func executerRun(db *gorp.DbMap, e *sdk.WorkflowNodeHookExecution) (bool, error) {
+	tx, errb := db.Begin()
+	if errb != nil {
+		return false, sdk.WrapError(errb, "executerRun> Unable to start a transaction")
+	}
+	defer tx.Rollback()
+
+	if ok, err := LockExecution(tx, e.ID); err != nil || !ok {
+		log.Debug("executerRun> Unable to lock execution %d: %v", e.ID, err)
+		return false, nil
+	}
+
+	//If the hook doesn't exist anymore: clean this execution and exit
+	if _, err := workflow.LoadHookByUUID(tx, e.WorkflowNodeHookUUID); err == sdk.ErrNoHook {
+		if err := DeleteExecution(tx, e); err != nil {
+			return false, sdk.WrapError(err, "executerRun> Unable to delete execution %d", e.ID)
+		}
+		return false, tx.Commit()
+	} else if err != nil {
+		return false, sdk.WrapError(err, "executerRun> Unable to load hook %s", e.WorkflowNodeHookUUID)
+	}
+
+	w, errw := workflow.LoadByHookUUID(tx, e.WorkflowNodeHookUUID)
+	if errw != nil {
+		return false, sdk.WrapError(errw, "executerRun> Unable to load workflow for hook %s", e.WorkflowNodeHookUUID)
+	}
+
+	h, ok := w.GetHooks()[e.WorkflowNodeHookUUID]
+	if !ok {
+		return false, sdk.WrapError(sdk.ErrNoHook, "executerRun> Unable to find hook %s", e.WorkflowNodeHookUUID)
+	}
+
+	payloads, errp := executionPayloads(tx, w, &h, e)
+	if errp != nil {
+		return false, sdk.WrapError(errp, "executerRun> Unable to compute payloads for hook %s", h.UUID)
+	}
+
+	for _, p := range payloads {
+		event := &sdk.WorkflowNodeRunHookEvent{
+			WorkflowNodeHookUUID: h.UUID,
+			Payload:              p,
+		}
+		wr, err := workflow.RunFromHook(tx, w, event)
+		if err != nil {
+			return false, sdk.WrapError(err, "executerRun> Unable to run workflow %s/%s from hook %s", w.ProjectKey, w.Name, h.UUID)
+		}
+		if wr != nil {
+			e.WorkflowRunNumber = wr.Number
+		}
+	}
+
+	t := time.Now()
+	e.ExecutionDate = &t
+	e.Executed = true
+	if err := UpdateExecution(tx, e); err != nil {
+		return false, sdk.WrapError(err, "executerRun> Unable to update execution %d", e.ID)
+	}
+
+	return true, tx.Commit()
+}
+
+//executionPayloads returns the payloads of the workflow runs to trigger for an execution
+func executionPayloads(db gorp.SqlExecutor, w *sdk.Workflow, h *sdk.WorkflowNodeHook, e *sdk.WorkflowNodeHookExecution) ([]interface{}, error) {
+	switch h.WorkflowHookModel.Name {
+	case workflow.WebHookModel.Name:
+		return []interface{}{e.Payload}, nil
+	case workflow.SchedulerModel.Name:
+		s := h.Config[sdk.WorkflowNodeHookConfigPayload]
+		if s == "" {
+			return []interface{}{nil}, nil
+		}
+		var payload interface{}
+		if err := json.Unmarshal([]byte(s), &payload); err != nil {
+			return nil, sdk.WrapError(err, "executionPayloads> Unable to read payload of scheduler")
+		}
+		return []interface{}{payload}, nil
+	case workflow.GitPollerModel.Name:
+		return pollerPayloads(db, w, h)
+	}
+	return nil, sdk.WrapError(sdk.ErrNotFound, "executionPayloads> Unsupported hook model %s", h.WorkflowHookModel.Name)
+}
+
+//pollerPayloads polls the repository of the application of the hooked node, and returns a payload for each push event.
+//If the pull requests are enabled on the hook, it also returns a payload for each opened or synchronized pull request.
+func pollerPayloads(db gorp.SqlExecutor, w *sdk.Workflow, h *sdk.WorkflowNodeHook) ([]interface{}, error) {
+	node := w.GetNode(h.WorkflowNodeID)
+	if node == nil || node.Context == nil || node.Context.Application == nil || node.Context.Application.RepositoriesManager == nil {
+		return nil, sdk.WrapError(sdk.ErrNoReposManager, "pollerPayloads> No repository linked to node %d", h.WorkflowNodeID)
+	}
+	app := node.Context.Application
+
+	//Get events since the last execution
+	since := time.Now()
+	past, errp := LoadPastExecutions(db, h.UUID)
+	if errp != nil {
+		return nil, sdk.WrapError(errp, "pollerPayloads")
+	}
+	if len(past) > 0 && past[len(past)-1].ExecutionDate != nil {
+		since = *past[len(past)-1].ExecutionDate
+	}
+
+	client, errc := repositoriesmanager.AuthorizedClient(db, w.ProjectKey, app.RepositoriesManager.Name)
+	if errc != nil {
+		return nil, sdk.WrapError(errc, "pollerPayloads> Unable to get client for %s %s", w.ProjectKey, app.RepositoriesManager.Name)
+	}
+
+	events, _, erre := client.GetEvents(app.RepositoryFullname, since)
+	if erre != nil && erre.Error() != "No new events" {
+		return nil, sdk.WrapError(erre, "pollerPayloads> Unable to get events for %s", app.RepositoryFullname)
+	}
+
+	pushEvents, errpe := client.PushEvents(app.RepositoryFullname, events)
+	if errpe != nil {
+		return nil, sdk.WrapError(errpe, "pollerPayloads> Unable to get push events for %s", app.RepositoryFullname)
+	}
+
+	payloads := []interface{}{}
+	for _, pe := range pushEvents {
+		if skipCommitRegexp.MatchString(pe.Commit.Message) {
+			log.Debug("pollerPayloads> Skipping commit %s on %s", pe.Commit.Hash, app.RepositoryFullname)
+			continue
+		}
+		payloads = append(payloads, map[string]string{
+			"git.repository": app.RepositoryFullname,
+			"git.branch":     pe.Branch.DisplayID,
+			"git.hash":       pe.Commit.Hash,
+			"git.author":     pe.Commit.Author.Name,
+			"git.message":    pe.Commit.Message,
+		})
+	}
+
+	if enabled, _ := strconv.ParseBool(h.Config[sdk.WorkflowNodeHookConfigPullRequests]); !enabled {
+		return payloads, nil
+	}
+
+	prEvents, errpr := client.PullRequestEvents(app.RepositoryFullname, events)
+	if errpr != nil {
+		return nil, sdk.WrapError(errpr, "pollerPayloads> Unable to get pull request events for %s", app.RepositoryFullname)
+	}
+
+	for _, pr := range prEvents {
+		if pr.Action != "opened" && pr.Action != "synchronize" {
+			continue
+		}
+		if skipCommitRegexp.MatchString(pr.Head.Commit.Message) {
+			log.Debug("pollerPayloads> Skipping commit %s of pull request %d on %s", pr.Head.Commit.Hash, pr.ID, app.RepositoryFullname)
+			continue
+		}
+		payloads = append(payloads, map[string]string{
+			"git.repository":       app.RepositoryFullname,
+			"git.branch":           pr.Head.Branch.DisplayID,
+			"git.hash":             pr.Head.Commit.Hash,
+			"git.author":           pr.User.Name,
+			"git.message":          pr.Head.Commit.Message,
+			"cds.pr.id":            strconv.FormatInt(pr.ID, 10),
+			"cds.pr.url":           pr.URL,
+			"cds.pr.ref":           pr.Ref,
+			"cds.pr.source.branch": pr.Head.Branch.DisplayID,
+			"cds.pr.target.branch": pr.Base.Branch.DisplayID,
+		})
+	}
+	return payloads, nil
+}
@@ -0,0 +1,48 @@
+package workflowhook
+
+import (
+	"github.com/go-gorp/gorp"
+
+	"github.com/ovh/cds/engine/api/database/gorpmapping"
+	"github.com/ovh/cds/sdk"
+)
+
+//Execution is a gorp wrapper around sdk.WorkflowNodeHookExecution
+type Execution sdk.WorkflowNodeHookExecution
+
+//PostInsert is a DB Hook on Execution to store payload as JSON in DB
+func (e *Execution) PostInsert(s gorp.SqlExecutor) error {
+	return e.PostUpdate(s)
+}
+
+//PostUpdate is a DB Hook on Execution to store payload as JSON in DB
+func (e *Execution) PostUpdate(s gorp.SqlExecutor) error {
+	payload, err := gorpmapping.JSONToNullString(e.Payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Exec("update workflow_node_hook_execution set payload = $2 where id = $1", e.ID, payload); err != nil {
+		return err
+	}
+	return nil
+}
+
+//PostGet is a DB Hook to get all data from DB
+func (e *Execution) PostGet(s gorp.SqlExecutor) error {
+	payload, err := s.SelectNullStr("select payload from workflow_node_hook_execution where id = $1", e.ID)
+	if err != nil {
+		return err
+	}
+
+	m := map[string]string{}
+	if err := gorpmapping.JSONNullString(payload, &m); err != nil {
+		return err
+	}
+	e.Payload = m
+	return nil
+}
+
+func init() {
+	gorpmapping.Register(gorpmapping.New(Execution{}, "workflow_node_hook_execution", true, "id"))
+}
@@ -0,0 +1,26 @@
+package workflowhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-gorp/gorp"
+)
+
+var apiURL string
+
+// Init initialize the workflowhook package
+func Init(url string) {
+	apiURL = url
+}
+
+//Initialize starts the 3 goroutines for workflow hooks
+func Initialize(c context.Context, nbExecToKeep int, DBFunc func() *gorp.DbMap) error {
+	if apiURL == "" {
+		return fmt.Errorf("workflowhook.Initialize> API URL is not set, webhooks URL cannot be computed")
+	}
+	go Cleaner(c, DBFunc, nbExecToKeep)
+	go Executer(c, DBFunc)
+	go Scheduler(c, DBFunc)
+	return nil
+}
@@ -0,0 +1,148 @@
+package workflowhook
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/gorhill/cronexpr"
+
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+//DefaultPollingDelay is the delay between two pollings of a repository if not set on the hook
+var DefaultPollingDelay = 60 * time.Second
+
+//Scheduler is the goroutine which computes date of next execution for scheduler and poller hooks
+func Scheduler(c context.Context, DBFunc func() *gorp.DbMap) {
+	tick := time.NewTicker(2 * time.Second).C
+	for {
+		select {
+		case <-c.Done():
+			if c.Err() != nil {
+				log.Error("Exiting workflowhook.Scheduler: %v", c.Err())
+				return
+			}
+		case <-tick:
+			if _, err := SchedulerRun(DBFunc()); err != nil {
+				log.Warning("workflowhook.Scheduler> Error : %s", err)
+			}
+		}
+	}
+}
+
+//SchedulerRun is the core function of Scheduler goroutine
+func SchedulerRun(db *gorp.DbMap) ([]sdk.WorkflowNodeHookExecution, error) {
+	tx, errb := db.Begin()
+	if errb != nil {
+		return nil, sdk.WrapError(errb, "SchedulerRun> Unable to start a transaction")
+	}
+	defer tx.Rollback()
+
+	//Starting with exclusive lock on the table
+	if err := LockExecutions(tx); err != nil {
+		return nil, nil
+	}
+
+	execs := []sdk.WorkflowNodeHookExecution{}
+	for _, m := range []*sdk.WorkflowHookModel{workflow.SchedulerModel, workflow.GitPollerModel} {
+		hooks, errl := workflow.LoadHooksByModelName(tx, m.Name)
+		if errl != nil {
+			return nil, sdk.WrapError(errl, "SchedulerRun> Unable to load hooks")
+		}
+
+		for i := range hooks {
+			h := &hooks[i]
+			last, errl := LoadLastExecution(tx, h.UUID)
+			if errl != nil {
+				return nil, sdk.WrapError(errl, "SchedulerRun> Unable to load last execution")
+			}
+
+			//An execution is already planned
+			if last != nil && !last.Executed {
+				continue
+			}
+
+			var lastDate *time.Time
+			if last != nil {
+				lastDate = last.ExecutionDate
+			}
+
+			var e *sdk.WorkflowNodeHookExecution
+			var errn error
+			switch m.Name {
+			case workflow.SchedulerModel.Name:
+				e, errn = NextScheduledExecution(h, lastDate)
+			case workflow.GitPollerModel.Name:
+				e, errn = NextPollingExecution(h, lastDate)
+			}
+			if errn != nil {
+				log.Warning("SchedulerRun> Unable to compute next execution for hook %s: %s", h.UUID, errn)
+				continue
+			}
+
+			if err := InsertExecution(tx, e); err != nil {
+				return nil, sdk.WrapError(err, "SchedulerRun> Unable to insert an execution")
+			}
+			execs = append(execs, *e)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, sdk.WrapError(err, "SchedulerRun> Unable to commit a transaction")
+	}
+
+	return execs, nil
+}
+
+//NextScheduledExecution computes the next execution of a scheduler hook according to its cron expression
+func NextScheduledExecution(h *sdk.WorkflowNodeHook, last *time.Time) (*sdk.WorkflowNodeHookExecution, error) {
+	cronExpr, err := cronexpr.Parse(h.Config[sdk.WorkflowNodeHookConfigCron])
+	if err != nil {
+		return nil, sdk.WrapError(err, "NextScheduledExecution> Unable to parse cron expression %s", h.Config[sdk.WorkflowNodeHookConfigCron])
+	}
+
+	timezone := h.Config[sdk.WorkflowNodeHookConfigTimezone]
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, sdk.WrapError(err, "NextScheduledExecution> Unable to load timezone %s", timezone)
+	}
+
+	t := time.Now()
+	if last != nil {
+		t = *last
+	}
+
+	return &sdk.WorkflowNodeHookExecution{
+		WorkflowNodeHookUUID: h.UUID,
+		ExecutionPlannedDate: cronExpr.Next(t.In(loc)),
+	}, nil
+}
+
+//NextPollingExecution computes the next execution of a poller hook according to its delay
+func NextPollingExecution(h *sdk.WorkflowNodeHook, last *time.Time) (*sdk.WorkflowNodeHookExecution, error) {
+	delay := DefaultPollingDelay
+	if s, ok := h.Config[sdk.WorkflowNodeHookConfigDelay]; ok && s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, sdk.WrapError(err, "NextPollingExecution> Invalid delay %s", s)
+		}
+		delay = time.Duration(d) * time.Second
+	}
+
+	t := time.Now()
+	if last != nil {
+		t = last.Add(delay)
+	}
+
+	return &sdk.WorkflowNodeHookExecution{
+		WorkflowNodeHookUUID: h.UUID,
+		ExecutionPlannedDate: t,
+	}, nil
+}
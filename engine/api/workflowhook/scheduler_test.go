@@ -0,0 +1,128 @@
+package workflowhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/engine/api/pipeline"
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/engine/api/test/assets"
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+)
+
+func TestSchedulerRun(t *testing.T) {
+	db := test.SetupPG(t)
+	test.NoError(t, workflow.CreateBuiltinWorkflowHookModels(db))
+	u, _ := assets.InsertAdminUser(db)
+
+	key := sdk.RandomString(10)
+	proj := assets.InsertTestProject(t, db, key, key, u)
+
+	pip := sdk.Pipeline{
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Name:       "pip1",
+		Type:       sdk.BuildPipeline,
+	}
+	test.NoError(t, pipeline.InsertPipeline(db, proj, &pip, u))
+
+	w := sdk.Workflow{
+		Name:       "test_scheduler",
+		ProjectID:  proj.ID,
+		ProjectKey: proj.Key,
+		Root: &sdk.WorkflowNode{
+			Pipeline: pip,
+			Hooks: []sdk.WorkflowNodeHook{
+				{
+					WorkflowHookModel: sdk.WorkflowHookModel{
+						Name: workflow.SchedulerModel.Name,
+					},
+					Config: sdk.WorkflowNodeHookConfig{
+						sdk.WorkflowNodeHookConfigCron:     "0 12 * * *",
+						sdk.WorkflowNodeHookConfigTimezone: "UTC",
+					},
+				},
+			},
+		},
+	}
+	test.NoError(t, workflow.Insert(db, &w, u))
+	defer workflow.Delete(db, &w, u)
+	uuid := w.Root.Hooks[0].UUID
+
+	findExecution := func(exs []sdk.WorkflowNodeHookExecution) *sdk.WorkflowNodeHookExecution {
+		for i := range exs {
+			if exs[i].WorkflowNodeHookUUID == uuid {
+				return &exs[i]
+			}
+		}
+		return nil
+	}
+
+	// the next execution of the hook is planned
+	exs, err := SchedulerRun(db)
+	test.NoError(t, err)
+	e := findExecution(exs)
+	if !assert.NotNil(t, e, "an execution should be planned for hook %s", uuid) {
+		return
+	}
+	assert.False(t, e.Executed)
+	assert.Equal(t, 12, e.ExecutionPlannedDate.UTC().Hour())
+	assert.True(t, e.ExecutionPlannedDate.After(time.Now()))
+
+	last, err := LoadLastExecution(db, uuid)
+	test.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, e.ID, last.ID)
+	}
+
+	// it is not planned again while it has not been executed
+	exs, err = SchedulerRun(db)
+	test.NoError(t, err)
+	assert.Nil(t, findExecution(exs))
+}
+
+func TestNextScheduledExecution(t *testing.T) {
+	h := &sdk.WorkflowNodeHook{
+		UUID: "123456789",
+		Config: sdk.WorkflowNodeHookConfig{
+			sdk.WorkflowNodeHookConfigCron:     "0 12 * * *",
+			sdk.WorkflowNodeHookConfigTimezone: "Europe/Paris",
+		},
+	}
+
+	loc, err := time.LoadLocation("Europe/Paris")
+	test.NoError(t, err)
+	last := time.Date(2017, 7, 10, 13, 0, 0, 0, loc)
+
+	e, err := NextScheduledExecution(h, &last)
+	test.NoError(t, err)
+	assert.Equal(t, "123456789", e.WorkflowNodeHookUUID)
+	assert.False(t, e.Executed)
+	assert.True(t, time.Date(2017, 7, 11, 12, 0, 0, 0, loc).Equal(e.ExecutionPlannedDate))
+
+	h.Config[sdk.WorkflowNodeHookConfigCron] = "this is not a cron"
+	_, err = NextScheduledExecution(h, &last)
+	assert.Error(t, err)
+}
+
+func TestNextPollingExecution(t *testing.T) {
+	h := &sdk.WorkflowNodeHook{
+		UUID: "123456789",
+		Config: sdk.WorkflowNodeHookConfig{
+			sdk.WorkflowNodeHookConfigDelay: "30",
+		},
+	}
+
+	last := time.Now()
+	e, err := NextPollingExecution(h, &last)
+	test.NoError(t, err)
+	assert.True(t, last.Add(30*time.Second).Equal(e.ExecutionPlannedDate))
+
+	delete(h.Config, sdk.WorkflowNodeHookConfigDelay)
+	e, err = NextPollingExecution(h, &last)
+	test.NoError(t, err)
+	assert.True(t, last.Add(DefaultPollingDelay).Equal(e.ExecutionPlannedDate))
+}
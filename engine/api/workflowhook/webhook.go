@@ -0,0 +1,42 @@
+package workflowhook
+
+import (
+	"fmt"
+
+	"github.com/ovh/cds/engine/api/workflow"
+	"github.com/ovh/cds/sdk"
+)
+
+//URL returns the public URL of a webhook given its uuid
+func URL(uuid string) string {
+	return fmt.Sprintf("%s/hook/workflow/%s", apiURL, uuid)
+}
+
+//SetWebHooksURL sets the public URL in the configuration of all the webhooks of the workflow
+func SetWebHooksURL(w *sdk.Workflow) {
+	setNodeWebHooksURL(w.Root)
+	for i := range w.Joins {
+		for j := range w.Joins[i].Triggers {
+			setNodeWebHooksURL(&w.Joins[i].Triggers[j].WorkflowDestNode)
+		}
+	}
+}
+
+func setNodeWebHooksURL(n *sdk.WorkflowNode) {
+	if n == nil {
+		return
+	}
+	for i := range n.Hooks {
+		h := &n.Hooks[i]
+		if h.WorkflowHookModel.Name != workflow.WebHookModel.Name {
+			continue
+		}
+		if h.Config == nil {
+			h.Config = sdk.WorkflowNodeHookConfig{}
+		}
+		h.Config[sdk.WorkflowNodeHookConfigWebHookURL] = URL(h.UUID)
+	}
+	for i := range n.Triggers {
+		setNodeWebHooksURL(&n.Triggers[i].WorkflowDestNode)
+	}
+}
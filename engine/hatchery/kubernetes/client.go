@@ -0,0 +1,211 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+//kubernetesClient is the subset of the kubernetes API used by the hatchery
+type kubernetesClient interface {
+	CreatePod(p *pod) (*pod, error)
+	ListPods(labelSelector string) ([]pod, error)
+	DeletePod(name string) error
+}
+
+//pod, and the types below, only map the fields of the kubernetes v1 API used by the hatchery
+type pod struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   objectMeta `json:"metadata"`
+	Spec       podSpec    `json:"spec"`
+	Status     podStatus  `json:"status,omitempty"`
+}
+
+type objectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreationTimestamp *time.Time        `json:"creationTimestamp,omitempty"`
+}
+
+type podSpec struct {
+	Containers    []container `json:"containers"`
+	RestartPolicy string      `json:"restartPolicy,omitempty"`
+	HostAliases   []hostAlias `json:"hostAliases,omitempty"`
+}
+
+type hostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+type container struct {
+	Name            string               `json:"name"`
+	Image           string               `json:"image"`
+	Command         []string             `json:"command,omitempty"`
+	Env             []envVar             `json:"env,omitempty"`
+	Resources       resourceRequirements `json:"resources,omitempty"`
+	ImagePullPolicy string               `json:"imagePullPolicy,omitempty"`
+}
+
+type envVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type resourceRequirements struct {
+	Limits   map[string]string `json:"limits,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"`
+}
+
+type podStatus struct {
+	Phase string `json:"phase,omitempty"`
+}
+
+type podList struct {
+	Items []pod `json:"items"`
+}
+
+//Pod phases
+const (
+	podPending   = "Pending"
+	podRunning   = "Running"
+	podSucceeded = "Succeeded"
+	podFailed    = "Failed"
+)
+
+//restClient calls the kubernetes API over HTTP, with a bearer token
+type restClient struct {
+	masterURL string
+	namespace string
+	token     string
+	client    *http.Client
+}
+
+//newRestClient returns a client on the namespace of a kubernetes cluster. Without masterURL, it uses the
+//service account of the pod running the hatchery.
+func newRestClient(masterURL, namespace, token, caFile string, insecureSkipVerify bool) (*restClient, error) {
+	if masterURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes master url not provided and the hatchery is not running in a cluster")
+		}
+		masterURL = "https://" + host + ":" + port
+		if token == "" {
+			btes, err := ioutil.ReadFile(serviceAccountTokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read service account token: %s", err)
+			}
+			token = strings.TrimSpace(string(btes))
+		}
+		if caFile == "" {
+			caFile = serviceAccountCAFile
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read certificate authority %s: %s", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid certificate authority %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &restClient{
+		masterURL: strings.TrimSuffix(masterURL, "/"),
+		namespace: namespace,
+		token:     token,
+		client: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+func (c *restClient) CreatePod(p *pod) (*pod, error) {
+	p.APIVersion = "v1"
+	p.Kind = "Pod"
+	res := &pod{}
+	if err := c.do("POST", "/pods", nil, p, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *restClient) ListPods(labelSelector string) ([]pod, error) {
+	res := podList{}
+	if err := c.do("GET", "/pods", url.Values{"labelSelector": {labelSelector}}, nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+func (c *restClient) DeletePod(name string) error {
+	return c.do("DELETE", "/pods/"+url.PathEscape(name), nil, nil, nil)
+}
+
+func (c *restClient) do(method, path string, query url.Values, in, out interface{}) error {
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s%s", c.masterURL, url.PathEscape(c.namespace), path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		btes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(btes)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	btes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, string(btes))
+	}
+	if out != nil {
+		return json.Unmarshal(btes, out)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/hatchery"
+)
+
+func init() {
+	hatcheryKubernetes = &HatcheryKubernetes{}
+
+	Cmd.Flags().StringVar(&hatcheryKubernetes.masterURL, "kubernetes-master-url", "", "Kubernetes API url, the service account of the pod is used if not set")
+	viper.BindPFlag("kubernetes-master-url", Cmd.Flags().Lookup("kubernetes-master-url"))
+
+	Cmd.Flags().StringVar(&hatcheryKubernetes.namespace, "kubernetes-namespace", "default", "Kubernetes namespace of the workers")
+	viper.BindPFlag("kubernetes-namespace", Cmd.Flags().Lookup("kubernetes-namespace"))
+
+	Cmd.Flags().StringVar(&hatcheryKubernetes.k8sToken, "kubernetes-token", "", "Kubernetes bearer token")
+	viper.BindPFlag("kubernetes-token", Cmd.Flags().Lookup("kubernetes-token"))
+
+	Cmd.Flags().StringVar(&hatcheryKubernetes.caFile, "kubernetes-ca-file", "", "Kubernetes certificate authority file")
+	viper.BindPFlag("kubernetes-ca-file", Cmd.Flags().Lookup("kubernetes-ca-file"))
+
+	Cmd.Flags().BoolVar(&hatcheryKubernetes.insecure, "kubernetes-insecure", false, "Skip the verification of the certificate of the Kubernetes API")
+	viper.BindPFlag("kubernetes-insecure", Cmd.Flags().Lookup("kubernetes-insecure"))
+
+	Cmd.Flags().IntVar(&hatcheryKubernetes.defaultMemory, "worker-memory", 1024, "Worker default memory")
+	viper.BindPFlag("worker-memory", Cmd.Flags().Lookup("worker-memory"))
+
+	Cmd.Flags().IntVar(&hatcheryKubernetes.workerTTL, "worker-ttl", 10, "Worker TTL (minutes)")
+	viper.BindPFlag("worker-ttl", Cmd.Flags().Lookup("worker-ttl"))
+
+	Cmd.Flags().IntVar(&hatcheryKubernetes.workerSpawnTimeout, "worker-spawn-timeout", 120, "Worker Timeout Spawning (seconds)")
+	viper.BindPFlag("worker-spawn-timeout", Cmd.Flags().Lookup("worker-spawn-timeout"))
+
+	Cmd.Flags().Int("spawn-threshold-critical", 10, "log critical if spawn take more than this value (in seconds)")
+	viper.BindPFlag("spawn-threshold-critical", Cmd.Flags().Lookup("spawn-threshold-critical"))
+
+	Cmd.Flags().Int("spawn-threshold-warning", 4, "log warning if spawn take more than this value (in seconds)")
+	viper.BindPFlag("spawn-threshold-warning", Cmd.Flags().Lookup("spawn-threshold-warning"))
+}
+
+// Cmd configures comamnd for HatcheryKubernetes
+var Cmd = &cobra.Command{
+	Use:   "kubernetes",
+	Short: "Hatchery Kubernetes commands: hatchery kubernetes --help",
+	Long: `Hatchery Kubernetes commands: hatchery kubernetes <command>
+Start worker model instances as pods on a kubernetes cluster
+
+$ cds generate token --group shared.infra --expiration persistent
+2706bda13748877c57029598b915d46236988c7c57ea0d3808524a1e1a3adef4
+
+$ hatchery kubernetes --api=https://<api.domain> --token=<token> --kubernetes-namespace=cds
+
+Inside the cluster, the hatchery uses the service account of its pod, which must be allowed to create, list and delete pods.
+
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		hatchery.Create(hatcheryKubernetes,
+			viper.GetString("name"),
+			viper.GetString("api"),
+			viper.GetString("token"),
+			viper.GetInt64("max-worker"),
+			viper.GetBool("provision-disabled"),
+			viper.GetInt("request-api-timeout"),
+			viper.GetInt("max-failures-heartbeat"),
+			viper.GetBool("insecure"),
+			viper.GetInt("provision-seconds"),
+			viper.GetInt("register-seconds"),
+			viper.GetInt("spawn-threshold-warning"),
+			viper.GetInt("spawn-threshold-critical"),
+			viper.GetInt("grace-time-queued"),
+			viper.GetBool("provision-adaptive"),
+			viper.GetInt("provision-min"),
+			viper.GetInt("provision-max"),
+			viper.GetInt("provision-cooldown-seconds"),
+		)
+	},
+	PreRun: func(cmd *cobra.Command, args []string) {
+		hatcheryKubernetes.token = viper.GetString("token")
+		hatcheryKubernetes.masterURL = viper.GetString("kubernetes-master-url")
+		hatcheryKubernetes.namespace = viper.GetString("kubernetes-namespace")
+		hatcheryKubernetes.k8sToken = viper.GetString("kubernetes-token")
+		hatcheryKubernetes.caFile = viper.GetString("kubernetes-ca-file")
+		hatcheryKubernetes.insecure = viper.GetBool("kubernetes-insecure")
+
+		if hatcheryKubernetes.namespace == "" {
+			sdk.Exit("flag or environment variable kubernetes-namespace not provided, aborting\n")
+		}
+
+		k8sClient, err := newRestClient(hatcheryKubernetes.masterURL, hatcheryKubernetes.namespace, hatcheryKubernetes.k8sToken, hatcheryKubernetes.caFile, hatcheryKubernetes.insecure)
+		if err != nil {
+			sdk.Exit("Unable to create kubernetes client: %s\n", err)
+		}
+		hatcheryKubernetes.k8sClient = k8sClient
+	},
+}
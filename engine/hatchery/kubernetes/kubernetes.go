@@ -0,0 +1,372 @@
+package kubernetes
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/namesgenerator"
+	"github.com/spf13/viper"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/cdsclient"
+	"github.com/ovh/cds/sdk/hatchery"
+	"github.com/ovh/cds/sdk/log"
+)
+
+const (
+	labelHatchery    = "cds-hatchery"
+	labelWorkerName  = "cds-worker-name"
+	labelWorkerModel = "cds-worker-model"
+)
+
+var (
+	hatcheryKubernetes *HatcheryKubernetes
+	invalidNameChars   = regexp.MustCompile("[^a-z0-9-]+")
+	invalidLabelChars  = regexp.MustCompile("[^A-Za-z0-9_.-]+")
+)
+
+// HatcheryKubernetes implements HatcheryMode interface for kubernetes mode, it spawns one pod per worker
+type HatcheryKubernetes struct {
+	hatch *sdk.Hatchery
+	token string
+
+	k8sClient kubernetesClient
+	client    cdsclient.Interface
+
+	masterURL string
+	namespace string
+	k8sToken  string
+	caFile    string
+	insecure  bool
+
+	defaultMemory      int
+	workerTTL          int
+	workerSpawnTimeout int
+}
+
+// ID must returns hatchery id
+func (h *HatcheryKubernetes) ID() int64 {
+	if h.hatch == nil {
+		return 0
+	}
+	return h.hatch.ID
+}
+
+//Hatchery returns hatchery instance
+func (h *HatcheryKubernetes) Hatchery() *sdk.Hatchery {
+	return h.hatch
+}
+
+//Client returns cdsclient instance
+func (h *HatcheryKubernetes) Client() cdsclient.Interface {
+	return h.client
+}
+
+// ModelType returns type of hatchery
+func (*HatcheryKubernetes) ModelType() string {
+	return sdk.Docker
+}
+
+// NeedRegistration return true if worker model need regsitration
+func (h *HatcheryKubernetes) NeedRegistration(wm *sdk.Model) bool {
+	if wm.NeedRegistration || wm.LastRegistration.Unix() < wm.UserLastModified.Unix() {
+		return true
+	}
+	return false
+}
+
+// Init registers the hatchery and starts the routine deleting the pods of the finished and awol workers
+func (h *HatcheryKubernetes) Init(name, api, token string, requestSecondsTimeout int, insecureSkipVerifyTLS bool) error {
+	h.hatch = &sdk.Hatchery{
+		Name:    hatchery.GenerateName("kubernetes", name),
+		Version: sdk.VERSION,
+	}
+
+	h.client = cdsclient.NewHatchery(api, token, requestSecondsTimeout, insecureSkipVerifyTLS)
+	if err := hatchery.Register(h); err != nil {
+		return fmt.Errorf("Cannot register: %s", err)
+	}
+
+	go h.killAwolWorkersRoutine()
+	return nil
+}
+
+// CanSpawn return wether or not hatchery can spawn model
+func (h *HatcheryKubernetes) CanSpawn(model *sdk.Model, jobID int64, requirements []sdk.Requirement) bool {
+	for _, r := range requirements {
+		if r.Type == sdk.MemoryRequirement {
+			if _, err := strconv.Atoi(r.Value); err != nil {
+				log.Debug("CanSpawn> Job %d has an invalid memory requirement %s", jobID, r.Value)
+				return false
+			}
+		}
+	}
+
+	n := h.WorkersStarted()
+	if n >= viper.GetInt("max-worker") {
+		log.Info("CanSpawn> max number of pods reached, aborting. Current: %d. Max: %d", n, viper.GetInt("max-worker"))
+		return false
+	}
+	return true
+}
+
+// SpawnWorker creates a pod running the worker, and a container for each service requirement.
+// The containers of a pod share the same network, the services are reachable on localhost with their names.
+func (h *HatcheryKubernetes) SpawnWorker(model *sdk.Model, jobID int64, requirements []sdk.Requirement, registerOnly bool, logInfo string) (string, error) {
+	name := podName(model.Name, registerOnly)
+	if jobID > 0 {
+		log.Info("SpawnWorker> spawning worker %s (%s) for job %d - %s", name, model.Image, jobID, logInfo)
+	} else {
+		log.Info("SpawnWorker> spawning worker %s (%s) - %s", name, model.Image, logInfo)
+	}
+
+	p, err := h.newPod(name, model, jobID, requirements, registerOnly)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := h.k8sClient.CreatePod(p); err != nil {
+		return "", fmt.Errorf("SpawnWorker> unable to create pod %s: %s", name, err)
+	}
+	return name, nil
+}
+
+func (h *HatcheryKubernetes) newPod(name string, model *sdk.Model, jobID int64, requirements []sdk.Requirement, registerOnly bool) (*pod, error) {
+	cmd := "rm -f worker && curl ${CDS_API}/download/worker/$(uname -m) -o worker && chmod +x worker && exec ./worker"
+	if registerOnly {
+		cmd += " register"
+	}
+
+	env := []envVar{
+		{Name: "CDS_API", Value: h.Client().APIURL()},
+		{Name: "CDS_TOKEN", Value: h.token},
+		{Name: "CDS_NAME", Value: name},
+		{Name: "CDS_MODEL", Value: strconv.FormatInt(model.ID, 10)},
+		{Name: "CDS_HATCHERY", Value: strconv.FormatInt(h.hatch.ID, 10)},
+		{Name: "CDS_HATCHERY_NAME", Value: h.hatch.Name},
+		{Name: "CDS_SINGLE_USE", Value: "1"},
+		{Name: "CDS_TTL", Value: strconv.Itoa(h.workerTTL)},
+	}
+	if viper.GetString("worker_graylog_host") != "" {
+		env = append(env, envVar{Name: "CDS_GRAYLOG_HOST", Value: viper.GetString("worker_graylog_host")})
+	}
+	if viper.GetString("worker_graylog_port") != "" {
+		env = append(env, envVar{Name: "CDS_GRAYLOG_PORT", Value: viper.GetString("worker_graylog_port")})
+	}
+	if viper.GetString("worker_graylog_extra_key") != "" {
+		env = append(env, envVar{Name: "CDS_GRAYLOG_EXTRA_KEY", Value: viper.GetString("worker_graylog_extra_key")})
+	}
+	if viper.GetString("worker_graylog_extra_value") != "" {
+		env = append(env, envVar{Name: "CDS_GRAYLOG_EXTRA_VALUE", Value: viper.GetString("worker_graylog_extra_value")})
+	}
+	if viper.GetString("grpc_api") != "" && model.Communication == sdk.GRPC {
+		env = append(env, envVar{Name: "CDS_GRPC_API", Value: viper.GetString("grpc_api")})
+		env = append(env, envVar{Name: "CDS_GRPC_INSECURE", Value: strconv.FormatBool(viper.GetBool("grpc_insecure"))})
+	}
+
+	memory := h.defaultMemory
+	services := []container{}
+	hostnames := []string{}
+	if jobID > 0 {
+		env = append(env, envVar{Name: "CDS_BOOKED_JOB_ID", Value: strconv.FormatInt(jobID, 10)})
+
+		for _, r := range requirements {
+			switch r.Type {
+			case sdk.MemoryRequirement:
+				var err error
+				memory, err = strconv.Atoi(r.Value)
+				if err != nil {
+					return nil, fmt.Errorf("SpawnWorker> unable to parse memory requirement %s: %s", r.Value, err)
+				}
+			case sdk.ServiceRequirement:
+				services = append(services, serviceContainer(r))
+				hostnames = append(hostnames, r.Name)
+			}
+		}
+	}
+
+	worker := container{
+		Name:      "worker",
+		Image:     model.Image,
+		Command:   []string{"sh", "-c", cmd},
+		Env:       env,
+		Resources: memoryResources(memory),
+	}
+	if strings.HasSuffix(model.Image, ":latest") {
+		worker.ImagePullPolicy = "Always"
+	}
+
+	p := &pod{
+		Metadata: objectMeta{
+			Name:      name,
+			Namespace: h.namespace,
+			Labels: map[string]string{
+				labelHatchery:    labelValue(h.hatch.Name),
+				labelWorkerName:  name,
+				labelWorkerModel: strconv.FormatInt(model.ID, 10),
+			},
+		},
+		Spec: podSpec{
+			Containers:    append([]container{worker}, services...),
+			RestartPolicy: "Never",
+		},
+	}
+	if len(hostnames) > 0 {
+		p.Spec.HostAliases = []hostAlias{{IP: "127.0.0.1", Hostnames: hostnames}}
+	}
+	return p, nil
+}
+
+//serviceContainer returns the sidecar container of a service requirement.
+//The value of the requirement is the image, followed by env variables: "postgres:9.6 POSTGRES_USER=cds".
+//The memory of the container can be set with CDS_SERVICE_MEMORY=1024
+func serviceContainer(r sdk.Requirement) container {
+	tuple := strings.Fields(r.Value)
+	c := container{
+		Name: strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(r.Name), "-"), "-"),
+	}
+	if len(tuple) == 0 {
+		return c
+	}
+	c.Image = tuple[0]
+	memory := 1024
+	for _, e := range tuple[1:] {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if kv[0] == "CDS_SERVICE_MEMORY" {
+			m, err := strconv.Atoi(kv[1])
+			if err != nil {
+				log.Warning("SpawnWorker> Unable to parse service option %s : %s", e, err)
+				continue
+			}
+			memory = m
+			continue
+		}
+		c.Env = append(c.Env, envVar{Name: kv[0], Value: kv[1]})
+	}
+	c.Resources = memoryResources(memory)
+	return c
+}
+
+//memoryResources returns the resources of a container, with 110% of the required memory in MB
+func memoryResources(memory int) resourceRequirements {
+	if memory <= 4 {
+		memory = 1024
+	}
+	m := fmt.Sprintf("%dMi", memory*110/100)
+	return resourceRequirements{
+		Limits:   map[string]string{"memory": m},
+		Requests: map[string]string{"memory": m},
+	}
+}
+
+//podName returns a valid pod name for a worker of the model
+func podName(model string, registerOnly bool) string {
+	name := "k8s-" + strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(model), "-"), "-")
+	if registerOnly {
+		name = "register-" + name
+	}
+	suffix := "-" + strings.Replace(namesgenerator.GetRandomName(1), "_", "-", -1)
+	if len(name)+len(suffix) > 63 {
+		name = name[:63-len(suffix)]
+	}
+	return name + suffix
+}
+
+//labelValue returns a valid label value
+func labelValue(s string) string {
+	s = strings.Trim(invalidLabelChars.ReplaceAllString(s, "-"), "-_.")
+	if len(s) > 63 {
+		s = strings.TrimRight(s[:63], "-_.")
+	}
+	return s
+}
+
+func (h *HatcheryKubernetes) listPods(model *sdk.Model) ([]pod, error) {
+	selector := labelHatchery + "=" + labelValue(h.hatch.Name)
+	if model != nil {
+		selector += "," + labelWorkerModel + "=" + strconv.FormatInt(model.ID, 10)
+	}
+	return h.k8sClient.ListPods(selector)
+}
+
+// WorkersStarted returns the number of pods started but
+// not necessarily register on CDS yet
+func (h *HatcheryKubernetes) WorkersStarted() int {
+	return h.countPods(nil)
+}
+
+// WorkersStartedByModel returns the number of pods of given model started but
+// not necessarily register on CDS yet
+func (h *HatcheryKubernetes) WorkersStartedByModel(model *sdk.Model) int {
+	return h.countPods(model)
+}
+
+func (h *HatcheryKubernetes) countPods(model *sdk.Model) int {
+	pods, err := h.listPods(model)
+	if err != nil {
+		log.Warning("WorkersStarted> error on list pods err:%s", err)
+		return 0
+	}
+	var n int
+	for _, p := range pods {
+		if p.Status.Phase != podSucceeded && p.Status.Phase != podFailed {
+			n++
+		}
+	}
+	return n
+}
+
+func (h *HatcheryKubernetes) killAwolWorkersRoutine() {
+	for {
+		time.Sleep(10 * time.Second)
+		workers, err := h.Client().WorkerList()
+		if err != nil {
+			log.Warning("killAwolWorkers> Cannot get workers: %s", err)
+			continue
+		}
+		if err := h.killAwolWorkers(workers); err != nil {
+			log.Warning("killAwolWorkers> Cannot kill awol workers: %s", err)
+		}
+	}
+}
+
+//killAwolWorkers deletes the pods which are over, the pods of the disabled workers, and the pods whose worker
+//has not registered before the spawn timeout
+func (h *HatcheryKubernetes) killAwolWorkers(workers []sdk.Worker) error {
+	pods, err := h.listPods(nil)
+	if err != nil {
+		return err
+	}
+
+	status := map[string]sdk.Status{}
+	for _, w := range workers {
+		status[w.Name] = w.Status
+	}
+
+	for _, p := range pods {
+		var reason string
+		s, found := status[p.Metadata.Name]
+		switch {
+		case p.Status.Phase == podSucceeded || p.Status.Phase == podFailed:
+			reason = "finished"
+		case found && s == sdk.StatusDisabled:
+			reason = "disabled"
+		case !found && p.Metadata.CreationTimestamp != nil && time.Since(*p.Metadata.CreationTimestamp) > time.Duration(h.workerSpawnTimeout)*time.Second:
+			reason = "awol"
+		default:
+			continue
+		}
+
+		log.Info("killAwolWorkers> deleting %s pod %s", reason, p.Metadata.Name)
+		if err := h.k8sClient.DeletePod(p.Metadata.Name); err != nil {
+			log.Warning("killAwolWorkers> Error while deleting pod %s err:%s", p.Metadata.Name, err)
+		}
+	}
+	return nil
+}
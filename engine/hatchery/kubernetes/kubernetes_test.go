@@ -0,0 +1,228 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/cdsclient"
+)
+
+//fakeClient is an in memory kubernetes client
+type fakeClient struct {
+	sync.Mutex
+	pods    map[string]pod
+	deleted []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{pods: map[string]pod{}}
+}
+
+func (c *fakeClient) CreatePod(p *pod) (*pod, error) {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.pods[p.Metadata.Name]; ok {
+		return nil, fmt.Errorf("pod %s already exists", p.Metadata.Name)
+	}
+	now := time.Now()
+	p.Metadata.CreationTimestamp = &now
+	p.Status.Phase = podPending
+	c.pods[p.Metadata.Name] = *p
+	return p, nil
+}
+
+func (c *fakeClient) ListPods(labelSelector string) ([]pod, error) {
+	c.Lock()
+	defer c.Unlock()
+	pods := []pod{}
+	for _, p := range c.pods {
+		match := true
+		for _, s := range strings.Split(labelSelector, ",") {
+			kv := strings.SplitN(s, "=", 2)
+			if p.Metadata.Labels[kv[0]] != kv[1] {
+				match = false
+			}
+		}
+		if match {
+			pods = append(pods, p)
+		}
+	}
+	return pods, nil
+}
+
+func (c *fakeClient) DeletePod(name string) error {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.pods[name]; !ok {
+		return fmt.Errorf("pod %s not found", name)
+	}
+	delete(c.pods, name)
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeClient) setPhase(name, phase string, created time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	p := c.pods[name]
+	p.Status.Phase = phase
+	p.Metadata.CreationTimestamp = &created
+	c.pods[name] = p
+}
+
+func newTestHatchery(k8s kubernetesClient) *HatcheryKubernetes {
+	return &HatcheryKubernetes{
+		hatch:              &sdk.Hatchery{ID: 1, Name: "my_hatchery-kubernetes"},
+		token:              "token",
+		k8sClient:          k8s,
+		client:             cdsclient.NewHatchery("http://cds.local", "token", 10, false),
+		namespace:          "cds",
+		defaultMemory:      1024,
+		workerTTL:          10,
+		workerSpawnTimeout: 120,
+	}
+}
+
+func TestHatcheryKubernetesSpawnWorker(t *testing.T) {
+	k8s := newFakeClient()
+	h := newTestHatchery(k8s)
+	model := &sdk.Model{ID: 42, Name: "Go_Official", Image: "golang:1.9"}
+	requirements := []sdk.Requirement{
+		{Name: "mem", Type: sdk.MemoryRequirement, Value: "2048"},
+		{Name: "pg", Type: sdk.ServiceRequirement, Value: "postgres:9.6 POSTGRES_USER=cds CDS_SERVICE_MEMORY=512"},
+		{Name: "redis", Type: sdk.ServiceRequirement, Value: "redis"},
+	}
+
+	name, err := h.SpawnWorker(model, 666, requirements, false, "test")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(name, "k8s-go-official-"), name)
+	assert.True(t, len(name) <= 63)
+
+	p, ok := k8s.pods[name]
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, "cds", p.Metadata.Namespace)
+	assert.Equal(t, "my_hatchery-kubernetes", p.Metadata.Labels[labelHatchery])
+	assert.Equal(t, "42", p.Metadata.Labels[labelWorkerModel])
+	assert.Equal(t, "Never", p.Spec.RestartPolicy)
+	if !assert.Len(t, p.Spec.Containers, 3) {
+		t.FailNow()
+	}
+
+	worker := p.Spec.Containers[0]
+	assert.Equal(t, "golang:1.9", worker.Image)
+	assert.Equal(t, "2252Mi", worker.Resources.Limits["memory"])
+	assert.Contains(t, worker.Env, envVar{Name: "CDS_BOOKED_JOB_ID", Value: "666"})
+	assert.Contains(t, worker.Env, envVar{Name: "CDS_NAME", Value: name})
+	assert.Contains(t, worker.Env, envVar{Name: "CDS_API", Value: "http://cds.local"})
+
+	pg := p.Spec.Containers[1]
+	assert.Equal(t, "pg", pg.Name)
+	assert.Equal(t, "postgres:9.6", pg.Image)
+	assert.Equal(t, []envVar{{Name: "POSTGRES_USER", Value: "cds"}}, pg.Env)
+	assert.Equal(t, "563Mi", pg.Resources.Limits["memory"])
+
+	redis := p.Spec.Containers[2]
+	assert.Equal(t, "redis", redis.Image)
+	assert.Equal(t, "1126Mi", redis.Resources.Limits["memory"])
+
+	assert.Equal(t, []hostAlias{{IP: "127.0.0.1", Hostnames: []string{"pg", "redis"}}}, p.Spec.HostAliases)
+
+	assert.Equal(t, 1, h.WorkersStarted())
+	assert.Equal(t, 1, h.WorkersStartedByModel(model))
+	assert.Equal(t, 0, h.WorkersStartedByModel(&sdk.Model{ID: 1}))
+
+	_, err = h.SpawnWorker(model, 666, []sdk.Requirement{{Type: sdk.MemoryRequirement, Value: "a lot"}}, false, "test")
+	assert.Error(t, err)
+}
+
+func TestHatcheryKubernetesKillAwolWorkers(t *testing.T) {
+	k8s := newFakeClient()
+	h := newTestHatchery(k8s)
+	model := &sdk.Model{ID: 42, Name: "go", Image: "golang:1.9"}
+
+	names := map[string]string{}
+	for _, s := range []string{"running", "succeeded", "failed", "disabled", "awol", "starting"} {
+		name, err := h.SpawnWorker(model, 0, nil, false, "test")
+		assert.NoError(t, err)
+		names[s] = name
+	}
+	old := time.Now().Add(-10 * time.Minute)
+	k8s.setPhase(names["running"], podRunning, old)
+	k8s.setPhase(names["succeeded"], podSucceeded, old)
+	k8s.setPhase(names["failed"], podFailed, old)
+	k8s.setPhase(names["disabled"], podRunning, old)
+	k8s.setPhase(names["awol"], podPending, old)
+	k8s.setPhase(names["starting"], podPending, time.Now())
+
+	//A pod of another hatchery is never deleted
+	k8s.pods["other"] = pod{Metadata: objectMeta{Name: "other", Labels: map[string]string{labelHatchery: "other"}}, Status: podStatus{Phase: podFailed}}
+
+	workers := []sdk.Worker{
+		{Name: names["running"], Status: sdk.StatusBuilding},
+		{Name: names["disabled"], Status: sdk.StatusDisabled},
+	}
+	assert.NoError(t, h.killAwolWorkers(workers))
+
+	assert.Len(t, k8s.deleted, 4)
+	for _, s := range []string{"succeeded", "failed", "disabled", "awol"} {
+		assert.Contains(t, k8s.deleted, names[s])
+	}
+	assert.Equal(t, 2, h.WorkersStarted())
+	assert.Contains(t, k8s.pods, "other")
+}
+
+func TestRestClient(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.Method {
+		case "POST":
+			var p pod
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, "Pod", p.Kind)
+			assert.Equal(t, "v1", p.APIVersion)
+			p.Status.Phase = podPending
+			json.NewEncoder(w).Encode(p)
+		case "GET":
+			json.NewEncoder(w).Encode(podList{Items: []pod{{Metadata: objectMeta{Name: "w1"}}}})
+		case "DELETE":
+			if strings.HasSuffix(r.URL.Path, "/unknown") {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	c, err := newRestClient(ts.URL+"/", "cds", "secret", "", false)
+	assert.NoError(t, err)
+
+	p, err := c.CreatePod(&pod{Metadata: objectMeta{Name: "w1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, podPending, p.Status.Phase)
+
+	pods, err := c.ListPods("cds-hatchery=h1")
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+
+	assert.NoError(t, c.DeletePod("w1"))
+	assert.Error(t, c.DeletePod("unknown"))
+
+	assert.Equal(t, []string{
+		"POST /api/v1/namespaces/cds/pods",
+		"GET /api/v1/namespaces/cds/pods?labelSelector=cds-hatchery%3Dh1",
+		"DELETE /api/v1/namespaces/cds/pods/w1",
+		"DELETE /api/v1/namespaces/cds/pods/unknown",
+	}, requests)
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	_ "expvar"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
 	"github.com/google/gops/agent"
 
 	"github.com/ovh/cds/engine/hatchery/docker"
+	"github.com/ovh/cds/engine/hatchery/kubernetes"
 	"github.com/ovh/cds/engine/hatchery/local"
 	"github.com/ovh/cds/engine/hatchery/marathon"
 	"github.com/ovh/cds/engine/hatchery/openstack"
@@ -57,6 +60,15 @@ var rootCmd = &cobra.Command{
 				sdk.Exit("Error on starting gops agent", err)
 			}
 		}
+
+		if viper.GetString("metrics-listen") != "" {
+			log.Info("Serving metrics on %s", viper.GetString("metrics-listen"))
+			go func() {
+				if err := http.ListenAndServe(viper.GetString("metrics-listen"), nil); err != nil {
+					log.Error("Error on serving metrics: %s", err)
+				}
+			}()
+		}
 	},
 }
 
@@ -77,6 +89,7 @@ func addCommands() {
 	rootCmd.AddCommand(swarm.Cmd)
 	rootCmd.AddCommand(openstack.Cmd)
 	rootCmd.AddCommand(vsphere.Cmd)
+	rootCmd.AddCommand(kubernetes.Cmd)
 	rootCmd.AddCommand(cmdVersion)
 }
 
@@ -111,6 +124,18 @@ func addFlags() {
 	rootCmd.PersistentFlags().Int("provision-seconds", 30, "Check provisioning each n Seconds")
 	viper.BindPFlag("provision-seconds", rootCmd.PersistentFlags().Lookup("provision-seconds"))
 
+	rootCmd.PersistentFlags().Bool("provision-adaptive", false, "Size the idle workers of each model from its queue depth, job arrival rate and spawn latency instead of its provision")
+	viper.BindPFlag("provision-adaptive", rootCmd.PersistentFlags().Lookup("provision-adaptive"))
+
+	rootCmd.PersistentFlags().Int("provision-min", 0, "Adaptive provisioning: minimum idle workers per model")
+	viper.BindPFlag("provision-min", rootCmd.PersistentFlags().Lookup("provision-min"))
+
+	rootCmd.PersistentFlags().Int("provision-max", 5, "Adaptive provisioning: maximum idle workers per model, 0 for no limit")
+	viper.BindPFlag("provision-max", rootCmd.PersistentFlags().Lookup("provision-max"))
+
+	rootCmd.PersistentFlags().Int("provision-cooldown-seconds", 300, "Adaptive provisioning: disable idle workers not needed since n Seconds")
+	viper.BindPFlag("provision-cooldown-seconds", rootCmd.PersistentFlags().Lookup("provision-cooldown-seconds"))
+
 	rootCmd.PersistentFlags().Int("register-seconds", 60, "Check if some worker model have to be registered each n Seconds")
 	viper.BindPFlag("register-seconds", rootCmd.PersistentFlags().Lookup("register-seconds"))
 
@@ -167,4 +192,7 @@ func addFlags() {
 
 	rootCmd.PersistentFlags().String("remote-debug-url", "", "If not empty, start a gops agent on specified URL. Ex: --remote-debug-url=localhost:9999")
 	viper.BindPFlag("remote-debug-url", rootCmd.PersistentFlags().Lookup("remote-debug-url"))
+
+	rootCmd.PersistentFlags().String("metrics-listen", "", "If not empty, serve the provisioning metrics on http://<address>/debug/vars. Ex: --metrics-listen=localhost:8086")
+	viper.BindPFlag("metrics-listen", rootCmd.PersistentFlags().Lookup("metrics-listen"))
 }
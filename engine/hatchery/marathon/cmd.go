@@ -77,6 +77,10 @@ $ hatchery marathon --api=https://<api.domain> --token=<token>
 			viper.GetInt("spawn-threshold-warning"),
 			viper.GetInt("spawn-threshold-critical"),
 			viper.GetInt("grace-time-queued"),
+			viper.GetBool("provision-adaptive"),
+			viper.GetInt("provision-min"),
+			viper.GetInt("provision-max"),
+			viper.GetInt("provision-cooldown-seconds"),
 		)
 	},
 	PreRun: func(cmd *cobra.Command, args []string) {
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "workflow_node_hook_execution" (
+    id BIGSERIAL PRIMARY KEY,
+    workflow_node_hook_uuid VARCHAR(256) NOT NULL,
+    execution_planned_date TIMESTAMP WITH TIME ZONE,
+    execution_date TIMESTAMP WITH TIME ZONE,
+    executed BOOLEAN NOT NULL DEFAULT FALSE,
+    workflow_run_number BIGINT NOT NULL DEFAULT 0,
+    payload JSONB,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+SELECT create_index('workflow_node_hook_execution', 'IDX_WORKFLOW_NODE_HOOK_EXECUTION_UUID', 'workflow_node_hook_uuid');
+SELECT create_index('workflow_node_hook_execution', 'IDX_WORKFLOW_NODE_HOOK_EXECUTION_PLANNED', 'executed,execution_planned_date');
+
+-- +migrate Down
+DROP TABLE workflow_node_hook_execution;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "artifact_retention" (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    workflow_id BIGINT,
+    keep_last_runs INT NOT NULL DEFAULT 0,
+    keep_days INT NOT NULL DEFAULT 0,
+    keep_tags JSONB
+);
+
+SELECT create_foreign_key_idx_cascade('FK_ARTIFACT_RETENTION_PROJECT', 'artifact_retention', 'project', 'project_id', 'id');
+SELECT create_foreign_key_idx_cascade('FK_ARTIFACT_RETENTION_WORKFLOW', 'artifact_retention', 'workflow', 'workflow_id', 'id');
+
+-- +migrate Down
+DROP TABLE artifact_retention;
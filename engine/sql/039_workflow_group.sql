@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS "workflow_group" (
+    workflow_id BIGINT NOT NULL,
+    group_id BIGINT NOT NULL,
+    role INT NOT NULL,
+    PRIMARY KEY(group_id, workflow_id)
+);
+
+SELECT create_foreign_key_idx_cascade('FK_WORKFLOW_GROUP_WORKFLOW', 'workflow_group', 'workflow', 'workflow_id', 'id');
+SELECT create_foreign_key_idx_cascade('FK_WORKFLOW_GROUP_GROUP', 'workflow_group', 'group', 'group_id', 'id');
+
+-- Existing workflows keep the permissions of their project
+INSERT INTO workflow_group (workflow_id, group_id, role)
+SELECT workflow.id, project_group.group_id, project_group.role
+FROM workflow
+JOIN project_group ON project_group.project_id = workflow.project_id;
+
+ALTER TABLE workflow_node_context ADD COLUMN protected_manual_run BOOLEAN NOT NULL DEFAULT false;
+
+-- +migrate Down
+DROP TABLE workflow_group;
+ALTER TABLE workflow_node_context DROP COLUMN protected_manual_run;
@@ -0,0 +1,21 @@
+-- +migrate Up
+ALTER TABLE pipeline_action ADD COLUMN cache JSONB;
+
+CREATE TABLE IF NOT EXISTS "workflow_job_cache" (
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    cache_key VARCHAR(64) NOT NULL,
+    workflow_name VARCHAR(256) NOT NULL,
+    run_number BIGINT NOT NULL,
+    workflow_node_run_id BIGINT NOT NULL,
+    artifacts JSONB,
+    variables JSONB,
+    created TIMESTAMP WITH TIME ZONE DEFAULT LOCALTIMESTAMP
+);
+
+SELECT create_unique_index('workflow_job_cache', 'IDX_WORKFLOW_JOB_CACHE_KEY', 'project_id,cache_key');
+SELECT create_foreign_key_idx_cascade('FK_WORKFLOW_JOB_CACHE_PROJECT', 'workflow_job_cache', 'project', 'project_id', 'id');
+
+-- +migrate Down
+ALTER TABLE pipeline_action DROP COLUMN cache;
+DROP TABLE workflow_job_cache;
@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE workflow ADD COLUMN priority INT NOT NULL DEFAULT 0;
+ALTER TABLE project ADD COLUMN max_concurrent_jobs INT NOT NULL DEFAULT 0;
+ALTER TABLE workflow_node_run_job ADD COLUMN project_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE workflow_node_run_job ADD COLUMN priority INT NOT NULL DEFAULT 0;
+
+UPDATE workflow_node_run_job SET project_id = workflow.project_id
+FROM workflow_node_run, workflow_run, workflow
+WHERE workflow_node_run.id = workflow_node_run_job.workflow_node_run_id
+AND workflow_run.id = workflow_node_run.workflow_run_id
+AND workflow.id = workflow_run.workflow_id;
+
+SELECT create_index('workflow_node_run_job', 'IDX_WORKFLOW_NODE_RUN_JOB_PROJECT_STATUS', 'project_id,status');
+
+-- +migrate Down
+ALTER TABLE workflow_node_run_job DROP COLUMN priority;
+ALTER TABLE workflow_node_run_job DROP COLUMN project_id;
+ALTER TABLE project DROP COLUMN max_concurrent_jobs;
+ALTER TABLE workflow DROP COLUMN priority;
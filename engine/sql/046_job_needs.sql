@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE pipeline_action ADD COLUMN needs JSONB;
+
+-- +migrate Down
+ALTER TABLE pipeline_action DROP COLUMN needs;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE project ADD COLUMN max_workflow_priority INT NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE project DROP COLUMN max_workflow_priority;
@@ -0,0 +1,7 @@
+-- +migrate Up
+DROP INDEX IF EXISTS IDX_WORKFLOW_NODE_HOOK_UUID;
+SELECT create_unique_index('workflow_node_hook', 'IDX_WORKFLOW_NODE_HOOK_UUID', 'uuid');
+
+-- +migrate Down
+DROP INDEX IF EXISTS IDX_WORKFLOW_NODE_HOOK_UUID;
+SELECT create_index('workflow_node_hook', 'IDX_WORKFLOW_NODE_HOOK_UUID', 'uuid');
@@ -0,0 +1,6 @@
+-- +migrate Up
+DELETE FROM artifact_retention WHERE id NOT IN (SELECT MAX(id) FROM artifact_retention GROUP BY project_id, COALESCE(workflow_id, 0));
+CREATE UNIQUE INDEX IDX_ARTIFACT_RETENTION_UNIQUE ON artifact_retention (project_id, COALESCE(workflow_id, 0));
+
+-- +migrate Down
+DROP INDEX IF EXISTS IDX_ARTIFACT_RETENTION_UNIQUE;
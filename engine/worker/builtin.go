@@ -22,6 +22,8 @@ func init() {
 	mapBuiltinActions[sdk.GitCloneAction] = runGitClone
 	mapBuiltinActions[sdk.GitTagAction] = runGitTag
 	mapBuiltinActions[sdk.ReleaseAction] = runRelease
+	mapBuiltinActions[sdk.CacheSave] = runCacheSave
+	mapBuiltinActions[sdk.CacheRestore] = runCacheRestore
 
 }
 
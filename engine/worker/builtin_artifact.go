@@ -122,6 +122,9 @@ func runArtifactUpload(w *currentWorker) BuiltInAction {
 				sendLog(res.Reason)
 				return res
 			}
+			// Keep the uploaded artifacts to record them in the result cache of the job
+			unescapedTag, _ := url.QueryUnescape(tag.Value)
+			w.currentJob.artifacts = append(w.currentJob.artifacts, sdk.WorkflowNodeRunArtifact{Name: filename, Tag: unescapedTag})
 		}
 
 		return res
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func runCacheSave(w *currentWorker) BuiltInAction {
+	return func(ctx context.Context, a *sdk.Action, buildID int64, params *[]sdk.Parameter, sendLog LoggerFunc) sdk.Result {
+		res := sdk.Result{Status: sdk.StatusSuccess.String()}
+		if w.currentJob.wJob == nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("%s is only available in workflows", sdk.CacheSave)
+			sendLog(res.Reason)
+			return res
+		}
+
+		workspace := cacheWorkspace(*params)
+		key, err := renderCacheKey(sdk.ParameterValue(a.Parameters, "key"), workspace)
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Invalid cache key: %s", err)
+			sendLog(res.Reason)
+			return res
+		}
+
+		paths := splitCacheLines(sdk.ParameterValue(a.Parameters, "path"))
+		if len(paths) == 0 {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = "path is empty. aborting"
+			sendLog(res.Reason)
+			return res
+		}
+
+		f, err := ioutil.TempFile("", "cds-cache")
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Unable to create cache archive: %s", err)
+			sendLog(res.Reason)
+			return res
+		}
+		defer os.Remove(f.Name())
+
+		n, err := tarCache(f, workspace, paths)
+		f.Close()
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Unable to create cache archive: %s", err)
+			sendLog(res.Reason)
+			return res
+		}
+		if n == 0 {
+			sendLog(fmt.Sprintf("No file to save in cache %s", key))
+			return res
+		}
+
+		sendLog(fmt.Sprintf("Saving %d files in cache %s", n, key))
+		// The cache only speeds up the next builds, the step does not fail if it cannot be saved
+		if err := w.client.QueueWorkerCacheSave(buildID, key, f.Name()); err != nil {
+			sendLog(fmt.Sprintf("Unable to save cache %s: %s", key, err))
+			return res
+		}
+		sendLog(fmt.Sprintf("Cache %s saved", key))
+		return res
+	}
+}
+
+func runCacheRestore(w *currentWorker) BuiltInAction {
+	return func(ctx context.Context, a *sdk.Action, buildID int64, params *[]sdk.Parameter, sendLog LoggerFunc) sdk.Result {
+		res := sdk.Result{Status: sdk.StatusSuccess.String()}
+		if w.currentJob.wJob == nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("%s is only available in workflows", sdk.CacheRestore)
+			sendLog(res.Reason)
+			return res
+		}
+
+		workspace := cacheWorkspace(*params)
+		key, err := renderCacheKey(sdk.ParameterValue(a.Parameters, "key"), workspace)
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Invalid cache key: %s", err)
+			sendLog(res.Reason)
+			return res
+		}
+
+		prefixes := []string{}
+		for _, p := range splitCacheLines(sdk.ParameterValue(a.Parameters, "restoreKeys")) {
+			prefix, err := renderCacheKey(p, workspace)
+			if err != nil {
+				res.Status = sdk.StatusFail.String()
+				res.Reason = fmt.Sprintf("Invalid cache restore key: %s", err)
+				sendLog(res.Reason)
+				return res
+			}
+			prefixes = append(prefixes, prefix)
+		}
+
+		f, err := ioutil.TempFile("", "cds-cache")
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Unable to download cache: %s", err)
+			sendLog(res.Reason)
+			return res
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		// A missing cache does not fail the step, the next steps have to rebuild what it would have restored
+		cache, err := w.client.QueueWorkerCacheRestore(buildID, key, prefixes, f)
+		if err != nil {
+			sendLog(fmt.Sprintf("Unable to restore cache %s: %s", key, err))
+			return res
+		}
+		if cache == nil {
+			sendLog(fmt.Sprintf("Cache %s not found", key))
+			return res
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Unable to read cache %s: %s", cache.Key, err)
+			sendLog(res.Reason)
+			return res
+		}
+		n, err := untarCache(f, workspace)
+		if err != nil {
+			res.Status = sdk.StatusFail.String()
+			res.Reason = fmt.Sprintf("Unable to extract cache %s: %s", cache.Key, err)
+			sendLog(res.Reason)
+			return res
+		}
+		sendLog(fmt.Sprintf("Cache %s restored: %d files", cache.Key, n))
+		return res
+	}
+}
+
+// cacheWorkspace returns the directory the paths of the caches are relative to
+func cacheWorkspace(params []sdk.Parameter) string {
+	if ws := sdk.ParameterValue(params, "cds.workspace"); ws != "" {
+		return ws
+	}
+	return "."
+}
+
+func splitCacheLines(s string) []string {
+	res := []string{}
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// renderCacheKey computes a cache key from its template. The variables have already been replaced by the worker,
+// hashFiles returns the hash of the files matching the given patterns in the workspace: {{hashFiles "go.sum"}}
+func renderCacheKey(s string, workspace string) (string, error) {
+	funcs := template.FuncMap{
+		"hashFiles": func(patterns ...string) (string, error) {
+			return hashFiles(workspace, patterns...)
+		},
+	}
+	t, err := template.New("key").Funcs(funcs).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]string{}); err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(buf.String())
+	if !sdk.IsValidWorkerCacheKey(key) {
+		return "", fmt.Errorf("%s does not match %s", key, sdk.WorkerCacheKeyPattern)
+	}
+	return key, nil
+}
+
+// hashFiles returns the sha256 of the files matching patterns in the workspace
+func hashFiles(workspace string, patterns ...string) (string, error) {
+	h := sha256.New()
+	var found bool
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(workspace, pattern))
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %s: %s", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if err := hashPath(h, workspace, m); err != nil {
+				return "", err
+			}
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no file matches %s", strings.Join(patterns, ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// tarCache writes a compressed archive of paths, relative to workspace, and returns the number of files archived
+func tarCache(out io.Writer, workspace string, paths []string) (int, error) {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	var n int
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(filepath.Join(workspace, pattern))
+		if err != nil {
+			return 0, fmt.Errorf("invalid path %s: %s", pattern, err)
+		}
+		for _, m := range matches {
+			errW := filepath.Walk(m, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				name, err := cacheEntryName(workspace, path)
+				if err != nil {
+					return err
+				}
+
+				var link string
+				if info.Mode()&os.ModeSymlink != 0 {
+					if link, err = os.Readlink(path); err != nil {
+						return err
+					}
+				}
+				hdr, err := tar.FileInfoHeader(info, link)
+				if err != nil {
+					return err
+				}
+				hdr.Name = name
+				if err := tw.WriteHeader(hdr); err != nil {
+					return err
+				}
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+
+				f, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if _, err := io.Copy(tw, f); err != nil {
+					return err
+				}
+				n++
+				return nil
+			})
+			if errW != nil {
+				return 0, errW
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// cacheEntryName returns the name of path in a cache archive, paths outside of the workspace can't be cached
+func cacheEntryName(workspace, path string) (string, error) {
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the workspace", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// untarCache extracts a compressed archive in the workspace and returns the number of files extracted.
+// The archive may have been saved by any job of the project: nothing is written outside of the workspace,
+// neither with a path nor through a symlink.
+func untarCache(in io.Reader, workspace string) (int, error) {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	realWorkspace, err := filepath.EvalSymlinks(workspace)
+	if err != nil {
+		return 0, err
+	}
+
+	tr := tar.NewReader(gz)
+	var n int
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		path := filepath.Join(realWorkspace, filepath.FromSlash(hdr.Name))
+		if _, err := cacheEntryName(realWorkspace, path); err != nil {
+			return n, err
+		}
+		if err := checkCacheEntryParent(realWorkspace, path); err != nil {
+			return n, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.FileMode(hdr.Mode).Perm()|0700); err != nil {
+				return n, err
+			}
+		case tar.TypeSymlink:
+			target := hdr.Linkname
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			if _, err := cacheEntryName(realWorkspace, target); err != nil {
+				return n, fmt.Errorf("symlink %s: %s", hdr.Name, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+				return n, err
+			}
+			os.Remove(path)
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return n, err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+				return n, err
+			}
+			// An existing symlink is replaced, the file is never written through it
+			if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(path); err != nil {
+					return n, err
+				}
+			}
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return n, err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return n, err
+			}
+			if err := f.Close(); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+}
+
+// checkCacheEntryParent checks that the existing directories of the path of an entry don't lead outside of the workspace
+func checkCacheEntryParent(workspace, path string) error {
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := cacheEntryName(workspace, realDir); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_renderCacheKey(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "cds-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "go.sum"), []byte("sum"), os.FileMode(0644)))
+
+	key, err := renderCacheKey(`go-myapp-{{hashFiles "go.sum"}}`, workspace)
+	assert.NoError(t, err)
+	assert.Len(t, key, len("go-myapp-")+64)
+
+	same, err := renderCacheKey(`go-myapp-{{hashFiles "*.sum"}}`, workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "go.sum"), []byte("sum2"), os.FileMode(0644)))
+	other, err := renderCacheKey(`go-myapp-{{hashFiles "go.sum"}}`, workspace)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	_, err = renderCacheKey(`go-myapp-{{hashFiles "package.json"}}`, workspace)
+	assert.Error(t, err)
+
+	// The variable has not been replaced by the worker
+	_, err = renderCacheKey(`go-{{.cds.unknown}}`, workspace)
+	assert.Error(t, err)
+
+	_, err = renderCacheKey(`go/myapp`, workspace)
+	assert.Error(t, err)
+}
+
+func Test_tarCacheUntarCache(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "cds-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(workspace, "node_modules", "lib"), os.FileMode(0755)))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "node_modules", "lib", "index.js"), []byte("module.exports = {}"), os.FileMode(0644)))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "node_modules", "run.sh"), []byte("#!/bin/sh"), os.FileMode(0755)))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "package.json"), []byte("{}"), os.FileMode(0644)))
+
+	var archive bytes.Buffer
+	n, err := tarCache(&archive, workspace, []string{"node_modules"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = tarCache(&bytes.Buffer{}, workspace, []string{"../"})
+	assert.Error(t, err)
+
+	restored, err := ioutil.TempDir("", "cds-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(restored)
+
+	n, err = untarCache(&archive, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	btes, err := ioutil.ReadFile(filepath.Join(restored, "node_modules", "lib", "index.js"))
+	assert.NoError(t, err)
+	assert.Equal(t, "module.exports = {}", string(btes))
+
+	info, err := os.Stat(filepath.Join(restored, "node_modules", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	_, err = os.Stat(filepath.Join(restored, "package.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_untarCacheOutsideWorkspace(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "cds-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	workspace := filepath.Join(tmp, "workspace")
+	outside := filepath.Join(tmp, "outside")
+	assert.NoError(t, os.MkdirAll(workspace, os.FileMode(0755)))
+	assert.NoError(t, os.MkdirAll(outside, os.FileMode(0755)))
+
+	archive := func(hdrs ...*tar.Header) *bytes.Buffer {
+		var b bytes.Buffer
+		gz := gzip.NewWriter(&b)
+		tw := tar.NewWriter(gz)
+		for _, hdr := range hdrs {
+			assert.NoError(t, tw.WriteHeader(hdr))
+			if hdr.Typeflag == tar.TypeReg {
+				_, err := tw.Write([]byte("pwned"))
+				assert.NoError(t, err)
+			}
+		}
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gz.Close())
+		return &b
+	}
+	file := func(name string) *tar.Header {
+		return &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 5}
+	}
+	symlink := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}
+	}
+
+	// a symlink leading outside of the workspace, then a file under it
+	_, err = untarCache(archive(symlink("link", outside), file("link/file")), workspace)
+	assert.Error(t, err)
+	_, err = untarCache(archive(symlink("link", "../outside"), file("link/file")), workspace)
+	assert.Error(t, err)
+	_, err = untarCache(archive(file("../outside/file")), workspace)
+	assert.Error(t, err)
+
+	// a symlink already in the workspace is never written through
+	assert.NoError(t, os.Symlink(outside, filepath.Join(workspace, "existing")))
+	_, err = untarCache(archive(file("existing/file")), workspace)
+	assert.Error(t, err)
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "file"), filepath.Join(workspace, "file")))
+	n, err := untarCache(archive(file("file")), workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = os.Stat(filepath.Join(outside, "file"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the symlinks inside the workspace are restored
+	n, err = untarCache(archive(symlink("lib", "node_modules"), file("node_modules/index.js"), file("lib/main.js")), workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = os.Stat(filepath.Join(workspace, "node_modules", "main.js"))
+	assert.NoError(t, err)
+}
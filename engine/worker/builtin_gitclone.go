@@ -108,6 +108,20 @@ func runGitClone(w *currentWorker) BuiltInAction {
 			clone.Depth = 1
 		}
 
+		//A pull request is built on its merge commit: the commit of its head to build is merged in its target branch
+		if prRef := sdk.ParameterFind(*params, "cds.pr.ref"); prRef != nil && prRef.Value != "" {
+			if target := sdk.ParameterFind(*params, "cds.pr.target.branch"); target != nil && target.Value != "" {
+				clone.Branch = target.Value
+			}
+			clone.Depth = 0
+			clone.MergeRef = prRef.Value
+			merged := prRef.Value
+			if clone.CheckoutCommit != "" {
+				merged = clone.CheckoutCommit
+			}
+			sendLog(fmt.Sprintf("Merging %s in branch %s", merged, clone.Branch))
+		}
+
 		var dir string
 		if directory != nil {
 			dir = directory.Value
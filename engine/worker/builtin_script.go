@@ -120,7 +120,9 @@ func runScriptAction(w *currentWorker) BuiltInAction {
 			}
 
 			log.Info("runScriptAction> %s %s", shell, strings.Trim(fmt.Sprint(opts), "[]"))
-			cmd := exec.CommandContext(ctx, shell, opts...)
+			cmd := exec.Command(shell, opts...)
+			// The script and all its children are killed when the step is canceled or timed out
+			setProcessGroup(cmd)
 			res.Status = sdk.StatusUnknown.String()
 
 			env := os.Environ()
@@ -239,8 +241,21 @@ func runScriptAction(w *currentWorker) BuiltInAction {
 				sendLog(res.Reason)
 				res.Status = sdk.StatusFail.String()
 				chanRes <- res
+				return
 			}
 
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				select {
+				case <-ctx.Done():
+					if err := killProcessGroup(cmd); err != nil {
+						log.Warning("runScriptAction> cannot kill script %s: %s", scriptPath, err)
+					}
+				case <-done:
+				}
+			}()
+
 			<-outchan
 			<-errchan
 			if err := cmd.Wait(); err != nil {
@@ -259,6 +274,13 @@ func runScriptAction(w *currentWorker) BuiltInAction {
 		for {
 			select {
 			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					sendLog("Script killed: timeout exceeded")
+					return sdk.Result{
+						Status: sdk.StatusTimeout.String(),
+						Reason: "Timeout exceeded",
+					}
+				}
 				log.Error("CDS Worker execution canceled: %v", ctx.Err())
 				sendLog("CDS Worker execution canceled")
 				return sdk.Result{
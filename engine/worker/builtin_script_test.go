@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_runScriptActionTimeout(t *testing.T) {
+	basedir, err := ioutil.TempDir("", "cds-worker")
+	assert.NoError(t, err)
+	defer os.RemoveAll(basedir)
+
+	w := &currentWorker{basedir: basedir}
+	pidFile := filepath.Join(basedir, "pid")
+	a := &sdk.Action{
+		Parameters: []sdk.Parameter{
+			{Name: "script", Value: "sleep 60 &\necho $! > " + pidFile + "\nwait"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t0 := time.Now()
+	res := runScriptAction(w)(ctx, a, 1, &[]sdk.Parameter{}, func(string) {})
+	assert.Equal(t, sdk.StatusTimeout.String(), res.Status)
+	assert.True(t, time.Since(t0) < 30*time.Second)
+
+	// The process started by the script has been killed with it
+	btes, err := ioutil.ReadFile(pidFile)
+	assert.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(btes)))
+	assert.NoError(t, err)
+
+	var killed bool
+	for i := 0; i < 50 && !killed; i++ {
+		killed = syscall.Kill(pid, 0) == syscall.ESRCH
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.True(t, killed)
+}
@@ -246,6 +246,11 @@ func mainCommandRun(w *currentWorker) func(cmd *cobra.Command, args []string) {
 					continue
 				}
 
+				if j.WaitingForSlot {
+					log.Debug("Job %d is waiting for a slot of its project, let's continue", j.ID)
+					continue
+				}
+
 				requirementsOK, _ := checkRequirements(w, &j.Job.Action, nil)
 				t := ""
 				if j.ID == w.bookedJobID {
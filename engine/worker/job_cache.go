@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+// jobCacheIgnoredParameters are the prefixes of the parameters which change on every run without changing the result of a job.
+// They are also ignored in the parameters of the parent nodes, ie. workflow.build.git.hash or workflow.build.run.number
+var jobCacheIgnoredParameters = []string{
+	"cds.workspace",
+	"cds.worker",
+	"cds.version",
+	"cds.run",
+	"cds.buildNumber",
+	"cds.semver",
+	"cds.release.version",
+	"cds.triggered_by.",
+	"cds.parent.",
+	"cds.pr.",
+	"git.hash",
+	"git.message",
+	"git.author",
+}
+
+func isJobCacheIgnoredParameter(name string) bool {
+	names := []string{name}
+	if strings.HasPrefix(name, "workflow.") {
+		if t := strings.SplitN(name, ".", 3); len(t) == 3 {
+			names = append(names, t[2], "cds."+t[2])
+		}
+	}
+	for _, n := range names {
+		for _, prefix := range jobCacheIgnoredParameters {
+			if strings.HasPrefix(n, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWorkspaceStep returns true if the step prepares the workspace of the job. These steps always run, the cache is
+// looked up once they are done so that the input paths can be hashed.
+func isWorkspaceStep(a sdk.Action) bool {
+	return a.Type == sdk.BuiltinAction && (a.Name == sdk.GitCloneAction || a.Name == sdk.ArtifactDownload)
+}
+
+// jobCacheKey computes the cache key of a job from the steps to run, the parameters of the job and the content of its input paths.
+// The input paths are required by the API, but a job stored without them keeps the git hash in its key so that
+// a new commit is always built.
+func jobCacheKey(steps []sdk.Action, params []sdk.Parameter, workspace string, paths []string) (string, error) {
+	h := sha256.New()
+
+	btes, err := json.Marshal(steps)
+	if err != nil {
+		return "", err
+	}
+	h.Write(btes)
+
+	ps := make([]sdk.Parameter, 0, len(params))
+	for _, p := range params {
+		if !isJobCacheIgnoredParameter(p.Name) {
+			ps = append(ps, p)
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	for _, p := range ps {
+		fmt.Fprintf(h, "\x00%s=%s", p.Name, p.Value)
+	}
+
+	if len(paths) == 0 {
+		fmt.Fprintf(h, "\x00git.hash=%s", sdk.ParameterValue(params, "git.hash"))
+	}
+
+	for _, pattern := range paths {
+		fmt.Fprintf(h, "\x00%s", pattern)
+		matches, err := filepath.Glob(filepath.Join(workspace, pattern))
+		if err != nil {
+			return "", fmt.Errorf("invalid cache path %s: %s", pattern, err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if err := hashPath(h, workspace, m); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashPath writes the relative path and the content of all the files under root in h
+func hashPath(h io.Writer, workspace, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "\x00%s -> %s", rel, target)
+		case info.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			fh := sha256.New()
+			if _, err := io.Copy(fh, f); err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "\x00%s %o %x", rel, info.Mode().Perm(), fh.Sum(nil))
+		}
+		return nil
+	})
+}
+
+// restoreJobCache looks up the result cache of a job before its first step which does not prepare the workspace.
+// On a cache hit, the API has restored the artifacts of the cached job on the node run, the variables are exported
+// again and the result of the job is returned. It returns nil if the steps have to run.
+func (w *currentWorker) restoreJobCache(buildID int64, steps []sdk.Action, params *[]sdk.Parameter) *sdk.Result {
+	job := w.currentJob.wJob
+	if job == nil || !job.Job.IsCached() || w.currentJob.cacheChecked || isWorkspaceStep(steps[0]) {
+		return nil
+	}
+	w.currentJob.cacheChecked = true
+	sendLog := getLogger(w, buildID, w.currentJob.currentStep)
+
+	key, err := jobCacheKey(steps, *params, sdk.ParameterValue(*params, "cds.workspace"), job.Job.Cache.Paths)
+	if err != nil {
+		sendLog(fmt.Sprintf("Unable to compute the cache key of the job: %s", err))
+		return nil
+	}
+	w.currentJob.cacheKey = key
+
+	entry, err := w.client.QueueJobCacheRestore(buildID, key)
+	if err != nil {
+		sendLog(fmt.Sprintf("Unable to restore the job from the cache: %s", err))
+		return nil
+	}
+	if entry == nil {
+		sendLog(fmt.Sprintf("No cached result for key %s", key))
+		return nil
+	}
+
+	sendLog(fmt.Sprintf("Restoring the result of workflow %s #%d from cache (key %s)", entry.WorkflowName, entry.RunNumber, key))
+	for _, a := range entry.Artifacts {
+		sendLog(fmt.Sprintf("Artifact %s restored", a.Name))
+	}
+	for _, v := range entry.Variables {
+		if _, err := w.addVariableInPipelineBuild(v, params); err != nil {
+			return &sdk.Result{
+				Status:  sdk.StatusFail.String(),
+				BuildID: buildID,
+				Reason:  fmt.Sprintf("Unable to restore variable %s from cache: %s", v.Name, err),
+			}
+		}
+		sendLog(fmt.Sprintf("Variable %s restored", v.Name))
+	}
+
+	return &sdk.Result{
+		Status:  sdk.StatusSkipped.String(),
+		BuildID: buildID,
+		Reason:  "cached",
+	}
+}
+
+// recordJobCache records the artifacts and the variables of a successful job in the cache
+func (w *currentWorker) recordJobCache(buildID int64) {
+	if w.currentJob.cacheKey == "" {
+		return
+	}
+	entry := sdk.JobCacheEntry{
+		Artifacts: w.currentJob.artifacts,
+		Variables: w.currentJob.buildVariables,
+	}
+	if err := w.client.QueueJobCacheRecord(buildID, w.currentJob.cacheKey, entry); err != nil {
+		log.Warning("recordJobCache> Unable to record job %d in cache: %s", buildID, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk"
+)
+
+func Test_isJobCacheIgnoredParameter(t *testing.T) {
+	assert.True(t, isJobCacheIgnoredParameter("cds.run.number"))
+	assert.True(t, isJobCacheIgnoredParameter("git.hash"))
+	assert.True(t, isJobCacheIgnoredParameter("workflow.build.git.hash"))
+	assert.True(t, isJobCacheIgnoredParameter("workflow.build.run.number"))
+	assert.False(t, isJobCacheIgnoredParameter("git.branch"))
+	assert.False(t, isJobCacheIgnoredParameter("cds.application"))
+	assert.False(t, isJobCacheIgnoredParameter("workflow.build.git.branch"))
+}
+
+func Test_jobCacheKey(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "cds-job-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(workspace, "component", "pkg"), os.FileMode(0755)))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "component", "pkg", "main.go"), []byte("package main"), os.FileMode(0644)))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "go.sum"), []byte("sum"), os.FileMode(0644)))
+
+	steps := []sdk.Action{sdk.NewStepScript("make -C component")}
+	paths := []string{"component", "*.sum"}
+	params := []sdk.Parameter{
+		{Name: "git.branch", Value: "master"},
+		{Name: "git.hash", Value: "a1b2c3"},
+		{Name: "cds.run.number", Value: "1"},
+	}
+
+	key, err := jobCacheKey(steps, params, workspace, paths)
+	assert.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	// Parameters which change on each run are ignored
+	otherRun := []sdk.Parameter{
+		{Name: "cds.run.number", Value: "2"},
+		{Name: "git.hash", Value: "d4e5f6"},
+		{Name: "git.branch", Value: "master"},
+	}
+	k, err := jobCacheKey(steps, otherRun, workspace, paths)
+	assert.NoError(t, err)
+	assert.Equal(t, key, k)
+
+	otherBranch := []sdk.Parameter{{Name: "git.branch", Value: "develop"}}
+	k, err = jobCacheKey(steps, otherBranch, workspace, paths)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, k)
+
+	k, err = jobCacheKey([]sdk.Action{sdk.NewStepScript("make -C component test")}, params, workspace, paths)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, k)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(workspace, "component", "pkg", "main.go"), []byte("package main\n"), os.FileMode(0644)))
+	k, err = jobCacheKey(steps, params, workspace, paths)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, k)
+
+	// Without input paths, each commit has its own key
+	key, err = jobCacheKey(steps, params, workspace, nil)
+	assert.NoError(t, err)
+	k, err = jobCacheKey(steps, otherRun, workspace, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, k)
+}
@@ -40,6 +40,9 @@ type currentWorker struct {
 		pkey           string
 		gitsshPath     string
 		params         []sdk.Parameter
+		cacheKey       string
+		cacheChecked   bool
+		artifacts      []sdk.WorkflowNodeRunArtifact
 	}
 	status struct {
 		Name   string `json:"name"`
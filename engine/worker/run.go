@@ -167,7 +167,7 @@ func (w *currentWorker) runJob(ctx context.Context, a *sdk.Action, buildID int64
 func (w *currentWorker) runSteps(ctx context.Context, steps []sdk.Action, a *sdk.Action, buildID int64, params *[]sdk.Parameter, stepOrder int, stepName string, stepBaseCount int) (sdk.Result, int) {
 	log.Debug("runSteps> start run %d stepOrder:%d len(steps):%d", buildID, stepOrder, len(steps))
 	defer log.Debug("runSteps> end run %d stepOrder:%d len(steps):%d", buildID, stepOrder, len(steps))
-	var criticalStepFailed bool
+	var criticalStepFailed, criticalStepTimeout bool
 	var nbDisabledChildren int
 
 	// Nothing to do, success !
@@ -201,6 +201,18 @@ func (w *currentWorker) runSteps(ctx context.Context, steps []sdk.Action, a *sdk
 			continue
 		}
 
+		// The result cache of the job is looked up at the top level, once the workspace is ready
+		if stepOrder == -1 && !criticalStepFailed {
+			if res := w.restoreJobCache(buildID, steps[i:], params); res != nil {
+				for j := i; j < len(steps); j++ {
+					if err := w.updateStepStatus(buildID, stepBaseCount+j, res.Status); err != nil {
+						log.Warning("Cannot update step (%d) status (%s) for build %d: %s", stepBaseCount+j, res.Status, buildID, err)
+					}
+				}
+				return *res, nbDisabledChildren
+			}
+		}
+
 		if !criticalStepFailed || child.AlwaysExecuted {
 			log.Debug("Running %s", childName)
 			// Update step status
@@ -209,9 +221,11 @@ func (w *currentWorker) runSteps(ctx context.Context, steps []sdk.Action, a *sdk
 			}
 			w.sendLog(buildID, fmt.Sprintf("Starting step %s\n", childName), w.currentJob.currentStep, false)
 
-			r = w.startAction(ctx, &child, buildID, params, w.currentJob.currentStep, childName)
-			if r.Status != sdk.StatusSuccess.String() && !child.Optional {
+			r = w.runStep(ctx, &child, buildID, params, w.currentJob.currentStep, childName)
+			// The job gets the status of the first critical step which failed
+			if r.Status != sdk.StatusSuccess.String() && !child.Optional && !criticalStepFailed {
 				criticalStepFailed = true
+				criticalStepTimeout = r.Status == sdk.StatusTimeout.String()
 			}
 
 			w.sendLog(buildID, fmt.Sprintf("End of step %s [%s]", childName, r.Status), w.currentJob.currentStep, true)
@@ -228,7 +242,9 @@ func (w *currentWorker) runSteps(ctx context.Context, steps []sdk.Action, a *sdk
 		}
 	}
 
-	if criticalStepFailed {
+	if criticalStepTimeout {
+		r.Status = sdk.StatusTimeout.String()
+	} else if criticalStepFailed {
 		r.Status = sdk.StatusFail.String()
 	} else {
 		r.Status = sdk.StatusSuccess.String()
@@ -237,6 +253,28 @@ func (w *currentWorker) runSteps(ctx context.Context, steps []sdk.Action, a *sdk
 	return r, nbDisabledChildren
 }
 
+// runStep runs a step within its timeout, a step without timeout stops with its job
+func (w *currentWorker) runStep(ctx context.Context, a *sdk.Action, buildID int64, params *[]sdk.Parameter, stepOrder int, stepName string) sdk.Result {
+	stepCtx := ctx
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, time.Duration(a.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	r := w.startAction(stepCtx, a, buildID, params, stepOrder, stepName)
+	if r.Status != sdk.StatusSuccess.String() && stepCtx.Err() == context.DeadlineExceeded {
+		r.Status = sdk.StatusTimeout.String()
+		if ctx.Err() == context.DeadlineExceeded {
+			r.Reason = "Job timed out"
+		} else {
+			r.Reason = fmt.Sprintf("Step timed out after %s", time.Duration(a.Timeout)*time.Second)
+		}
+		w.sendLog(buildID, r.Reason+"\n", stepOrder, false)
+	}
+	return r
+}
+
 func (w *currentWorker) updateStepStatus(pbJobID int64, stepOrder int, status string) error {
 	step := sdk.StepStatus{
 		StepOrder: stepOrder,
@@ -304,11 +342,21 @@ func workingDirectory(basedir, jobPath string) string {
 	return path.Join(basedir, jobPath, gen)
 }
 
+// defaultJobTimeout is the timeout of the jobs which do not set one
+const defaultJobTimeout = 6 * time.Hour
+
+func jobTimeout(j sdk.Job) time.Duration {
+	if j.Timeout > 0 {
+		return time.Duration(j.Timeout) * time.Second
+	}
+	return defaultJobTimeout
+}
+
 func (w *currentWorker) processJob(ctx context.Context, jobInfo *worker.WorkflowNodeJobRunInfo) sdk.Result {
 	t0 := time.Now()
 	defer func() { log.Info("processJob> Process Job Done (%s)", sdk.Round(time.Since(t0), time.Second).String()) }()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout(jobInfo.NodeJobRun.Job.Job))
 	defer cancel()
 
 	defer w.drainLogsAndCloseLogger(ctx)
@@ -406,6 +454,10 @@ func (w *currentWorker) processJob(ctx context.Context, jobInfo *worker.Workflow
 	res := w.startAction(ctx, &jobInfo.NodeJobRun.Job.Action, jobInfo.NodeJobRun.ID, &jobInfo.NodeJobRun.Parameters, -1, "")
 	logsecrets = nil
 
+	if res.Status == sdk.StatusSuccess.String() {
+		w.recordJobCache(jobInfo.NodeJobRun.ID)
+	}
+
 	log.Debug("processJob> call teardownBuildDirectory wd:%s", wd)
 	if err := teardownBuildDirectory(wd); err != nil {
 		log.Error("Cannot remove build directory: %s", err)
@@ -422,7 +474,7 @@ func (w *currentWorker) run(ctx context.Context, pbji *worker.PipelineBuildJobIn
 		log.Info("run> Run Pipeline Build Job Done (%s)", sdk.Round(time.Since(t0), time.Second).String())
 	}()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout(pbji.PipelineBuildJob.Job.Job))
 	defer cancel()
 
 	defer w.drainLogsAndCloseLogger(ctx)
@@ -26,6 +26,9 @@ func (w *currentWorker) takeWorkflowJob(ctx context.Context, job sdk.WorkflowNod
 	w.currentJob.gitsshPath = ""
 	w.currentJob.pkey = ""
 	w.currentJob.buildVariables = nil
+	w.currentJob.cacheKey = ""
+	w.currentJob.cacheChecked = false
+	w.currentJob.artifacts = nil
 
 	start := time.Now()
 
@@ -19,6 +19,7 @@ type Action struct {
 	Enabled        bool          `json:"enabled" yaml:"-"`
 	Optional       bool          `json:"optional" yaml:"-"`
 	AlwaysExecuted bool          `json:"always_executed" yaml:"-"`
+	Timeout        int64         `json:"timeout,omitempty" yaml:"-"` // Timeout of the step in seconds, 0 means the timeout of the job
 	LastModified   int64         `json:"last_modified"`
 }
 
@@ -71,6 +72,8 @@ const (
 	GitCloneAction = "GitClone"
 	GitTagAction   = "GitTag"
 	ReleaseAction  = "Release"
+	CacheSave      = "CacheSave"
+	CacheRestore   = "CacheRestore"
 )
 
 const (
@@ -28,6 +28,8 @@ type ActionScript struct {
 		JUnitReport      string                       `json:"jUnitReport,omitempty"`
 		Plugin           map[string]map[string]string `json:"plugin,omitempty"`
 		Release          map[string]string            `json:"release,omitempty"`
+		CacheSave        map[string]string            `json:"cacheSave,omitempty"`
+		CacheRestore     map[string]string            `json:"cacheRestore,omitempty"`
 	} `json:"steps"`
 }
 
@@ -113,6 +115,26 @@ func NewStepArtifactDownload(v map[string]string) Action {
 	return newAction
 }
 
+// NewStepCacheSave returns an action (basically used as a step of a job) of cache save type
+func NewStepCacheSave(v map[string]string) Action {
+	newAction := Action{
+		Name:       CacheSave,
+		Type:       BuiltinAction,
+		Parameters: ParametersFromMap(v),
+	}
+	return newAction
+}
+
+// NewStepCacheRestore returns an action (basically used as a step of a job) of cache restore type
+func NewStepCacheRestore(v map[string]string) Action {
+	newAction := Action{
+		Name:       CacheRestore,
+		Type:       BuiltinAction,
+		Parameters: ParametersFromMap(v),
+	}
+	return newAction
+}
+
 // NewStepPlugin returns an action (basically used as a step of a job) of plugin type
 func NewStepPlugin(v map[string]map[string]string) (*Action, error) {
 	if len(v) != 1 {
@@ -224,6 +246,18 @@ func NewActionFromScript(btes []byte) (*Action, error) {
 			newAction = NewStepRelease(v.Release)
 		}
 
+		//Action builtin = CacheSave
+		if v.CacheSave != nil {
+			newAction = NewStepCacheSave(v.CacheSave)
+			goto next
+		}
+
+		//Action builtin = CacheRestore
+		if v.CacheRestore != nil {
+			newAction = NewStepCacheRestore(v.CacheRestore)
+			goto next
+		}
+
 		//Action builtin = Plugin
 		if v.Plugin != nil {
 			a, err := NewStepPlugin(v.Plugin)
@@ -36,6 +36,12 @@ type Artifact struct {
 	ObjectPath   string `json:"object_path,omitempty"`
 }
 
+//ArtifactsStore describes the objectstore used for the artifacts
+type ArtifactsStore struct {
+	TemporaryURLSupported bool  `json:"temporary_url_supported"`
+	TemporaryURLMaxSize   int64 `json:"temporary_url_max_size,omitempty"`
+}
+
 //GetName returns the name the artifact
 func (a *Artifact) GetName() string {
 	return a.Name
@@ -0,0 +1,48 @@
+package sdk
+
+import "strings"
+
+//ArtifactRetention defines the artifacts kept for a project, or for a workflow if WorkflowID is set. The rule of
+//a workflow overrides the rule of its project.
+//The artifacts of a run are purged unless the run is one of the KeepLastRuns last runs, is younger than KeepDays days,
+//or has one of the KeepTags. A KeepTags entry is either a tag name, or a tag name and a value as "name=value"; for the
+//pipeline artifacts, it is compared to the tag of the artifact. A rule without KeepLastRuns and KeepDays purges nothing.
+type ArtifactRetention struct {
+	ID           int64    `json:"id"`
+	ProjectID    int64    `json:"project_id"`
+	WorkflowID   int64    `json:"workflow_id,omitempty"`
+	WorkflowName string   `json:"workflow_name,omitempty"`
+	KeepLastRuns int      `json:"keep_last_runs"`
+	KeepDays     int      `json:"keep_days"`
+	KeepTags     []string `json:"keep_tags,omitempty"`
+}
+
+//IsActive returns true if the rule purges something
+func (r *ArtifactRetention) IsActive() bool {
+	return r.KeepLastRuns > 0 || r.KeepDays > 0
+}
+
+//KeepTagged returns true if one of the tags matches the KeepTags of the rule
+func (r *ArtifactRetention) KeepTagged(tags []WorkflowRunTag) bool {
+	for _, k := range r.KeepTags {
+		name, value := k, ""
+		hasValue := false
+		if i := strings.Index(k, "="); i >= 0 {
+			name, value, hasValue = k[:i], k[i+1:], true
+		}
+		for _, t := range tags {
+			if t.Tag == name && (!hasValue || t.Value == value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//ArtifactPurgeReport lists the artifacts purged by the retention rules, or to be purged on a dry run
+type ArtifactPurgeReport struct {
+	DryRun            bool                      `json:"dry_run"`
+	WorkflowArtifacts []WorkflowNodeRunArtifact `json:"workflow_artifacts"`
+	Artifacts         []Artifact                `json:"artifacts"`
+	Size              int64                     `json:"size"`
+}
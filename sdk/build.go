@@ -82,6 +82,12 @@ func StatusFromString(in string) Status {
 		return StatusDisabled
 	case StatusSkipped.String():
 		return StatusSkipped
+	case StatusStopped.String():
+		return StatusStopped
+	case StatusTimeout.String():
+		return StatusTimeout
+	case StatusWaitingApproval.String():
+		return StatusWaitingApproval
 	default:
 		return StatusUnknown
 	}
@@ -102,8 +108,25 @@ const (
 	StatusNeverBuilt Status = "Never Built"
 	StatusUnknown    Status = "Unknown"
 	StatusSkipped    Status = "Skipped"
+	StatusStopped    Status = "Stopped"
+	StatusTimeout    Status = "Timeout"
+
+	StatusWaitingApproval Status = "Waiting Approval"
 )
 
+// StatusRunning are the statuses of a node run which is not done yet
+var StatusRunning = []Status{StatusWaiting, StatusChecking, StatusBuilding, StatusWaitingApproval}
+
+// IsRunning returns true if the status is one of StatusRunning
+func (t Status) IsRunning() bool {
+	for _, s := range StatusRunning {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Translate translates messages in pipelineBuildJob
 func (p *PipelineBuildJob) Translate(lang string) {
 	for ki, info := range p.SpawnInfos {
@@ -0,0 +1,16 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusIsRunning(t *testing.T) {
+	for _, s := range []Status{StatusWaiting, StatusChecking, StatusBuilding, StatusWaitingApproval} {
+		assert.True(t, s.IsRunning(), "%s is running", s)
+	}
+	for _, s := range []Status{StatusSuccess, StatusFail, StatusStopped, StatusSkipped, StatusDisabled, StatusNeverBuilt, StatusTimeout} {
+		assert.False(t, s.IsRunning(), "%s is done", s)
+	}
+}
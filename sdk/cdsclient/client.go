@@ -15,7 +15,11 @@ type client struct {
 	isWorker   bool
 	isHatchery bool
 	HTTPClient HTTPClient
-	config     Config
+	//HTTPNoTimeoutClient has no timeout, it is used to follow the Server-Sent Events streams
+	//and to transfer the artifacts with the temporary urls
+	HTTPNoTimeoutClient HTTPClient
+	config              Config
+	artifactsStore      *sdk.ArtifactsStore
 }
 
 // New returns a client from a config struct
@@ -25,6 +29,7 @@ func New(c Config) Interface {
 	cli.HTTPClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
+	cli.HTTPNoTimeoutClient = &http.Client{}
 	cli.init()
 	return cli
 }
@@ -40,6 +45,7 @@ func NewWorker(endpoint string) Interface {
 	cli.HTTPClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
+	cli.HTTPNoTimeoutClient = &http.Client{}
 	cli.isWorker = true
 	cli.init()
 	return cli
@@ -61,6 +67,11 @@ func NewHatchery(endpoint string, token string, requestSecondsTimeout int, insec
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerifyTLS},
 		},
 	}
+	cli.HTTPNoTimeoutClient = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerifyTLS},
+		},
+	}
 	cli.isHatchery = true
 	cli.init()
 	return cli
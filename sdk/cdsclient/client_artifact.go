@@ -0,0 +1,151 @@
+package cdsclient
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+)
+
+//temporaryURLSupported returns true if the artifacts can be uploaded and downloaded directly on the objectstore.
+//The answer of the API is kept for the life of the client.
+func (c *client) temporaryURLSupported() bool {
+	if c.artifactsStore == nil {
+		store := sdk.ArtifactsStore{}
+		if _, err := c.GetJSON("/artifact/store", &store); err != nil {
+			return false
+		}
+		c.artifactsStore = &store
+	}
+	return c.artifactsStore.TemporaryURLSupported
+}
+
+//temporaryURLUploadSupported returns true if a file of this size can be uploaded directly on the objectstore
+func (c *client) temporaryURLUploadSupported(size int64) bool {
+	if !c.temporaryURLSupported() {
+		return false
+	}
+	return c.artifactsStore.TemporaryURLMaxSize == 0 || size <= c.artifactsStore.TemporaryURLMaxSize
+}
+
+func (c *client) queueArtifactUploadWithTempURL(id int64, tag, filePath string) error {
+	stat, md5sum, err := fileMD5sum(filePath)
+	if err != nil {
+		return err
+	}
+	_, name := filepath.Split(filePath)
+
+	art := sdk.WorkflowNodeRunArtifact{
+		Name:   name,
+		Size:   stat.Size(),
+		Perm:   uint32(stat.Mode().Perm()),
+		MD5sum: md5sum,
+	}
+	uri := fmt.Sprintf("/queue/workflows/%d/artifact/%s/url", id, tag)
+	if _, err := c.PostJSON(uri, art, &art); err != nil {
+		return err
+	}
+
+	for i := 0; i <= c.config.Retry; i++ {
+		if err = c.uploadWithTempURL(art.TempURL, filePath, stat.Size()); err == nil {
+			break
+		}
+		time.Sleep(1 * time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("x%d: %v", c.config.Retry, err)
+	}
+
+	if _, err := c.PostJSON(uri+"/callback", art, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+//uploadWithTempURL sends the file on the objectstore, without the CDS credentials
+func (c *client) uploadWithTempURL(tempURL, filePath string, size int64) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest("PUT", tempURL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	resp, err := c.HTTPNoTimeoutClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Cannot upload artifact. Http code error : %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+//workflowNodeRunArtifactDownloadWithTempURL downloads the artifact directly from the objectstore and checks its md5sum
+func (c *client) workflowNodeRunArtifactDownloadWithTempURL(projectKey string, name string, artifactID int64, w io.Writer) error {
+	art := sdk.WorkflowNodeRunArtifact{}
+	url := fmt.Sprintf("/project/%s/workflows/%s/artifact/%d/url", projectKey, name, artifactID)
+	if _, err := c.GetJSON(url, &art); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", art.TempURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPNoTimeoutClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Cannot download artifact. Http code error : %d: %s", resp.StatusCode, body)
+	}
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return err
+	}
+	if md5sum := hex.EncodeToString(hash.Sum(nil)); art.MD5sum != "" && md5sum != art.MD5sum {
+		return fmt.Errorf("Invalid md5sum for artifact %s: %s, expected %s", art.Name, md5sum, art.MD5sum)
+	}
+	return nil
+}
+
+//fileMD5sum returns the stat and the md5sum of a file
+func fileMD5sum(filePath string) (os.FileInfo, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, "", err
+	}
+	return stat, hex.EncodeToString(hash.Sum(nil)), nil
+}
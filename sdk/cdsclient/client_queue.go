@@ -3,10 +3,9 @@ package cdsclient
 import (
 	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -65,7 +64,13 @@ func (c *client) QueuePolling(ctx context.Context, jobs chan<- sdk.WorkflowNodeJ
 				if _, err := c.GetJSON("/queue/workflows", &queue, SetHeader("If-Modified-Since", t0.Format(time.RFC1123))); err != nil {
 					errs <- sdk.WrapError(err, "Unable to load jobs")
 				}
+				// The jobs waiting for a slot of their project are polled again until they get one
 				t0 = time.Now()
+				for _, j := range queue {
+					if j.WaitingForSlot && j.Queued.Before(t0) {
+						t0 = j.Queued.Local()
+					}
+				}
 				for _, j := range queue {
 					jobs <- j
 				}
@@ -174,24 +179,120 @@ func (c *client) QueueSendResult(id int64, res sdk.Result) error {
 	return nil
 }
 
+// QueueJobCacheRecord records the result of a successful job in the cache
+func (c *client) QueueJobCacheRecord(id int64, key string, entry sdk.JobCacheEntry) error {
+	path := fmt.Sprintf("/queue/workflows/%d/cache/%s", id, key)
+	if code, err := c.PostJSON(path, entry, nil); err != nil {
+		return err
+	} else if code != http.StatusOK {
+		return fmt.Errorf("HTTP Error: %d", code)
+	}
+	return nil
+}
+
+// QueueJobCacheRestore restores the result of a job from the cache, it returns nil if the key is not cached
+func (c *client) QueueJobCacheRestore(id int64, key string) (*sdk.JobCacheEntry, error) {
+	path := fmt.Sprintf("/queue/workflows/%d/cache/%s/restore", id, key)
+	var entry sdk.JobCacheEntry
+	code, err := c.PostJSON(path, nil, &entry)
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("HTTP Error: %d", code)
+	}
+	return &entry, nil
+}
+
+// QueueWorkerCacheSave uploads a cache archive under key
+func (c *client) QueueWorkerCacheSave(id int64, key string, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// The archive is streamed from the file, it may be too big to be loaded in memory
+	body := func(req *http.Request) {
+		f.Seek(0, io.SeekStart)
+		req.Body = ioutil.NopCloser(f)
+		req.ContentLength = stat.Size()
+		if stat.Size() == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	path := fmt.Sprintf("/queue/workflows/%d/workercache/%s", id, key)
+	reader, code, err := c.stream(c.HTTPNoTimeoutClient, "POST", path, nil, body, SetHeader("Content-Type", "application/octet-stream"))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	btes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if err := sdk.DecodeError(btes); err != nil {
+		return err
+	}
+	if code != http.StatusOK {
+		return fmt.Errorf("HTTP Error: %d", code)
+	}
+	return nil
+}
+
+// QueueWorkerCacheRestore downloads in w the cache saved under key, or else the latest cache matching one of the prefixes.
+// It returns nil if there is no such cache
+func (c *client) QueueWorkerCacheRestore(id int64, key string, prefixes []string, w io.Writer) (*sdk.WorkerCache, error) {
+	path := fmt.Sprintf("/queue/workflows/%d/workercache/%s/restore", id, key)
+	var cache sdk.WorkerCache
+	code, err := c.PostJSON(path, prefixes, &cache)
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("HTTP Error: %d", code)
+	}
+
+	path = fmt.Sprintf("/queue/workflows/%d/workercache/%s/download", id, cache.Key)
+	reader, code, err := c.Stream("POST", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("HTTP Error: %d", code)
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
+
 func (c *client) QueueArtifactUpload(id int64, tag, filePath string) error {
-	fileForMD5, errop := os.Open(filePath)
-	if errop != nil {
-		return errop
-	}
-	//File stat
-	stat, errst := fileForMD5.Stat()
-	if errst != nil {
-		return errst
-	}
-	//Compute md5sum
-	hash := md5.New()
-	if _, errcopy := io.Copy(hash, fileForMD5); errcopy != nil {
-		return errcopy
-	}
-	hashInBytes := hash.Sum(nil)[:16]
-	md5sumStr := hex.EncodeToString(hashInBytes)
-	fileForMD5.Close()
+	stat, errS := os.Stat(filePath)
+	if errS != nil {
+		return errS
+	}
+	//The files too large for the temporary urls are uploaded through the API
+	if c.temporaryURLUploadSupported(stat.Size()) {
+		return c.queueArtifactUploadWithTempURL(id, tag, filePath)
+	}
+
+	stat, md5sumStr, errs := fileMD5sum(filePath)
+	if errs != nil {
+		return errs
+	}
 	//Reopen the file because we already read it for md5
 	fileReopen, erro := os.Open(filePath)
 	if erro != nil {
@@ -1,7 +1,13 @@
 package cdsclient
 
 import (
+	"bufio"
+	"encoding/json"
 	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
 
 	"fmt"
 
@@ -26,6 +32,34 @@ func (c *client) WorkflowGet(projectKey, name string) (*sdk.Workflow, error) {
 	return w, nil
 }
 
+func (c *client) WorkflowExport(projectKey, name, format string) ([]byte, error) {
+	url := fmt.Sprintf("/project/%s/export/workflows/%s?format=%s", projectKey, name, format)
+	btes, code, err := c.Request("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("Cannot export workflow. Http code error : %d", code)
+	}
+	return btes, nil
+}
+
+func (c *client) WorkflowImport(projectKey string, content []byte, format string, force bool) (*sdk.Workflow, error) {
+	url := fmt.Sprintf("/project/%s/import/workflows?format=%s&forceUpdate=%t", projectKey, format, force)
+	btes, code, err := c.Request("POST", url, content)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("Cannot import workflow. Http code error : %d", code)
+	}
+	w := sdk.Workflow{}
+	if err := json.Unmarshal(btes, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
 func (c *client) WorkflowRun(projectKey string, name string, number int64) (*sdk.WorkflowRun, error) {
 	url := fmt.Sprintf("/project/%s/workflows/%s/runs/%d", projectKey, name, number)
 	run := sdk.WorkflowRun{}
@@ -62,7 +96,86 @@ func (c *client) WorkflowNodeRunArtifacts(projectKey string, name string, number
 	return arts, nil
 }
 
+// WorkflowNodeRunJobLogs sends the logs of all the steps of a job on the logs channel, which is closed at the end of the stream.
+// With follow, the logs are streamed until the end of the job.
+func (c *client) WorkflowNodeRunJobLogs(projectKey string, name string, number int64, nodeRunID int64, runJobID int64, follow bool, logs chan<- sdk.Log) error {
+	defer close(logs)
+
+	httpClient := c.HTTPNoTimeoutClient
+	if httpClient == nil {
+		httpClient = c.HTTPClient
+	}
+
+	url := fmt.Sprintf("/project/%s/workflows/%s/runs/%d/nodes/%d/job/%d/logs?follow=%t", projectKey, name, number, nodeRunID, runJobID, follow)
+	//received keeps the length of the logs received for each step, to resume a stream which has been cut
+	received := map[int64]int{}
+	for {
+		cut, err := c.workflowNodeRunJobLogsStream(httpClient, url, received, logs)
+		if !cut || !follow {
+			return err
+		}
+		//The stream has been cut before the end of the job (server write timeout, proxy...), resume it
+		time.Sleep(time.Second)
+	}
+}
+
+//workflowNodeRunJobLogsStream reads a logs stream, skipping the logs already received. It returns true if the stream has been cut before its end event
+func (c *client) workflowNodeRunJobLogsStream(httpClient HTTPClient, url string, received map[int64]int, logs chan<- sdk.Log) (bool, error) {
+	reader, code, err := c.stream(httpClient, "GET", url, nil, SetHeader("Accept", "text/event-stream"))
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	if code >= 300 {
+		body, _ := ioutil.ReadAll(reader)
+		if err := sdk.DecodeError(body); err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("Cannot get job logs. Http code error : %d", code)
+	}
+
+	//Each stream sends the logs of a step from the beginning
+	read := map[int64]int{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "event: end" {
+			return false, nil
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var l sdk.Log
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &l); err != nil {
+			return false, err
+		}
+		start := read[l.StepOrder]
+		read[l.StepOrder] += len(l.Val)
+		if read[l.StepOrder] <= received[l.StepOrder] {
+			continue
+		}
+		if start < received[l.StepOrder] {
+			l.Val = l.Val[received[l.StepOrder]-start:]
+		}
+		received[l.StepOrder] = read[l.StepOrder]
+		logs <- l
+	}
+	if err := scanner.Err(); err != nil {
+		if _, isNetErr := err.(net.Error); isNetErr || err == io.ErrUnexpectedEOF {
+			return true, err
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (c *client) WorkflowNodeRunArtifactDownload(projectKey string, name string, artifactID int64, w io.Writer) error {
+	if c.temporaryURLSupported() {
+		return c.workflowNodeRunArtifactDownloadWithTempURL(projectKey, name, artifactID, w)
+	}
+
 	url := fmt.Sprintf("/project/%s/workflows/%s/artifact/%d", projectKey, name, artifactID)
 	reader, _, err := c.Stream("GET", url, nil)
 	if err != nil {
@@ -86,3 +199,29 @@ func (c *client) WorkflowNodeRunRelease(projectKey string, workflowName string,
 	}
 	return nil
 }
+
+func (c *client) WorkflowStop(projectKey string, workflowName string, number int64) (*sdk.WorkflowRun, error) {
+	url := fmt.Sprintf("/project/%s/workflows/%s/runs/%d/stop", projectKey, workflowName, number)
+	run := sdk.WorkflowRun{}
+	code, err := c.PostJSON(url, nil, &run)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("Cannot stop workflow run. Http code error : %d", code)
+	}
+	return &run, nil
+}
+
+func (c *client) WorkflowNodeStop(projectKey string, workflowName string, number int64, nodeRunID int64) (*sdk.WorkflowNodeRun, error) {
+	url := fmt.Sprintf("/project/%s/workflows/%s/runs/%d/nodes/%d/stop", projectKey, workflowName, number, nodeRunID)
+	nodeRun := sdk.WorkflowNodeRun{}
+	code, err := c.PostJSON(url, nil, &nodeRun)
+	if err != nil {
+		return nil, err
+	}
+	if code >= 300 {
+		return nil, fmt.Errorf("Cannot stop workflow node run. Http code error : %d", code)
+	}
+	return &nodeRun, nil
+}
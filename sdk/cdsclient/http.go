@@ -119,6 +119,10 @@ func (c *client) Request(method string, path string, args []byte, mods ...Reques
 
 // Stream makes an authenticated http request and return io.ReadCloser
 func (c *client) Stream(method string, path string, args []byte, mods ...RequestModifier) (io.ReadCloser, int, error) {
+	return c.stream(c.HTTPClient, method, path, args, mods...)
+}
+
+func (c *client) stream(httpClient HTTPClient, method string, path string, args []byte, mods ...RequestModifier) (io.ReadCloser, int, error) {
 	var savederror error
 
 	if c.config.Verbose {
@@ -159,7 +163,7 @@ func (c *client) Stream(method string, path string, args []byte, mods ...Request
 			}
 		}
 
-		resp, err := c.HTTPClient.Do(req)
+		resp, err := httpClient.Do(req)
 
 		// if everything is fine, return body
 		if err == nil && resp.StatusCode < 500 {
@@ -45,6 +45,10 @@ type Interface interface {
 	QueueJobSendSpawnInfo(isWorkflowJob bool, id int64, in []sdk.SpawnInfo) error
 	QueueSendResult(int64, sdk.Result) error
 	QueueArtifactUpload(id int64, tag, filePath string) error
+	QueueJobCacheRecord(id int64, key string, entry sdk.JobCacheEntry) error
+	QueueJobCacheRestore(id int64, key string) (*sdk.JobCacheEntry, error)
+	QueueWorkerCacheSave(id int64, key string, filePath string) error
+	QueueWorkerCacheRestore(id int64, key string, prefixes []string, w io.Writer) (*sdk.WorkerCache, error)
 	Requirements() ([]sdk.Requirement, error)
 	UserLogin(username, password string) (bool, string, error)
 	UserList() ([]sdk.User, error)
@@ -53,6 +57,7 @@ type Interface interface {
 	UserGetGroups(username string) (map[string][]sdk.Group, error)
 	UserReset(username, email string) error
 	UserConfirm(username, token string) (bool, string, error)
+	WorkerDisable(id string) error
 	WorkerList() ([]sdk.Worker, error)
 	WorkerModelSpawnError(id int64, info string) error
 	WorkerModelsEnabled() ([]sdk.Model, error)
@@ -61,10 +66,15 @@ type Interface interface {
 	WorkerSetStatus(sdk.Status) error
 	WorkflowList(projectKey string) ([]sdk.Workflow, error)
 	WorkflowGet(projectKey, name string) (*sdk.Workflow, error)
+	WorkflowExport(projectKey, name, format string) ([]byte, error)
+	WorkflowImport(projectKey string, content []byte, format string, force bool) (*sdk.Workflow, error)
 	WorkflowRun(projectKey string, name string, number int64) (*sdk.WorkflowRun, error)
 	WorkflowRunArtifacts(projectKey string, name string, number int64) ([]sdk.Artifact, error)
+	WorkflowStop(projectKey string, workflowName string, number int64) (*sdk.WorkflowRun, error)
 	WorkflowNodeRun(projectKey string, name string, number int64, nodeRunID int64) (*sdk.WorkflowNodeRun, error)
 	WorkflowNodeRunArtifacts(projectKey string, name string, number int64, nodeRunID int64) ([]sdk.Artifact, error)
+	WorkflowNodeRunJobLogs(projectKey string, name string, number int64, nodeRunID int64, runJobID int64, follow bool, logs chan<- sdk.Log) error
 	WorkflowNodeRunArtifactDownload(projectKey string, name string, artifactID int64, w io.Writer) error
 	WorkflowNodeRunRelease(projectKey string, workflowName string, runNumber int64, nodeRunID int64, release sdk.WorkflowNodeRunRelease) error
+	WorkflowNodeStop(projectKey string, workflowName string, number int64, nodeRunID int64) (*sdk.WorkflowNodeRun, error)
 }
@@ -116,6 +116,16 @@ var (
 	ErrParameterNotExists                    = &Error{ID: 100, Status: http.StatusNotFound}
 	ErrUnknownKeyType                        = &Error{ID: 101, Status: http.StatusBadRequest}
 	ErrInvalidKeyPattern                     = &Error{ID: 102, Status: http.StatusBadRequest}
+	ErrWorkflowNodeRunNotRestartable         = &Error{ID: 103, Status: http.StatusBadRequest}
+	ErrWorkflowAlreadyExists                 = &Error{ID: 104, Status: http.StatusConflict}
+	ErrWorkflowNodeRunNotWaitingApproval     = &Error{ID: 105, Status: http.StatusBadRequest}
+	ErrWorkflowNodeRunAlreadyApproved        = &Error{ID: 106, Status: http.StatusConflict}
+	ErrInvalidWorkerCacheKey                 = &Error{ID: 107, Status: http.StatusBadRequest}
+	ErrWorkerCacheTooLarge                   = &Error{ID: 108, Status: http.StatusRequestEntityTooLarge}
+	ErrJobWaitingForSlot                     = &Error{ID: 109, Status: http.StatusConflict}
+	ErrInvalidJobMatrix                      = &Error{ID: 110, Status: http.StatusBadRequest}
+	ErrInvalidJobNeeds                       = &Error{ID: 111, Status: http.StatusBadRequest}
+	ErrInvalidJobCache                       = &Error{ID: 112, Status: http.StatusBadRequest}
 )
 
 var errorsAmericanEnglish = map[int]string{
@@ -221,6 +231,16 @@ var errorsAmericanEnglish = map[int]string{
 	ErrParameterNotExists.ID:                    "This parameter doesn't exist",
 	ErrUnknownKeyType.ID:                        "Unknown key type",
 	ErrInvalidKeyPattern.ID:                     "key name must respect the following pattern: '^[a-zA-Z0-9.-_-]{1,}$'",
+	ErrWorkflowNodeRunNotRestartable.ID:         "Only a failed or stopped workflow node run can be restarted",
+	ErrWorkflowAlreadyExists.ID:                 "Workflow already exists",
+	ErrWorkflowNodeRunNotWaitingApproval.ID:     "Workflow node run is not waiting for approval",
+	ErrWorkflowNodeRunAlreadyApproved.ID:        "You have already approved or rejected this workflow node run",
+	ErrInvalidWorkerCacheKey.ID:                 "cache key must respect the following pattern: '^[a-zA-Z0-9._-]{1,256}$'",
+	ErrWorkerCacheTooLarge.ID:                   "Cache is larger than the quota of the project",
+	ErrJobWaitingForSlot.ID:                     "The project or the matrix of the job has reached its maximum number of concurrent jobs",
+	ErrInvalidJobMatrix.ID:                      "Invalid job matrix",
+	ErrInvalidJobNeeds.ID:                       "Invalid job needs: a job can only need other jobs of its stage, without cycle",
+	ErrInvalidJobCache.ID:                       "Invalid job cache: the input paths of the job must be set",
 }
 
 var errorsFrench = map[int]string{
@@ -326,6 +346,16 @@ var errorsFrench = map[int]string{
 	ErrParameterNotExists.ID:                    "Ce paramètre n'existe pas",
 	ErrUnknownKeyType.ID:                        "Le type de clé n'est pas connu",
 	ErrInvalidKeyPattern.ID:                     "le nom de la clé doit respecter le pattern suivant; '^[a-zA-Z0-9.-_-]{1,}$'",
+	ErrWorkflowNodeRunNotRestartable.ID:         "Seul un pipeline en échec ou arrêté peut être relancé",
+	ErrWorkflowAlreadyExists.ID:                 "Le workflow existe déjà",
+	ErrWorkflowNodeRunNotWaitingApproval.ID:     "Ce pipeline n'est pas en attente d'approbation",
+	ErrWorkflowNodeRunAlreadyApproved.ID:        "Vous avez déjà approuvé ou rejeté ce pipeline",
+	ErrInvalidWorkerCacheKey.ID:                 "la clé de cache doit respecter le pattern suivant : '^[a-zA-Z0-9._-]{1,256}$'",
+	ErrWorkerCacheTooLarge.ID:                   "Le cache dépasse le quota du projet",
+	ErrJobWaitingForSlot.ID:                     "Le projet ou la matrice du job a atteint son nombre maximum de jobs simultanés",
+	ErrInvalidJobMatrix.ID:                      "Matrice du job invalide",
+	ErrInvalidJobNeeds.ID:                       "Dépendances du job invalides : un job ne peut dépendre que d'autres jobs de son stage, sans cycle",
+	ErrInvalidJobCache.ID:                       "Cache du job invalide : les chemins des entrées du job doivent être renseignés",
 }
 
 var errorsLanguages = []map[int]string{
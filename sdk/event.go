@@ -65,3 +65,63 @@ type EventNotif struct {
 	Subject    string   `json:"subject,omitempty"`
 	Body       string   `json:"body,omitempty"`
 }
+
+// EventWorkflowNodeRunApproval contains event data for a workflow node run waiting for approval.
+// Username, Approved and Comment are set when a user has approved or rejected the node run.
+type EventWorkflowNodeRunApproval struct {
+	ProjectKey      string   `json:"projectKey,omitempty"`
+	WorkflowName    string   `json:"workflowName,omitempty"`
+	Number          int64    `json:"number,omitempty"`
+	SubNumber       int64    `json:"subNumber,omitempty"`
+	NodeRunID       int64    `json:"nodeRunID,omitempty"`
+	PipelineName    string   `json:"pipelineName,omitempty"`
+	ApplicationName string   `json:"applicationName,omitempty"`
+	EnvironmentName string   `json:"environmentName,omitempty"`
+	Status          Status   `json:"status,omitempty"`
+	NbApprovers     int      `json:"nbApprovers,omitempty"`
+	NbApprovals     int      `json:"nbApprovals,omitempty"`
+	Groups          []string `json:"groups,omitempty"`
+	Username        string   `json:"username,omitempty"`
+	Approved        bool     `json:"approved,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+// EventWorkflowNodeRun contains event data for a workflow node run
+type EventWorkflowNodeRun struct {
+	ProjectKey            string `json:"projectKey,omitempty"`
+	WorkflowName          string `json:"workflowName,omitempty"`
+	Number                int64  `json:"number,omitempty"`
+	SubNumber             int64  `json:"subNumber,omitempty"`
+	NodeRunID             int64  `json:"nodeRunID,omitempty"`
+	NodeName              string `json:"nodeName,omitempty"`
+	PipelineName          string `json:"pipelineName,omitempty"`
+	ApplicationName       string `json:"applicationName,omitempty"`
+	EnvironmentName       string `json:"environmentName,omitempty"`
+	Status                Status `json:"status,omitempty"`
+	Start                 int64  `json:"start,omitempty"`
+	Done                  int64  `json:"done,omitempty"`
+	BranchName            string `json:"branchName,omitempty"`
+	Hash                  string `json:"hash,omitempty"`
+	PullRequestID         int64  `json:"pullRequestID,omitempty"`
+	RepositoryManagerName string `json:"repositoryManagerName,omitempty"`
+	RepositoryFullname    string `json:"repositoryFullname,omitempty"`
+	TestsTotal            int    `json:"testsTotal,omitempty"`
+	TestsOK               int    `json:"testsOK,omitempty"`
+	TestsKO               int    `json:"testsKO,omitempty"`
+	TestsSkipped          int    `json:"testsSkipped,omitempty"`
+}
+
+// EventWorkflowRun contains event data for a workflow run which is over.
+// The repository and the pull request are the ones of the root node.
+type EventWorkflowRun struct {
+	ProjectKey            string                 `json:"projectKey,omitempty"`
+	WorkflowName          string                 `json:"workflowName,omitempty"`
+	Number                int64                  `json:"number,omitempty"`
+	Status                Status                 `json:"status,omitempty"`
+	BranchName            string                 `json:"branchName,omitempty"`
+	Hash                  string                 `json:"hash,omitempty"`
+	PullRequestID         int64                  `json:"pullRequestID,omitempty"`
+	RepositoryManagerName string                 `json:"repositoryManagerName,omitempty"`
+	RepositoryFullname    string                 `json:"repositoryFullname,omitempty"`
+	Nodes                 []EventWorkflowNodeRun `json:"nodes,omitempty"`
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 
@@ -37,6 +38,24 @@ type Job struct {
 	Enabled      *bool         `json:"enabled,omitempty" yaml:"enabled,omitempty"`
 	Steps        []Step        `json:"steps,omitempty" yaml:"steps,omitempty" hcl:"step,omitempty"`
 	Requirements []Requirement `json:"requirements,omitempty" yaml:"requirements,omitempty" hcl:"requirement,omitempty"`
+	Cache        *JobCache     `json:"cache,omitempty" yaml:"cache,omitempty" hcl:"cache,omitempty"`
+	Timeout      string        `json:"timeout,omitempty" yaml:"timeout,omitempty" hcl:"timeout,omitempty"`
+	Matrix       *JobMatrix    `json:"matrix,omitempty" yaml:"matrix,omitempty" hcl:"matrix,omitempty"`
+	Needs        []string      `json:"needs,omitempty" yaml:"needs,omitempty" hcl:"needs,omitempty"`
+}
+
+// JobMatrix represents exported matrix of a job
+type JobMatrix struct {
+	Variables   map[string][]string `json:"variables" yaml:"variables" hcl:"variables"`
+	Include     []map[string]string `json:"include,omitempty" yaml:"include,omitempty" hcl:"include,omitempty"`
+	Exclude     []map[string]string `json:"exclude,omitempty" yaml:"exclude,omitempty" hcl:"exclude,omitempty"`
+	MaxParallel int                 `json:"max_parallel,omitempty" yaml:"max_parallel,omitempty" hcl:"max_parallel,omitempty"`
+	FailFast    bool                `json:"fail_fast,omitempty" yaml:"fail_fast,omitempty" hcl:"fail_fast,omitempty"`
+}
+
+// JobCache represents exported result cache of a job, the cache is enabled as soon as it is declared
+type JobCache struct {
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty" hcl:"paths,omitempty"`
 }
 
 // Step represents exported step used in a job
@@ -46,7 +65,7 @@ type Step map[string]interface{}
 func (s Step) IsValid() bool {
 	keys := []string{}
 	for k := range s {
-		if k != "enabled" && k != "optional" && k != "always_executed" {
+		if k != "enabled" && k != "optional" && k != "always_executed" && k != "timeout" {
 			keys = append(keys, k)
 		}
 	}
@@ -56,7 +75,7 @@ func (s Step) IsValid() bool {
 func (s Step) key() string {
 	keys := []string{}
 	for k := range s {
-		if k != "enabled" && k != "optional" && k != "always_executed" {
+		if k != "enabled" && k != "optional" && k != "always_executed" && k != "timeout" {
 			keys = append(keys, k)
 		}
 	}
@@ -287,6 +306,74 @@ func (s Step) AsArtifactDownload() (*sdk.Action, bool, error) {
 	return &a, true, nil
 }
 
+//AsCacheSave returns the step a sdk.Action
+func (s Step) AsCacheSave() (*sdk.Action, bool, error) {
+	if !s.IsValid() {
+		return nil, false, fmt.Errorf("Malformatted Step")
+	}
+
+	bI, ok := s["cacheSave"]
+	if !ok {
+		return nil, false, nil
+	}
+
+	argss := map[string]string{}
+	if err := mapstructure.Decode(bI, &argss); err != nil {
+		return nil, true, sdk.WrapError(err, "Malformatted Step")
+	}
+	a := sdk.NewStepCacheSave(argss)
+
+	var err error
+	a.Enabled, err = s.IsFlagged("enabled")
+	if err != nil {
+		return nil, true, err
+	}
+	a.Optional, err = s.IsFlagged("optional")
+	if err != nil {
+		return nil, true, err
+	}
+	a.AlwaysExecuted, err = s.IsFlagged("always_executed")
+	if err != nil {
+		return nil, true, err
+	}
+
+	return &a, true, nil
+}
+
+//AsCacheRestore returns the step a sdk.Action
+func (s Step) AsCacheRestore() (*sdk.Action, bool, error) {
+	if !s.IsValid() {
+		return nil, false, fmt.Errorf("Malformatted Step")
+	}
+
+	bI, ok := s["cacheRestore"]
+	if !ok {
+		return nil, false, nil
+	}
+
+	argss := map[string]string{}
+	if err := mapstructure.Decode(bI, &argss); err != nil {
+		return nil, true, sdk.WrapError(err, "Malformatted Step")
+	}
+	a := sdk.NewStepCacheRestore(argss)
+
+	var err error
+	a.Enabled, err = s.IsFlagged("enabled")
+	if err != nil {
+		return nil, true, err
+	}
+	a.Optional, err = s.IsFlagged("optional")
+	if err != nil {
+		return nil, true, err
+	}
+	a.AlwaysExecuted, err = s.IsFlagged("always_executed")
+	if err != nil {
+		return nil, true, err
+	}
+
+	return &a, true, nil
+}
+
 // Is returns true the step has the flag set
 func (s Step) IsFlagged(flag string) (bool, error) {
 	bI, ok := s[flag]
@@ -301,6 +388,28 @@ func (s Step) IsFlagged(flag string) (bool, error) {
 	return bS, nil
 }
 
+// Timeout returns the timeout of the step in seconds, 0 if it has no timeout
+func (s Step) Timeout() (int64, error) {
+	bI, ok := s["timeout"]
+	if !ok {
+		return 0, nil
+	}
+	bS, ok := bI.(string)
+	if !ok {
+		return 0, fmt.Errorf("Malformatted Step : timeout must be a duration")
+	}
+	return parseTimeout(bS)
+}
+
+// parseTimeout parses an exported timeout, ie. 1h30m, in seconds
+func parseTimeout(s string) (int64, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("Invalid timeout %s : it must be a duration of at least 1s", s)
+	}
+	return int64(d / time.Second), nil
+}
+
 // Requirement represents an exported sdk.Requirement
 type Requirement struct {
 	Binary   string             `json:"binary,omitempty" yaml:"binary,omitempty"`
@@ -358,9 +467,13 @@ func NewPipeline(pip *sdk.Pipeline) (p *Pipeline) {
 			case 0:
 				return
 			case 1:
-				p.Steps = newSteps(pip.Stages[0].Jobs[0].Action)
-				p.Requirements = newRequirements(pip.Stages[0].Jobs[0].Action.Requirements)
-				return
+				//A cached job, a job with a timeout or a matrix job keeps its own section to export them
+				if !pip.Stages[0].Jobs[0].IsCached() && pip.Stages[0].Jobs[0].Timeout == 0 && !pip.Stages[0].Jobs[0].IsMatrix() {
+					p.Steps = newSteps(pip.Stages[0].Jobs[0].Action)
+					p.Requirements = newRequirements(pip.Stages[0].Jobs[0].Action.Requirements)
+					return
+				}
+				p.Jobs = newJobs(pip.Stages[0].Jobs)
 			default:
 				p.Jobs = newJobs(pip.Stages[0].Jobs)
 			}
@@ -439,6 +552,22 @@ func newJobs(jobs []sdk.Job) map[string]Job {
 		jo.Steps = newSteps(j.Action)
 		jo.Description = j.Action.Description
 		jo.Requirements = newRequirements(j.Action.Requirements)
+		if j.IsCached() {
+			jo.Cache = &JobCache{Paths: j.Cache.Paths}
+		}
+		if j.Timeout > 0 {
+			jo.Timeout = (time.Duration(j.Timeout) * time.Second).String()
+		}
+		if j.IsMatrix() {
+			jo.Matrix = &JobMatrix{
+				Variables:   j.Matrix.Variables,
+				Include:     j.Matrix.Include,
+				Exclude:     j.Matrix.Exclude,
+				MaxParallel: j.Matrix.MaxParallel,
+				FailFast:    j.Matrix.FailFast,
+			}
+		}
+		jo.Needs = j.Needs
 		res[j.Action.Name] = jo
 	}
 	return res
@@ -452,6 +581,9 @@ func newSteps(a sdk.Action) []Step {
 		s["enabled"] = act.Enabled
 		s["optional"] = act.Optional
 		s["always_executed"] = act.AlwaysExecuted
+		if act.Timeout > 0 {
+			s["timeout"] = (time.Duration(act.Timeout) * time.Second).String()
+		}
 
 		switch act.Type {
 		case sdk.BuiltinAction:
@@ -491,6 +623,28 @@ func newSteps(a sdk.Action) []Step {
 					artifactUploadArgs["tag"] = tag.Value
 				}
 				s["artifactUpload"] = artifactUploadArgs
+			case sdk.CacheSave:
+				cacheSaveArgs := map[string]string{}
+				key := sdk.ParameterFind(act.Parameters, "key")
+				if key != nil {
+					cacheSaveArgs["key"] = key.Value
+				}
+				path := sdk.ParameterFind(act.Parameters, "path")
+				if path != nil {
+					cacheSaveArgs["path"] = path.Value
+				}
+				s["cacheSave"] = cacheSaveArgs
+			case sdk.CacheRestore:
+				cacheRestoreArgs := map[string]string{}
+				key := sdk.ParameterFind(act.Parameters, "key")
+				if key != nil {
+					cacheRestoreArgs["key"] = key.Value
+				}
+				restoreKeys := sdk.ParameterFind(act.Parameters, "restoreKeys")
+				if restoreKeys != nil && restoreKeys.Value != "" {
+					cacheRestoreArgs["restoreKeys"] = restoreKeys.Value
+				}
+				s["cacheRestore"] = cacheRestoreArgs
 			case sdk.GitCloneAction:
 				gitCloneArgs := map[string]string{}
 				branch := sdk.ParameterFind(act.Parameters, "branch")
@@ -617,6 +771,9 @@ func (p *Pipeline) Pipeline() (*sdk.Pipeline, error) {
 			}
 			stage.Jobs = append(stage.Jobs, *job)
 		}
+		if err := sdk.CheckJobsNeeds(stage.Jobs); err != nil {
+			return nil, err
+		}
 		pip.Stages = []sdk.Stage{stage}
 	} else {
 		//There is more than one stage
@@ -668,6 +825,9 @@ func (p *Pipeline) Pipeline() (*sdk.Pipeline, error) {
 				}
 				s.Jobs = append(s.Jobs, *job)
 			}
+			if err := sdk.CheckJobsNeeds(s.Jobs); err != nil {
+				return nil, err
+			}
 
 			pip.Stages = append(pip.Stages, s)
 		}
@@ -688,7 +848,19 @@ func computeSteps(steps []Step) ([]sdk.Action, error) {
 	return res, nil
 }
 
-func computeStep(s Step) (a *sdk.Action, e error) {
+func computeStep(s Step) (*sdk.Action, error) {
+	a, err := computeStepAction(s)
+	if err != nil || a == nil {
+		return a, err
+	}
+	a.Timeout, err = s.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func computeStepAction(s Step) (a *sdk.Action, e error) {
 	if !s.IsValid() {
 		e = fmt.Errorf("Malformatted step")
 		return
@@ -705,6 +877,16 @@ func computeStep(s Step) (a *sdk.Action, e error) {
 		return
 	}
 
+	a, ok, e = s.AsCacheSave()
+	if ok {
+		return
+	}
+
+	a, ok, e = s.AsCacheRestore()
+	if ok {
+		return
+	}
+
 	a, ok, e = s.AsJUnitReport()
 	if ok {
 		return
@@ -785,6 +967,32 @@ func computeJob(name string, j Job) (*sdk.Job, error) {
 	}
 	job.Action.Enabled = job.Enabled
 	job.Action.Requirements = computeJobRequirements(j.Requirements)
+	if j.Cache != nil {
+		job.Cache = &sdk.JobCache{Enabled: true, Paths: j.Cache.Paths}
+		if err := job.CheckCache(); err != nil {
+			return nil, err
+		}
+	}
+	if j.Timeout != "" {
+		timeout, err := parseTimeout(j.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		job.Timeout = timeout
+	}
+	if j.Matrix != nil {
+		job.Matrix = &sdk.JobMatrix{
+			Variables:   j.Matrix.Variables,
+			Include:     j.Matrix.Include,
+			Exclude:     j.Matrix.Exclude,
+			MaxParallel: j.Matrix.MaxParallel,
+			FailFast:    j.Matrix.FailFast,
+		}
+		if _, err := job.Matrix.Combinations(); err != nil {
+			return nil, err
+		}
+	}
+	job.Needs = j.Needs
 
 	//Compute steps for the jobs
 	children, err := computeSteps(j.Steps)
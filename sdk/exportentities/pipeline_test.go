@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 
@@ -541,6 +542,181 @@ steps:
 	assert.Len(t, p.Stages[0].Jobs[0].Action.Actions[0].Parameters, 7)
 }
 
+func Test_ImportAndExportPipelineWithJobCache(t *testing.T) {
+	in := `name: build-component
+jobs:
+  build:
+    cache:
+      paths:
+      - component/**
+      - go.sum
+    steps:
+    - script: make -C component
+`
+
+	payload := &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+
+	p, err := payload.Pipeline()
+	test.NoError(t, err)
+
+	assert.Len(t, p.Stages[0].Jobs, 1)
+	job := p.Stages[0].Jobs[0]
+	assert.True(t, job.IsCached())
+	assert.Equal(t, []string{"component/**", "go.sum"}, job.Cache.Paths)
+
+	exported := NewPipeline(p)
+	assert.Len(t, exported.Steps, 0)
+	assert.Equal(t, &JobCache{Paths: []string{"component/**", "go.sum"}}, exported.Jobs["build"].Cache)
+
+	// a cache without input paths would restore the result of another commit
+	in = `name: build-component
+jobs:
+  build:
+    cache: {}
+    steps:
+    - script: make -C component
+`
+	payload = &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+	_, err = payload.Pipeline()
+	assert.Error(t, err)
+	assert.Equal(t, sdk.ErrInvalidJobCache, errors.Cause(err))
+}
+
+func Test_ImportAndExportPipelineWithCacheSteps(t *testing.T) {
+	in := `name: build-component
+steps:
+- cacheRestore:
+    key: go-{{.cds.application}}-{{hashFiles "go.sum"}}
+    restoreKeys: go-{{.cds.application}}-
+- script: go build ./...
+- cacheSave:
+    key: go-{{.cds.application}}-{{hashFiles "go.sum"}}
+    path: .gopath/pkg/mod
+`
+
+	payload := &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+
+	p, err := payload.Pipeline()
+	test.NoError(t, err)
+
+	steps := p.Stages[0].Jobs[0].Action.Actions
+	assert.Len(t, steps, 3)
+	assert.Equal(t, sdk.CacheRestore, steps[0].Name)
+	assert.Equal(t, sdk.BuiltinAction, steps[0].Type)
+	assert.Equal(t, "go-{{.cds.application}}-", sdk.ParameterValue(steps[0].Parameters, "restoreKeys"))
+	assert.Equal(t, sdk.CacheSave, steps[2].Name)
+	assert.Equal(t, ".gopath/pkg/mod", sdk.ParameterValue(steps[2].Parameters, "path"))
+
+	exported := NewPipeline(p)
+	assert.Len(t, exported.Steps, 3)
+	assert.Equal(t, map[string]string{
+		"key":         `go-{{.cds.application}}-{{hashFiles "go.sum"}}`,
+		"restoreKeys": "go-{{.cds.application}}-",
+	}, exported.Steps[0]["cacheRestore"])
+	assert.Equal(t, map[string]string{
+		"key":  `go-{{.cds.application}}-{{hashFiles "go.sum"}}`,
+		"path": ".gopath/pkg/mod",
+	}, exported.Steps[2]["cacheSave"])
+}
+
+func Test_ImportAndExportPipelineWithTimeouts(t *testing.T) {
+	in := `name: build-component
+jobs:
+  build:
+    timeout: 1h30m
+    steps:
+    - script: make test
+      timeout: 10m
+    - script: make package
+`
+
+	payload := &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+
+	p, err := payload.Pipeline()
+	test.NoError(t, err)
+
+	job := p.Stages[0].Jobs[0]
+	assert.Equal(t, int64(5400), job.Timeout)
+	assert.Equal(t, int64(600), job.Action.Actions[0].Timeout)
+	assert.Equal(t, int64(0), job.Action.Actions[1].Timeout)
+
+	exported := NewPipeline(p)
+	assert.Len(t, exported.Steps, 0)
+	assert.Equal(t, "1h30m0s", exported.Jobs["build"].Timeout)
+	assert.Equal(t, "10m0s", exported.Jobs["build"].Steps[0]["timeout"])
+	_, ok := exported.Jobs["build"].Steps[1]["timeout"]
+	assert.False(t, ok)
+
+	payload.Jobs["build"].Steps[0]["timeout"] = "10"
+	_, err = payload.Pipeline()
+	assert.Error(t, err)
+}
+
+func Test_ImportAndExportPipelineWithMatrix(t *testing.T) {
+	in := `name: build-component
+jobs:
+  build:
+    matrix:
+      variables:
+        go:
+        - "1.8"
+        - "1.9"
+        os:
+        - linux
+        - windows
+      exclude:
+      - go: "1.8"
+        os: windows
+      include:
+      - go: "1.9"
+        os: darwin
+      max_parallel: 2
+      fail_fast: true
+    requirements:
+    - model: golang-{{.cds.matrix.go}}-{{.cds.matrix.os}}
+    steps:
+    - script: make test
+`
+
+	payload := &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+
+	p, err := payload.Pipeline()
+	test.NoError(t, err)
+
+	job := p.Stages[0].Jobs[0]
+	assert.True(t, job.IsMatrix())
+	assert.Equal(t, map[string][]string{"go": {"1.8", "1.9"}, "os": {"linux", "windows"}}, job.Matrix.Variables)
+	assert.Equal(t, []map[string]string{{"go": "1.8", "os": "windows"}}, job.Matrix.Exclude)
+	assert.Equal(t, []map[string]string{{"go": "1.9", "os": "darwin"}}, job.Matrix.Include)
+	assert.Equal(t, 2, job.Matrix.MaxParallel)
+	assert.True(t, job.Matrix.FailFast)
+
+	jobs, err := job.ExpandMatrix()
+	test.NoError(t, err)
+	assert.Len(t, jobs, 4)
+	assert.Equal(t, "golang-1.9-darwin", jobs[3].Action.Requirements[0].Value)
+
+	exported := NewPipeline(p)
+	assert.Len(t, exported.Steps, 0)
+	assert.Equal(t, &JobMatrix{
+		Variables:   job.Matrix.Variables,
+		Include:     job.Matrix.Include,
+		Exclude:     job.Matrix.Exclude,
+		MaxParallel: 2,
+		FailFast:    true,
+	}, exported.Jobs["build"].Matrix)
+
+	payload.Jobs["build"].Matrix.Exclude = []map[string]string{{"go": "1.8"}, {"go": "1.9"}}
+	payload.Jobs["build"].Matrix.Include = nil
+	_, err = payload.Pipeline()
+	assert.Error(t, err)
+}
+
 func Test_IsFlagged(t *testing.T) {
 	testc := []struct {
 		flag     string
@@ -591,3 +767,42 @@ func Test_IsFlagged(t *testing.T) {
 	}
 
 }
+
+func Test_ImportAndExportPipelineWithNeeds(t *testing.T) {
+	in := `name: build-component
+jobs:
+  lint:
+    steps:
+    - script: make lint
+  test:
+    steps:
+    - script: make test
+  package:
+    needs:
+    - lint
+    - test
+    steps:
+    - script: make package
+`
+
+	payload := &Pipeline{}
+	test.NoError(t, yaml.Unmarshal([]byte(in), payload))
+
+	p, err := payload.Pipeline()
+	test.NoError(t, err)
+
+	for _, j := range p.Stages[0].Jobs {
+		if j.Action.Name == "package" {
+			assert.Equal(t, []string{"lint", "test"}, j.Needs)
+		} else {
+			assert.Len(t, j.Needs, 0)
+		}
+	}
+
+	exported := NewPipeline(p)
+	assert.Equal(t, []string{"lint", "test"}, exported.Jobs["package"].Needs)
+
+	payload.Jobs["lint"] = Job{Needs: []string{"package"}, Steps: payload.Jobs["lint"].Steps}
+	_, err = payload.Pipeline()
+	assert.Error(t, err)
+}
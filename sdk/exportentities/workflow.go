@@ -0,0 +1,421 @@
+package exportentities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/fsamin/go-dump"
+
+	"github.com/ovh/cds/sdk"
+)
+
+// Workflow represents exported sdk.Workflow
+// The nodes are indexed by their name, the graph is described by the depends_on field of each node:
+// a node without dependency is the root of the workflow, a node with one dependency is triggered by this node
+// and a node with several dependencies is triggered by the join of all of them.
+type Workflow struct {
+	Name        string                  `json:"name" yaml:"name" hcl:"name"`
+	Description string                  `json:"description,omitempty" yaml:"description,omitempty" hcl:"description"`
+	Priority    int                     `json:"priority,omitempty" yaml:"priority,omitempty" hcl:"priority"`
+	Nodes       map[string]WorkflowNode `json:"nodes" yaml:"nodes" hcl:"nodes"`
+}
+
+// WorkflowNode represents exported sdk.WorkflowNode with the trigger or the join which leads to it
+type WorkflowNode struct {
+	DependsOn   []string            `json:"depends_on,omitempty" yaml:"depends_on,omitempty" hcl:"depends_on"`
+	Conditions  []WorkflowCondition `json:"conditions,omitempty" yaml:"conditions,omitempty" hcl:"conditions"`
+	Pipeline    string              `json:"pipeline" yaml:"pipeline" hcl:"pipeline"`
+	Application string              `json:"application,omitempty" yaml:"application,omitempty" hcl:"application"`
+	Environment string              `json:"environment,omitempty" yaml:"environment,omitempty" hcl:"environment"`
+	Payload     map[string]string   `json:"payload,omitempty" yaml:"payload,omitempty" hcl:"payload"`
+	Parameters  map[string]string   `json:"parameters,omitempty" yaml:"parameters,omitempty" hcl:"parameters"`
+	Hooks       []WorkflowHook      `json:"hooks,omitempty" yaml:"hooks,omitempty" hcl:"hooks"`
+}
+
+// WorkflowCondition represents exported sdk.WorkflowTriggerCondition
+type WorkflowCondition struct {
+	Variable string `json:"variable" yaml:"variable" hcl:"variable"`
+	Operator string `json:"operator" yaml:"operator" hcl:"operator"`
+	Value    string `json:"value" yaml:"value" hcl:"value"`
+}
+
+// WorkflowHook represents exported sdk.WorkflowNodeHook
+type WorkflowHook struct {
+	Model      string              `json:"model" yaml:"model" hcl:"model"`
+	Config     map[string]string   `json:"config,omitempty" yaml:"config,omitempty" hcl:"config"`
+	Conditions []WorkflowCondition `json:"conditions,omitempty" yaml:"conditions,omitempty" hcl:"conditions"`
+}
+
+//NewWorkflow returns an exportable Workflow from a sdk.Workflow
+func NewWorkflow(w sdk.Workflow) (*Workflow, error) {
+	exportedWorkflow := &Workflow{
+		Name:        w.Name,
+		Description: w.Description,
+		Priority:    w.Priority,
+		Nodes:       map[string]WorkflowNode{},
+	}
+
+	if w.Root == nil {
+		return nil, sdk.ErrWorkflowInvalidRoot
+	}
+
+	if err := exportedWorkflow.addNode(w.Root, nil, nil); err != nil {
+		return nil, err
+	}
+
+	for _, j := range w.Joins {
+		dependsOn := make([]string, 0, len(j.SourceNodeIDs))
+		for _, id := range j.SourceNodeIDs {
+			n := w.GetNode(id)
+			if n == nil {
+				return nil, fmt.Errorf("Unable to find join source node %d", id)
+			}
+			dependsOn = append(dependsOn, n.Name)
+		}
+		sort.Strings(dependsOn)
+
+		for i := range j.Triggers {
+			t := &j.Triggers[i]
+			if err := exportedWorkflow.addNode(&t.WorkflowDestNode, dependsOn, t.Conditions); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return exportedWorkflow, nil
+}
+
+func (w *Workflow) addNode(n *sdk.WorkflowNode, dependsOn []string, conditions []sdk.WorkflowTriggerCondition) error {
+	if _, ok := w.Nodes[n.Name]; ok {
+		return fmt.Errorf("Duplicate node name %s", n.Name)
+	}
+
+	node := WorkflowNode{
+		DependsOn:  dependsOn,
+		Conditions: newWorkflowConditions(conditions),
+		Pipeline:   n.Pipeline.Name,
+	}
+
+	if n.Context != nil {
+		if n.Context.Application != nil {
+			node.Application = n.Context.Application.Name
+		}
+		if n.Context.Environment != nil && n.Context.Environment.Name != sdk.DefaultEnv.Name {
+			node.Environment = n.Context.Environment.Name
+		}
+
+		if n.Context.DefaultPayload != nil {
+			m, err := dump.ToMap(n.Context.DefaultPayload)
+			if err != nil {
+				return fmt.Errorf("Unable to export payload of node %s: %v", n.Name, err)
+			}
+			for k, v := range m {
+				//Skip the metadata computed by dump
+				if strings.Contains(k, "__") {
+					continue
+				}
+				if node.Payload == nil {
+					node.Payload = map[string]string{}
+				}
+				node.Payload[k] = v
+			}
+		}
+
+		if len(n.Context.DefaultPipelineParameters) > 0 {
+			node.Parameters = make(map[string]string, len(n.Context.DefaultPipelineParameters))
+			for _, p := range n.Context.DefaultPipelineParameters {
+				node.Parameters[p.Name] = p.Value
+			}
+		}
+	}
+
+	for _, h := range n.Hooks {
+		hook := WorkflowHook{
+			Model:      h.WorkflowHookModel.Name,
+			Conditions: newWorkflowConditions(h.Conditions),
+		}
+		for k, v := range h.Config {
+			//The webhook URL is computed by the API
+			if k == sdk.WorkflowNodeHookConfigWebHookURL {
+				continue
+			}
+			if hook.Config == nil {
+				hook.Config = map[string]string{}
+			}
+			hook.Config[k] = v
+		}
+		node.Hooks = append(node.Hooks, hook)
+	}
+
+	w.Nodes[n.Name] = node
+
+	for i := range n.Triggers {
+		t := &n.Triggers[i]
+		if err := w.addNode(&t.WorkflowDestNode, []string{n.Name}, t.Conditions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newWorkflowConditions(conditions []sdk.WorkflowTriggerCondition) []WorkflowCondition {
+	if len(conditions) == 0 {
+		return nil
+	}
+	res := make([]WorkflowCondition, len(conditions))
+	for i, c := range conditions {
+		res[i] = WorkflowCondition{
+			Variable: c.Variable,
+			Operator: c.Operator,
+			Value:    c.Value,
+		}
+	}
+	return res
+}
+
+func (c WorkflowCondition) condition() sdk.WorkflowTriggerCondition {
+	return sdk.WorkflowTriggerCondition{
+		Variable: c.Variable,
+		Operator: c.Operator,
+		Value:    c.Value,
+	}
+}
+
+//HCLTemplate returns text/template
+func (w *Workflow) HCLTemplate() (*template.Template, error) {
+	tmpl := `name = {{printf "%q" .Name}}
+{{if .Description -}}
+description = {{printf "%q" .Description}}
+{{end}}
+{{- if .Priority -}}
+priority = {{.Priority}}
+{{end}}
+nodes { {{ range $name, $node := .Nodes }}
+	{{printf "%q" $name}} {
+		pipeline = {{printf "%q" $node.Pipeline}}
+		{{- if $node.Application}}
+		application = {{printf "%q" $node.Application}}
+		{{- end}}
+		{{- if $node.Environment}}
+		environment = {{printf "%q" $node.Environment}}
+		{{- end}}
+		{{- if $node.DependsOn}}
+		depends_on = [{{ range $i, $d := $node.DependsOn }}{{if $i}}, {{end}}{{printf "%q" $d}}{{end}}]
+		{{- end}}
+		{{- if $node.Conditions}}
+		conditions = [ {{- range $node.Conditions}}
+			{
+				variable = {{printf "%q" .Variable}}
+				operator = {{printf "%q" .Operator}}
+				value = {{printf "%q" .Value}}
+			},
+		{{- end}}
+		]
+		{{- end}}
+		{{- if $node.Payload}}
+		payload { {{ range $key, $value := $node.Payload }}
+			{{printf "%q" $key}} = {{printf "%q" $value}}{{ end }}
+		}
+		{{- end}}
+		{{- if $node.Parameters}}
+		parameters { {{ range $key, $value := $node.Parameters }}
+			{{printf "%q" $key}} = {{printf "%q" $value}}{{ end }}
+		}
+		{{- end}}
+		{{- if $node.Hooks}}
+		hooks = [ {{- range $node.Hooks}}
+			{
+				model = {{printf "%q" .Model}}
+				{{- if .Config}}
+				config = { {{ range $key, $value := .Config }}
+					{{printf "%q" $key}} = {{printf "%q" $value}}{{ end }}
+				}
+				{{- end}}
+				{{- if .Conditions}}
+				conditions = [ {{- range .Conditions}}
+					{
+						variable = {{printf "%q" .Variable}}
+						operator = {{printf "%q" .Operator}}
+						value = {{printf "%q" .Value}}
+					},
+				{{- end}}
+				]
+				{{- end}}
+			},
+		{{- end}}
+		]
+		{{- end}}
+	}
+{{ end }}
+}
+`
+	t := template.New("t")
+	return t.Parse(tmpl)
+}
+
+//Workflow returns a sdk.Workflow entity.
+//Pipelines, applications, environments and hook models are only referenced by their names,
+//they have to be resolved in the project before inserting the workflow.
+func (w *Workflow) Workflow() (*sdk.Workflow, error) {
+	wf := &sdk.Workflow{
+		Name:        w.Name,
+		Description: w.Description,
+		Priority:    w.Priority,
+	}
+
+	names := make([]string, 0, len(w.Nodes))
+	for name := range w.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	//Check the dependencies and find the root
+	var root string
+	for _, name := range names {
+		n := w.Nodes[name]
+		if n.Pipeline == "" {
+			return nil, fmt.Errorf("Pipeline is mandatory on node %s", name)
+		}
+		if len(n.DependsOn) == 0 {
+			if root != "" {
+				return nil, fmt.Errorf("Workflow %s has several roots: %s and %s", w.Name, root, name)
+			}
+			root = name
+			continue
+		}
+		for _, d := range n.DependsOn {
+			if _, ok := w.Nodes[d]; !ok {
+				return nil, fmt.Errorf("Node %s depends on unknown node %s", name, d)
+			}
+		}
+	}
+	if root == "" {
+		return nil, sdk.ErrWorkflowInvalidRoot
+	}
+
+	visited := map[string]bool{}
+	var errBuild error
+	wf.Root, errBuild = w.node(root, names, visited)
+	if errBuild != nil {
+		return nil, errBuild
+	}
+
+	//Nodes depending on several nodes are triggered by joins, grouped by their sources
+	joinsIndex := map[string]int{}
+	for _, name := range names {
+		n := w.Nodes[name]
+		if len(n.DependsOn) < 2 {
+			continue
+		}
+		sources := make([]string, len(n.DependsOn))
+		copy(sources, n.DependsOn)
+		sort.Strings(sources)
+		key := strings.Join(sources, ",")
+
+		i, ok := joinsIndex[key]
+		if !ok {
+			wf.Joins = append(wf.Joins, sdk.WorkflowNodeJoin{SourceNodeRefs: sources})
+			i = len(wf.Joins) - 1
+			joinsIndex[key] = i
+		}
+
+		dest, err := w.node(name, names, visited)
+		if err != nil {
+			return nil, err
+		}
+		wf.Joins[i].Triggers = append(wf.Joins[i].Triggers, sdk.WorkflowNodeJoinTrigger{
+			WorkflowDestNode: *dest,
+			Conditions:       n.conditions(),
+		})
+	}
+
+	for _, name := range names {
+		if !visited[name] {
+			return nil, fmt.Errorf("Node %s is not reachable from the root %s", name, root)
+		}
+	}
+
+	return wf, nil
+}
+
+//node computes the sdk.WorkflowNode named name, and all the nodes it triggers
+func (w *Workflow) node(name string, names []string, visited map[string]bool) (*sdk.WorkflowNode, error) {
+	if visited[name] {
+		return nil, fmt.Errorf("Node %s is involved in a cycle", name)
+	}
+	visited[name] = true
+
+	n := w.Nodes[name]
+	node := &sdk.WorkflowNode{
+		Name:     name,
+		Ref:      name,
+		Pipeline: sdk.Pipeline{Name: n.Pipeline},
+		Context:  &sdk.WorkflowNodeContext{},
+	}
+
+	if n.Application != "" {
+		node.Context.Application = &sdk.Application{Name: n.Application}
+	}
+	if n.Environment != "" {
+		node.Context.Environment = &sdk.Environment{Name: n.Environment}
+	}
+	if n.Payload != nil {
+		node.Context.DefaultPayload = n.Payload
+	}
+	for k, v := range n.Parameters {
+		node.Context.DefaultPipelineParameters = append(node.Context.DefaultPipelineParameters, sdk.Parameter{
+			Name:  k,
+			Type:  sdk.StringParameter,
+			Value: v,
+		})
+	}
+	sort.Slice(node.Context.DefaultPipelineParameters, func(i, j int) bool {
+		return node.Context.DefaultPipelineParameters[i].Name < node.Context.DefaultPipelineParameters[j].Name
+	})
+
+	for _, h := range n.Hooks {
+		hook := sdk.WorkflowNodeHook{
+			WorkflowHookModel: sdk.WorkflowHookModel{Name: h.Model},
+			Config:            sdk.WorkflowNodeHookConfig{},
+		}
+		for k, v := range h.Config {
+			hook.Config[k] = v
+		}
+		for _, c := range h.Conditions {
+			hook.Conditions = append(hook.Conditions, c.condition())
+		}
+		node.Hooks = append(node.Hooks, hook)
+	}
+
+	//Browse the nodes triggered by this one
+	for _, childName := range names {
+		child := w.Nodes[childName]
+		if len(child.DependsOn) != 1 || child.DependsOn[0] != name {
+			continue
+		}
+		dest, err := w.node(childName, names, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.Triggers = append(node.Triggers, sdk.WorkflowNodeTrigger{
+			WorkflowDestNode: *dest,
+			Conditions:       child.conditions(),
+		})
+	}
+
+	return node, nil
+}
+
+func (n WorkflowNode) conditions() []sdk.WorkflowTriggerCondition {
+	if len(n.Conditions) == 0 {
+		return nil
+	}
+	res := make([]sdk.WorkflowTriggerCondition, len(n.Conditions))
+	for i, c := range n.Conditions {
+		res[i] = c.condition()
+	}
+	return res
+}
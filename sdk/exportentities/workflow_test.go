@@ -0,0 +1,219 @@
+package exportentities
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ovh/cds/engine/api/test"
+	"github.com/ovh/cds/sdk"
+)
+
+func testExportWorkflow() sdk.Workflow {
+	return sdk.Workflow{
+		Name:        "my-workflow",
+		Description: "my description",
+		Priority:    5,
+		Root: &sdk.WorkflowNode{
+			ID:       1,
+			Name:     "build",
+			Pipeline: sdk.Pipeline{Name: "build"},
+			Context: &sdk.WorkflowNodeContext{
+				Application:    &sdk.Application{Name: "my-app"},
+				DefaultPayload: map[string]string{"git.branch": "master"},
+				DefaultPipelineParameters: []sdk.Parameter{
+					{Name: "param", Type: sdk.StringParameter, Value: "value"},
+				},
+			},
+			Hooks: []sdk.WorkflowNodeHook{
+				{
+					UUID:              "1234",
+					WorkflowHookModel: sdk.WorkflowHookModel{Name: "Scheduler"},
+					Config: sdk.WorkflowNodeHookConfig{
+						"cron": "0 * * * *",
+					},
+					Conditions: []sdk.WorkflowTriggerCondition{
+						{Variable: "git.branch", Operator: "eq", Value: "master"},
+					},
+				},
+			},
+			Triggers: []sdk.WorkflowNodeTrigger{
+				{
+					Conditions: []sdk.WorkflowTriggerCondition{
+						{Variable: "cds.status", Operator: "eq", Value: "Success"},
+					},
+					WorkflowDestNode: sdk.WorkflowNode{
+						ID:       2,
+						Name:     "test",
+						Pipeline: sdk.Pipeline{Name: "test"},
+						Context: &sdk.WorkflowNodeContext{
+							Application: &sdk.Application{Name: "my-app"},
+							Environment: &sdk.Environment{Name: "staging"},
+						},
+					},
+				},
+				{
+					WorkflowDestNode: sdk.WorkflowNode{
+						ID:       3,
+						Name:     "lint",
+						Pipeline: sdk.Pipeline{Name: "lint"},
+					},
+				},
+			},
+		},
+		Joins: []sdk.WorkflowNodeJoin{
+			{
+				SourceNodeIDs: []int64{2, 3},
+				Triggers: []sdk.WorkflowNodeJoinTrigger{
+					{
+						WorkflowDestNode: sdk.WorkflowNode{
+							ID:       4,
+							Name:     "deploy",
+							Pipeline: sdk.Pipeline{Name: "deploy"},
+							Context: &sdk.WorkflowNodeContext{
+								Environment: &sdk.Environment{Name: "production"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewWorkflow(t *testing.T) {
+	w, err := NewWorkflow(testExportWorkflow())
+	test.NoError(t, err)
+
+	assert.Equal(t, "my-workflow", w.Name)
+	assert.Equal(t, 5, w.Priority)
+	assert.Len(t, w.Nodes, 4)
+
+	build := w.Nodes["build"]
+	assert.Empty(t, build.DependsOn)
+	assert.Equal(t, "my-app", build.Application)
+	assert.Equal(t, map[string]string{"git.branch": "master"}, build.Payload)
+	assert.Equal(t, map[string]string{"param": "value"}, build.Parameters)
+	if !assert.Len(t, build.Hooks, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Scheduler", build.Hooks[0].Model)
+	assert.Equal(t, "0 * * * *", build.Hooks[0].Config["cron"])
+
+	testNode := w.Nodes["test"]
+	assert.Equal(t, []string{"build"}, testNode.DependsOn)
+	assert.Equal(t, "staging", testNode.Environment)
+	assert.Equal(t, []WorkflowCondition{{Variable: "cds.status", Operator: "eq", Value: "Success"}}, testNode.Conditions)
+
+	assert.Equal(t, []string{"lint", "test"}, w.Nodes["deploy"].DependsOn)
+}
+
+func TestWorkflowMarshalRoundTrip(t *testing.T) {
+	w, err := NewWorkflow(testExportWorkflow())
+	test.NoError(t, err)
+
+	for _, f := range []Format{FormatYAML, FormatJSON, FormatHCL} {
+		btes, err := Marshal(w, f)
+		test.NoError(t, err, "format", f)
+		t.Logf("%s", btes)
+
+		var imported Workflow
+		switch f {
+		case FormatYAML:
+			err = yaml.Unmarshal(btes, &imported)
+		case FormatJSON:
+			err = json.Unmarshal(btes, &imported)
+		default:
+			err = hcl.Unmarshal(btes, &imported)
+		}
+		test.NoError(t, err, "format", f)
+		assert.Equal(t, *w, imported, "format %s", f)
+	}
+}
+
+func TestWorkflowToSDK(t *testing.T) {
+	w, err := NewWorkflow(testExportWorkflow())
+	test.NoError(t, err)
+
+	wf, err := w.Workflow()
+	test.NoError(t, err)
+
+	test.NotNil(t, wf.Root)
+	assert.Equal(t, 5, wf.Priority)
+	assert.Equal(t, "build", wf.Root.Name)
+	assert.Equal(t, "build", wf.Root.Ref)
+	assert.Equal(t, "my-app", wf.Root.Context.Application.Name)
+	if !assert.Len(t, wf.Root.Hooks, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Scheduler", wf.Root.Hooks[0].WorkflowHookModel.Name)
+
+	if !assert.Len(t, wf.Root.Triggers, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "lint", wf.Root.Triggers[0].WorkflowDestNode.Name)
+	assert.Equal(t, "test", wf.Root.Triggers[1].WorkflowDestNode.Name)
+	assert.Len(t, wf.Root.Triggers[1].Conditions, 1)
+	assert.Equal(t, "staging", wf.Root.Triggers[1].WorkflowDestNode.Context.Environment.Name)
+
+	if !assert.Len(t, wf.Joins, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"lint", "test"}, wf.Joins[0].SourceNodeRefs)
+	if !assert.Len(t, wf.Joins[0].Triggers, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "deploy", wf.Joins[0].Triggers[0].WorkflowDestNode.Name)
+}
+
+func TestWorkflowToSDKErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes map[string]WorkflowNode
+	}{
+		{
+			name: "no root",
+			nodes: map[string]WorkflowNode{
+				"a": {Pipeline: "a", DependsOn: []string{"b"}},
+				"b": {Pipeline: "b", DependsOn: []string{"a"}},
+			},
+		},
+		{
+			name: "several roots",
+			nodes: map[string]WorkflowNode{
+				"a": {Pipeline: "a"},
+				"b": {Pipeline: "b"},
+			},
+		},
+		{
+			name: "unknown dependency",
+			nodes: map[string]WorkflowNode{
+				"a": {Pipeline: "a"},
+				"b": {Pipeline: "b", DependsOn: []string{"c"}},
+			},
+		},
+		{
+			name: "unreachable node",
+			nodes: map[string]WorkflowNode{
+				"a": {Pipeline: "a"},
+				"b": {Pipeline: "b", DependsOn: []string{"c"}},
+				"c": {Pipeline: "c", DependsOn: []string{"b"}},
+			},
+		},
+		{
+			name: "missing pipeline",
+			nodes: map[string]WorkflowNode{
+				"a": {},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		w := Workflow{Name: "w", Nodes: tt.nodes}
+		_, err := w.Workflow()
+		assert.Error(t, err, tt.name)
+	}
+}
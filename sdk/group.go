@@ -16,6 +16,7 @@ type Group struct {
 	PipelineGroups    []PipelineGroup    `json:"pipelines,omitempty" yaml:"-"`
 	ApplicationGroups []ApplicationGroup `json:"applications,omitempty" yaml:"-"`
 	EnvironmentGroups []EnvironmentGroup `json:"environments,omitempty" yaml:"-"`
+	WorkflowGroups    []WorkflowGroup    `json:"workflows,omitempty" yaml:"-"`
 }
 
 // GroupPermission represent a group and his role in the project
@@ -42,6 +43,12 @@ type PipelineGroup struct {
 	Permission int      `json:"permission"`
 }
 
+// WorkflowGroup represent a link with a workflow
+type WorkflowGroup struct {
+	Workflow   Workflow `json:"workflow"`
+	Permission int      `json:"permission"`
+}
+
 // ProjectGroup represent a link with a project
 type ProjectGroup struct {
 	Project    Project `json:"project"`
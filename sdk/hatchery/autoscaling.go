@@ -0,0 +1,308 @@
+package hatchery
+
+import (
+	"expvar"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ovh/cds/sdk"
+	"github.com/ovh/cds/sdk/log"
+)
+
+const (
+	// arrivalWindow is the period over which the job arrival rate of a model is computed
+	arrivalWindow = 10 * time.Minute
+	// defaultSpawnLatency is used as spawn latency of a model until one of its workers has been spawned
+	defaultSpawnLatency = 30 * time.Second
+)
+
+var (
+	spawnLatencies = &spawnLatency{values: map[int64]time.Duration{}}
+	// provisioningMetrics exports the last provisioning decisions, per worker model, on /debug/vars
+	provisioningMetrics = expvar.NewMap("provisioning")
+)
+
+// spawnLatency keeps a moving average of the time taken to spawn a worker of each model
+type spawnLatency struct {
+	mutex  sync.Mutex
+	values map[int64]time.Duration
+}
+
+func (s *spawnLatency) record(modelID int64, d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if v, ok := s.values[modelID]; ok {
+		d = (3*v + d) / 4
+	}
+	s.values[modelID] = d
+}
+
+func (s *spawnLatency) get(modelID int64) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if v, ok := s.values[modelID]; ok {
+		return v
+	}
+	return defaultSpawnLatency
+}
+
+// provisionDecision describes the state of a worker model and the number of idle workers wanted for it
+type provisionDecision struct {
+	queued  int           // jobs of the model waiting in the queue
+	fresh   int           // waiting jobs queued since less than the grace time, they are left to the idle workers
+	rate    float64       // jobs of the model queued per second
+	latency time.Duration // time taken to spawn a worker of the model
+	idle    int           // workers of the model started and not building
+	target  int
+}
+
+// autoscaler sizes the pool of idle workers of each model from its queue, its job arrival rate and its spawn latency
+type autoscaler struct {
+	min, max int
+	cooldown time.Duration
+	arrivals map[int64][]time.Time // model -> time the waiting jobs were first seen
+	seen     map[string]time.Time  // jobs already counted in arrivals
+	needed   map[int64]time.Time   // model -> last time all the idle workers were needed
+}
+
+func newAutoscaler(min, max int, cooldown time.Duration) *autoscaler {
+	return &autoscaler{
+		min:      min,
+		max:      max,
+		cooldown: cooldown,
+		arrivals: map[int64][]time.Time{},
+		seen:     map[string]time.Time{},
+		needed:   map[int64]time.Time{},
+	}
+}
+
+// recordArrivals records the jobs seen for the first time and returns the arrival rate of the model, in jobs per second
+func (a *autoscaler) recordArrivals(now time.Time, modelID int64, jobs []string) float64 {
+	for _, j := range jobs {
+		if _, ok := a.seen[j]; ok {
+			continue
+		}
+		a.seen[j] = now
+		a.arrivals[modelID] = append(a.arrivals[modelID], now)
+	}
+
+	arrivals := a.arrivals[modelID][:0]
+	for _, t := range a.arrivals[modelID] {
+		if now.Sub(t) < arrivalWindow {
+			arrivals = append(arrivals, t)
+		}
+	}
+	a.arrivals[modelID] = arrivals
+	return float64(len(arrivals)) / arrivalWindow.Seconds()
+}
+
+// forget removes the jobs seen which are not in the queue anymore
+func (a *autoscaler) forget(queued map[string]bool) {
+	for j := range a.seen {
+		if !queued[j] {
+			delete(a.seen, j)
+		}
+	}
+}
+
+// target returns the number of idle workers wanted for a model: the fresh jobs plus the jobs expected to arrive
+// while a worker spawns. It is never less than the provision of the model and stays in the bounds of the hatchery.
+func (a *autoscaler) target(d provisionDecision, provision int64) int {
+	t := d.fresh + int(math.Ceil(d.rate*d.latency.Seconds()))
+	if int(provision) > t {
+		t = int(provision)
+	}
+	if t < a.min {
+		t = a.min
+	}
+	if a.max > 0 && t > a.max {
+		t = a.max
+	}
+	return t
+}
+
+// scaleDown returns the number of idle workers to remove from a model, once they have not been needed during the cooldown
+func (a *autoscaler) scaleDown(now time.Time, modelID int64, idle, target int) int {
+	last, ok := a.needed[modelID]
+	if idle <= target || !ok {
+		a.needed[modelID] = now
+		return 0
+	}
+	if now.Sub(last) < a.cooldown {
+		return 0
+	}
+	return idle - target
+}
+
+// provision spawns or disables idle workers of each model to reach the target of the model.
+// available is the number of workers this hatchery can still start.
+func (a *autoscaler) provision(h Interface, models []sdk.Model, available, graceSeconds int, hostname string) {
+	wJobs, pbJobs, errQ := h.Client().Queue()
+	if errQ != nil {
+		log.Warning("provisioning> cannot get queue: %s", errQ)
+		return
+	}
+	workers, errW := h.Client().WorkerList()
+	if errW != nil {
+		log.Warning("provisioning> cannot get workers: %s", errW)
+		return
+	}
+
+	now := time.Now()
+	decisions := map[int64]*provisionDecision{}
+	jobs := map[int64][]string{}
+	queued := map[string]bool{}
+	addJob := func(key string, queuedSeconds int64, execGroups []sdk.Group, requirements []sdk.Requirement) {
+		for k := range models {
+			m := &models[k]
+			if m.Type != h.ModelType() || !canRunModel(h, execGroups, requirements, m, hostname) {
+				continue
+			}
+			d, ok := decisions[m.ID]
+			if !ok {
+				d = &provisionDecision{}
+				decisions[m.ID] = d
+			}
+			d.queued++
+			if queuedSeconds < int64(graceSeconds) {
+				d.fresh++
+			}
+			jobs[m.ID] = append(jobs[m.ID], key)
+			queued[key] = true
+			return
+		}
+	}
+	for _, j := range wJobs {
+		if j.Status == sdk.StatusWaiting.String() && !j.WaitingForSlot && j.BookedBy.ID == 0 {
+			addJob(fmt.Sprintf("workflow-%d", j.ID), j.QueuedSeconds, nil, j.Job.Action.Requirements)
+		}
+	}
+	for _, j := range pbJobs {
+		if j.Status == sdk.StatusWaiting.String() && j.BookedBy.ID == 0 {
+			addJob(fmt.Sprintf("pipeline-%d", j.ID), j.QueuedSeconds, j.ExecGroups, j.Job.Action.Requirements)
+		}
+	}
+
+	building := map[int64]int{}
+	waiting := map[int64][]sdk.Worker{}
+	for _, w := range workers {
+		if w.HatcheryID != h.Hatchery().ID {
+			continue
+		}
+		switch w.Status {
+		case sdk.StatusBuilding:
+			building[w.ModelID]++
+		case sdk.StatusWaiting:
+			waiting[w.ModelID] = append(waiting[w.ModelID], w)
+		}
+	}
+
+	for k := range models {
+		m := &models[k]
+		if m.Type != h.ModelType() {
+			continue
+		}
+		d, ok := decisions[m.ID]
+		if !ok {
+			d = &provisionDecision{}
+		}
+		d.rate = a.recordArrivals(now, m.ID, jobs[m.ID])
+		d.latency = spawnLatencies.get(m.ID)
+		d.idle = h.WorkersStartedByModel(m) - building[m.ID]
+		if d.idle < 0 {
+			d.idle = 0
+		}
+		d.target = a.target(*d, m.Provision)
+		metrics := publishDecision(m, *d)
+
+		if d.target >= d.idle {
+			a.needed[m.ID] = now
+			n := d.target - d.idle
+			if n > available {
+				n = available
+			}
+			if n <= 0 {
+				continue
+			}
+			available -= n
+			log.Info("provisioning> model %s: %d queued, %.2f jobs/min, spawn latency %s, %d idle workers, target %d: spawn %d workers",
+				m.Name, d.queued, d.rate*60, sdk.Round(d.latency, time.Second), d.idle, d.target, n)
+			for i := 0; i < n; i++ {
+				go spawnProvision(h, *m)
+			}
+			metrics.Add("spawned", int64(n))
+			continue
+		}
+
+		n := a.scaleDown(now, m.ID, d.idle, d.target)
+		if n == 0 {
+			continue
+		}
+		// Only the registered workers waiting for a job can be disabled, the hatchery kills the disabled workers
+		idles := waiting[m.ID]
+		if n > len(idles) {
+			n = len(idles)
+		}
+		log.Info("provisioning> model %s: %d idle workers, target %d since %s: disable %d workers", m.Name, d.idle, d.target, a.cooldown, n)
+		for _, w := range idles[:n] {
+			if err := h.Client().WorkerDisable(w.ID); err != nil {
+				log.Warning("provisioning> cannot disable worker %s: %s", w.Name, err)
+				continue
+			}
+			metrics.Add("disabled", 1)
+		}
+		a.needed[m.ID] = now
+	}
+
+	a.forget(queued)
+}
+
+// canRunModel checks if a worker of the model could run a job, without asking the hatchery
+func canRunModel(h Interface, execGroups []sdk.Group, requirements []sdk.Requirement, model *sdk.Model, hostname string) bool {
+	if model.NbSpawnErr > 5 && h.Hatchery().GroupID != model.ID {
+		return false
+	}
+	if len(execGroups) > 0 {
+		var checkGroup bool
+		for _, g := range execGroups {
+			if g.ID == model.GroupID {
+				checkGroup = true
+				break
+			}
+		}
+		if !checkGroup {
+			return false
+		}
+	}
+	return matchRequirements(0, 0, requirements, model, hostname)
+}
+
+// publishDecision exports the decision taken for a model and returns its metrics
+func publishDecision(m *sdk.Model, d provisionDecision) *expvar.Map {
+	metrics, ok := provisioningMetrics.Get(m.Name).(*expvar.Map)
+	if !ok {
+		metrics = new(expvar.Map).Init()
+		provisioningMetrics.Set(m.Name, metrics)
+	}
+	setInt(metrics, "queued", d.queued)
+	setInt(metrics, "fresh", d.fresh)
+	setFloat(metrics, "arrivals_per_minute", d.rate*60)
+	setFloat(metrics, "spawn_latency_seconds", d.latency.Seconds())
+	setInt(metrics, "idle", d.idle)
+	setInt(metrics, "target", d.target)
+	return metrics
+}
+
+func setInt(m *expvar.Map, key string, value int) {
+	v := new(expvar.Int)
+	v.Set(int64(value))
+	m.Set(key, v)
+}
+
+func setFloat(m *expvar.Map, key string, value float64) {
+	v := new(expvar.Float)
+	v.Set(value)
+	m.Set(key, v)
+}
@@ -0,0 +1,62 @@
+package hatchery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_autoscalerTarget(t *testing.T) {
+	a := newAutoscaler(1, 4, time.Minute)
+
+	// no job: the minimum of the hatchery
+	assert.Equal(t, 1, a.target(provisionDecision{latency: defaultSpawnLatency}, 0))
+	// the provision of the model is kept as a floor
+	assert.Equal(t, 2, a.target(provisionDecision{latency: defaultSpawnLatency}, 2))
+	// 2 fresh jobs + 6 jobs/min during 30s
+	assert.Equal(t, 4, a.target(provisionDecision{fresh: 2, rate: 0.1, latency: 30 * time.Second}, 0))
+	// bounded by the maximum of the hatchery
+	assert.Equal(t, 4, a.target(provisionDecision{fresh: 10, rate: 1, latency: time.Minute}, 0))
+
+	a.max = 0
+	assert.Equal(t, 70, a.target(provisionDecision{fresh: 10, rate: 1, latency: time.Minute}, 0))
+}
+
+func Test_autoscalerRecordArrivals(t *testing.T) {
+	a := newAutoscaler(0, 0, time.Minute)
+	now := time.Now()
+
+	assert.Equal(t, 2/arrivalWindow.Seconds(), a.recordArrivals(now, 1, []string{"workflow-1", "workflow-2"}))
+	// jobs already seen are not counted twice
+	assert.Equal(t, 3/arrivalWindow.Seconds(), a.recordArrivals(now.Add(time.Minute), 1, []string{"workflow-1", "workflow-2", "pipeline-1"}))
+	assert.Equal(t, 0.0, a.recordArrivals(now, 2, nil))
+
+	// arrivals older than the window are forgotten
+	later := now.Add(arrivalWindow + 30*time.Second)
+	assert.Equal(t, 1/arrivalWindow.Seconds(), a.recordArrivals(later, 1, []string{"workflow-1"}))
+	a.forget(map[string]bool{"workflow-1": true})
+	assert.Len(t, a.seen, 1)
+
+	// a job still queued after the window is not counted again
+	assert.Equal(t, 0.0, a.recordArrivals(later.Add(arrivalWindow), 1, []string{"workflow-1"}))
+
+	// a job is counted again once it has left the queue
+	a.forget(map[string]bool{})
+	assert.Len(t, a.seen, 0)
+	assert.Equal(t, 1/arrivalWindow.Seconds(), a.recordArrivals(later.Add(arrivalWindow), 1, []string{"workflow-1"}))
+}
+
+func Test_autoscalerScaleDown(t *testing.T) {
+	a := newAutoscaler(0, 0, 5*time.Minute)
+	now := time.Now()
+
+	assert.Equal(t, 0, a.scaleDown(now, 1, 3, 1), "the cooldown starts when the model is first seen")
+	assert.Equal(t, 0, a.scaleDown(now.Add(time.Minute), 1, 3, 1), "idle workers are kept during the cooldown")
+	assert.Equal(t, 2, a.scaleDown(now.Add(6*time.Minute), 1, 3, 1))
+
+	// the cooldown restarts each time the idle workers are needed
+	assert.Equal(t, 0, a.scaleDown(now.Add(7*time.Minute), 1, 1, 1))
+	assert.Equal(t, 0, a.scaleDown(now.Add(11*time.Minute), 1, 3, 1))
+	assert.Equal(t, 2, a.scaleDown(now.Add(12*time.Minute), 1, 3, 1))
+}
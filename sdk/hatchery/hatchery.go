@@ -132,6 +132,7 @@ func routine(h Interface, isWorkflowJob bool, models []sdk.Model, execGroups []s
 				continue // try another model
 			}
 
+			spawnLatencies.record(model.ID, time.Since(start))
 			infos = append(infos, sdk.SpawnInfo{
 				RemoteTime: time.Now(),
 				Message: sdk.SpawnMsg{ID: sdk.MsgSpawnInfoHatcheryStartsSuccessfully.ID,
@@ -163,19 +164,25 @@ func provisioning(h Interface, provisionDisabled bool, models []sdk.Model) {
 		if models[k].Type == h.ModelType() {
 			existing := h.WorkersStartedByModel(&models[k])
 			for i := existing; i < int(models[k].Provision); i++ {
-				go func(m sdk.Model) {
-					if name, errSpawn := h.SpawnWorker(&m, 0, nil, false, "spawn for provision"); errSpawn != nil {
-						log.Warning("provisioning> cannot spawn worker %s with model %s for provisioning: %s", name, m.Name, errSpawn)
-						if err := h.Client().WorkerModelSpawnError(m.ID, fmt.Sprintf("routine> cannot spawn worker %s for provisioning: %s", m.Name, errSpawn)); err != nil {
-							log.Error("provisioning> cannot client.WorkerModelSpawnError for worker %s with model %s for provisioning: %s", name, m.Name, errSpawn)
-						}
-					}
-				}(models[k])
+				go spawnProvision(h, models[k])
 			}
 		}
 	}
 }
 
+func spawnProvision(h Interface, m sdk.Model) {
+	start := time.Now()
+	name, errSpawn := h.SpawnWorker(&m, 0, nil, false, "spawn for provision")
+	if errSpawn != nil {
+		log.Warning("provisioning> cannot spawn worker %s with model %s for provisioning: %s", name, m.Name, errSpawn)
+		if err := h.Client().WorkerModelSpawnError(m.ID, fmt.Sprintf("routine> cannot spawn worker %s for provisioning: %s", m.Name, errSpawn)); err != nil {
+			log.Error("provisioning> cannot client.WorkerModelSpawnError for worker %s with model %s for provisioning: %s", name, m.Name, errSpawn)
+		}
+		return
+	}
+	spawnLatencies.record(m.ID, time.Since(start))
+}
+
 func canRunJob(h Interface, timestamp int64, execGroups []sdk.Group, jobID int64, requirements []sdk.Requirement, model *sdk.Model, hostname string) bool {
 	if model.Type != h.ModelType() {
 		return false
@@ -201,7 +208,15 @@ func canRunJob(h Interface, timestamp int64, execGroups []sdk.Group, jobID int64
 		}
 	}
 
-	// Common check
+	if !matchRequirements(timestamp, jobID, requirements, model, hostname) {
+		return false
+	}
+
+	return h.CanSpawn(model, jobID, requirements)
+}
+
+// matchRequirements checks the requirements of a job against the capabilities of a worker model
+func matchRequirements(timestamp int64, jobID int64, requirements []sdk.Requirement, model *sdk.Model, hostname string) bool {
 	for _, r := range requirements {
 		// If requirement is a Model requirement, it's easy. It's either can or can't run
 		if r.Type == sdk.ModelRequirement && r.Value != model.Name {
@@ -244,7 +259,7 @@ func canRunJob(h Interface, timestamp int64, execGroups []sdk.Group, jobID int64
 		}
 	}
 
-	return h.CanSpawn(model, jobID, requirements)
+	return true
 }
 
 func logTime(name string, then time.Time, warningSeconds, criticalSeconds int) {
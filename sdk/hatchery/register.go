@@ -17,7 +17,7 @@ import (
 )
 
 // Create creates hatchery
-func Create(h Interface, name, api, token string, maxWorkers int64, provisionDisabled bool, requestSecondsTimeout int, maxFailures int, insecureSkipVerifyTLS bool, provisionSeconds, registerSeconds, warningSeconds, criticalSeconds, graceSeconds int) {
+func Create(h Interface, name, api, token string, maxWorkers int64, provisionDisabled bool, requestSecondsTimeout int, maxFailures int, insecureSkipVerifyTLS bool, provisionSeconds, registerSeconds, warningSeconds, criticalSeconds, graceSeconds int, provisionAdaptive bool, provisionMin, provisionMax, provisionCooldownSeconds int) {
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -75,6 +75,12 @@ func Create(h Interface, name, api, token string, maxWorkers int64, provisionDis
 	var maxWorkersReached bool
 	var models []sdk.Model
 
+	// With the adaptive provisioning, the idle workers of each model are sized from its queue instead of its provision
+	var scaler *autoscaler
+	if provisionAdaptive {
+		scaler = newAutoscaler(provisionMin, provisionMax, time.Duration(provisionCooldownSeconds)*time.Second)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -112,6 +118,10 @@ func Create(h Interface, name, api, token string, maxWorkers int64, provisionDis
 				}
 			}(j)
 		case j := <-wjobs:
+			if j.WaitingForSlot {
+				log.Debug("job %d is waiting for a slot of its project", j.ID)
+				continue
+			}
 			if maxWorkersReached {
 				log.Debug("maxWorkerReached:%d", workersStarted)
 				continue
@@ -125,7 +135,11 @@ func Create(h Interface, name, api, token string, maxWorkers int64, provisionDis
 		case err := <-errs:
 			log.Error("%v", err)
 		case <-tickerProvision.C:
-			provisioning(h, provisionDisabled, models)
+			if scaler == nil || provisionDisabled {
+				provisioning(h, provisionDisabled, models)
+			} else {
+				scaler.provision(h, models, int(maxWorkers-workersStarted), graceSeconds, hostname)
+			}
 		case <-tickerRegister.C:
 			if err := workerRegister(h, models); err != nil {
 				log.Warning("Error on workerRegister: %s", err)
@@ -1,5 +1,12 @@
 package sdk
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
 // Job is the element of a stage
 type Job struct {
 	PipelineActionID int64                  `json:"pipeline_action_id"`
@@ -8,4 +15,238 @@ type Job struct {
 	LastModified     int64                  `json:"last_modified"`
 	Action           Action                 `json:"action"`
 	Warnings         []PipelineBuildWarning `json:"warnings"`
+	Cache            *JobCache              `json:"cache,omitempty"`
+	Timeout          int64                  `json:"timeout,omitempty"` // Timeout of the job in seconds, 0 means the default timeout of the workers
+	Matrix           *JobMatrix             `json:"matrix,omitempty"`
+	MatrixValues     map[string]string      `json:"matrix_values,omitempty"` // Values of the matrix variables of a job expanded from its matrix
+	Needs            []string               `json:"needs,omitempty"`         // Names of the jobs of the stage which have to succeed before the job starts
+}
+
+// MaxJobMatrixCombinations is the maximum number of jobs a matrix can expand into
+const MaxJobMatrixCombinations = 256
+
+// JobMatrix fans out a job over the combinations of the values of its variables.
+// Each combination runs as a job of the stage with the variables cds.matrix.<name>.
+type JobMatrix struct {
+	Variables   map[string][]string `json:"variables"`
+	Include     []map[string]string `json:"include,omitempty"`      // Combinations added to the matrix
+	Exclude     []map[string]string `json:"exclude,omitempty"`      // A combination is excluded if it has all the values of one of these rules
+	MaxParallel int                 `json:"max_parallel,omitempty"` // Maximum number of combinations building at the same time, 0 means no limit
+	FailFast    bool                `json:"fail_fast,omitempty"`    // Stop the other combinations as soon as one of them fails
+}
+
+// JobCache is the configuration of the result cache of a job.
+// The cache key is computed from the actions of the job, its resolved parameters and the content of the input paths.
+type JobCache struct {
+	Enabled bool     `json:"enabled"`
+	Paths   []string `json:"paths,omitempty"` // Input paths relative to the workspace, globs are allowed
+}
+
+// JobCacheEntry is the result of a job recorded in the cache: the artifacts it uploaded and the variables it exported
+type JobCacheEntry struct {
+	ID                int64                     `json:"id"`
+	ProjectID         int64                     `json:"project_id"`
+	Key               string                    `json:"key"`
+	WorkflowName      string                    `json:"workflow_name"`
+	RunNumber         int64                     `json:"run_number"`
+	WorkflowNodeRunID int64                     `json:"workflow_node_run_id"`
+	Artifacts         []WorkflowNodeRunArtifact `json:"artifacts"`
+	Variables         []Variable                `json:"variables"`
+	Created           time.Time                 `json:"created"`
+}
+
+// IsCached returns true if the result cache of the job is enabled
+func (j Job) IsCached() bool {
+	return j.Cache != nil && j.Cache.Enabled
+}
+
+// CheckCache checks that the input paths of an enabled cache are set: the git hash is not part of the cache key,
+// the input paths stand for the sources of the job
+func (j Job) CheckCache() error {
+	if j.IsCached() && len(j.Cache.Paths) == 0 {
+		return WrapError(ErrInvalidJobCache, "job %s has no cache path", j.Action.Name)
+	}
+	return nil
+}
+
+// CheckJobsNeeds checks that the jobs of a stage only need other jobs of the stage, without cycle
+func CheckJobsNeeds(jobs []Job) error {
+	index := make(map[string]int, len(jobs))
+	for i, j := range jobs {
+		index[j.Action.Name] = i
+	}
+	for _, j := range jobs {
+		for _, n := range j.Needs {
+			if _, ok := index[n]; !ok {
+				return WrapError(ErrInvalidJobNeeds, "job %s needs unknown job %s", j.Action.Name, n)
+			}
+		}
+	}
+
+	// 1: the needs of the job are being visited, 2: the job has no cycle
+	state := make([]int, len(jobs))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return WrapError(ErrInvalidJobNeeds, "job %s is in a cycle of needs", jobs[i].Action.Name)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, n := range jobs[i].Needs {
+			if err := visit(index[n]); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range jobs {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsMatrix returns true if the job is fanned out over a matrix
+func (j Job) IsMatrix() bool {
+	return j.Matrix != nil
+}
+
+// ExpandMatrix returns one job per combination of the matrix of the job. The requirements of each job are interpolated
+// with its matrix variables, ie. a model requirement golang-{{.cds.matrix.go}}. A job without matrix, or already
+// expanded, is returned as is.
+func (j Job) ExpandMatrix() ([]Job, error) {
+	if j.Matrix == nil || j.MatrixValues != nil {
+		return []Job{j}, nil
+	}
+
+	combinations, err := j.Matrix.Combinations()
+	if err != nil {
+		return nil, WrapError(err, "job %s", j.Action.Name)
+	}
+
+	jobs := make([]Job, len(combinations))
+	for i, c := range combinations {
+		job := j
+		job.MatrixValues = c
+		job.Action.Name = fmt.Sprintf("%s (%s)", j.Action.Name, matrixValuesString(c))
+		job.Action.Requirements = make([]Requirement, len(j.Action.Requirements))
+		for k, r := range j.Action.Requirements {
+			r.Name = interpolateMatrixValues(r.Name, c)
+			r.Value = interpolateMatrixValues(r.Value, c)
+			job.Action.Requirements[k] = r
+		}
+		jobs[i] = job
+	}
+	return jobs, nil
+}
+
+// Combinations returns the combinations of the values of the variables, without the excluded ones and with the included ones
+func (m JobMatrix) Combinations() ([]map[string]string, error) {
+	names := make([]string, 0, len(m.Variables))
+	for n := range m.Variables {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	combinations := []map[string]string{}
+	if len(names) > 0 {
+		combinations = append(combinations, map[string]string{})
+	}
+	for _, n := range names {
+		values := m.Variables[n]
+		if len(values) == 0 {
+			return nil, WrapError(ErrInvalidJobMatrix, "variable %s has no value", n)
+		}
+		if len(combinations)*len(values) > MaxJobMatrixCombinations {
+			return nil, WrapError(ErrInvalidJobMatrix, "more than %d combinations", MaxJobMatrixCombinations)
+		}
+		next := make([]map[string]string, 0, len(combinations)*len(values))
+		for _, c := range combinations {
+			for _, v := range values {
+				nc := make(map[string]string, len(c)+1)
+				for k := range c {
+					nc[k] = c[k]
+				}
+				nc[n] = v
+				next = append(next, nc)
+			}
+		}
+		combinations = next
+	}
+
+	res := make([]map[string]string, 0, len(combinations))
+	for _, c := range combinations {
+		excluded := false
+		for _, rule := range m.Exclude {
+			if len(rule) > 0 && matrixValuesMatch(c, rule) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			res = append(res, c)
+		}
+	}
+
+	for _, inc := range m.Include {
+		if len(inc) == 0 {
+			continue
+		}
+		var found bool
+		for _, c := range res {
+			if len(c) == len(inc) && matrixValuesMatch(c, inc) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			nc := make(map[string]string, len(inc))
+			for k := range inc {
+				nc[k] = inc[k]
+			}
+			res = append(res, nc)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, WrapError(ErrInvalidJobMatrix, "no combination")
+	}
+	if len(res) > MaxJobMatrixCombinations {
+		return nil, WrapError(ErrInvalidJobMatrix, "more than %d combinations", MaxJobMatrixCombinations)
+	}
+	return res, nil
+}
+
+// matrixValuesMatch returns true if values has all the values of rule
+func matrixValuesMatch(values, rule map[string]string) bool {
+	for k, v := range rule {
+		if values[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func matrixValuesString(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = n + "=" + values[n]
+	}
+	return strings.Join(s, ", ")
+}
+
+func interpolateMatrixValues(s string, values map[string]string) string {
+	for n, v := range values {
+		s = strings.Replace(s, "{{.cds.matrix."+n+"}}", v, -1)
+	}
+	return s
 }
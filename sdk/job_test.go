@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobMatrixCombinations(t *testing.T) {
+	m := JobMatrix{
+		Variables: map[string][]string{
+			"os": {"linux", "windows"},
+			"go": {"1.8", "1.9"},
+		},
+		Exclude: []map[string]string{
+			{"os": "windows", "go": "1.8"},
+		},
+		Include: []map[string]string{
+			{"os": "darwin", "go": "1.9"},
+			{"os": "linux", "go": "1.9"},
+		},
+	}
+
+	combinations, err := m.Combinations()
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"go": "1.8", "os": "linux"},
+		{"go": "1.9", "os": "linux"},
+		{"go": "1.9", "os": "windows"},
+		{"go": "1.9", "os": "darwin"},
+	}, combinations)
+
+	_, err = JobMatrix{Variables: map[string][]string{"os": {}}}.Combinations()
+	assert.Error(t, err)
+
+	_, err = JobMatrix{
+		Variables: map[string][]string{"os": {"linux"}},
+		Exclude:   []map[string]string{{"os": "linux"}},
+	}.Combinations()
+	assert.Error(t, err)
+
+	values := make([]string, 20)
+	_, err = JobMatrix{Variables: map[string][]string{"a": values, "b": values}}.Combinations()
+	assert.Error(t, err)
+}
+
+func TestJobExpandMatrix(t *testing.T) {
+	j := Job{
+		PipelineActionID: 42,
+		Action: Action{
+			Name: "build",
+			Requirements: []Requirement{
+				{Name: "golang-{{.cds.matrix.go}}", Type: ModelRequirement, Value: "golang-{{.cds.matrix.go}}"},
+				{Name: "git", Type: BinaryRequirement, Value: "git"},
+			},
+		},
+	}
+
+	jobs, err := j.ExpandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, []Job{j}, jobs)
+
+	j.Matrix = &JobMatrix{
+		Variables:   map[string][]string{"go": {"1.8", "1.9"}},
+		MaxParallel: 1,
+	}
+	jobs, err = j.ExpandMatrix()
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "build (go=1.8)", jobs[0].Action.Name)
+	assert.Equal(t, map[string]string{"go": "1.8"}, jobs[0].MatrixValues)
+	assert.Equal(t, "golang-1.8", jobs[0].Action.Requirements[0].Value)
+	assert.Equal(t, "git", jobs[0].Action.Requirements[1].Value)
+	assert.Equal(t, "build (go=1.9)", jobs[1].Action.Name)
+	assert.Equal(t, "golang-1.9", jobs[1].Action.Requirements[0].Value)
+	assert.Equal(t, int64(42), jobs[1].PipelineActionID)
+	assert.Equal(t, "golang-{{.cds.matrix.go}}", j.Action.Requirements[0].Value)
+
+	// an expanded job is not expanded again, ie. when it is restarted
+	again, err := jobs[1].ExpandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, []Job{jobs[1]}, again)
+}
+
+func TestCheckJobsNeeds(t *testing.T) {
+	job := func(name string, needs ...string) Job {
+		return Job{Action: Action{Name: name}, Needs: needs}
+	}
+
+	assert.NoError(t, CheckJobsNeeds(nil))
+	assert.NoError(t, CheckJobsNeeds([]Job{job("test"), job("lint"), job("package", "test", "lint"), job("deploy", "package")}))
+	assert.Error(t, CheckJobsNeeds([]Job{job("test"), job("package", "build")}))
+	assert.Error(t, CheckJobsNeeds([]Job{job("test", "test")}))
+	assert.Error(t, CheckJobsNeeds([]Job{job("test", "deploy"), job("package", "test"), job("deploy", "package")}))
+}
+
+func TestJobCheckCache(t *testing.T) {
+	j := Job{Action: Action{Name: "build"}}
+	assert.NoError(t, j.CheckCache())
+
+	j.Cache = &JobCache{Enabled: true}
+	assert.Error(t, j.CheckCache())
+
+	j.Cache.Paths = []string{"go.sum"}
+	assert.NoError(t, j.CheckCache())
+}
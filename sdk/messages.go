@@ -60,6 +60,13 @@ var (
 	MsgSpawnInfoJobError                   = &Message{"MsgSpawnInfoJobError", trad{FR: "Impossible de lancer ce job : %s", EN: "Unable to run this job: %s"}, nil}
 	MsgWorkflowStarting                    = &Message{"MsgWorkflowStarting", trad{FR: "Le workflow %s#%s a été démarré", EN: "Workflow %s#%s has been started"}, nil}
 	MsgWorkflowError                       = &Message{"MsgWorkflowError", trad{FR: "Une erreur est survenue: %v", EN: "An error has occured: %v"}, nil}
+	MsgWorkflowNodeStop                    = &Message{"MsgWorkflowNodeStop", trad{FR: "Le pipeline %s a été arrêté par %s", EN: "Pipeline %s has been stopped by %s"}, nil}
+	MsgWorkflowNodeWaitingApproval         = &Message{"MsgWorkflowNodeWaitingApproval", trad{FR: "Le pipeline %s attend %d approbation(s)", EN: "Pipeline %s is waiting for %d approval(s)"}, nil}
+	MsgWorkflowNodeApproved                = &Message{"MsgWorkflowNodeApproved", trad{FR: "Le pipeline %s a été approuvé par %s: %s", EN: "Pipeline %s has been approved by %s: %s"}, nil}
+	MsgWorkflowNodeRejected                = &Message{"MsgWorkflowNodeRejected", trad{FR: "Le pipeline %s a été rejeté par %s: %s", EN: "Pipeline %s has been rejected by %s: %s"}, nil}
+	MsgSpawnInfoJobCached                  = &Message{"MsgSpawnInfoJobCached", trad{FR: "Job ignoré (en cache) : les résultats du workflow %s #%d ont été restaurés", EN: "Job skipped (cached): results of workflow %s #%d have been restored"}, nil}
+	MsgWorkflowRunOutdated                 = &Message{"MsgWorkflowRunOutdated", trad{FR: "Le workflow a été arrêté, la pull request %s a été mise à jour avec le commit %s", EN: "Workflow has been stopped, pull request %s has been updated with commit %s"}, nil}
+	MsgSpawnInfoJobNeedsFailed             = &Message{"MsgSpawnInfoJobNeedsFailed", trad{FR: "Job ignoré : le job %s dont il dépend n'a pas réussi", EN: "Job skipped: job %s it needs has not succeeded"}, nil}
 )
 
 // Messages contains all sdk Messages
@@ -103,6 +110,14 @@ var Messages = map[string]*Message{
 	MsgSpawnInfoWorkerForJob.ID:               MsgSpawnInfoWorkerForJob,
 	MsgSpawnInfoWorkerForJobError.ID:          MsgSpawnInfoWorkerForJobError,
 	MsgWorkflowStarting.ID:                    MsgWorkflowStarting,
+	MsgWorkflowError.ID:                       MsgWorkflowError,
+	MsgWorkflowNodeStop.ID:                    MsgWorkflowNodeStop,
+	MsgWorkflowNodeWaitingApproval.ID:         MsgWorkflowNodeWaitingApproval,
+	MsgWorkflowNodeApproved.ID:                MsgWorkflowNodeApproved,
+	MsgWorkflowNodeRejected.ID:                MsgWorkflowNodeRejected,
+	MsgSpawnInfoJobCached.ID:                  MsgSpawnInfoJobCached,
+	MsgWorkflowRunOutdated.ID:                 MsgWorkflowRunOutdated,
+	MsgSpawnInfoJobNeedsFailed.ID:             MsgSpawnInfoJobNeedsFailed,
 }
 
 //Message represent a struc format translated messages
@@ -11,20 +11,22 @@ import (
 
 // Project represent a team with group of users and pipelines
 type Project struct {
-	ID            int64                 `json:"-" yaml:"-" db:"id" cli:"-"`
-	Key           string                `json:"key" yaml:"key" db:"projectkey" cli:"key,key"`
-	Name          string                `json:"name" yaml:"name" db:"name" cli:"name"`
-	Pipelines     []Pipeline            `json:"pipelines,omitempty" yaml:"pipelines,omitempty" db:"-"  cli:"-"`
-	Applications  []Application         `json:"applications,omitempty" yaml:"applications,omitempty" db:"-"  cli:"-"`
-	ProjectGroups []GroupPermission     `json:"groups,omitempty" yaml:"permissions,omitempty" db:"-"  cli:"-"`
-	Variable      []Variable            `json:"variables,omitempty" yaml:"variables,omitempty" db:"-"  cli:"-"`
-	Environments  []Environment         `json:"environments,omitempty"  yaml:"environments,omitempty" db:"-"  cli:"-"`
-	Permission    int                   `json:"permission"  yaml:"-" db:"-"  cli:"-"`
-	Created       time.Time             `json:"created"  yaml:"created" db:"created" `
-	LastModified  time.Time             `json:"last_modified"  yaml:"last_modified" db:"last_modified"`
-	ReposManager  []RepositoriesManager `json:"repositories_manager"  yaml:"-" db:"-" cli:"-"`
-	Metadata      Metadata              `json:"metadata" yaml:"metadata" db:"-" cli:"-"`
-	Keys          []ProjectKey          `json:"keys" yaml:"keys" db:"-" cli:"-"`
+	ID                  int64                 `json:"-" yaml:"-" db:"id" cli:"-"`
+	Key                 string                `json:"key" yaml:"key" db:"projectkey" cli:"key,key"`
+	Name                string                `json:"name" yaml:"name" db:"name" cli:"name"`
+	Pipelines           []Pipeline            `json:"pipelines,omitempty" yaml:"pipelines,omitempty" db:"-"  cli:"-"`
+	Applications        []Application         `json:"applications,omitempty" yaml:"applications,omitempty" db:"-"  cli:"-"`
+	ProjectGroups       []GroupPermission     `json:"groups,omitempty" yaml:"permissions,omitempty" db:"-"  cli:"-"`
+	Variable            []Variable            `json:"variables,omitempty" yaml:"variables,omitempty" db:"-"  cli:"-"`
+	Environments        []Environment         `json:"environments,omitempty"  yaml:"environments,omitempty" db:"-"  cli:"-"`
+	Permission          int                   `json:"permission"  yaml:"-" db:"-"  cli:"-"`
+	Created             time.Time             `json:"created"  yaml:"created" db:"created" `
+	LastModified        time.Time             `json:"last_modified"  yaml:"last_modified" db:"last_modified"`
+	ReposManager        []RepositoriesManager `json:"repositories_manager"  yaml:"-" db:"-" cli:"-"`
+	Metadata            Metadata              `json:"metadata" yaml:"metadata" db:"-" cli:"-"`
+	Keys                []ProjectKey          `json:"keys" yaml:"keys" db:"-" cli:"-"`
+	MaxConcurrentJobs   int                   `json:"max_concurrent_jobs,omitempty" yaml:"-" db:"max_concurrent_jobs" cli:"-"`     // Number of jobs of the project which can run at the same time, 0 means unlimited
+	MaxWorkflowPriority int                   `json:"max_workflow_priority,omitempty" yaml:"-" db:"max_workflow_priority" cli:"-"` // Highest priority of the workflows of the project
 }
 
 // ProjectVariableAudit represents an audit on a project variable
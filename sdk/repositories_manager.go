@@ -16,6 +16,12 @@ const (
 	Stash RepositoriesManagerType = "STASH"
 	//Github is valued to "GITHUB"
 	Github RepositoriesManagerType = "GITHUB"
+	//Gitlab is valued to "GITLAB"
+	Gitlab RepositoriesManagerType = "GITLAB"
+	//Gitea is valued to "GITEA", it also supports Gogs
+	Gitea RepositoriesManagerType = "GITEA"
+	//Git is valued to "GIT", it supports any git server through the git command line
+	Git RepositoriesManagerType = "GIT"
 )
 
 //RepositoriesManager is the struct for every repositories manager.
@@ -310,6 +316,9 @@ type RepositoriesManagerClient interface {
 	// Set build status on repository
 	SetStatus(event Event) error
 
+	// Comment a pull request
+	PullRequestComment(repo string, id int64, body string) error
+
 	// Release
 	Release(repo, tagName, releaseTitle, releaseDescription string) (*VCSRelease, error)
 	UploadReleaseFile(repo string, release *VCSRelease, runArtifact WorkflowNodeRunArtifact, file *bytes.Buffer) error
@@ -374,8 +383,10 @@ type VCSDeleteEvent struct {
 
 //VCSPullRequestEvent represents a push events for polling
 type VCSPullRequestEvent struct {
-	Action string       `json:"action"` // opened | closed
+	ID     int64        `json:"id"`
+	Action string       `json:"action"` // opened | synchronize | closed
 	URL    string       `json:"url"`
+	Ref    string       `json:"ref"` // Reference to fetch the head of the pull request, ie. refs/pull/1/head
 	User   VCSAuthor    `json:"user"`
 	Head   VCSPushEvent `json:"head"`
 	Base   VCSPushEvent `json:"base"`
@@ -0,0 +1,21 @@
+package sdk
+
+import "time"
+
+//SecretRotation is the progress of the encryption of the secrets with the last key of the keyring
+type SecretRotation struct {
+	Running    bool                  `json:"running"`
+	KeyVersion int                   `json:"key_version"`
+	Start      time.Time             `json:"start"`
+	End        time.Time             `json:"end,omitempty"`
+	Tables     []SecretRotationTable `json:"tables"`
+	Error      string                `json:"error,omitempty"`
+}
+
+//SecretRotationTable is the progress of the rotation on a table
+type SecretRotationTable struct {
+	Name    string `json:"name"`
+	Total   int64  `json:"total"`
+	Done    int64  `json:"done"`
+	Rotated int64  `json:"rotated"`
+}
@@ -22,6 +22,9 @@ type CloneOpts struct {
 	Quiet                   bool
 	CheckoutCommit          string
 	NoStrictHostKeyChecking bool
+	// MergeRef is fetched after the clone and merged in the checked out branch, ie. the head of a pull request.
+	// With a CheckoutCommit, this exact commit of MergeRef is merged.
+	MergeRef string
 }
 
 // Clone make a git clone
@@ -106,7 +109,7 @@ func gitCloneCommand(repo string, path string, opts *CloneOpts) cmds {
 			gitcmd.args = append(gitcmd.args, "--verbose")
 		}
 
-		if opts.CheckoutCommit == "" {
+		if opts.CheckoutCommit == "" && opts.MergeRef == "" {
 			if opts.Depth != 0 {
 				gitcmd.args = append(gitcmd.args, "--depth", fmt.Sprintf("%d", opts.Depth))
 			}
@@ -131,21 +134,41 @@ func gitCloneCommand(repo string, path string, opts *CloneOpts) cmds {
 
 	allCmd = append(allCmd, gitcmd)
 
-	if opts != nil && opts.CheckoutCommit != "" {
+	//Locate the next commands to the right directory
+	dir := path
+	if dir == "" {
+		t := strings.Split(repo, "/")
+		dir = strings.TrimSuffix(t[len(t)-1], ".git")
+	}
+
+	if opts != nil && opts.CheckoutCommit != "" && opts.MergeRef == "" {
 		resetCmd := cmd{
+			dir:  dir,
 			cmd:  "git",
 			args: []string{"reset", "--hard", opts.CheckoutCommit},
 		}
-		//Locate the git reset cmd to the right directory
-		if path == "" {
-			t := strings.Split(repo, "/")
-			resetCmd.dir = strings.TrimSuffix(t[len(t)-1], ".git")
-		} else {
-			resetCmd.dir = path
-		}
-
 		allCmd = append(allCmd, resetCmd)
 	}
 
+	if opts != nil && opts.MergeRef != "" {
+		fetchCmd := cmd{
+			dir:  dir,
+			cmd:  "git",
+			args: []string{"fetch", "--quiet", "origin", opts.MergeRef},
+		}
+		//The ref may have moved since the commit to build
+		merged := "FETCH_HEAD"
+		if opts.CheckoutCommit != "" {
+			merged = opts.CheckoutCommit
+		}
+		//The merge commit is only local, its author doesn't matter
+		mergeCmd := cmd{
+			dir:  dir,
+			cmd:  "git",
+			args: []string{"-c", "user.name=CDS", "-c", "user.email=cds@localhost", "merge", "--no-edit", merged},
+		}
+		allCmd = append(allCmd, fetchCmd, mergeCmd)
+	}
+
 	return cmds(allCmd)
 }
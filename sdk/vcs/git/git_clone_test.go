@@ -0,0 +1,80 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ovh/cds/sdk/vcs"
+)
+
+func TestCloneMergeRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmp, err := ioutil.TempDir("", "cds-git")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	assert.NoError(t, os.MkdirAll(repo, 0700))
+	gitInDir(t, repo, "init", "--quiet")
+	gitInDir(t, repo, "checkout", "--quiet", "-b", "master")
+	gitInDir(t, repo, "commit", "--quiet", "--allow-empty", "-m", "first commit")
+	gitInDir(t, repo, "checkout", "--quiet", "-b", "feature")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, "feature.txt"), []byte("feature"), 0600))
+	gitInDir(t, repo, "add", "feature.txt")
+	gitInDir(t, repo, "commit", "--quiet", "-m", "feature commit")
+	gitInDir(t, repo, "update-ref", "refs/pull/1/head", "feature")
+	hash := revParse(t, repo, "feature")
+	// the pull request has been updated since the commit to build
+	gitInDir(t, repo, "commit", "--quiet", "--allow-empty", "-m", "newer feature commit")
+	gitInDir(t, repo, "update-ref", "refs/pull/1/head", "feature")
+	gitInDir(t, repo, "checkout", "--quiet", "master")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repo, "master.txt"), []byte("master"), 0600))
+	gitInDir(t, repo, "add", "master.txt")
+	gitInDir(t, repo, "commit", "--quiet", "-m", "master commit")
+
+	//Local repositories don't need the key, but the auth is required out of https
+	auth := &AuthOpts{PrivateKey: vcs.SSHKey{Filename: filepath.Join(tmp, "id_rsa")}}
+	dir := filepath.Join(tmp, "clone")
+	assert.NoError(t, Clone(repo, dir, auth, &CloneOpts{Branch: "master", MergeRef: "refs/pull/1/head", Depth: 1}, nil))
+
+	_, err = os.Stat(filepath.Join(dir, "feature.txt"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "master.txt"))
+	assert.NoError(t, err)
+
+	commits, err := Log(dir, &LogOpts{MaxCount: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, commits, 1) {
+		assert.Len(t, commits[0].Parents, 2)
+		assert.True(t, strings.HasPrefix(commits[0].Message, "Merge"))
+	}
+
+	// the exact commit is merged, not the current head of the pull request
+	exact := filepath.Join(tmp, "exact")
+	assert.NoError(t, Clone(repo, exact, auth, &CloneOpts{Branch: "master", MergeRef: "refs/pull/1/head", CheckoutCommit: hash}, nil))
+	commits, err = Log(exact, &LogOpts{MaxCount: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, commits, 1) && assert.Len(t, commits[0].Parents, 2) {
+		assert.Equal(t, revParse(t, repo, "master"), commits[0].Parents[0])
+		assert.Equal(t, hash, commits[0].Parents[1])
+	}
+}
+
+func revParse(t *testing.T, dir, rev string) string {
+	c := exec.Command("git", "rev-parse", rev)
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse %s: %s", rev, err)
+	}
+	return strings.TrimSpace(string(out))
+}
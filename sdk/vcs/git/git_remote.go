@@ -0,0 +1,260 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ref is a git reference and the commit it points to
+type Ref struct {
+	Name   string
+	Hash   string
+	Target string // For symbolic references like HEAD, the referenced ref
+}
+
+// LsRemoteOpts represents options for git ls-remote command
+type LsRemoteOpts struct {
+	Heads                   bool
+	Tags                    bool
+	NoStrictHostKeyChecking bool
+}
+
+// MirrorOpts represents options for the mirror of a remote repository
+type MirrorOpts struct {
+	NoStrictHostKeyChecking bool
+}
+
+// LogOpts represents options for git log command
+type LogOpts struct {
+	Revisions []string // ie. "master" or "^since", "until"
+	MaxCount  int
+}
+
+// Commit is a commit read by Log
+type Commit struct {
+	Hash          string
+	Parents       []string
+	AuthorName    string
+	AuthorEmail   string
+	AuthorDate    time.Time
+	CommitterDate time.Time
+	Message       string
+}
+
+// isLocal returns true if repo is a path on the local filesystem
+func isLocal(repo string) bool {
+	return strings.HasPrefix(repo, "file://") || filepath.IsAbs(repo)
+}
+
+// remoteEnv returns the repository URL to use and the environment needed to run a command on a remote repository
+func remoteEnv(repo string, auth *AuthOpts, noStrictHostKeyChecking bool) (string, []string, error) {
+	if strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "ftp://") || strings.HasPrefix(repo, "ftps://") {
+		return "", nil, fmt.Errorf("Git protocol not supported")
+	}
+
+	if isLocal(repo) {
+		return repo, nil, nil
+	}
+
+	if strings.HasPrefix(repo, "https://") {
+		if auth == nil || auth.Username == "" {
+			return repo, nil, nil
+		}
+		u, err := url.Parse(repo)
+		if err != nil {
+			return "", nil, err
+		}
+		u.User = url.UserPassword(auth.Username, auth.Password)
+		return u.String(), nil, nil
+	}
+
+	if auth == nil {
+		return "", nil, fmt.Errorf("Authentication is required for git over ssh")
+	}
+
+	gitSSHCmd := exec.Command("ssh").Path
+	if noStrictHostKeyChecking {
+		gitSSHCmd += " -o StrictHostKeyChecking=no"
+	}
+	gitSSHCmd += " -i " + auth.PrivateKey.Filename
+
+	var wrapper string
+	if runtime.GOOS == "windows" {
+		gitSSHCmd += " %*"
+		wrapper = gitSSHCmd
+	} else {
+		gitSSHCmd += ` "$@"`
+		wrapper = `#!/bin/sh
+` + gitSSHCmd
+	}
+
+	// The wrapper may be used by concurrent commands, it is only written if it has changed
+	wrapperPath := filepath.Join(filepath.Dir(auth.PrivateKey.Filename), "gitwrapper-"+filepath.Base(auth.PrivateKey.Filename))
+	if b, err := ioutil.ReadFile(wrapperPath); err != nil || string(b) != wrapper {
+		if err := ioutil.WriteFile(wrapperPath, []byte(wrapper), os.FileMode(0700)); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return repo, []string{"GIT_SSH=" + wrapperPath}, nil
+}
+
+// LsRemote lists the references of a remote repository
+func LsRemote(repo string, auth *AuthOpts, opts *LsRemoteOpts) ([]Ref, error) {
+	remote, envs, err := remoteEnv(repo, auth, opts != nil && opts.NoStrictHostKeyChecking)
+	if err != nil {
+		return nil, err
+	}
+
+	c := cmd{
+		cmd:  "git",
+		args: []string{"ls-remote", "--symref"},
+	}
+	if opts != nil {
+		if opts.Heads {
+			c.args = append(c.args, "--heads")
+		}
+		if opts.Tags {
+			c.args = append(c.args, "--tags")
+		}
+	}
+	c.args = append(c.args, remote)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	if err := runCommand(cmds{c}, &OutputOpts{Stdout: stdout, Stderr: stderr}, envs...); err != nil {
+		return nil, fmt.Errorf("git ls-remote failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseLsRemote(stdout.String()), nil
+}
+
+func parseLsRemote(s string) []Ref {
+	refs := []Ref{}
+	targets := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		t := strings.Split(strings.TrimSpace(line), "\t")
+		if len(t) != 2 {
+			continue
+		}
+		// Symbolic references are listed as "ref: refs/heads/master	HEAD"
+		if strings.HasPrefix(t[0], "ref: ") {
+			targets[t[1]] = strings.TrimPrefix(t[0], "ref: ")
+			continue
+		}
+		refs = append(refs, Ref{Name: t[1], Hash: t[0]})
+	}
+	for i := range refs {
+		refs[i].Target = targets[refs[i].Name]
+	}
+	return refs
+}
+
+// Mirror creates a bare mirror of a remote repository in path, or updates it if it already exists
+func Mirror(repo string, path string, auth *AuthOpts, opts *MirrorOpts, output *OutputOpts) error {
+	remote, envs, err := remoteEnv(repo, auth, opts != nil && opts.NoStrictHostKeyChecking)
+	if err != nil {
+		return err
+	}
+
+	var c cmd
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+		c = cmd{
+			dir:  path,
+			cmd:  "git",
+			args: []string{"fetch", "--prune", "--quiet", remote, "+refs/*:refs/*"},
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0700)); err != nil {
+			return err
+		}
+		c = cmd{
+			cmd:  "git",
+			args: []string{"clone", "--mirror", "--quiet", remote, path},
+		}
+	}
+
+	return runCommand(cmds{c}, output, envs...)
+}
+
+// revisionRegexp matches the revisions accepted by Log: a hash or a ref name, optionally excluded with ^
+var revisionRegexp = regexp.MustCompile(`^\^?[a-zA-Z0-9_][a-zA-Z0-9_./-]*$`)
+
+const (
+	logFieldSeparator  = "\x1f"
+	logRecordSeparator = "\x1e"
+	logFormat          = "%H%x1f%P%x1f%an%x1f%ae%x1f%at%x1f%ct%x1f%B%x1e"
+)
+
+// Log returns the commits of a local repository
+func Log(dir string, opts *LogOpts) ([]Commit, error) {
+	c := cmd{
+		dir:  dir,
+		cmd:  "git",
+		args: []string{"log", "--format=" + logFormat},
+	}
+	if opts != nil {
+		if opts.MaxCount > 0 {
+			c.args = append(c.args, "--max-count", strconv.Itoa(opts.MaxCount))
+		}
+		// The revisions may come from the users, they must never be parsed as options
+		for _, r := range opts.Revisions {
+			if !revisionRegexp.MatchString(r) || strings.Contains(r, "..") {
+				return nil, fmt.Errorf("Invalid git revision %s", r)
+			}
+		}
+		c.args = append(c.args, "--end-of-options")
+		c.args = append(c.args, opts.Revisions...)
+	}
+	c.args = append(c.args, "--")
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	if err := runCommand(cmds{c}, &OutputOpts{Stdout: stdout, Stderr: stderr}); err != nil {
+		return nil, fmt.Errorf("git log failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseLog(stdout.String())
+}
+
+func parseLog(s string) ([]Commit, error) {
+	commits := []Commit{}
+	for _, record := range strings.Split(s, logRecordSeparator) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		t := strings.SplitN(record, logFieldSeparator, 7)
+		if len(t) != 7 {
+			return nil, fmt.Errorf("Unable to parse git log output: %s", record)
+		}
+		authorDate, err := strconv.ParseInt(t[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse git log author date %s", t[4])
+		}
+		committerDate, err := strconv.ParseInt(t[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse git log committer date %s", t[5])
+		}
+		commits = append(commits, Commit{
+			Hash:          t[0],
+			Parents:       strings.Fields(t[1]),
+			AuthorName:    t[2],
+			AuthorEmail:   t[3],
+			AuthorDate:    time.Unix(authorDate, 0),
+			CommitterDate: time.Unix(committerDate, 0),
+			Message:       strings.TrimSpace(t[6]),
+		})
+	}
+	return commits, nil
+}
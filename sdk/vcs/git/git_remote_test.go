@@ -0,0 +1,100 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gitInDir(t *testing.T, dir string, args ...string) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=John Doe", "GIT_AUTHOR_EMAIL=john@example.com",
+		"GIT_COMMITTER_NAME=John Doe", "GIT_COMMITTER_EMAIL=john@example.com",
+	)
+	if out, err := c.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+func TestLsRemoteMirrorLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmp, err := ioutil.TempDir("", "cds-git")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	repo := filepath.Join(tmp, "repo")
+	assert.NoError(t, os.MkdirAll(repo, 0700))
+	gitInDir(t, repo, "init", "--quiet")
+	gitInDir(t, repo, "checkout", "--quiet", "-b", "master")
+	gitInDir(t, repo, "commit", "--quiet", "--allow-empty", "-m", "first commit")
+	gitInDir(t, repo, "commit", "--quiet", "--allow-empty", "-m", "second commit\n\nwith a body")
+	gitInDir(t, repo, "branch", "feature")
+	gitInDir(t, repo, "tag", "v1.0")
+
+	refs, err := LsRemote(repo, nil, &LsRemoteOpts{Heads: true})
+	assert.NoError(t, err)
+	assert.Len(t, refs, 2)
+	names := []string{}
+	for _, r := range refs {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"refs/heads/feature", "refs/heads/master"}, names)
+
+	refs, err = LsRemote(repo, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "HEAD", refs[0].Name)
+	assert.Equal(t, "refs/heads/master", refs[0].Target)
+
+	_, err = LsRemote("git@localhost:repo.git", nil, nil)
+	assert.Error(t, err)
+
+	mirror := filepath.Join(tmp, "mirrors", "repo.git")
+	assert.NoError(t, Mirror(repo, mirror, nil, nil, nil))
+
+	commits, err := Log(mirror, &LogOpts{Revisions: []string{"master"}})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "second commit\n\nwith a body", commits[0].Message)
+	assert.Equal(t, "John Doe", commits[0].AuthorName)
+	assert.Equal(t, "john@example.com", commits[0].AuthorEmail)
+	assert.Equal(t, []string{commits[1].Hash}, commits[0].Parents)
+	assert.False(t, commits[0].CommitterDate.IsZero())
+
+	gitInDir(t, repo, "commit", "--quiet", "--allow-empty", "-m", "third commit")
+	gitInDir(t, repo, "branch", "-D", "feature")
+	assert.NoError(t, Mirror(repo, mirror, nil, nil, nil))
+
+	refs, err = LsRemote(mirror, nil, &LsRemoteOpts{Heads: true})
+	assert.NoError(t, err)
+	assert.Len(t, refs, 1)
+
+	commits, err = Log(mirror, &LogOpts{Revisions: []string{"^" + commits[1].Hash, "master"}})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "third commit", commits[0].Message)
+
+	commits, err = Log(mirror, &LogOpts{Revisions: []string{"master"}, MaxCount: 1})
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+
+	_, err = Log(mirror, &LogOpts{Revisions: []string{"unknown"}})
+	assert.Error(t, err)
+
+	// a revision is never parsed as an option
+	output := filepath.Join(tmp, "output")
+	_, err = Log(mirror, &LogOpts{Revisions: []string{"--output=" + output}})
+	assert.Error(t, err)
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+	_, err = Log(mirror, &LogOpts{Revisions: []string{"master..feature"}})
+	assert.Error(t, err)
+}
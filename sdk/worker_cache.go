@@ -0,0 +1,38 @@
+package sdk
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// WorkerCacheKeyPattern is the pattern of the keys of the caches saved by the workers
+const WorkerCacheKeyPattern = "^[a-zA-Z0-9._-]{1,256}$"
+
+var workerCacheKeyRegexp = regexp.MustCompile(WorkerCacheKeyPattern)
+
+// WorkerCache is a compressed archive of directories saved by a worker with the CacheSave action,
+// to be restored by the next jobs of the project with the CacheRestore action
+type WorkerCache struct {
+	ID        int64     `json:"id"`
+	ProjectID int64     `json:"project_id"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	Created   time.Time `json:"created"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+//GetName returns the name of the cache in the objectstore
+func (c *WorkerCache) GetName() string {
+	return c.Key + ".tar.gz"
+}
+
+//GetPath returns the path of the cache in the objectstore
+func (c *WorkerCache) GetPath() string {
+	return fmt.Sprintf("cache-%d", c.ProjectID)
+}
+
+// IsValidWorkerCacheKey returns true if key can be used as a cache key
+func IsValidWorkerCacheKey(key string) bool {
+	return workerCacheKeyRegexp.MatchString(key)
+}
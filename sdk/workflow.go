@@ -17,6 +17,8 @@ type Workflow struct {
 	RootID       int64              `json:"root_id,omitempty" db:"root_node_id" cli:"-"`
 	Root         *WorkflowNode      `json:"root" db:"-" cli:"-"`
 	Joins        []WorkflowNodeJoin `json:"joins,omitempty" db:"-" cli:"-"`
+	Groups       []GroupPermission  `json:"groups,omitempty" db:"-" cli:"-"`
+	Priority     int                `json:"priority,omitempty" db:"priority" cli:"-"`
 }
 
 //JoinsID returns joins ID
@@ -64,6 +66,26 @@ func (w *Workflow) GetNode(id int64) *WorkflowNode {
 	return nil
 }
 
+//GetHooks returns all hooks used in the workflow indexed by their UUID
+func (w *Workflow) GetHooks() map[string]WorkflowNodeHook {
+	if w.Root == nil {
+		return nil
+	}
+
+	res := map[string]WorkflowNodeHook{}
+	for k, v := range w.Root.GetHooks() {
+		res[k] = v
+	}
+	for _, j := range w.Joins {
+		for _, t := range j.Triggers {
+			for k, v := range t.WorkflowDestNode.GetHooks() {
+				res[k] = v
+			}
+		}
+	}
+	return res
+}
+
 //GetJoin returns the join given its id
 func (w *Workflow) GetJoin(id int64) *WorkflowNodeJoin {
 	for _, j := range w.Joins {
@@ -231,6 +253,20 @@ func (n *WorkflowNode) GetNode(id int64) *WorkflowNode {
 	return nil
 }
 
+//GetHooks returns all hooks of the node and its children indexed by their UUID
+func (n *WorkflowNode) GetHooks() map[string]WorkflowNodeHook {
+	res := map[string]WorkflowNodeHook{}
+	for _, h := range n.Hooks {
+		res[h.UUID] = h
+	}
+	for _, t := range n.Triggers {
+		for k, v := range t.WorkflowDestNode.GetHooks() {
+			res[k] = v
+		}
+	}
+	return res
+}
+
 //Nodes returns a slice with all node IDs
 func (n *WorkflowNode) Nodes() []int64 {
 	res := []int64{n.ID}
@@ -338,14 +374,28 @@ type WorkflowTriggerCondition struct {
 
 //WorkflowNodeContext represents a context attached on a node
 type WorkflowNodeContext struct {
-	ID                        int64        `json:"id" db:"id"`
-	WorkflowNodeID            int64        `json:"workflow_node_id" db:"workflow_node_id"`
-	ApplicationID             int64        `json:"application_id" db:"application_id"`
-	Application               *Application `json:"application,omitempty" db:"-"`
-	Environment               *Environment `json:"environment,omitempty" db:"-"`
-	EnvironmentID             int64        `json:"environment_id" db:"environment_id"`
-	DefaultPayload            interface{}  `json:"default_payload,omitempty" db:"-"`
-	DefaultPipelineParameters []Parameter  `json:"default_pipeline_parameters,omitempty" db:"-"`
+	ID                        int64                 `json:"id" db:"id"`
+	WorkflowNodeID            int64                 `json:"workflow_node_id" db:"workflow_node_id"`
+	ApplicationID             int64                 `json:"application_id" db:"application_id"`
+	Application               *Application          `json:"application,omitempty" db:"-"`
+	Environment               *Environment          `json:"environment,omitempty" db:"-"`
+	EnvironmentID             int64                 `json:"environment_id" db:"environment_id"`
+	DefaultPayload            interface{}           `json:"default_payload,omitempty" db:"-"`
+	DefaultPipelineParameters []Parameter           `json:"default_pipeline_parameters,omitempty" db:"-"`
+	ProtectedManualRun        bool                  `json:"protected_manual_run" db:"protected_manual_run"`
+	Approval                  *WorkflowNodeApproval `json:"approval,omitempty" db:"-"`
+}
+
+//WorkflowNodeApproval represents the approvals required before running a node.
+//Approvers must belong to one of the groups, or have the approve permission on the workflow if there is no group.
+type WorkflowNodeApproval struct {
+	NbApprovers int      `json:"nb_approvers"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+//IsRequired returns true if the node has to be approved before running
+func (a *WorkflowNodeApproval) IsRequired() bool {
+	return a != nil && a.NbApprovers > 0
 }
 
 //WorkflowNodeHook represents a hook which cann trigger the workflow from a given node
@@ -359,8 +409,31 @@ type WorkflowNodeHook struct {
 	Config              WorkflowNodeHookConfig     `json:"config" db:"-"`
 }
 
+//WorkflowHookModelBuiltin is the type of the hook models provided by CDS
 var WorkflowHookModelBuiltin = "builtin"
 
+//Configuration keys of the builtin hook models
+const (
+	WorkflowNodeHookConfigWebHookURL   = "webHookURL"
+	WorkflowNodeHookConfigCron         = "cron"
+	WorkflowNodeHookConfigTimezone     = "timezone"
+	WorkflowNodeHookConfigPayload      = "payload"
+	WorkflowNodeHookConfigDelay        = "delay"
+	WorkflowNodeHookConfigPullRequests = "pullRequests"
+)
+
+//WorkflowNodeHookExecution represents a planned or done execution of a workflow node hook
+type WorkflowNodeHookExecution struct {
+	ID                   int64             `json:"id" db:"id"`
+	WorkflowNodeHookUUID string            `json:"workflow_node_hook_uuid" db:"workflow_node_hook_uuid"`
+	ExecutionPlannedDate time.Time         `json:"execution_planned_date" db:"execution_planned_date"`
+	ExecutionDate        *time.Time        `json:"execution_date" db:"execution_date"`
+	Executed             bool              `json:"executed" db:"executed"`
+	WorkflowRunNumber    int64             `json:"workflow_run_number" db:"workflow_run_number"`
+	Error                string            `json:"error,omitempty" db:"error"`
+	Payload              map[string]string `json:"payload,omitempty" db:"-"`
+}
+
 //WorkflowNodeHookConfig represents the configguration for a WorkflowNodeHook
 type WorkflowNodeHookConfig map[string]string
 
@@ -3,7 +3,6 @@ package sdk
 import (
 	"fmt"
 	"regexp"
-	"strings"
 )
 
 // Workflow conditions operator
@@ -15,6 +14,7 @@ const (
 	WorkflowConditionsOperatorGreaterThan        = "gt"
 	WorkflowConditionsOperatorGreaterOrEqualThan = "ge"
 	WorkflowConditionsOperatorRegex              = "regex"
+	WorkflowConditionsOperatorExpression         = "expr"
 )
 
 // Workflow conditions operator
@@ -27,6 +27,7 @@ var (
 		WorkflowConditionsOperatorGreaterThan:        ">",
 		WorkflowConditionsOperatorGreaterOrEqualThan: ">=",
 		WorkflowConditionsOperatorRegex:              "match",
+		WorkflowConditionsOperatorExpression:         "expression",
 	}
 )
 
@@ -57,16 +58,16 @@ func WorkflowCheckConditions(conditions []WorkflowTriggerCondition, params []Par
 			conditionsOK = conditionsOK && cond.Value != mapParams[cond.Variable]
 
 		case WorkflowConditionsOperatorLessThan:
-			conditionsOK = conditionsOK && strings.Compare(mapParams[cond.Variable], cond.Value) < 0
+			conditionsOK = conditionsOK && compareConditionValues(mapParams[cond.Variable], cond.Value) < 0
 
 		case WorkflowConditionsOperatorLessOrEqualThan:
-			conditionsOK = conditionsOK && strings.Compare(mapParams[cond.Variable], cond.Value) <= 0
+			conditionsOK = conditionsOK && compareConditionValues(mapParams[cond.Variable], cond.Value) <= 0
 
 		case WorkflowConditionsOperatorGreaterThan:
-			conditionsOK = conditionsOK && strings.Compare(mapParams[cond.Variable], cond.Value) > 0
+			conditionsOK = conditionsOK && compareConditionValues(mapParams[cond.Variable], cond.Value) > 0
 
 		case WorkflowConditionsOperatorGreaterOrEqualThan:
-			conditionsOK = conditionsOK && strings.Compare(mapParams[cond.Variable], cond.Value) >= 0
+			conditionsOK = conditionsOK && compareConditionValues(mapParams[cond.Variable], cond.Value) >= 0
 
 		case WorkflowConditionsOperatorRegex:
 			match, err := regexp.MatchString(cond.Value, mapParams[cond.Variable])
@@ -74,8 +75,34 @@ func WorkflowCheckConditions(conditions []WorkflowTriggerCondition, params []Par
 				return false, fmt.Errorf("Unable to match string with regex %s (%v)", cond.Value, err)
 			}
 			conditionsOK = conditionsOK && match
+
+		case WorkflowConditionsOperatorExpression:
+			ok, err := EvalConditionExpression(cond.Value, mapParams)
+			if err != nil {
+				return false, fmt.Errorf("Unable to evaluate expression %s (%v)", cond.Value, err)
+			}
+			conditionsOK = conditionsOK && ok
 		}
 	}
 
 	return conditionsOK, nil
 }
+
+//compareConditionValues compares two values as numbers if both are numbers, else as strings
+func compareConditionValues(a, b string) int {
+	c, _ := compareValues(stringValue(a), stringValue(b))
+	return c
+}
+
+//WorkflowCheckConditionsSyntax checks the expressions of the conditions
+func WorkflowCheckConditionsSyntax(conditions []WorkflowTriggerCondition) error {
+	for _, cond := range conditions {
+		if cond.Operator != WorkflowConditionsOperatorExpression {
+			continue
+		}
+		if _, err := ParseConditionExpression(cond.Value); err != nil {
+			return fmt.Errorf("Invalid expression %s (%v)", cond.Value, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,800 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blang/semver"
+)
+
+// ConditionExpressionFunctions lists the functions available in workflow conditions expressions
+var ConditionExpressionFunctions = map[string]string{
+	"glob":        "glob(git.branch, \"feature/*\") - matches a value with glob patterns, ** matches /",
+	"match":       "match(git.branch, \"^release-.*$\") - matches a value with a regular expression",
+	"semver":      "semver(git.tag) >= semver(\"1.2.0\") - compares semantic versions",
+	"contains":    "contains(git.message, \"[deploy]\") - checks that a value contains a string",
+	"startswith":  "startswith(git.branch, \"release/\") - checks that a value starts with a string",
+	"endswith":    "endswith(git.branch, \"-hotfix\") - checks that a value ends with a string",
+	"status":      "status(\"build\") == \"Success\" - returns the status of a parent pipeline",
+	"all_success": "all_success() - checks that all the parent pipelines are successful",
+	"any_failure": "any_failure() - checks that at least one parent pipeline has failed",
+}
+
+var conditionExpressionFunctionsArity = map[string][2]int{
+	"glob":        {2, -1},
+	"match":       {2, 2},
+	"semver":      {1, 1},
+	"contains":    {2, 2},
+	"startswith":  {2, 2},
+	"endswith":    {2, 2},
+	"status":      {1, 1},
+	"all_success": {0, 0},
+	"any_failure": {0, 0},
+}
+
+// ConditionExpression is a parsed workflow condition expression such as
+// `git.branch in ["master", "develop"] && cds.run.number > 9`
+type ConditionExpression struct {
+	raw  string
+	root exprNode
+}
+
+// ParseConditionExpression parses and checks a workflow condition expression
+func ParseConditionExpression(s string) (*ConditionExpression, error) {
+	tokens, err := lexExpression(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+	return &ConditionExpression{raw: s, root: root}, nil
+}
+
+// String returns the expression as written by the user
+func (e *ConditionExpression) String() string {
+	return e.raw
+}
+
+// Variables returns the sorted list of the variables used in the expression
+func (e *ConditionExpression) Variables() []string {
+	m := map[string]bool{}
+	e.root.variables(m)
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Eval evaluates the expression with the given variables. Unknown variables are empty strings.
+func (e *ConditionExpression) Eval(params map[string]string) (bool, error) {
+	v, err := e.root.eval(params)
+	if err != nil {
+		return false, err
+	}
+	return v.toBool()
+}
+
+// EvalConditionExpression parses and evaluates a workflow condition expression
+func EvalConditionExpression(s string, params map[string]string) (bool, error) {
+	e, err := ParseConditionExpression(s)
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(params)
+}
+
+/* Values */
+
+type exprValueKind int
+
+const (
+	kindString exprValueKind = iota
+	kindNumber
+	kindBool
+	kindList
+	kindVersion
+)
+
+type exprValue struct {
+	kind    exprValueKind
+	str     string
+	num     float64
+	boolean bool
+	list    []exprValue
+	version semver.Version
+}
+
+func stringValue(s string) exprValue { return exprValue{kind: kindString, str: s} }
+func boolValue(b bool) exprValue     { return exprValue{kind: kindBool, boolean: b} }
+
+func (v exprValue) String() string {
+	switch v.kind {
+	case kindNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindBool:
+		return strconv.FormatBool(v.boolean)
+	case kindVersion:
+		return v.version.String()
+	case kindList:
+		s := make([]string, len(v.list))
+		for i := range v.list {
+			s[i] = v.list[i].String()
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	}
+	return v.str
+}
+
+func (v exprValue) toBool() (bool, error) {
+	switch v.kind {
+	case kindBool:
+		return v.boolean, nil
+	case kindString:
+		b, err := strconv.ParseBool(v.str)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a boolean", v.str)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("%s is not a boolean", v)
+}
+
+func (v exprValue) toNumber() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindString:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func (v exprValue) toVersion() (semver.Version, error) {
+	if v.kind == kindVersion {
+		return v.version, nil
+	}
+	return semver.ParseTolerant(strings.TrimSpace(v.String()))
+}
+
+// compareValues returns -1, 0 or 1. Versions are compared as semver, numbers as numbers, and the others as strings.
+func compareValues(a, b exprValue) (int, error) {
+	if a.kind == kindVersion || b.kind == kindVersion {
+		va, err := a.toVersion()
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid version", a)
+		}
+		vb, err := b.toVersion()
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid version", b)
+		}
+		return va.Compare(vb), nil
+	}
+	if na, ok := a.toNumber(); ok {
+		if nb, ok := b.toNumber(); ok {
+			switch {
+			case na < nb:
+				return -1, nil
+			case na > nb:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return strings.Compare(a.String(), b.String()), nil
+}
+
+func equalValues(a, b exprValue) (bool, error) {
+	if a.kind == kindBool || b.kind == kindBool {
+		ba, erra := a.toBool()
+		bb, errb := b.toBool()
+		if erra != nil || errb != nil {
+			return false, nil
+		}
+		return ba == bb, nil
+	}
+	if a.kind == kindList || b.kind == kindList {
+		return a.String() == b.String(), nil
+	}
+	c, err := compareValues(a, b)
+	if err != nil {
+		return false, err
+	}
+	return c == 0, nil
+}
+
+/* Lexer */
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type exprToken struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+var exprOperators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lexExpression(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(s)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb bytes.Buffer
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: tokenString, value: sb.String(), pos: start})
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, value: string(runes[start:i]), pos: start})
+
+		default:
+			var found bool
+			for _, op := range exprOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, exprToken{kind: tokenOperator, value: op, pos: i})
+					i += len([]rune(op))
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+/* Parser */
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next exprToken if it is one of the given operators or keywords
+func (p *exprParser) accept(values ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && t.kind != tokenIdent {
+		return "", false
+	}
+	for _, v := range values {
+		if t.value == v {
+			p.pos++
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(value string) error {
+	if _, ok := p.accept(value); !ok {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q at the end of the expression", value)
+		}
+		return fmt.Errorf("expected %q at position %d, got %q", value, t.pos, t.value)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "=~", "!~", "in"); ok {
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return newComparisonNode(op, left, right)
+	}
+	// not in
+	if t := p.peek(); t.kind == tokenIdent && t.value == "not" && p.tokens[p.pos+1].kind == tokenIdent && p.tokens[p.pos+1].value == "in" {
+		p.pos += 2
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return newComparisonNode("not in", left, right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{stringValue(t.value)}, nil
+
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			// Versions such as 1.2.3 are kept as strings
+			return &literalNode{stringValue(t.value)}, nil
+		}
+		return &literalNode{exprValue{kind: kindNumber, num: f, str: t.value}}, nil
+
+	case tokenIdent:
+		switch t.value {
+		case "true", "false":
+			return &literalNode{boolValue(t.value == "true")}, nil
+		case "and", "or", "not", "in":
+			return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		return &variableNode{t.value}, nil
+
+	case tokenOperator:
+		switch t.value {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items}, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+	}
+	return nil, fmt.Errorf("unexpected end of the expression")
+}
+
+func (p *exprParser) parseList(end string) ([]exprNode, error) {
+	items := []exprNode{}
+	if _, ok := p.accept(end); ok {
+		return items, nil
+	}
+	for {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, n)
+		if _, ok := p.accept(","); ok {
+			continue
+		}
+		if err := p.expect(end); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	arity, ok := conditionExpressionFunctionsArity[name.value]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.value, name.pos)
+	}
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+		return nil, fmt.Errorf("wrong number of arguments for function %s at position %d", name.value, name.pos)
+	}
+	return &callNode{name: name.value, args: args}, nil
+}
+
+/* AST */
+
+type exprNode interface {
+	eval(params map[string]string) (exprValue, error)
+	variables(map[string]bool)
+}
+
+type literalNode struct {
+	value exprValue
+}
+
+func (n *literalNode) eval(map[string]string) (exprValue, error) { return n.value, nil }
+func (n *literalNode) variables(map[string]bool)                 {}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(params map[string]string) (exprValue, error) {
+	return stringValue(params[n.name]), nil
+}
+func (n *variableNode) variables(m map[string]bool) { m[n.name] = true }
+
+type listNode struct {
+	items []exprNode
+}
+
+func (n *listNode) eval(params map[string]string) (exprValue, error) {
+	v := exprValue{kind: kindList}
+	for _, i := range n.items {
+		iv, err := i.eval(params)
+		if err != nil {
+			return v, err
+		}
+		v.list = append(v.list, iv)
+	}
+	return v, nil
+}
+
+func (n *listNode) variables(m map[string]bool) {
+	for _, i := range n.items {
+		i.variables(m)
+	}
+}
+
+type notNode struct {
+	node exprNode
+}
+
+func (n *notNode) eval(params map[string]string) (exprValue, error) {
+	v, err := n.node.eval(params)
+	if err != nil {
+		return v, err
+	}
+	b, err := v.toBool()
+	if err != nil {
+		return v, err
+	}
+	return boolValue(!b), nil
+}
+func (n *notNode) variables(m map[string]bool) { n.node.variables(m) }
+
+type logicalNode struct {
+	or          bool
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(params map[string]string) (exprValue, error) {
+	l, err := n.left.eval(params)
+	if err != nil {
+		return l, err
+	}
+	lb, err := l.toBool()
+	if err != nil {
+		return l, err
+	}
+	if lb == n.or {
+		return boolValue(lb), nil
+	}
+	r, err := n.right.eval(params)
+	if err != nil {
+		return r, err
+	}
+	rb, err := r.toBool()
+	if err != nil {
+		return r, err
+	}
+	return boolValue(rb), nil
+}
+
+func (n *logicalNode) variables(m map[string]bool) {
+	n.left.variables(m)
+	n.right.variables(m)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right exprNode
+	regex       *regexp.Regexp
+}
+
+func newComparisonNode(op string, left, right exprNode) (exprNode, error) {
+	n := &comparisonNode{op: op, left: left, right: right}
+	// Compile constant regular expressions once, and report their errors at parsing
+	if l, ok := right.(*literalNode); ok && (op == "=~" || op == "!~") {
+		r, err := regexp.Compile(l.value.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", l.value, err)
+		}
+		n.regex = r
+	}
+	return n, nil
+}
+
+func (n *comparisonNode) eval(params map[string]string) (exprValue, error) {
+	l, err := n.left.eval(params)
+	if err != nil {
+		return l, err
+	}
+	r, err := n.right.eval(params)
+	if err != nil {
+		return r, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq, err := equalValues(l, r)
+		if err != nil {
+			return l, err
+		}
+		return boolValue(eq == (n.op == "==")), nil
+
+	case "<", "<=", ">", ">=":
+		c, err := compareValues(l, r)
+		if err != nil {
+			return l, err
+		}
+		switch n.op {
+		case "<":
+			return boolValue(c < 0), nil
+		case "<=":
+			return boolValue(c <= 0), nil
+		case ">":
+			return boolValue(c > 0), nil
+		}
+		return boolValue(c >= 0), nil
+
+	case "=~", "!~":
+		re := n.regex
+		if re == nil {
+			re, err = regexp.Compile(r.String())
+			if err != nil {
+				return r, fmt.Errorf("invalid regular expression %q: %v", r, err)
+			}
+		}
+		return boolValue(re.MatchString(l.String()) == (n.op == "=~")), nil
+
+	case "in", "not in":
+		if r.kind != kindList {
+			return r, fmt.Errorf("%s is not a list", r)
+		}
+		var found bool
+		for _, i := range r.list {
+			eq, err := equalValues(l, i)
+			if err != nil {
+				return l, err
+			}
+			if eq {
+				found = true
+				break
+			}
+		}
+		return boolValue(found == (n.op == "in")), nil
+	}
+	return l, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func (n *comparisonNode) variables(m map[string]bool) {
+	n.left.variables(m)
+	n.right.variables(m)
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+func (n *callNode) variables(m map[string]bool) {
+	for _, a := range n.args {
+		a.variables(m)
+	}
+}
+
+func (n *callNode) eval(params map[string]string) (exprValue, error) {
+	args := make([]exprValue, len(n.args))
+	for i := range n.args {
+		v, err := n.args[i].eval(params)
+		if err != nil {
+			return v, err
+		}
+		args[i] = v
+	}
+
+	switch n.name {
+	case "glob":
+		for _, pattern := range args[1:] {
+			if globToRegexp(pattern.String()).MatchString(args[0].String()) {
+				return boolValue(true), nil
+			}
+		}
+		return boolValue(false), nil
+
+	case "match":
+		match, err := regexp.MatchString(args[1].String(), args[0].String())
+		if err != nil {
+			return args[1], fmt.Errorf("invalid regular expression %q: %v", args[1], err)
+		}
+		return boolValue(match), nil
+
+	case "semver":
+		v, err := args[0].toVersion()
+		if err != nil {
+			return args[0], fmt.Errorf("%q is not a valid version", args[0])
+		}
+		return exprValue{kind: kindVersion, version: v}, nil
+
+	case "contains":
+		return boolValue(strings.Contains(args[0].String(), args[1].String())), nil
+
+	case "startswith":
+		return boolValue(strings.HasPrefix(args[0].String(), args[1].String())), nil
+
+	case "endswith":
+		return boolValue(strings.HasSuffix(args[0].String(), args[1].String())), nil
+
+	case "status":
+		return stringValue(params["workflow."+args[0].String()+".status"]), nil
+
+	case "all_success", "any_failure":
+		statuses := parentStatuses(params)
+		if n.name == "all_success" {
+			for _, s := range statuses {
+				if s != StatusSuccess.String() {
+					return boolValue(false), nil
+				}
+			}
+			return boolValue(len(statuses) > 0), nil
+		}
+		for _, s := range statuses {
+			if s == StatusFail.String() {
+				return boolValue(true), nil
+			}
+		}
+		return boolValue(false), nil
+	}
+	return exprValue{}, fmt.Errorf("unknown function %s", n.name)
+}
+
+// parentStatuses returns the statuses of the parent pipelines which are given as workflow.<pipeline>.status
+func parentStatuses(params map[string]string) []string {
+	res := []string{}
+	for k, v := range params {
+		if !strings.HasPrefix(k, "workflow.") || !strings.HasSuffix(k, ".status") {
+			continue
+		}
+		if StatusFromString(v) == StatusUnknown {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// globToRegexp converts a glob pattern: * and ? don't match /, ** matches everything
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb bytes.Buffer
+	sb.WriteString("^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowCheckConditions(t *testing.T) {
+	params := []Parameter{
+		{Name: "cds.run.number", Value: "10"},
+		{Name: "git.branch", Value: "feature/login"},
+	}
+
+	tests := []struct {
+		conditions []WorkflowTriggerCondition
+		expected   bool
+	}{
+		{[]WorkflowTriggerCondition{{Variable: "cds.run.number", Operator: "gt", Value: "9"}}, true},
+		{[]WorkflowTriggerCondition{{Variable: "cds.run.number", Operator: "le", Value: "9"}}, false},
+		{[]WorkflowTriggerCondition{{Variable: "git.branch", Operator: "eq", Value: "feature/login"}}, true},
+		{[]WorkflowTriggerCondition{{Variable: "git.branch", Operator: "regex", Value: "^feature/.*"}}, true},
+		{[]WorkflowTriggerCondition{
+			{Variable: "git.branch", Operator: "regex", Value: "^feature/.*"},
+			{Operator: "expr", Value: `cds.run.number >= 11`},
+		}, false},
+		{[]WorkflowTriggerCondition{{Operator: "expr", Value: `glob(git.branch, "feature/*") && cds.run.number > 9`}}, true},
+	}
+
+	for _, tt := range tests {
+		ok, err := WorkflowCheckConditions(tt.conditions, params)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, ok, "%v", tt.conditions)
+	}
+}
+
+func TestEvalConditionExpression(t *testing.T) {
+	params := map[string]string{
+		"cds.run.number":         "10",
+		"git.branch":             "release/2.1",
+		"git.tag":                "v1.10.0",
+		"cds.status":             "Success",
+		"workflow.build.status":  "Success",
+		"workflow.tests.status":  "Fail",
+		"workflow.build.version": "12",
+	}
+
+	tests := map[string]bool{
+		`cds.run.number > 9`:                                  true,
+		`cds.run.number > "9"`:                                true,
+		`cds.run.number == 10.0`:                              true,
+		`git.branch == "release/2.1" and cds.run.number < 20`: true,
+		`git.branch == 'master' || git.branch == "develop"`:   false,
+		`!(git.branch == "master")`:                           true,
+		`not git.branch == "master"`:                          true,
+		`git.branch in ["master", "release/2.1"]`:             true,
+		`git.branch not in ["master", "release/2.1"]`:         false,
+		`cds.run.number in [1, 10]`:                           true,
+		`git.branch =~ "^release/[0-9.]+$"`:                   true,
+		`git.branch !~ "^release/"`:                           false,
+		`glob(git.branch, "release/*")`:                       true,
+		`glob(git.branch, "release*")`:                        false,
+		`glob(git.branch, "release**")`:                       true,
+		`glob(git.branch, "master", "release/?.?")`:           true,
+		`match(git.branch, "^rel")`:                           true,
+		`semver(git.tag) > semver("1.9.0")`:                   true,
+		`semver(git.tag) >= 1.10.0`:                           true,
+		`semver(git.tag) < "v1.2.0"`:                          false,
+		`startswith(git.branch, "release/")`:                  true,
+		`endswith(git.branch, "2.1")`:                         true,
+		`contains(git.branch, "ease")`:                        true,
+		`status("build") == "Success"`:                        true,
+		`all_success()`:                                       false,
+		`any_failure()`:                                       true,
+		`unknown.variable == ""`:                              true,
+		`true && (false || cds.status == "Success")`:          true,
+	}
+
+	for expr, expected := range tests {
+		ok, err := EvalConditionExpression(expr, params)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, ok, expr)
+	}
+}
+
+func TestParseConditionExpression(t *testing.T) {
+	e, err := ParseConditionExpression(`git.branch in ["master", cds.dest.pipeline] && status("build") == "Success"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cds.dest.pipeline", "git.branch"}, e.Variables())
+
+	invalids := []string{
+		``,
+		`git.branch ==`,
+		`(git.branch == "master"`,
+		`git.branch == "master`,
+		`unknown(git.branch)`,
+		`glob(git.branch)`,
+		`all_success(git.branch)`,
+		`git.branch =~ "["`,
+		`git.branch == "master" git.branch`,
+		`git.branch $ "master"`,
+	}
+	for _, s := range invalids {
+		_, err := ParseConditionExpression(s)
+		assert.Error(t, err, s)
+	}
+
+	// Runtime errors
+	_, err = EvalConditionExpression(`git.branch`, map[string]string{"git.branch": "master"})
+	assert.Error(t, err)
+	_, err = EvalConditionExpression(`semver(git.branch) > semver("1.0.0")`, map[string]string{"git.branch": "master"})
+	assert.Error(t, err)
+}
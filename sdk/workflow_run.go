@@ -63,6 +63,9 @@ type WorkflowRunInfo struct {
 	UserMessage string `json:"user_message,omitempty" db:"-"`
 }
 
+//WorkflowRunTagRelease is the tag set on the workflow runs which have been released
+const WorkflowRunTagRelease = "release"
+
 //WorkflowRunTag is a tag on workflow run
 type WorkflowRunTag struct {
 	WorkflowRunID int64  `json:"-" db:"workflow_run_id"`
@@ -91,6 +94,41 @@ type WorkflowNodeRun struct {
 	Artifacts          []WorkflowNodeRunArtifact `json:"artifacts,omitempty" db:"-"`
 	Tests              *venom.Tests              `json:"tests,omitempty" db:"-"`
 	Commits            []VCSCommit               `json:"commits,omitempty" db:"-"`
+	Approvals          []WorkflowNodeRunApproval `json:"approvals,omitempty" db:"-"`
+}
+
+//WorkflowNodeRunApproval is the decision of a user on a node run waiting for approval
+type WorkflowNodeRunApproval struct {
+	Username string    `json:"username"`
+	Approved bool      `json:"approved"`
+	Comment  string    `json:"comment,omitempty"`
+	Date     time.Time `json:"date"`
+}
+
+//NbApprovals returns the number of users who have approved the node run
+func (nr *WorkflowNodeRun) NbApprovals() int {
+	var n int
+	for _, a := range nr.Approvals {
+		if a.Approved {
+			n++
+		}
+	}
+	return n
+}
+
+//IsApproved returns true if the node run has got all the approvals it needs
+func (nr *WorkflowNodeRun) IsApproved(a *WorkflowNodeApproval) bool {
+	return !a.IsRequired() || nr.NbApprovals() >= a.NbApprovers
+}
+
+//Approval returns the decision of the user on the node run
+func (nr *WorkflowNodeRun) Approval(username string) *WorkflowNodeRunApproval {
+	for i := range nr.Approvals {
+		if nr.Approvals[i].Username == username {
+			return &nr.Approvals[i]
+		}
+	}
+	return nil
 }
 
 // Translate translates messages in WorkflowNodeRun
@@ -115,6 +153,8 @@ type WorkflowNodeRunArtifact struct {
 	MD5sum            string    `json:"md5sum,omitempty" db:"md5sum"`
 	ObjectPath        string    `json:"object_path,omitempty" db:"object_path"`
 	Created           time.Time `json:"created,omitempty" db:"created"`
+	TempURL           string    `json:"temp_url,omitempty" db:"-"`
+	TempURLSecretKey  string    `json:"temp_url_secret_key,omitempty" db:"-"`
 }
 
 //WorkflowNodeJobRun represents an job to be run
@@ -131,6 +171,9 @@ type WorkflowNodeJobRun struct {
 	Model             string      `json:"model,omitempty" db:"model"`
 	BookedBy          Hatchery    `json:"bookedby" db:"-"`
 	SpawnInfos        []SpawnInfo `json:"spawninfos" db:"-"`
+	ProjectID         int64       `json:"project_id" db:"project_id"`
+	Priority          int         `json:"priority" db:"priority"`
+	WaitingForSlot    bool        `json:"waiting_for_slot,omitempty" db:"-"`
 }
 
 // Translate translates messages in WorkflowNodeJobRun
@@ -144,9 +187,10 @@ func (njr *WorkflowNodeJobRun) Translate(lang string) {
 
 //WorkflowNodeRunHookEvent is an instanc of event received on a hook
 type WorkflowNodeRunHookEvent struct {
-	Payload            interface{} `json:"payload" db:"-"`
-	PipelineParameters []Parameter `json:"pipeline_parameter" db:"-"`
-	WorkflowNodeHookID int64       `json:"workflow_node_hook_id" db:"-"`
+	Payload              interface{} `json:"payload" db:"-"`
+	PipelineParameters   []Parameter `json:"pipeline_parameter" db:"-"`
+	WorkflowNodeHookID   int64       `json:"workflow_node_hook_id" db:"-"`
+	WorkflowNodeHookUUID string      `json:"workflow_node_hook_uuid" db:"-"`
 }
 
 //WorkflowNodeRunManual is an instanc of event received on a hook
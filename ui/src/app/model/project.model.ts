@@ -18,6 +18,8 @@ export class Project {
     repositories_manager: Array<RepositoriesManager>;
     permission: number;
     last_modified: string;
+    max_concurrent_jobs: number;
+    max_workflow_priority: number;
 
     // true if someone has updated the project ( used for warnings )
     externalChange: boolean;
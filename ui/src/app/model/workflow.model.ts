@@ -15,6 +15,7 @@ export class Workflow {
     root_id: number;
     joins: Array<WorkflowNodeJoin>;
     last_modified: Date;
+    priority: number;
 
     // UI params
     externalChange: boolean;
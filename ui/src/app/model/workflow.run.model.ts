@@ -71,6 +71,9 @@ export class WorkflowNodeJobRun {
     model: string;
     bookedby: Hatchery;
     spawninfos: Array<SpawnInfo>;
+    project_id: number;
+    priority: number;
+    waiting_for_slot: boolean;
 }
 
 // WorkflowNodeRunHookEvent is an instanc of event received on a hook